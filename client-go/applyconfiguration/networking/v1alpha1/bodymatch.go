@@ -21,7 +21,8 @@ package v1alpha1
 // BodyMatchApplyConfiguration represents a declarative configuration of the BodyMatch type for use
 // with apply.
 type BodyMatchApplyConfiguration struct {
-	Model *string `json:"model,omitempty"`
+	Model  *string                            `json:"model,omitempty"`
+	Fields []BodyFieldMatchApplyConfiguration `json:"fields,omitempty"`
 }
 
 // BodyMatchApplyConfiguration constructs a declarative configuration of the BodyMatch type for use with
@@ -37,3 +38,16 @@ func (b *BodyMatchApplyConfiguration) WithModel(value string) *BodyMatchApplyCon
 	b.Model = &value
 	return b
 }
+
+// WithFields adds the given value to the Fields field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Fields field.
+func (b *BodyMatchApplyConfiguration) WithFields(values ...*BodyFieldMatchApplyConfiguration) *BodyMatchApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithFields")
+		}
+		b.Fields = append(b.Fields, *values[i])
+	}
+	return b
+}
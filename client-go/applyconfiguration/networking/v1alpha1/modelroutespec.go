@@ -20,17 +20,22 @@ package v1alpha1
 
 import (
 	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
 	v1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 // ModelRouteSpecApplyConfiguration represents a declarative configuration of the ModelRouteSpec type for use
 // with apply.
 type ModelRouteSpecApplyConfiguration struct {
-	ModelName    *string                      `json:"modelName,omitempty"`
-	LoraAdapters []string                     `json:"loraAdapters,omitempty"`
-	ParentRefs   []v1.ParentReference         `json:"parentRefs,omitempty"`
-	Rules        []*networkingv1alpha1.Rule   `json:"rules,omitempty"`
-	RateLimit    *RateLimitApplyConfiguration `json:"rateLimit,omitempty"`
+	ModelName          *string                          `json:"modelName,omitempty"`
+	LoraAdapters       []string                         `json:"loraAdapters,omitempty"`
+	ParentRefs         []v1.ParentReference             `json:"parentRefs,omitempty"`
+	Rules              []*networkingv1alpha1.Rule       `json:"rules,omitempty"`
+	RateLimit          *RateLimitApplyConfiguration     `json:"rateLimit,omitempty"`
+	MaxRequestBodySize *resource.Quantity               `json:"maxRequestBodySize,omitempty"`
+	SLO                *LatencySLOApplyConfiguration    `json:"slo,omitempty"`
+	Cache              *ResponseCacheApplyConfiguration `json:"cache,omitempty"`
+	Guardrails         []GuardrailApplyConfiguration    `json:"guardrails,omitempty"`
 }
 
 // ModelRouteSpecApplyConfiguration constructs a declarative configuration of the ModelRouteSpec type for use with
@@ -87,3 +92,40 @@ func (b *ModelRouteSpecApplyConfiguration) WithRateLimit(value *RateLimitApplyCo
 	b.RateLimit = value
 	return b
 }
+
+// WithMaxRequestBodySize sets the MaxRequestBodySize field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the MaxRequestBodySize field is set to the value of the last call.
+func (b *ModelRouteSpecApplyConfiguration) WithMaxRequestBodySize(value resource.Quantity) *ModelRouteSpecApplyConfiguration {
+	b.MaxRequestBodySize = &value
+	return b
+}
+
+// WithSLO sets the SLO field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SLO field is set to the value of the last call.
+func (b *ModelRouteSpecApplyConfiguration) WithSLO(value *LatencySLOApplyConfiguration) *ModelRouteSpecApplyConfiguration {
+	b.SLO = value
+	return b
+}
+
+// WithCache sets the Cache field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Cache field is set to the value of the last call.
+func (b *ModelRouteSpecApplyConfiguration) WithCache(value *ResponseCacheApplyConfiguration) *ModelRouteSpecApplyConfiguration {
+	b.Cache = value
+	return b
+}
+
+// WithGuardrails adds the given value to the Guardrails field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Guardrails field.
+func (b *ModelRouteSpecApplyConfiguration) WithGuardrails(values ...*GuardrailApplyConfiguration) *ModelRouteSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithGuardrails")
+		}
+		b.Guardrails = append(b.Guardrails, *values[i])
+	}
+	return b
+}
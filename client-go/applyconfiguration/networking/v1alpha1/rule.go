@@ -25,9 +25,14 @@ import (
 // RuleApplyConfiguration represents a declarative configuration of the Rule type for use
 // with apply.
 type RuleApplyConfiguration struct {
-	Name         *string                           `json:"name,omitempty"`
-	ModelMatch   *ModelMatchApplyConfiguration     `json:"modelMatch,omitempty"`
-	TargetModels []*networkingv1alpha1.TargetModel `json:"targetModels,omitempty"`
+	Name         *string                             `json:"name,omitempty"`
+	ModelMatch   *ModelMatchApplyConfiguration       `json:"modelMatch,omitempty"`
+	TargetModels []*networkingv1alpha1.TargetModel   `json:"targetModels,omitempty"`
+	Fallback     *FallbackApplyConfiguration         `json:"fallback,omitempty"`
+	Mirror       *MirrorApplyConfiguration           `json:"mirror,omitempty"`
+	Transforms   []BodyTransformApplyConfiguration   `json:"transforms,omitempty"`
+	Classifier   *PromptClassifierApplyConfiguration `json:"classifier,omitempty"`
+	Rollout      *RolloutApplyConfiguration          `json:"rollout,omitempty"`
 }
 
 // RuleApplyConfiguration constructs a declarative configuration of the Rule type for use with
@@ -64,3 +69,48 @@ func (b *RuleApplyConfiguration) WithTargetModels(values ...**networkingv1alpha1
 	}
 	return b
 }
+
+// WithFallback sets the Fallback field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Fallback field is set to the value of the last call.
+func (b *RuleApplyConfiguration) WithFallback(value *FallbackApplyConfiguration) *RuleApplyConfiguration {
+	b.Fallback = value
+	return b
+}
+
+// WithMirror sets the Mirror field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Mirror field is set to the value of the last call.
+func (b *RuleApplyConfiguration) WithMirror(value *MirrorApplyConfiguration) *RuleApplyConfiguration {
+	b.Mirror = value
+	return b
+}
+
+// WithTransforms adds the given value to the Transforms field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Transforms field.
+func (b *RuleApplyConfiguration) WithTransforms(values ...*BodyTransformApplyConfiguration) *RuleApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithTransforms")
+		}
+		b.Transforms = append(b.Transforms, *values[i])
+	}
+	return b
+}
+
+// WithClassifier sets the Classifier field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Classifier field is set to the value of the last call.
+func (b *RuleApplyConfiguration) WithClassifier(value *PromptClassifierApplyConfiguration) *RuleApplyConfiguration {
+	b.Classifier = value
+	return b
+}
+
+// WithRollout sets the Rollout field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Rollout field is set to the value of the last call.
+func (b *RuleApplyConfiguration) WithRollout(value *RolloutApplyConfiguration) *RuleApplyConfiguration {
+	b.Rollout = value
+	return b
+}
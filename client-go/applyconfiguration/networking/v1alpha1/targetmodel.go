@@ -23,6 +23,7 @@ package v1alpha1
 type TargetModelApplyConfiguration struct {
 	ModelServerName *string `json:"modelServerName,omitempty"`
 	Weight          *uint32 `json:"weight,omitempty"`
+	Category        *string `json:"category,omitempty"`
 }
 
 // TargetModelApplyConfiguration constructs a declarative configuration of the TargetModel type for use with
@@ -46,3 +47,11 @@ func (b *TargetModelApplyConfiguration) WithWeight(value uint32) *TargetModelApp
 	b.Weight = &value
 	return b
 }
+
+// WithCategory sets the Category field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Category field is set to the value of the last call.
+func (b *TargetModelApplyConfiguration) WithCategory(value string) *TargetModelApplyConfiguration {
+	b.Category = &value
+	return b
+}
@@ -34,30 +34,66 @@ import (
 func ForKind(kind schema.GroupVersionKind) interface{} {
 	switch kind {
 	// Group=networking.serving.volcano.sh, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithKind("BodyFieldMatch"):
+		return &networkingv1alpha1.BodyFieldMatchApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("BodyMatch"):
 		return &networkingv1alpha1.BodyMatchApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("BodyTransform"):
+		return &networkingv1alpha1.BodyTransformApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Fallback"):
+		return &networkingv1alpha1.FallbackApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("FallbackTarget"):
+		return &networkingv1alpha1.FallbackTargetApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GlobalRateLimit"):
 		return &networkingv1alpha1.GlobalRateLimitApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Guardrail"):
+		return &networkingv1alpha1.GuardrailApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("KVConnectorSpec"):
 		return &networkingv1alpha1.KVConnectorSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("LatencySLO"):
+		return &networkingv1alpha1.LatencySLOApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Mirror"):
+		return &networkingv1alpha1.MirrorApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelMatch"):
 		return &networkingv1alpha1.ModelMatchApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelRoute"):
 		return &networkingv1alpha1.ModelRouteApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelRouteSpec"):
 		return &networkingv1alpha1.ModelRouteSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("ModelRouteStatus"):
+		return &networkingv1alpha1.ModelRouteStatusApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelServer"):
 		return &networkingv1alpha1.ModelServerApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ModelServerSpec"):
 		return &networkingv1alpha1.ModelServerSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("ModelServerStatus"):
+		return &networkingv1alpha1.ModelServerStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("NumberRange"):
+		return &networkingv1alpha1.NumberRangeApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("PDGroup"):
 		return &networkingv1alpha1.PDGroupApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PromptCategory"):
+		return &networkingv1alpha1.PromptCategoryApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PromptClassifier"):
+		return &networkingv1alpha1.PromptClassifierApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("RateLimit"):
 		return &networkingv1alpha1.RateLimitApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("RedisConfig"):
 		return &networkingv1alpha1.RedisConfigApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("ResponseCache"):
+		return &networkingv1alpha1.ResponseCacheApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Retry"):
 		return &networkingv1alpha1.RetryApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Rollout"):
+		return &networkingv1alpha1.RolloutApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RolloutAnalysis"):
+		return &networkingv1alpha1.RolloutAnalysisApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RolloutAnalysisResult"):
+		return &networkingv1alpha1.RolloutAnalysisResultApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RolloutStatus"):
+		return &networkingv1alpha1.RolloutStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("RolloutStep"):
+		return &networkingv1alpha1.RolloutStepApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Rule"):
 		return &networkingv1alpha1.RuleApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("StringMatch"):
@@ -66,6 +102,8 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &networkingv1alpha1.TargetModelApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("TrafficPolicy"):
 		return &networkingv1alpha1.TrafficPolicyApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("TransformMessage"):
+		return &networkingv1alpha1.TransformMessageApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("WorkloadPort"):
 		return &networkingv1alpha1.WorkloadPortApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("WorkloadSelector"):
@@ -98,6 +136,10 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &applyconfigurationworkloadv1alpha1.HeterogeneousTargetParamApplyConfiguration{}
 	case workloadv1alpha1.SchemeGroupVersion.WithKind("HomogeneousTarget"):
 		return &applyconfigurationworkloadv1alpha1.HomogeneousTargetApplyConfiguration{}
+	case workloadv1alpha1.SchemeGroupVersion.WithKind("LoraAdapter"):
+		return &applyconfigurationworkloadv1alpha1.LoraAdapterApplyConfiguration{}
+	case workloadv1alpha1.SchemeGroupVersion.WithKind("LoraAdapterStatus"):
+		return &applyconfigurationworkloadv1alpha1.LoraAdapterStatusApplyConfiguration{}
 	case workloadv1alpha1.SchemeGroupVersion.WithKind("Metadata"):
 		return &applyconfigurationworkloadv1alpha1.MetadataApplyConfiguration{}
 	case workloadv1alpha1.SchemeGroupVersion.WithKind("MetricEndpoint"):
@@ -30,6 +30,7 @@ type ModelBoosterSpecApplyConfiguration struct {
 	Backend           *ModelBackendApplyConfiguration                  `json:"backend,omitempty"`
 	AutoscalingPolicy *AutoscalingPolicySpecApplyConfiguration         `json:"autoscalingPolicy,omitempty"`
 	ModelMatch        *networkingv1alpha1.ModelMatchApplyConfiguration `json:"modelMatch,omitempty"`
+	LoraAdapters      []LoraAdapterApplyConfiguration                  `json:"loraAdapters,omitempty"`
 }
 
 // ModelBoosterSpecApplyConfiguration constructs a declarative configuration of the ModelBoosterSpec type for use with
@@ -77,3 +78,16 @@ func (b *ModelBoosterSpecApplyConfiguration) WithModelMatch(value *networkingv1a
 	b.ModelMatch = value
 	return b
 }
+
+// WithLoraAdapters adds the given value to the LoraAdapters field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the LoraAdapters field.
+func (b *ModelBoosterSpecApplyConfiguration) WithLoraAdapters(values ...*LoraAdapterApplyConfiguration) *ModelBoosterSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithLoraAdapters")
+		}
+		b.LoraAdapters = append(b.LoraAdapters, *values[i])
+	}
+	return b
+}
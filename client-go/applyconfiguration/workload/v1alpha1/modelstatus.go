@@ -25,8 +25,9 @@ import (
 // ModelStatusApplyConfiguration represents a declarative configuration of the ModelStatus type for use
 // with apply.
 type ModelStatusApplyConfiguration struct {
-	Conditions         []v1.ConditionApplyConfiguration `json:"conditions,omitempty"`
-	ObservedGeneration *int64                           `json:"observedGeneration,omitempty"`
+	Conditions         []v1.ConditionApplyConfiguration      `json:"conditions,omitempty"`
+	ObservedGeneration *int64                                `json:"observedGeneration,omitempty"`
+	LoraAdapters       []LoraAdapterStatusApplyConfiguration `json:"loraAdapters,omitempty"`
 }
 
 // ModelStatusApplyConfiguration constructs a declarative configuration of the ModelStatus type for use with
@@ -55,3 +56,16 @@ func (b *ModelStatusApplyConfiguration) WithObservedGeneration(value int64) *Mod
 	b.ObservedGeneration = &value
 	return b
 }
+
+// WithLoraAdapters adds the given value to the LoraAdapters field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the LoraAdapters field.
+func (b *ModelStatusApplyConfiguration) WithLoraAdapters(values ...*LoraAdapterStatusApplyConfiguration) *ModelStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithLoraAdapters")
+		}
+		b.LoraAdapters = append(b.LoraAdapters, *values[i])
+	}
+	return b
+}
@@ -22,8 +22,11 @@ import (
 	"os"
 	"strconv"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
@@ -50,7 +53,7 @@ type aggregatedController struct {
 
 var _ Controller = &aggregatedController{}
 
-func startControllers(store datastore.Store, stop <-chan struct{}, enableGatewayAPI bool, defaultPort string, enableGatewayAPIInferenceExtension bool, kubeAPIQPS float32, kubeAPIBurst int) Controller {
+func startControllers(store datastore.Store, stop <-chan struct{}, enableGatewayAPI bool, defaultPort string, enableGatewayAPIInferenceExtension bool, enableLeaderElection bool, kubeAPIQPS float32, kubeAPIBurst int) Controller {
 	cfg, err := clientcmd.BuildConfigFromFlags("", "")
 	if err != nil {
 		klog.Fatalf("Error building kubeconfig: %s", err.Error())
@@ -78,6 +81,8 @@ func startControllers(store datastore.Store, stop <-chan struct{}, enableGateway
 
 	modelRouteController := controller.NewModelRouteController(kthenaInformerFactory, store)
 	modelServerController := controller.NewModelServerController(kthenaInformerFactory, kubeInformerFactory, store)
+	statusController := controller.NewStatusController(kthenaClient, kthenaInformerFactory, store)
+	rolloutController := controller.NewRolloutController(kthenaClient, kthenaInformerFactory)
 
 	kubeInformerFactory.Start(stop)
 	kthenaInformerFactory.Start(stop)
@@ -119,6 +124,15 @@ func startControllers(store datastore.Store, stop <-chan struct{}, enableGateway
 		gatewayInformerFactory := gatewayinformers.NewSharedInformerFactory(gatewayClient, 0)
 		gatewayController := controller.NewGatewayController(gatewayInformerFactory, store)
 
+		// Only TLS Secrets can be referenced by HTTPS listeners, avoid caching all Secrets of the cluster
+		secretInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)).String()
+			}))
+		secretController := controller.NewSecretController(secretInformerFactory, store)
+		secretInformerFactory.Start(stop)
+		statusController.EnableGatewayAPI(gatewayClient, gatewayInformerFactory, enableGatewayAPIInferenceExtension)
+
 		// Gateway API Inference Extension controllers are optional
 		var httpRouteController *controller.HTTPRouteController
 		if enableGatewayAPIInferenceExtension {
@@ -134,7 +148,13 @@ func startControllers(store datastore.Store, stop <-chan struct{}, enableGateway
 			}
 		}()
 
-		controllers = append(controllers, gatewayController)
+		go func() {
+			if err := secretController.Run(stop); err != nil {
+				klog.Fatalf("Error running secret controller: %s", err.Error())
+			}
+		}()
+
+		controllers = append(controllers, gatewayController, secretController)
 
 		// Gateway API Inference Extension controllers are optional
 		if enableGatewayAPIInferenceExtension {
@@ -167,6 +187,32 @@ func startControllers(store datastore.Store, stop <-chan struct{}, enableGateway
 		klog.Info("Gateway API controllers are disabled")
 	}
 
+	runStatusController := func(ctx context.Context) {
+		if err := statusController.Run(ctx); err != nil {
+			klog.Errorf("Error running status controller: %s", err.Error())
+		}
+	}
+	if enableLeaderElection {
+		// Status and rollouts are written by a single replica only
+		leaderElector, err := initLeaderElector(kubeClient, func(ctx context.Context) {
+			go func() {
+				if err := rolloutController.Run(ctx); err != nil {
+					klog.Errorf("Error running rollout controller: %s", err.Error())
+				}
+			}()
+			runStatusController(ctx)
+		})
+		if err != nil {
+			klog.Fatalf("Error building leader elector: %s", err.Error())
+		}
+		// Keep competing for the lease after losing it, the router itself keeps serving traffic
+		go wait.UntilWithContext(wait.ContextForChannel(stop), leaderElector.Run, defaultRetryPeriod)
+	} else {
+		// Every replica would step the rollouts on its own schedule and analyze its own traffic only
+		klog.Warning("Progressive rollouts are disabled without leader election")
+		go runStatusController(wait.ContextForChannel(stop))
+	}
+
 	return &aggregatedController{
 		controllers: controllers,
 	}
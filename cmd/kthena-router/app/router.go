@@ -18,7 +18,9 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -30,9 +32,12 @@ import (
 	"k8s.io/klog/v2"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
+	"github.com/volcano-sh/kthena/pkg/kthena-router/controller"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/debug"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/extproc"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/router"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
 )
 
 const (
@@ -72,6 +77,11 @@ func (s *Server) startRouter(ctx context.Context, router *router.Router, store d
 			}
 		})
 
+		// Reload listener certificates when a referenced TLS Secret changes
+		store.RegisterCallback("Secret", func(data datastore.EventData) {
+			listenerManager.ReloadCertificatesForSecret(data.Secret.String())
+		})
+
 		// Initialize listeners for existing Gateways that were added before callback registration
 		// This ensures we don't lose Gateway events that occurred during controller startup
 		existingGateways := store.GetAllGateways()
@@ -137,6 +147,22 @@ func (s *Server) startDebugServer(ctx context.Context, store datastore.Store) {
 	}()
 }
 
+// startExtProcServer starts the Envoy ext_proc endpoint picker for the configured InferencePool,
+// sharing the scheduler of the router
+func (s *Server) startExtProcServer(ctx context.Context, r *router.Router, store datastore.Store) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.ExtProcPort))
+	if err != nil {
+		klog.Fatalf("ext_proc server listen failed: %v", err)
+	}
+	server := extproc.NewServer(store, r.Scheduler(), s.ExtProcInferencePool)
+	go func() {
+		if err := server.Run(ctx, lis); err != nil {
+			klog.Fatalf("ext_proc server failed: %v", err)
+		}
+		klog.Info("ext_proc server exited")
+	}()
+}
+
 // startDefaultServer starts the default HTTP server on fixed port
 // This server handles healthz, readyz, metrics, and /v1/*path
 func (s *Server) startDefaultServer(ctx context.Context, router *router.Router, store datastore.Store) {
@@ -218,6 +244,8 @@ type PortListenerInfo struct {
 	Server       *http.Server
 	ShutdownFunc context.CancelFunc
 	Listeners    []ListenerConfig
+	// Protocol is the protocol served on this port, listeners with another protocol are rejected
+	Protocol string
 }
 
 // ListenerManager manages Gateway listeners dynamically
@@ -230,6 +258,9 @@ type ListenerManager struct {
 	mu               sync.RWMutex
 	portListeners    map[int32]*PortListenerInfo // key: port
 	gatewayListeners map[string][]ListenerConfig // key: gatewayKey, tracks listeners per gateway
+
+	certMu       sync.RWMutex
+	certificates map[string][]tls.Certificate // key: gatewayKey/listenerName, certificates of HTTPS listeners
 }
 
 // NewListenerManager creates a new listener manager
@@ -241,6 +272,7 @@ func NewListenerManager(ctx context.Context, router *router.Router, store datast
 		server:           server,
 		portListeners:    make(map[int32]*PortListenerInfo),
 		gatewayListeners: make(map[string][]ListenerConfig),
+		certificates:     make(map[string][]tls.Certificate),
 	}
 }
 
@@ -263,25 +295,26 @@ func (lm *ListenerManager) findBestMatchingListener(port int32, hostname string)
 		return nil, false
 	}
 
-	// First, try to find an exact hostname match
+	// Gateway API semantics: the most specific matching hostname wins, precise hostnames before
+	// wildcards, longer wildcards before shorter ones, and listeners without hostname last
+	var best *ListenerConfig
+	bestSpecificity := -1
 	for i := range portInfo.Listeners {
 		listener := &portInfo.Listeners[i]
-		if listener.Hostname != nil && *listener.Hostname == hostname {
-			return listener, true
+		listenerHostname := ""
+		if listener.Hostname != nil {
+			listenerHostname = *listener.Hostname
 		}
-	}
-
-	// If no exact match, try to find a listener without hostname restriction (wildcard)
-	// TODO: support wildcard hostname matching
-	for i := range portInfo.Listeners {
-		listener := &portInfo.Listeners[i]
-		if listener.Hostname == nil {
-			return listener, true
+		if !utils.HostnameMatches(listenerHostname, hostname) {
+			continue
+		}
+		if specificity := utils.HostnameSpecificity(listenerHostname); specificity > bestSpecificity {
+			best = listener
+			bestSpecificity = specificity
 		}
 	}
 
-	// No match found
-	return nil, false
+	return best, best != nil
 }
 
 // createPortHandler creates a gin handler for a specific port that routes to the best matching listener
@@ -314,11 +347,8 @@ func (lm *ListenerManager) createPortHandler(port int32) gin.HandlerFunc {
 			}
 		}
 
-		hostname := c.Request.Host
 		// Remove port from hostname if present
-		if idx := strings.Index(hostname, ":"); idx != -1 {
-			hostname = hostname[:idx]
-		}
+		hostname := utils.NormalizeHost(c.Request.Host)
 
 		listenerConfig, found := lm.findBestMatchingListener(port, hostname)
 		if !found {
@@ -330,6 +360,7 @@ func (lm *ListenerManager) createPortHandler(port int32) gin.HandlerFunc {
 
 		// Set gateway key in context so router can filter ModelRoutes by gateway
 		c.Set(router.GatewayKey, listenerConfig.GatewayKey)
+		c.Set(router.ListenerKey, listenerConfig.ListenerName)
 
 		// Apply middleware and route
 		AccessLogMiddleware(lm.router)(c)
@@ -348,6 +379,94 @@ func (lm *ListenerManager) createPortHandler(port int32) gin.HandlerFunc {
 	}
 }
 
+// getCertificate returns the tls.Config GetCertificate callback of an HTTPS port.
+// The certificate is selected by matching the SNI server name against the listeners on the port.
+func (lm *ListenerManager) getCertificate(port int32) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		listenerConfig, found := lm.findBestMatchingListener(port, hello.ServerName)
+		if !found {
+			return nil, fmt.Errorf("no listener on port %d matches server name %q", port, hello.ServerName)
+		}
+
+		lm.certMu.RLock()
+		certificates := lm.certificates[listenerConfig.certificateKey()]
+		lm.certMu.RUnlock()
+		if len(certificates) == 0 {
+			return nil, fmt.Errorf("no valid certificate for listener %s/%s", listenerConfig.GatewayKey, listenerConfig.ListenerName)
+		}
+
+		// Multiple certificateRefs are allowed, e.g. RSA and ECDSA, pick the first one the client supports
+		for i := range certificates {
+			if hello.SupportsCertificate(&certificates[i]) == nil {
+				return &certificates[i], nil
+			}
+		}
+		return &certificates[0], nil
+	}
+}
+
+// refreshCertificates loads the certificates of all HTTPS listeners of a Gateway from their certificateRefs.
+// Listeners with invalid references keep no certificate, so TLS handshakes for them fail.
+func (lm *ListenerManager) refreshCertificates(gateway *gatewayv1.Gateway) {
+	gatewayKey := fmt.Sprintf("%s/%s", gateway.Namespace, gateway.Name)
+
+	lm.certMu.Lock()
+	defer lm.certMu.Unlock()
+
+	for key := range lm.certificates {
+		if strings.HasPrefix(key, gatewayKey+"/") {
+			delete(lm.certificates, key)
+		}
+	}
+	for i := range gateway.Spec.Listeners {
+		listener := &gateway.Spec.Listeners[i]
+		if listener.Protocol != gatewayv1.HTTPSProtocolType {
+			continue
+		}
+		certificates, err := controller.LoadListenerCertificates(gateway.Namespace, listener, lm.store)
+		if err != nil {
+			klog.Errorf("Failed to load certificates for listener %s/%s: %v", gatewayKey, listener.Name, err)
+			continue
+		}
+		lm.certificates[fmt.Sprintf("%s/%s", gatewayKey, listener.Name)] = certificates
+	}
+}
+
+// ReloadCertificatesForSecret reloads the certificates of all Gateways with a listener referencing the Secret
+func (lm *ListenerManager) ReloadCertificatesForSecret(secretKey string) {
+	lm.mu.RLock()
+	gatewayKeys := make([]string, 0, len(lm.gatewayListeners))
+	for gatewayKey := range lm.gatewayListeners {
+		gatewayKeys = append(gatewayKeys, gatewayKey)
+	}
+	lm.mu.RUnlock()
+
+	for _, gatewayKey := range gatewayKeys {
+		gateway := lm.store.GetGateway(gatewayKey)
+		if gateway == nil || !referencesSecret(gateway, secretKey) {
+			continue
+		}
+		klog.Infof("Reloading certificates of Gateway %s after Secret %s changed", gatewayKey, secretKey)
+		lm.refreshCertificates(gateway)
+	}
+}
+
+func referencesSecret(gateway *gatewayv1.Gateway, secretKey string) bool {
+	for i := range gateway.Spec.Listeners {
+		for _, key := range controller.CertificateRefKeys(gateway.Namespace, &gateway.Spec.Listeners[i]) {
+			if key == secretKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certificateKey returns the key of the listener in the certificate cache
+func (c *ListenerConfig) certificateKey() string {
+	return fmt.Sprintf("%s/%s", c.GatewayKey, c.ListenerName)
+}
+
 // listenerConfigKey creates a unique key for a listener config for comparison
 func (c *ListenerConfig) listenerConfigKey() string {
 	hostnameStr := ""
@@ -365,9 +484,9 @@ func buildListenerConfigsFromGateway(gateway *gatewayv1.Gateway) []ListenerConfi
 	for _, listener := range gateway.Spec.Listeners {
 		protocol := string(listener.Protocol)
 
-		// Only support HTTP for now
-		if protocol != string(gatewayv1.HTTPProtocolType) {
-			klog.Errorf("Unsupported protocol %s for listener %s/%s, only HTTP is supported", protocol, gatewayKey, listener.Name)
+		// Only support HTTP and HTTPS (TLS terminated by the router) for now
+		if protocol != string(gatewayv1.HTTPProtocolType) && protocol != string(gatewayv1.HTTPSProtocolType) {
+			klog.Errorf("Unsupported protocol %s for listener %s/%s, only HTTP and HTTPS are supported", protocol, gatewayKey, listener.Name)
 			continue
 		}
 
@@ -412,8 +531,9 @@ func (lm *ListenerManager) removeListenerFromPort(port int32, configToRemove Lis
 }
 
 // addListenerToPort adds a listener config to a port
+// Returns false if the listener conflicts with the protocol already served on the port
 // NOTE: Caller must hold lm.mu lock
-func (lm *ListenerManager) addListenerToPort(port int32, config ListenerConfig, enableTLS bool, tlsCertFile, tlsKeyFile string) {
+func (lm *ListenerManager) addListenerToPort(port int32, config ListenerConfig, enableTLS bool, tlsCertFile, tlsKeyFile string) bool {
 	portInfo, exists := lm.portListeners[port]
 	if !exists {
 		// Create new port listener
@@ -426,9 +546,18 @@ func (lm *ListenerManager) addListenerToPort(port int32, config ListenerConfig,
 			Handler: engine.Handler(),
 		}
 
+		// HTTPS listeners terminate TLS with the certificates from their certificateRefs
+		if config.Protocol == string(gatewayv1.HTTPSProtocolType) {
+			server.TLSConfig = &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: lm.getCertificate(port),
+			}
+		}
+
 		portInfo = &PortListenerInfo{
 			Server:    server,
 			Listeners: []ListenerConfig{config},
+			Protocol:  config.Protocol,
 		}
 		lm.portListeners[port] = portInfo
 
@@ -437,10 +566,13 @@ func (lm *ListenerManager) addListenerToPort(port int32, config ListenerConfig,
 		portInfo.ShutdownFunc = cancel
 
 		// Start the server
-		go func(p int32, srv *http.Server, ctx context.Context, tls bool, cert, key string) {
+		go func(p int32, srv *http.Server, ctx context.Context, enableTLS bool, cert, key string) {
 			klog.Infof("Starting Gateway listener server on port %d", p)
 			var err error
-			if tls {
+			if srv.TLSConfig != nil {
+				// Certificates are provided by TLSConfig.GetCertificate
+				err = srv.ListenAndServeTLS("", "")
+			} else if enableTLS {
 				if cert == "" || key == "" {
 					klog.Fatalf("TLS enabled but cert or key file not specified for port %d", p)
 				}
@@ -464,12 +596,18 @@ func (lm *ListenerManager) addListenerToPort(port int32, config ListenerConfig,
 			}
 		}(port, server, cancel)
 	} else {
+		if portInfo.Protocol != config.Protocol {
+			klog.Errorf("Listener %s/%s with protocol %s conflicts with protocol %s already served on port %d",
+				config.GatewayKey, config.ListenerName, config.Protocol, portInfo.Protocol, port)
+			return false
+		}
 		// Add listener to existing port
 		portInfo.mu.Lock()
 		portInfo.Listeners = append(portInfo.Listeners, config)
 		portInfo.mu.Unlock()
 		klog.V(4).Infof("Added listener %s/%s to existing port %d", config.GatewayKey, config.ListenerName, port)
 	}
+	return true
 }
 
 // StartListenersForGateway starts listeners for a Gateway, only processing delta changes
@@ -495,6 +633,9 @@ func (lm *ListenerManager) StartListenersForGateway(gateway *gatewayv1.Gateway)
 		newConfigMap[key] = config
 	}
 
+	// Load certificates before the HTTPS listeners start serving
+	lm.refreshCertificates(gateway)
+
 	// Find listeners to remove (in old but not in new)
 	for key, config := range oldConfigMap {
 		if _, exists := newConfigMap[key]; !exists {
@@ -503,25 +644,30 @@ func (lm *ListenerManager) StartListenersForGateway(gateway *gatewayv1.Gateway)
 		}
 	}
 
-	// Find listeners to add (in new but not in old)
+	// Only listeners actually bound to a port are tracked, so rejected ones are retried on the next update
+	activeConfigs := make([]ListenerConfig, 0, len(newConfigs))
 	for key, config := range newConfigMap {
-		if _, exists := oldConfigMap[key]; !exists {
-			// Check if this is the default port to determine TLS settings
-			defaultPort, _ := strconv.Atoi(lm.server.Port)
-			enableTLS := false
-			tlsCertFile := ""
-			tlsKeyFile := ""
-			if int32(defaultPort) == config.Port {
-				enableTLS = lm.server.EnableTLS
-				tlsCertFile = lm.server.TLSCertFile
-				tlsKeyFile = lm.server.TLSKeyFile
-			}
-			lm.addListenerToPort(config.Port, config, enableTLS, tlsCertFile, tlsKeyFile)
+		if _, exists := oldConfigMap[key]; exists {
+			activeConfigs = append(activeConfigs, config)
+			continue
+		}
+		// Check if this is the default port to determine TLS settings
+		defaultPort, _ := strconv.Atoi(lm.server.Port)
+		enableTLS := false
+		tlsCertFile := ""
+		tlsKeyFile := ""
+		if int32(defaultPort) == config.Port {
+			enableTLS = lm.server.EnableTLS
+			tlsCertFile = lm.server.TLSCertFile
+			tlsKeyFile = lm.server.TLSKeyFile
+		}
+		if lm.addListenerToPort(config.Port, config, enableTLS, tlsCertFile, tlsKeyFile) {
+			activeConfigs = append(activeConfigs, config)
 		}
 	}
 
 	// Update gateway listeners map
-	lm.gatewayListeners[gatewayKey] = newConfigs
+	lm.gatewayListeners[gatewayKey] = activeConfigs
 }
 
 // StopListenersForGateway stops all listeners for a Gateway
@@ -536,6 +682,14 @@ func (lm *ListenerManager) StopListenersForGateway(gatewayKey string) {
 	}
 	delete(lm.gatewayListeners, gatewayKey)
 
+	lm.certMu.Lock()
+	for key := range lm.certificates {
+		if strings.HasPrefix(key, gatewayKey+"/") {
+			delete(lm.certificates, key)
+		}
+	}
+	lm.certMu.Unlock()
+
 	// Build map of ports that might need checking
 	portsToCheck := make(map[int32]bool)
 
@@ -0,0 +1,195 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+func newTestTLSSecret(t *testing.T, name string, dnsNames ...string) *corev1.Secret {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+func newTestHTTPSGateway() *gatewayv1.Gateway {
+	hostname := gatewayv1.Hostname("a.example.com")
+	return &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gw"},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{
+				{
+					Name:     "https-a",
+					Port:     8443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					Hostname: &hostname,
+					TLS: &gatewayv1.ListenerTLSConfig{
+						CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "cert-a"}},
+					},
+				},
+				{
+					Name:     "https-default",
+					Port:     8443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					TLS: &gatewayv1.ListenerTLSConfig{
+						CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "cert-default"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildListenerConfigsFromGatewayHTTPS(t *testing.T) {
+	gw := newTestHTTPSGateway()
+	gw.Spec.Listeners = append(gw.Spec.Listeners, gatewayv1.Listener{Name: "tcp", Port: 9000, Protocol: gatewayv1.TCPProtocolType})
+
+	configs := buildListenerConfigsFromGateway(gw)
+	require.Len(t, configs, 2)
+	for _, config := range configs {
+		assert.Equal(t, string(gatewayv1.HTTPSProtocolType), config.Protocol)
+		assert.Equal(t, int32(8443), config.Port)
+	}
+}
+
+func TestListenerManagerGetCertificateBySNI(t *testing.T) {
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateSecret(newTestTLSSecret(t, "cert-a", "a.example.com")))
+	require.NoError(t, store.AddOrUpdateSecret(newTestTLSSecret(t, "cert-default", "default.example.com")))
+	gw := newTestHTTPSGateway()
+	require.NoError(t, store.AddOrUpdateGateway(gw))
+
+	lm := NewListenerManager(context.Background(), nil, store, &Server{Port: "8080"})
+	configs := buildListenerConfigsFromGateway(gw)
+	lm.portListeners[8443] = &PortListenerInfo{Listeners: configs, Protocol: string(gatewayv1.HTTPSProtocolType)}
+	lm.gatewayListeners["default/gw"] = configs
+	lm.refreshCertificates(gw)
+
+	getCertificate := lm.getCertificate(8443)
+	cert, err := getCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.example.com"}, cert.Leaf.DNSNames)
+
+	cert, err = getCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default.example.com"}, cert.Leaf.DNSNames)
+
+	// Rotating the Secret is picked up without restarting the listener
+	require.NoError(t, store.AddOrUpdateSecret(newTestTLSSecret(t, "cert-a", "rotated.example.com")))
+	lm.ReloadCertificatesForSecret("default/cert-a")
+	cert, err = getCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rotated.example.com"}, cert.Leaf.DNSNames)
+
+	// Deleting the Secret makes the handshake fail instead of serving a stale certificate
+	require.NoError(t, store.DeleteSecret("default/cert-a"))
+	lm.ReloadCertificatesForSecret("default/cert-a")
+	_, err = getCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+	assert.Error(t, err)
+}
+
+func TestListenerManagerRejectsProtocolConflict(t *testing.T) {
+	lm := NewListenerManager(context.Background(), nil, datastore.New(), &Server{Port: "8080"})
+	lm.portListeners[8443] = &PortListenerInfo{
+		Listeners: []ListenerConfig{{GatewayKey: "default/http", ListenerName: "http", Port: 8443, Protocol: string(gatewayv1.HTTPProtocolType)}},
+		Protocol:  string(gatewayv1.HTTPProtocolType),
+	}
+
+	added := lm.addListenerToPort(8443, ListenerConfig{
+		GatewayKey:   "default/gw",
+		ListenerName: "https",
+		Port:         8443,
+		Protocol:     string(gatewayv1.HTTPSProtocolType),
+	}, false, "", "")
+	assert.False(t, added)
+	assert.Len(t, lm.portListeners[8443].Listeners, 1)
+}
+
+func TestFindBestMatchingListenerHostnames(t *testing.T) {
+	hostname := func(h string) *string { return &h }
+	lm := NewListenerManager(context.Background(), nil, datastore.New(), &Server{Port: "8080"})
+	lm.portListeners[80] = &PortListenerInfo{
+		Listeners: []ListenerConfig{
+			{GatewayKey: "default/gw", ListenerName: "catch-all", Port: 80},
+			{GatewayKey: "default/gw", ListenerName: "wildcard", Port: 80, Hostname: hostname("*.example.com")},
+			{GatewayKey: "default/gw", ListenerName: "models-wildcard", Port: 80, Hostname: hostname("*.models.example.com")},
+			{GatewayKey: "default/gw", ListenerName: "exact", Port: 80, Hostname: hostname("llama.models.example.com")},
+		},
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "llama.models.example.com", want: "exact"},
+		{host: "LLAMA.models.example.com:80", want: "exact"},
+		{host: "qwen.models.example.com", want: "models-wildcard"},
+		{host: "a.b.models.example.com", want: "models-wildcard"},
+		{host: "models.example.com", want: "wildcard"},
+		{host: "foo.example.com", want: "wildcard"},
+		{host: "example.com", want: "catch-all"},
+		{host: "other.org", want: "catch-all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			listener, ok := lm.findBestMatchingListener(80, tt.host)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, listener.ListenerName)
+		})
+	}
+
+	_, ok := lm.findBestMatchingListener(8081, "foo.example.com")
+	assert.False(t, ok)
+}
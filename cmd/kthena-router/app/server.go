@@ -19,6 +19,7 @@ package app
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -35,12 +36,17 @@ type Server struct {
 	Port                               string
 	EnableGatewayAPI                   bool
 	EnableGatewayAPIInferenceExtension bool
+	EnableLeaderElection               bool
 	DebugPort                          int
 	KubeAPIQPS                         float32
 	KubeAPIBurst                       int
+	// ExtProcPort is the port of the ext_proc endpoint picker, 0 disables it
+	ExtProcPort int
+	// ExtProcInferencePool is the InferencePool the ext_proc endpoint picker schedules requests to
+	ExtProcInferencePool types.NamespacedName
 }
 
-func NewServer(port string, enableTLS bool, cert, key string, enableGatewayAPI bool, enableGatewayAPIInferenceExtension bool, debugPort int, kubeAPIQPS float32, kubeAPIBurst int) *Server {
+func NewServer(port string, enableTLS bool, cert, key string, enableGatewayAPI bool, enableGatewayAPIInferenceExtension bool, enableLeaderElection bool, debugPort int, kubeAPIQPS float32, kubeAPIBurst int, extProcPort int, extProcInferencePool types.NamespacedName) *Server {
 	return &Server{
 		store:                              nil,
 		EnableTLS:                          enableTLS,
@@ -49,9 +55,12 @@ func NewServer(port string, enableTLS bool, cert, key string, enableGatewayAPI b
 		Port:                               port,
 		EnableGatewayAPI:                   enableGatewayAPI,
 		EnableGatewayAPIInferenceExtension: enableGatewayAPIInferenceExtension,
+		EnableLeaderElection:               enableLeaderElection,
 		DebugPort:                          debugPort,
 		KubeAPIQPS:                         kubeAPIQPS,
 		KubeAPIBurst:                       kubeAPIBurst,
+		ExtProcPort:                        extProcPort,
+		ExtProcInferencePool:               extProcInferencePool,
 	}
 }
 
@@ -63,7 +72,7 @@ func (s *Server) Run(ctx context.Context) {
 	// must be run before the controller, because it will register callbacks
 	r := NewRouter(store)
 	// start controller
-	s.controllers = startControllers(store, ctx.Done(), s.EnableGatewayAPI, s.Port, s.EnableGatewayAPIInferenceExtension, s.KubeAPIQPS, s.KubeAPIBurst)
+	s.controllers = startControllers(store, ctx.Done(), s.EnableGatewayAPI, s.Port, s.EnableGatewayAPIInferenceExtension, s.EnableLeaderElection, s.KubeAPIQPS, s.KubeAPIBurst)
 
 	// Start store's periodic update loop after controllers have synced
 	if !cache.WaitForCacheSync(ctx.Done(), s.controllers.HasSynced) {
@@ -73,6 +82,10 @@ func (s *Server) Run(ctx context.Context) {
 	store.Run(ctx)
 	// start router
 	s.startRouter(ctx, r, store)
+	// start ext_proc endpoint picker if enabled
+	if s.ExtProcPort > 0 {
+		s.startExtProcServer(ctx, r, store)
+	}
 
 	// Block until context is cancelled to keep the process running
 	klog.Info("Router server started, waiting for shutdown signal...")
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // TestNewServerDebugPortDefault tests that NewServer accepts different debug port values
@@ -35,7 +36,7 @@ func TestNewServerDebugPortDefault(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			server := NewServer("8080", false, "", "", false, false, tc.debugPort, 0, 0)
+			server := NewServer("8080", false, "", "", false, false, false, tc.debugPort, 0, 0, 0, types.NamespacedName{})
 			assert.Equal(t, tc.debugPort, server.DebugPort, "DebugPort should match the provided value")
 		})
 	}
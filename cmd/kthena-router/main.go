@@ -26,8 +26,10 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/volcano-sh/kthena/cmd/kthena-router/app"
@@ -45,6 +47,7 @@ func main() {
 		enableWebhook                      bool
 		enableGatewayAPI                   bool
 		enableGatewayAPIInferenceExtension bool
+		enableLeaderElection               bool
 		webhookPort                        int
 		webhookCert                        string
 		webhookKey                         string
@@ -53,6 +56,8 @@ func main() {
 		debugPort                          int
 		kubeAPIQPS                         float32
 		kubeAPIBurst                       int
+		extProcPort                        int
+		extProcInferencePool               string
 	)
 
 	klog.InitFlags(nil)
@@ -63,6 +68,9 @@ func main() {
 	pflag.BoolVar(&enableWebhook, "enable-webhook", true, "Enable built-in admission webhook server")
 	pflag.BoolVar(&enableGatewayAPI, "enable-gateway-api", false, "Enable Gateway API related features")
 	pflag.BoolVar(&enableGatewayAPIInferenceExtension, "enable-gateway-api-inference-extension", false, "Enable Gateway API Inference Extension features (requires --enable-gateway-api)")
+	pflag.BoolVar(&enableLeaderElection, "leader-elect", true, "Enable leader election for the status writer and the rollouts. "+
+		"Enabling this will ensure there is only one router replica updating resource status. "+
+		"Progressive rollouts are disabled without it. Default is true.")
 	pflag.IntVar(&webhookPort, "webhook-port", 8443, "The port for the webhook server")
 	pflag.StringVar(&webhookCert, "webhook-tls-cert-file", "/etc/tls/tls.crt", "Path to the webhook TLS certificate file")
 	pflag.StringVar(&webhookKey, "webhook-tls-private-key-file", "/etc/tls/tls.key", "Path to the webhook TLS private key file")
@@ -71,6 +79,8 @@ func main() {
 	pflag.IntVar(&debugPort, "debug-port", 15000, "The port for the debug server (localhost only)")
 	pflag.Float32Var(&kubeAPIQPS, "kube-api-qps", 0, "QPS to use while talking with kubernetes apiserver. If 0, use default value.")
 	pflag.IntVar(&kubeAPIBurst, "kube-api-burst", 0, "Burst to use while talking with kubernetes apiserver. If 0, use default value.")
+	pflag.IntVar(&extProcPort, "ext-proc-port", 0, "The port for the Envoy ext_proc endpoint picker gRPC server. If 0, the endpoint picker is disabled.")
+	pflag.StringVar(&extProcInferencePool, "ext-proc-inference-pool", "", "The InferencePool, in namespace/name format, the ext_proc endpoint picker schedules requests to")
 	defer klog.Flush()
 	pflag.Parse()
 
@@ -90,6 +100,21 @@ func main() {
 		klog.Fatalf("invalid debug port: %d", debugPort)
 	}
 
+	var extProcPool types.NamespacedName
+	if extProcPort != 0 {
+		if extProcPort < 0 || extProcPort > 65535 {
+			klog.Fatalf("invalid ext_proc port: %d", extProcPort)
+		}
+		if !enableGatewayAPIInferenceExtension {
+			klog.Fatal("--ext-proc-port requires --enable-gateway-api-inference-extension to be enabled")
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(extProcInferencePool)
+		if err != nil || namespace == "" || name == "" {
+			klog.Fatalf("--ext-proc-inference-pool must be in namespace/name format, got %q", extProcInferencePool)
+		}
+		extProcPool = types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
 	pflag.CommandLine.VisitAll(func(f *pflag.Flag) {
 		klog.Infof("Flag: %s, Value: %s", f.Name, f.Value.String())
 	})
@@ -111,7 +136,7 @@ func main() {
 		klog.Info("Webhook server is disabled")
 	}
 
-	app.NewServer(routerPort, tlsCert != "" && tlsKey != "", tlsCert, tlsKey, enableGatewayAPI, enableGatewayAPIInferenceExtension, debugPort, kubeAPIQPS, kubeAPIBurst).Run(ctx)
+	app.NewServer(routerPort, tlsCert != "" && tlsKey != "", tlsCert, tlsKey, enableGatewayAPI, enableGatewayAPIInferenceExtension, enableLeaderElection, debugPort, kubeAPIQPS, kubeAPIBurst, extProcPort, extProcPool).Run(ctx)
 }
 
 // ensureWebhookCertificate generates a certificate secret if needed and returns the CA bundle.
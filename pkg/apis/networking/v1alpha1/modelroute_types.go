@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
@@ -53,6 +55,114 @@ type ModelRouteSpec struct {
 	// There is no limitation if this field is not set.
 	// +optional
 	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// MaxRequestBodySize is the maximum size of the body of the requests matched by this route, e.g. `1Mi`.
+	// Larger requests are rejected with an HTTP 413 status code.
+	// The route is only known once the body is read, so the router reads the body up to the largest limit of the
+	// routes the request can match, and checks the limit of the matched route afterwards.
+	// If this field is not set, only the limit configured for the router applies.
+	// +optional
+	MaxRequestBodySize *resource.Quantity `json:"maxRequestBodySize,omitempty"`
+
+	// SLO is the latency objective of the requests matched by this route, as observed by the router.
+	// The router counts the requests meeting or missing each objective.
+	// +optional
+	SLO *LatencySLO `json:"slo,omitempty"`
+
+	// Cache caches the responses of the deterministic completion requests matched by this route,
+	// those with a temperature of 0. Identical requests served by the same ModelServer are answered
+	// from the cache until the cached response expires.
+	// There is no caching if this field is not set.
+	// +optional
+	Cache *ResponseCache `json:"cache,omitempty"`
+
+	// Guardrails check the prompts of the requests matched by this route before inference, and the
+	// text generated in their responses, with guard services. They are called in order.
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	Guardrails []Guardrail `json:"guardrails,omitempty"`
+}
+
+// GuardrailProtocol is the protocol of a guard service.
+// +kubebuilder:validation:Enum=HTTP;GRPC
+type GuardrailProtocol string
+
+const (
+	// GuardrailHTTP guard services answer POST requests with a JSON verdict.
+	GuardrailHTTP GuardrailProtocol = "HTTP"
+	// GuardrailGRPC guard services implement the kthena.guardrail.v1.Guard/Check method.
+	GuardrailGRPC GuardrailProtocol = "GRPC"
+)
+
+// GuardrailPhase is a step of the serving of a request checked by a guardrail.
+// +kubebuilder:validation:Enum=Request;Response
+type GuardrailPhase string
+
+const (
+	// GuardrailRequest checks the prompt of a request before it is sent to the model.
+	GuardrailRequest GuardrailPhase = "Request"
+	// GuardrailResponse checks the text generated by the model before it is sent to the client.
+	GuardrailResponse GuardrailPhase = "Response"
+)
+
+// Guardrail calls a guard service to check the content of the requests and of their responses.
+// The guard service allows, blocks, redacts or annotates the content.
+type Guardrail struct {
+	// Name identifies the guardrail in the access logs.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Protocol is the protocol of the guard service.
+	// +optional
+	// +kubebuilder:default=HTTP
+	Protocol GuardrailProtocol `json:"protocol,omitempty"`
+	// Endpoint is the URL of an HTTP guard service, or the host:port of a gRPC guard service.
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+	// Phases are the steps checked by the guardrail.
+	// Both the requests and the responses are checked if this field is not set.
+	// +optional
+	// +kubebuilder:validation:MaxItems=2
+	Phases []GuardrailPhase `json:"phases,omitempty"`
+	// Timeout is the time to wait for the verdict of the guard service, 1s if this field is not set.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// FailOpen lets the content through when the guard service can not check it.
+	// The content is blocked otherwise.
+	// +optional
+	FailOpen bool `json:"failOpen,omitempty"`
+	// StreamChunkSize is the minimum number of characters of generated text checked at once in
+	// streaming responses. The events of a stream are held until their text is checked.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=200
+	StreamChunkSize *int32 `json:"streamChunkSize,omitempty"`
+}
+
+// ResponseCache configures the caching of the responses of a route by the router.
+type ResponseCache struct {
+	// TTL is how long a response is served from the cache.
+	// +optional
+	// +kubebuilder:default="5m"
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// Redis shares the cached responses between the router replicas.
+	// If this field is not set, each router replica caches the responses in memory.
+	// +optional
+	Redis *RedisConfig `json:"redis,omitempty"`
+}
+
+// LatencySLO defines latency thresholds of the requests. Only the thresholds that are set are checked.
+type LatencySLO struct {
+	// TimeToFirstToken is the maximum time to the first token of streaming requests, measured from
+	// the arrival of the request at the router.
+	// +optional
+	TimeToFirstToken *metav1.Duration `json:"timeToFirstToken,omitempty"`
+	// TimePerOutputToken is the maximum average time per output token of streaming requests,
+	// after the first one.
+	// +optional
+	TimePerOutputToken *metav1.Duration `json:"timePerOutputToken,omitempty"`
+	// EndToEnd is the maximum time to complete the response of a request.
+	// +optional
+	EndToEnd *metav1.Duration `json:"endToEnd,omitempty"`
 }
 
 type Rule struct {
@@ -65,8 +175,276 @@ type Rule struct {
 	ModelMatch *ModelMatch `json:"modelMatch,omitempty"`
 	// +kubebuilder:validation:MaxItems=16
 	TargetModels []*TargetModel `json:"targetModels"`
+	// Fallback is an ordered chain of ModelServers, tried when the target model selected
+	// for a request can not serve it.
+	// There is no fallback if this field is not set.
+	// +optional
+	Fallback *Fallback `json:"fallback,omitempty"`
+	// Mirror sends a copy of a share of the requests of the rule to a shadow ModelServer.
+	// The shadow responses are discarded.
+	// +optional
+	Mirror *Mirror `json:"mirror,omitempty"`
+	// Transforms modify the body of the requests of the rule before they are scheduled, in order.
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	Transforms []BodyTransform `json:"transforms,omitempty"`
+	// Classifier assigns a category to the prompt of each request of the rule, e.g. to send the simple
+	// prompts to a small model and the hard ones to a large model. The target model is then chosen among
+	// the targets of the category.
+	// +optional
+	Classifier *PromptClassifier `json:"classifier,omitempty"`
+	// Rollout shifts the traffic of the rule progressively from a baseline target to a canary target,
+	// gating each step on the error rate and the latency of the canary observed by the router, and
+	// rolling back on regression. The weights of the two targets are then set by the rollout.
+	// The rule must have a name.
+	// +optional
+	Rollout *Rollout `json:"rollout,omitempty"`
 }
 
+// Rollout steps the weights of two targets of a rule, from a baseline ModelServer to a canary ModelServer.
+type Rollout struct {
+	// Baseline is the ModelServer of the target serving the traffic before the rollout.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Baseline string `json:"baseline"`
+	// Canary is the ModelServer of the target the traffic is shifted to. Changing it starts a new rollout.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Canary string `json:"canary"`
+	// Steps are the successive weights of the canary, in order. After the last step, the canary
+	// receives all the traffic of the two targets.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	Steps []RolloutStep `json:"steps"`
+	// Analysis gates each step on the metrics of the canary. Without analysis, the steps only last their pause.
+	// +optional
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// RolloutStep is a step of a rollout.
+type RolloutStep struct {
+	// Weight is the percentage of the traffic of the two targets sent to the canary during the step.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight uint32 `json:"weight"`
+	// Pause is the minimum duration of the step, 5m if this field is not set.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// RolloutAnalysis defines the thresholds the canary must not exceed during each step of a rollout.
+// The rollout is rolled back as soon as a threshold is exceeded.
+type RolloutAnalysis struct {
+	// MaxErrorPercent is the maximum percentage of the canary requests failing with a server error,
+	// a timeout or no available pod, e.g. 1 or 0.5.
+	// +optional
+	MaxErrorPercent *resource.Quantity `json:"maxErrorPercent,omitempty"`
+	// MaxLatency is the maximum 95th percentile of the end-to-end latency of the canary requests.
+	// +optional
+	MaxLatency *metav1.Duration `json:"maxLatency,omitempty"`
+	// MinRequests is the number of canary requests a step needs to be analyzed. A step is held until
+	// it has enough requests, the thresholds are not checked before.
+	//
+	// +optional
+	// +kubebuilder:default=20
+	// +kubebuilder:validation:Minimum=1
+	MinRequests *int32 `json:"minRequests,omitempty"`
+	// PrometheusURL is the URL of a Prometheus server scraping the metrics of all the router replicas.
+	// Without it, the metrics of the router replica running the rollouts are analyzed, which only see
+	// a share of the traffic when the router has several replicas.
+	// The traffic a step observed is also lost when another replica becomes the leader, the step is then
+	// analyzed again from zero. It should be set when the router has several replicas.
+	// +optional
+	PrometheusURL string `json:"prometheusURL,omitempty"`
+}
+
+// PromptClassifier assigns a category to the prompt of a request.
+type PromptClassifier struct {
+	// Type is the kind of classifier.
+	// +optional
+	// +kubebuilder:default=Heuristic
+	Type PromptClassifierType `json:"type,omitempty"`
+	// Categories are the categories of the Heuristic classifier, in order. A prompt is assigned the first
+	// category whose conditions it satisfies.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Categories []PromptCategory `json:"categories,omitempty"`
+	// Endpoint is the URL of the HTTP classifier. It receives a POST request with the JSON object
+	// `{"model": "...", "prompt": "..."}`, and answers with the JSON object `{"category": "..."}`.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Timeout is the maximum time to wait for the answer of the HTTP classifier, 1s if this field is not set.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// DefaultCategory is assigned to the prompts which match no category, or which the classifier fails to classify.
+	// +optional
+	DefaultCategory string `json:"defaultCategory,omitempty"`
+}
+
+// PromptClassifierType is the kind of a PromptClassifier.
+// +kubebuilder:validation:Enum=Heuristic;HTTP
+type PromptClassifierType string
+
+const (
+	// PromptClassifierHeuristic classifies the prompts with the conditions of the categories of the classifier.
+	PromptClassifierHeuristic PromptClassifierType = "Heuristic"
+	// PromptClassifierHTTP asks an HTTP endpoint for the category of the prompts.
+	PromptClassifierHTTP PromptClassifierType = "HTTP"
+)
+
+// PromptCategory is a category of the Heuristic classifier. A prompt is in the category if it satisfies
+// all its conditions, a category without conditions contains all the prompts.
+type PromptCategory struct {
+	// Name is the name of the category.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// MinLength is the minimum number of characters of the prompt.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinLength *int32 `json:"minLength,omitempty"`
+	// MaxLength is the maximum number of characters of the prompt.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxLength *int32 `json:"maxLength,omitempty"`
+	// Keywords match the prompts containing any of them, ignoring case.
+	// +optional
+	Keywords []string `json:"keywords,omitempty"`
+	// Regex matches the prompts matching this regular expression.
+	// +optional
+	Regex *string `json:"regex,omitempty"`
+}
+
+// BodyTransform is a modification of the JSON body of a request, e.g. to clamp `max_tokens`,
+// default `temperature`, strip `logprobs` or inject a system prompt.
+// Transforms whose path does not fit the body, e.g. a number where an object is expected, are skipped.
+type BodyTransform struct {
+	// Type is the kind of modification.
+	Type BodyTransformType `json:"type"`
+	// Path is the dot-separated path of the field to modify, e.g. `max_tokens` or `metadata.tier`.
+	// It is required by the Set, Default, Clamp and Remove transforms.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Value is the JSON value of the field, for the Set and Default transforms.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Value *apiextensionsv1.JSON `json:"value,omitempty"`
+	// Min is the lowest number allowed in the field, for the Clamp transform.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Min *apiextensionsv1.JSON `json:"min,omitempty"`
+	// Max is the highest number allowed in the field, for the Clamp transform.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Max *apiextensionsv1.JSON `json:"max,omitempty"`
+	// Message is the message added before the `messages` of a chat request, for the PrependMessage transform.
+	// +optional
+	Message *TransformMessage `json:"message,omitempty"`
+	// Role is the role of the `messages` removed from a chat request, for the RemoveMessages transform.
+	// +optional
+	Role string `json:"role,omitempty"`
+}
+
+// BodyTransformType is the kind of modification made by a BodyTransform.
+// +kubebuilder:validation:Enum=Set;Default;Clamp;Remove;PrependMessage;RemoveMessages
+type BodyTransformType string
+
+const (
+	// BodyTransformSet sets the field to the value, replacing any value of the request.
+	BodyTransformSet BodyTransformType = "Set"
+	// BodyTransformDefault sets the field to the value if the request does not set it.
+	BodyTransformDefault BodyTransformType = "Default"
+	// BodyTransformClamp bounds the number of the field between min and max, if the request sets it.
+	BodyTransformClamp BodyTransformType = "Clamp"
+	// BodyTransformRemove removes the field from the request.
+	BodyTransformRemove BodyTransformType = "Remove"
+	// BodyTransformPrependMessage adds the message before the `messages` of a chat request.
+	BodyTransformPrependMessage BodyTransformType = "PrependMessage"
+	// BodyTransformRemoveMessages removes the `messages` with the role from a chat request.
+	BodyTransformRemoveMessages BodyTransformType = "RemoveMessages"
+)
+
+// TransformMessage is a chat message added to a request.
+type TransformMessage struct {
+	// Role is the role of the message.
+	// +kubebuilder:default=system
+	// +kubebuilder:validation:Enum=system;developer;user;assistant
+	// +optional
+	Role string `json:"role,omitempty"`
+	// Content is the text of the message.
+	// +kubebuilder:validation:MinLength=1
+	Content string `json:"content"`
+}
+
+// Mirror defines the shadow ModelServer the requests of a rule are mirrored to, e.g. to validate
+// a new model version with live traffic. Mirrored requests are sent in the background, they do
+// not delay the response of the target model and are not counted by the rate limits.
+type Mirror struct {
+	// ModelServerName is the name of the shadow ModelServer within the same namespace.
+	//
+	// +kubebuilder:validation:MinLength=1
+	ModelServerName string `json:"modelServerName"`
+	// Percent is the percentage of the requests which are mirrored.
+	// All the requests are mirrored if this field is not set.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percent *int32 `json:"percent,omitempty"`
+	// LogResponse logs an access log entry for each mirrored request, with the latency and the token
+	// counts of the shadow response, and the hashes of the content of the shadow and the original
+	// responses to tell whether they differ.
+	// +optional
+	LogResponse bool `json:"logResponse,omitempty"`
+}
+
+// Fallback defines the ModelServers which serve a request when its target model fails.
+// The targets are tried in order, until one of them serves the request or fails with
+// a condition which does not trigger the fallback.
+type Fallback struct {
+	// Targets are the ModelServers tried after the target model, in order.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Targets []FallbackTarget `json:"targets"`
+	// Conditions are the failures of a target which trigger the fallback to the next one.
+	// If this field is not set, all the conditions trigger it.
+	// +optional
+	Conditions []FallbackCondition `json:"conditions,omitempty"`
+	// Timeout is the maximum time to wait for the response of a target, before falling back
+	// with the Timeout condition. Responses are not interrupted once they have started.
+	// There is no timeout if this field is not set.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// FallbackTarget is a ModelServer which serves the requests of a rule when the previous targets fail.
+type FallbackTarget struct {
+	// ModelServerName is the name of the ModelServer within the same namespace.
+	//
+	// +kubebuilder:validation:MinLength=1
+	ModelServerName string `json:"modelServerName"`
+}
+
+// FallbackCondition is a failure of a target which triggers the fallback to the next one.
+// +kubebuilder:validation:Enum=NoEndpoints;ServerError;RateLimited;Timeout
+type FallbackCondition string
+
+const (
+	// FallbackOnNoEndpoints falls back when the ModelServer has no pod able to serve the request.
+	FallbackOnNoEndpoints FallbackCondition = "NoEndpoints"
+	// FallbackOnServerError falls back when the pods answer with a 5xx status code, or can not be reached.
+	FallbackOnServerError FallbackCondition = "ServerError"
+	// FallbackOnRateLimited falls back when the pods answer with a 429 status code.
+	FallbackOnRateLimited FallbackCondition = "RateLimited"
+	// FallbackOnTimeout falls back when the pods do not respond within the timeout of the fallback.
+	FallbackOnTimeout FallbackCondition = "Timeout"
+)
+
 // ModelMatch defines the predicate used to match LLM inference requests to a given
 // TargetModels. Multiple match conditions are ANDed together, i.e. the match will
 // evaluate to true only if all conditions are satisfied.
@@ -92,6 +470,47 @@ type BodyMatch struct {
 	// If this field is not specified, any model or lora adapter will be matched.
 	// +optional
 	Model *string `json:"model,omitempty"`
+	// Fields are conditions on other fields of the request body, which must all be satisfied.
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Fields []BodyFieldMatch `json:"fields,omitempty"`
+}
+
+// BodyFieldMatch defines a condition on a field of the JSON body of a request.
+// Exactly one of exact, prefix, regex, range and exists must be set.
+type BodyFieldMatch struct {
+	// Path is the dot-separated path of the field, e.g. `stream`, `user` or `metadata.tier`.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// Exact matches the field if it is equal to this value. Fields which are not strings are
+	// compared with their JSON encoding, e.g. `true` or `2`.
+	// +optional
+	Exact *string `json:"exact,omitempty"`
+	// Prefix matches the field if it starts with this value, fields which are not strings are
+	// compared with their JSON encoding.
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+	// Regex matches the field if it matches this regular expression, fields which are not strings are
+	// compared with their JSON encoding.
+	// +optional
+	Regex *string `json:"regex,omitempty"`
+	// Range matches a number within the bounds. Strings and arrays are matched by their length,
+	// e.g. the number of characters of a `prompt` or the number of `messages`.
+	// +optional
+	Range *NumberRange `json:"range,omitempty"`
+	// Exists matches the field if it is set and not null when true, or if it is not set when false.
+	// +optional
+	Exists *bool `json:"exists,omitempty"`
+}
+
+// NumberRange is an inclusive range of numbers. A missing bound is not checked.
+type NumberRange struct {
+	// Min is the lowest number of the range.
+	// +optional
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the highest number of the range.
+	// +optional
+	Max *resource.Quantity `json:"max,omitempty"`
 }
 
 // StringMatch defines the matching conditions for string fields.
@@ -116,6 +535,10 @@ type TargetModel struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
 	Weight *uint32 `json:"weight,omitempty"`
+	// Category is the category of the requests served by this target, when the rule has a classifier.
+	// The targets without a category serve the requests of the categories which have no target, or else the targets of the default category of the classifier.
+	// +optional
+	Category string `json:"category,omitempty"`
 }
 
 type RateLimit struct {
@@ -166,8 +589,100 @@ const (
 
 // ModelRouteStatus defines the observed state of ModelRoute.
 type ModelRouteStatus struct {
+	// Conditions describe the current state of the ModelRoute as observed by kthena-router.
+	// Known condition types are "Accepted", "ResolvedRefs", "RolloutProgressing" and "RolloutDegraded".
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// MatchedPods is the number of pods backing all the ModelServers referenced by this ModelRoute.
+	// +optional
+	MatchedPods int32 `json:"matchedPods,omitempty"`
+	// ObservedGeneration is the most recent generation observed by kthena-router.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Rollouts are the states of the rollouts of the rules.
+	// +optional
+	// +listType=map
+	// +listMapKey=rule
+	Rollouts []RolloutStatus `json:"rollouts,omitempty"`
+}
+
+// RolloutPhase is the phase of a rollout.
+type RolloutPhase string
+
+const (
+	// RolloutProgressing means that the traffic is being shifted to the canary.
+	RolloutProgressing RolloutPhase = "Progressing"
+	// RolloutSucceeded means that the canary receives all the traffic.
+	RolloutSucceeded RolloutPhase = "Succeeded"
+	// RolloutRolledBack means that the canary exceeded a threshold of the analysis, the baseline
+	// receives all the traffic again.
+	RolloutRolledBack RolloutPhase = "RolledBack"
+)
+
+// RolloutStatus is the state of the rollout of a rule.
+type RolloutStatus struct {
+	// Rule is the name of the rule.
+	Rule string `json:"rule"`
+	// Baseline is the ModelServer of the baseline target.
+	Baseline string `json:"baseline"`
+	// Canary is the ModelServer of the canary target.
+	Canary string `json:"canary"`
+	// Phase is the phase of the rollout.
+	Phase RolloutPhase `json:"phase"`
+	// Step is the index of the current step, the number of steps once the rollout is complete.
+	Step int32 `json:"step"`
+	// CanaryWeight is the weight set on the canary target.
+	CanaryWeight uint32 `json:"canaryWeight"`
+	// StepStartTime is the time the current step started.
+	// +optional
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+	// Analysis is the last analysis of the canary during the current step.
+	// +optional
+	Analysis *RolloutAnalysisResult `json:"analysis,omitempty"`
+	// Message describes the state of the rollout, e.g. the reason of a rollback.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
+// RolloutAnalysisResult is the traffic of the canary observed during a step of a rollout.
+type RolloutAnalysisResult struct {
+	// Requests is the number of canary requests.
+	Requests int64 `json:"requests"`
+	// ErrorPercent is the percentage of the canary requests which failed.
+	// +optional
+	ErrorPercent *resource.Quantity `json:"errorPercent,omitempty"`
+	// LatencyP95 is the 95th percentile of the end-to-end latency of the canary requests.
+	// +optional
+	LatencyP95 *metav1.Duration `json:"latencyP95,omitempty"`
+}
+
+type ModelRouteConditionType string
+
+const (
+	// ModelRouteConditionAccepted indicates whether the ModelRoute has been accepted by the router,
+	// i.e. its parentRefs point to existing kthena-router Gateways.
+	ModelRouteConditionAccepted ModelRouteConditionType = "Accepted"
+	// ModelRouteConditionResolvedRefs indicates whether all the ModelServers referenced by the rules exist.
+	ModelRouteConditionResolvedRefs ModelRouteConditionType = "ResolvedRefs"
+	// ModelRouteConditionRolloutProgressing indicates whether the traffic of a rule is being shifted to a canary.
+	ModelRouteConditionRolloutProgressing ModelRouteConditionType = "RolloutProgressing"
+	// ModelRouteConditionRolloutDegraded indicates whether a rollout was rolled back because its canary regressed.
+	ModelRouteConditionRolloutDegraded ModelRouteConditionType = "RolloutDegraded"
+)
+
+const (
+	ModelRouteReasonAccepted         = "Accepted"
+	ModelRouteReasonNoMatchingParent = "NoMatchingParent"
+	ModelRouteReasonResolvedRefs     = "ResolvedRefs"
+	ModelRouteReasonBackendNotFound  = "BackendNotFound"
+	ModelRouteReasonRolloutStepping  = "RolloutStepping"
+	ModelRouteReasonRolloutSucceeded = "RolloutSucceeded"
+	ModelRouteReasonRolledBack       = "RolledBack"
+	ModelRouteReasonAnalysisPassing  = "AnalysisPassing"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
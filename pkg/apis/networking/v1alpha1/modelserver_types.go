@@ -94,13 +94,19 @@ type WorkloadPort struct {
 	// +kubebuilder:validation:Maximum=65535
 	Port int32 `json:"port"`
 
-	// The protocol of the model server. Supported values are "http" and "https".
+	// The protocol of the model server. Supported values are "http", "https" and "h2c".
+	// With "h2c", the requests are sent over HTTP/2 without TLS, the model server must accept HTTP/2 with prior knowledge.
 	// +optional
 	// +kubebuilder:default="http"
-	// +kubebuilder:validation:Enum=http;https
+	// +kubebuilder:validation:Enum=http;https;h2c
 	Protocol string `json:"protocol,omitempty"`
 }
 
+const (
+	// WorkloadPortProtocolH2C is the protocol of the model servers reached over HTTP/2 without TLS
+	WorkloadPortProtocolH2C = "h2c"
+)
+
 type KVConnectorType string
 
 const (
@@ -143,10 +149,32 @@ type Retry struct {
 
 // ModelServerStatus defines the observed state of ModelServer.
 type ModelServerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Conditions describe the current state of the ModelServer as observed by kthena-router.
+	// Known condition type is "Ready".
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// MatchedPods is the number of pods selected by the WorkloadSelector.
+	// +optional
+	MatchedPods int32 `json:"matchedPods,omitempty"`
+	// ObservedGeneration is the most recent generation observed by kthena-router.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+type ModelServerConditionType string
+
+const (
+	// ModelServerConditionReady indicates whether the ModelServer has at least one pod to serve traffic.
+	ModelServerConditionReady ModelServerConditionType = "Ready"
+)
+
+const (
+	ModelServerReasonPodsAvailable = "PodsAvailable"
+	ModelServerReasonNoPods        = "NoPods"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
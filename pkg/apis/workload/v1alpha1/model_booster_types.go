@@ -45,6 +45,29 @@ type ModelBoosterSpec struct {
 	// evaluate to true only if all conditions are satisfied.
 	// +optional
 	ModelMatch *networking.ModelMatch `json:"modelMatch,omitempty"`
+	// LoraAdapters are the LoRA adapters loaded on the serving pods of the backend.
+	// The adapters are downloaded and loaded by the runtime sidecar without restarting the pods,
+	// the engine must allow runtime LoRA updating, e.g. VLLM_ALLOW_RUNTIME_LORA_UPDATING=True for vLLM.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	LoraAdapters []LoraAdapter `json:"loraAdapters,omitempty"`
+}
+
+// LoraAdapter defines a LoRA adapter served by the model.
+type LoraAdapter struct {
+	// Name is the name of the adapter. Requests use it as the model name to be served by the adapter.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	Name string `json:"name"`
+	// ArtifactURL is the URI where you download the adapter. Support hf://, s3://, obs://, pvc://.
+	// +kubebuilder:validation:Pattern=`^(hf://|s3://|obs://|pvc://).+`
+	ArtifactURL string `json:"artifactURL"`
+	// Replicas is the number of pods of each role the adapter is loaded on.
+	// If not set, the adapter is loaded on all the pods.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
 }
 
 // ModelBackend defines the configuration for a model backend.
@@ -172,8 +195,41 @@ type ModelStatus struct {
 	// ObservedGeneration track of generation
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LoraAdapters is the state of the LoRA adapters of the model, including the removed adapters
+	// which are not unloaded yet.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	LoraAdapters []LoraAdapterStatus `json:"loraAdapters,omitempty"`
+}
+
+// LoraAdapterStatus defines the observed state of a LoRA adapter.
+type LoraAdapterStatus struct {
+	// Name is the name of the adapter.
+	Name string `json:"name"`
+	// Phase is the loading phase of the adapter.
+	Phase LoraAdapterPhase `json:"phase"`
+	// Pods are the names of the pods the adapter is loaded on.
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+	// Message is the reason of the last loading failure.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
+// LoraAdapterPhase defines the loading phase of a LoRA adapter.
+// +kubebuilder:validation:Enum=Pending;Loaded;Failed
+type LoraAdapterPhase string
+
+const (
+	// LoraAdapterPending means there is no ready pod to load the adapter on yet.
+	LoraAdapterPending LoraAdapterPhase = "Pending"
+	// LoraAdapterLoaded means the adapter is loaded on the requested number of ready pods.
+	LoraAdapterLoaded LoraAdapterPhase = "Loaded"
+	// LoraAdapterFailed means the adapter could not be loaded or unloaded on some pods.
+	LoraAdapterFailed LoraAdapterPhase = "Failed"
+)
+
 type ModelStatusConditionType string
 
 const (
@@ -419,6 +419,46 @@ func (in *HomogeneousTarget) DeepCopy() *HomogeneousTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoraAdapter) DeepCopyInto(out *LoraAdapter) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoraAdapter.
+func (in *LoraAdapter) DeepCopy() *LoraAdapter {
+	if in == nil {
+		return nil
+	}
+	out := new(LoraAdapter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoraAdapterStatus) DeepCopyInto(out *LoraAdapterStatus) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoraAdapterStatus.
+func (in *LoraAdapterStatus) DeepCopy() *LoraAdapterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LoraAdapterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata) DeepCopyInto(out *Metadata) {
 	*out = *in
@@ -577,6 +617,13 @@ func (in *ModelBoosterSpec) DeepCopyInto(out *ModelBoosterSpec) {
 		*out = new(networkingv1alpha1.ModelMatch)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LoraAdapters != nil {
+		in, out := &in.LoraAdapters, &out.LoraAdapters
+		*out = make([]LoraAdapter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelBoosterSpec.
@@ -713,6 +760,13 @@ func (in *ModelStatus) DeepCopyInto(out *ModelStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LoraAdapters != nil {
+		in, out := &in.LoraAdapters, &out.LoraAdapters
+		*out = make([]LoraAdapterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelStatus.
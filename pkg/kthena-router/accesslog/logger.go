@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -146,8 +147,12 @@ func (l *accessLoggerImpl) formatJSON(entry *AccessLogEntry) (string, error) {
 // formatText formats the entry as structured text
 func (l *accessLoggerImpl) formatText(entry *AccessLogEntry) (string, error) {
 	// Format: [timestamp] "METHOD /path PROTOCOL" status_code [error=type:message]
-	// model_name=name model_route=route model_server=server selected_pod=pod request_id=id tokens=input/output
+	// model_name=name model_route=route model_server=server selected_pod=pod request_id=id
+	// prompt_category=category (classified requests only) guardrails=name:phase:action,... (guarded requests only)
+	// tokens=input/output
+	// ttft=ms tpot=ms (streams only)
 	// timings=total(req+upstream+resp)ms
+	// mirror=true response_hash=hash primary_response_hash=hash response_match=bool (shadow requests only)
 
 	timestamp := entry.Timestamp.Format(time.RFC3339Nano)
 
@@ -177,11 +182,24 @@ func (l *accessLoggerImpl) formatText(entry *AccessLogEntry) (string, error) {
 	if entry.RequestID != "" {
 		line += fmt.Sprintf(" request_id=%s", entry.RequestID)
 	}
+	if entry.PromptCategory != "" {
+		line += fmt.Sprintf(" prompt_category=%s", entry.PromptCategory)
+	}
+	if len(entry.Guardrails) > 0 {
+		verdicts := make([]string, 0, len(entry.Guardrails))
+		for _, verdict := range entry.Guardrails {
+			verdicts = append(verdicts, fmt.Sprintf("%s:%s:%s", verdict.Name, verdict.Phase, verdict.Action))
+		}
+		line += " guardrails=" + strings.Join(verdicts, ",")
+	}
 
 	// Add token information
 	if entry.InputTokens > 0 || entry.OutputTokens > 0 {
 		line += fmt.Sprintf(" tokens=%d/%d", entry.InputTokens, entry.OutputTokens)
 	}
+	if entry.TTFT > 0 {
+		line += fmt.Sprintf(" ttft=%dms tpot=%dms", entry.TTFT, entry.TPOT)
+	}
 
 	// Add complete timing breakdown with total and breakdown
 	line += fmt.Sprintf(" timings=%dms(%d+%d+%d)",
@@ -190,6 +208,11 @@ func (l *accessLoggerImpl) formatText(entry *AccessLogEntry) (string, error) {
 		entry.DurationUpstreamProcessing,
 		entry.DurationResponseProcessing)
 
+	if entry.Mirror != nil {
+		line += fmt.Sprintf(" mirror=true response_hash=%s primary_response_hash=%s response_match=%t",
+			entry.Mirror.ResponseHash, entry.Mirror.PrimaryResponseHash, entry.Mirror.ResponseMatch)
+	}
+
 	return line, nil
 }
 
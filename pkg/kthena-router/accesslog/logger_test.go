@@ -80,18 +80,25 @@ func TestAccessLogEntry_ToJSON(t *testing.T) {
 
 func TestAccessLogEntry_ToText(t *testing.T) {
 	entry := &AccessLogEntry{
-		Timestamp:                  time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
-		Method:                     "POST",
-		Path:                       "/v1/chat/completions",
-		Protocol:                   "HTTP/1.1",
-		StatusCode:                 200,
-		ModelName:                  "llama2-7b",
-		ModelRoute:                 "default/llama2-route-v1",
-		ModelServer:                "default/llama2-server",
-		SelectedPod:                "llama2-deployment-5f7b8c9d-xk2p4",
-		RequestID:                  "test-request-id",
+		Timestamp:      time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
+		Method:         "POST",
+		Path:           "/v1/chat/completions",
+		Protocol:       "HTTP/1.1",
+		StatusCode:     200,
+		ModelName:      "llama2-7b",
+		ModelRoute:     "default/llama2-route-v1",
+		ModelServer:    "default/llama2-server",
+		SelectedPod:    "llama2-deployment-5f7b8c9d-xk2p4",
+		RequestID:      "test-request-id",
+		PromptCategory: "simple",
+		Guardrails: []GuardrailVerdict{
+			{Name: "moderation", Phase: "request", Action: "allow"},
+			{Name: "moderation", Phase: "response", Action: "redact", Reason: "email address"},
+		},
 		InputTokens:                150,
 		OutputTokens:               75,
+		TTFT:                       120,
+		TPOT:                       28,
 		DurationTotal:              2350,
 		DurationRequestProcessing:  45,
 		DurationUpstreamProcessing: 2180,
@@ -117,8 +124,9 @@ func TestAccessLogEntry_ToText(t *testing.T) {
 		`model_route=default/llama2-route-v1`,
 		`model_server=default/llama2-server`,
 		`selected_pod=llama2-deployment-5f7b8c9d-xk2p4`,
-		`request_id=test-request-id`,
+		`request_id=test-request-id prompt_category=simple guardrails=moderation:request:allow,moderation:response:redact`,
 		`tokens=150/75`,
+		`ttft=120ms tpot=28ms`,
 		`timings=2350ms(45+2180+5)`,
 	}
 
@@ -171,6 +179,38 @@ func TestAccessLogEntry_WithError(t *testing.T) {
 	assert.Contains(t, output, "error=timeout:Model inference timeout after 30s")
 }
 
+func TestAccessLogEntry_WithMirror(t *testing.T) {
+	entry := &AccessLogEntry{
+		Timestamp:     time.Date(2024, 1, 15, 10, 30, 45, 123000000, time.UTC),
+		Method:        "POST",
+		Path:          "/v1/chat/completions",
+		Protocol:      "HTTP/1.1",
+		StatusCode:    200,
+		ModelName:     "llama2-7b",
+		ModelServer:   "default/llama2-shadow",
+		DurationTotal: 100,
+		Mirror: &MirrorInfo{
+			ResponseHash:        "0123456789abcdef",
+			PrimaryResponseHash: "fedcba9876543210",
+		},
+	}
+	logger := &accessLoggerImpl{config: &AccessLoggerConfig{Format: FormatJSON, Output: "stdout", Enabled: true}}
+
+	output, err := logger.formatJSON(entry)
+	require.NoError(t, err)
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Equal(t, map[string]interface{}{
+		"response_hash":         "0123456789abcdef",
+		"primary_response_hash": "fedcba9876543210",
+		"response_match":        false,
+	}, parsed["mirror"])
+
+	output, err = logger.formatText(entry)
+	require.NoError(t, err)
+	assert.Contains(t, output, "mirror=true response_hash=0123456789abcdef primary_response_hash=fedcba9876543210 response_match=false")
+}
+
 func TestAccessLogContext_Lifecycle(t *testing.T) {
 	modelName := "test-model"
 	requestID := "test-request-123"
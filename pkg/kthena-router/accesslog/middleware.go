@@ -87,6 +87,20 @@ func SetRequestRouting(c *gin.Context, modelRoute, modelServer, selectedPod stri
 	}
 }
 
+// SetPromptCategory sets the category assigned to the prompt by a classifier in the access log context
+func SetPromptCategory(c *gin.Context, category string) {
+	if ctx := GetAccessLogContext(c); ctx != nil {
+		ctx.PromptCategory = category
+	}
+}
+
+// AddGuardrailVerdict records the verdict of a guardrail in the access log context
+func AddGuardrailVerdict(c *gin.Context, verdict GuardrailVerdict) {
+	if ctx := GetAccessLogContext(c); ctx != nil {
+		ctx.AddGuardrailVerdict(verdict)
+	}
+}
+
 // SetTokenCounts sets token counts in the access log context
 func SetTokenCounts(c *gin.Context, inputTokens, outputTokens int) {
 	if ctx := GetAccessLogContext(c); ctx != nil {
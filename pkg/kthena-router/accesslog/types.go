@@ -38,18 +38,54 @@ type AccessLogEntry struct {
 	ModelServer string `json:"model_server,omitempty"`
 	SelectedPod string `json:"selected_pod,omitempty"`
 	RequestID   string `json:"request_id,omitempty"`
+	// PromptCategory is the category assigned to the prompt by the classifier of the ModelRoute rule
+	PromptCategory string `json:"prompt_category,omitempty"`
+	// Guardrails are the verdicts of the guardrails of the ModelRoute
+	Guardrails []GuardrailVerdict `json:"guardrails,omitempty"`
 
 	// Token information
 	InputTokens  int `json:"input_tokens,omitempty"`
 	OutputTokens int `json:"output_tokens,omitempty"`
 
+	// Streaming latency (in milliseconds): time to first token and time per output token
+	TTFT int64 `json:"ttft,omitempty"`
+	TPOT int64 `json:"tpot,omitempty"`
+
 	// Timing breakdown (in milliseconds) - flattened fields
 	DurationTotal              int64 `json:"duration_total"`
 	DurationRequestProcessing  int64 `json:"duration_request_processing"`
 	DurationUpstreamProcessing int64 `json:"duration_upstream_processing"`
 	DurationResponseProcessing int64 `json:"duration_response_processing"`
+
+	// Mirror is set on the entries of the shadow requests of mirrored requests
+	Mirror *MirrorInfo `json:"mirror,omitempty"`
+}
+
+// MirrorInfo compares the response of a shadow request with the response of the original request
+type MirrorInfo struct {
+	// ResponseHash and PrimaryResponseHash are the digests of the generated content of the shadow and
+	// the original responses, they are empty if a response has no content
+	ResponseHash        string `json:"response_hash,omitempty"`
+	PrimaryResponseHash string `json:"primary_response_hash,omitempty"`
+	ResponseMatch       bool   `json:"response_match"`
 }
 
+// GuardrailVerdict is the verdict of a guardrail on the request or on the response. A response checked
+// several times, e.g. a stream, has the most severe verdict of its checks.
+type GuardrailVerdict struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+	// Action is allow, annotate, redact or block
+	Action      string            `json:"action"`
+	Reason      string            `json:"reason,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Error is the failure of the guard service, the action is then the one of its failure policy
+	Error string `json:"error,omitempty"`
+}
+
+// guardrailActionSeverity orders the actions of the guardrails
+var guardrailActionSeverity = map[string]int{"allow": 0, "annotate": 1, "redact": 2, "block": 3}
+
 // ErrorInfo contains error details for failed requests
 type ErrorInfo struct {
 	Type    string `json:"type"`
@@ -69,10 +105,20 @@ type AccessLogContext struct {
 	ModelServer string
 	SelectedPod string
 
+	// Category assigned to the prompt by the classifier of the rule
+	PromptCategory string
+
+	// Verdicts of the guardrails of the route
+	Guardrails []GuardrailVerdict
+
 	// Token counts
 	InputTokens  int
 	OutputTokens int
 
+	// Streaming latency
+	TTFT time.Duration
+	TPOT time.Duration
+
 	// Timing checkpoints
 	RequestProcessingStart  time.Time
 	RequestProcessingEnd    time.Time
@@ -115,6 +161,38 @@ func (ctx *AccessLogContext) SetTokenCounts(inputTokens, outputTokens int) {
 	ctx.OutputTokens = outputTokens
 }
 
+// SetTokenLatency sets the time to first token and the time per output token of a stream
+func (ctx *AccessLogContext) SetTokenLatency(ttft, tpot time.Duration) {
+	ctx.TTFT = ttft
+	ctx.TPOT = tpot
+}
+
+// AddGuardrailVerdict records the verdict of a guardrail. A single verdict is kept per guardrail and
+// phase, the most severe one, with the annotations of all the checks.
+func (ctx *AccessLogContext) AddGuardrailVerdict(verdict GuardrailVerdict) {
+	for i := range ctx.Guardrails {
+		recorded := &ctx.Guardrails[i]
+		if recorded.Name != verdict.Name || recorded.Phase != verdict.Phase {
+			continue
+		}
+		for key, value := range verdict.Annotations {
+			if recorded.Annotations == nil {
+				recorded.Annotations = make(map[string]string)
+			}
+			recorded.Annotations[key] = value
+		}
+		if guardrailActionSeverity[verdict.Action] > guardrailActionSeverity[recorded.Action] {
+			recorded.Action = verdict.Action
+			recorded.Reason = verdict.Reason
+		}
+		if verdict.Error != "" {
+			recorded.Error = verdict.Error
+		}
+		return
+	}
+	ctx.Guardrails = append(ctx.Guardrails, verdict)
+}
+
 // SetError sets error information
 func (ctx *AccessLogContext) SetError(errorType, message string) {
 	ctx.Error = &ErrorInfo{
@@ -187,8 +265,12 @@ func (ctx *AccessLogContext) ToAccessLogEntry(statusCode int) *AccessLogEntry {
 		ModelServer:                modelServerName,
 		SelectedPod:                ctx.SelectedPod,
 		RequestID:                  ctx.RequestID,
+		PromptCategory:             ctx.PromptCategory,
+		Guardrails:                 ctx.Guardrails,
 		InputTokens:                ctx.InputTokens,
 		OutputTokens:               ctx.OutputTokens,
+		TTFT:                       ctx.TTFT.Milliseconds(),
+		TPOT:                       ctx.TPOT.Milliseconds(),
 		DurationTotal:              total,
 		DurationRequestProcessing:  requestProcessing,
 		DurationUpstreamProcessing: upstreamProcessing,
@@ -0,0 +1,267 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package anthropic translates the Anthropic Messages API to the OpenAI chat completions API served by
+// the inference engines, so that clients using Anthropic SDKs can be routed like any other request.
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MessagesPath is the path of the Anthropic Messages API
+	MessagesPath = "/v1/messages"
+	// ChatCompletionsPath is the path of the OpenAI chat completions API the requests are translated to
+	ChatCompletionsPath = "/v1/chat/completions"
+)
+
+// IsMessagesRequest returns true if the request path is the Anthropic Messages API
+func IsMessagesRequest(path string) bool {
+	return strings.TrimSuffix(path, "/") == MessagesPath
+}
+
+// ToChatCompletionsRequest translates an Anthropic Messages API request body to an OpenAI chat completions
+// request body. Unsupported fields, such as extended thinking or server tools, are dropped.
+func ToChatCompletionsRequest(body map[string]interface{}) (map[string]interface{}, error) {
+	model, ok := body["model"].(string)
+	if !ok {
+		return nil, fmt.Errorf("model not found")
+	}
+	messages, ok := body["messages"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("messages is not a list")
+	}
+
+	var chatMessages []interface{}
+	if system := textOf(body["system"]); system != "" {
+		chatMessages = append(chatMessages, map[string]interface{}{"role": "system", "content": system})
+	}
+	for _, message := range messages {
+		msgMap, ok := message.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("message is not an object")
+		}
+		converted, err := convertMessage(msgMap)
+		if err != nil {
+			return nil, err
+		}
+		chatMessages = append(chatMessages, converted...)
+	}
+
+	request := map[string]interface{}{
+		"model":    model,
+		"messages": chatMessages,
+	}
+	for _, field := range []string{"max_tokens", "temperature", "top_p", "top_k"} {
+		if value, ok := body[field]; ok {
+			request[field] = value
+		}
+	}
+	if stop, ok := body["stop_sequences"]; ok {
+		request["stop"] = stop
+	}
+	if metadata, ok := body["metadata"].(map[string]interface{}); ok {
+		if userID, ok := metadata["user_id"].(string); ok {
+			request["user"] = userID
+		}
+	}
+	if stream, ok := body["stream"].(bool); ok && stream {
+		request["stream"] = true
+		// The usage is needed to write the final message_delta event
+		request["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if tools := convertTools(body["tools"]); len(tools) > 0 {
+		request["tools"] = tools
+	}
+	if toolChoice, ok := body["tool_choice"].(map[string]interface{}); ok {
+		if choice := convertToolChoice(toolChoice); choice != nil {
+			request["tool_choice"] = choice
+		}
+		if disabled, ok := toolChoice["disable_parallel_tool_use"].(bool); ok && disabled {
+			request["parallel_tool_calls"] = false
+		}
+	}
+	return request, nil
+}
+
+// convertMessage converts an Anthropic message into OpenAI chat messages. Tool results of a user message
+// become tool messages, placed before the rest of the user content.
+func convertMessage(message map[string]interface{}) ([]interface{}, error) {
+	role, ok := message["role"].(string)
+	if !ok {
+		return nil, fmt.Errorf("message role not found")
+	}
+
+	var blocks []interface{}
+	switch content := message["content"].(type) {
+	case string:
+		return []interface{}{map[string]interface{}{"role": role, "content": content}}, nil
+	case []interface{}:
+		blocks = content
+	default:
+		return nil, fmt.Errorf("message content is not a string or a list")
+	}
+
+	var result []interface{}
+	var parts []interface{}
+	var toolCalls []interface{}
+	onlyText := true
+	for _, block := range blocks {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		blockType, _ := blockMap["type"].(string)
+		switch blockType {
+		case "text":
+			text, _ := blockMap["text"].(string)
+			parts = append(parts, map[string]interface{}{"type": "text", "text": text})
+		case "image":
+			url := imageURL(blockMap["source"])
+			if url == "" {
+				continue
+			}
+			onlyText = false
+			parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": url}})
+		case "tool_use":
+			arguments, err := json.Marshal(blockMap["input"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid tool_use input: %v", err)
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":   blockMap["id"],
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      blockMap["name"],
+					"arguments": string(arguments),
+				},
+			})
+		case "tool_result":
+			result = append(result, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": blockMap["tool_use_id"],
+				"content":      textOf(blockMap["content"]),
+			})
+		}
+	}
+
+	if len(parts) == 0 && len(toolCalls) == 0 {
+		return result, nil
+	}
+	msg := map[string]interface{}{"role": role}
+	if onlyText {
+		// Plain text content is supported by every chat template
+		var texts []string
+		for _, part := range parts {
+			texts = append(texts, part.(map[string]interface{})["text"].(string))
+		}
+		msg["content"] = strings.Join(texts, "\n")
+	} else {
+		msg["content"] = parts
+	}
+	if len(toolCalls) > 0 {
+		msg["tool_calls"] = toolCalls
+		if len(parts) == 0 {
+			msg["content"] = nil
+		}
+	}
+	return append(result, msg), nil
+}
+
+// imageURL returns the url of an image source, base64 images are returned as data urls
+func imageURL(source interface{}) string {
+	sourceMap, ok := source.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	switch sourceMap["type"] {
+	case "base64":
+		mediaType, _ := sourceMap["media_type"].(string)
+		data, _ := sourceMap["data"].(string)
+		return fmt.Sprintf("data:%s;base64,%s", mediaType, data)
+	case "url":
+		url, _ := sourceMap["url"].(string)
+		return url
+	default:
+		return ""
+	}
+}
+
+// textOf returns the text of a string or of a list of text blocks, as used by system prompts and tool results
+func textOf(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var texts []string
+		for _, block := range v {
+			if blockMap, ok := block.(map[string]interface{}); ok && blockMap["type"] == "text" {
+				if text, ok := blockMap["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+// convertTools converts the Anthropic client tools to OpenAI function tools. Server tools, which have no
+// input schema, are executed by Anthropic and can not be translated.
+func convertTools(value interface{}) []interface{} {
+	tools, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var result []interface{}
+	for _, tool := range tools {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok || toolMap["input_schema"] == nil {
+			continue
+		}
+		function := map[string]interface{}{
+			"name":       toolMap["name"],
+			"parameters": toolMap["input_schema"],
+		}
+		if description, ok := toolMap["description"]; ok {
+			function["description"] = description
+		}
+		result = append(result, map[string]interface{}{"type": "function", "function": function})
+	}
+	return result
+}
+
+func convertToolChoice(toolChoice map[string]interface{}) interface{} {
+	switch toolChoice["type"] {
+	case "auto":
+		return "auto"
+	case "any":
+		return "required"
+	case "none":
+		return "none"
+	case "tool":
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]interface{}{"name": toolChoice["name"]},
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMessagesRequest(t *testing.T) {
+	assert.True(t, IsMessagesRequest("/v1/messages"))
+	assert.True(t, IsMessagesRequest("/v1/messages/"))
+	assert.False(t, IsMessagesRequest("/v1/messages/count_tokens"))
+	assert.False(t, IsMessagesRequest("/v1/chat/completions"))
+}
+
+func TestToChatCompletionsRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "text conversation",
+			body: `{
+				"model": "claude",
+				"max_tokens": 256,
+				"system": [{"type": "text", "text": "Be brief."}],
+				"temperature": 0.5,
+				"stop_sequences": ["END"],
+				"metadata": {"user_id": "user-1"},
+				"messages": [
+					{"role": "user", "content": "Hello"},
+					{"role": "assistant", "content": [{"type": "text", "text": "Hi!"}]},
+					{"role": "user", "content": [{"type": "text", "text": "How"}, {"type": "text", "text": "are you?"}]}
+				]
+			}`,
+			want: `{
+				"model": "claude",
+				"max_tokens": 256,
+				"temperature": 0.5,
+				"stop": ["END"],
+				"user": "user-1",
+				"messages": [
+					{"role": "system", "content": "Be brief."},
+					{"role": "user", "content": "Hello"},
+					{"role": "assistant", "content": "Hi!"},
+					{"role": "user", "content": "How\nare you?"}
+				]
+			}`,
+		},
+		{
+			name: "streaming with images",
+			body: `{
+				"model": "claude",
+				"stream": true,
+				"messages": [{"role": "user", "content": [
+					{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "AAAA"}},
+					{"type": "image", "source": {"type": "url", "url": "https://example.com/cat.png"}},
+					{"type": "text", "text": "Compare"}
+				]}]
+			}`,
+			want: `{
+				"model": "claude",
+				"stream": true,
+				"stream_options": {"include_usage": true},
+				"messages": [{"role": "user", "content": [
+					{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}},
+					{"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}},
+					{"type": "text", "text": "Compare"}
+				]}]
+			}`,
+		},
+		{
+			name: "tool use",
+			body: `{
+				"model": "claude",
+				"tools": [
+					{"name": "get_weather", "description": "Get the weather", "input_schema": {"type": "object"}},
+					{"type": "web_search_20250305", "name": "web_search"}
+				],
+				"tool_choice": {"type": "tool", "name": "get_weather", "disable_parallel_tool_use": true},
+				"messages": [
+					{"role": "user", "content": "Weather in Paris?"},
+					{"role": "assistant", "content": [
+						{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+					]},
+					{"role": "user", "content": [
+						{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "sunny"}]},
+						{"type": "text", "text": "Thanks"}
+					]}
+				]
+			}`,
+			want: `{
+				"model": "claude",
+				"tools": [{"type": "function", "function": {"name": "get_weather", "description": "Get the weather", "parameters": {"type": "object"}}}],
+				"tool_choice": {"type": "function", "function": {"name": "get_weather"}},
+				"parallel_tool_calls": false,
+				"messages": [
+					{"role": "user", "content": "Weather in Paris?"},
+					{"role": "assistant", "content": null, "tool_calls": [
+						{"id": "toolu_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+					]},
+					{"role": "tool", "tool_call_id": "toolu_1", "content": "sunny"},
+					{"role": "user", "content": "Thanks"}
+				]
+			}`,
+		},
+		{name: "missing messages", body: `{"model": "claude"}`, wantErr: true},
+		{name: "invalid content", body: `{"model": "claude", "messages": [{"role": "user", "content": 1}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+
+			got, err := ToChatCompletionsRequest(body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			data, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	assert.Equal(t, "auto", convertToolChoice(map[string]interface{}{"type": "auto"}))
+	assert.Equal(t, "required", convertToolChoice(map[string]interface{}{"type": "any"}))
+	assert.Equal(t, "none", convertToolChoice(map[string]interface{}{"type": "none"}))
+	assert.Nil(t, convertToolChoice(map[string]interface{}{"type": "unknown"}))
+}
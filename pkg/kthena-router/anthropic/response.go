@@ -0,0 +1,337 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+)
+
+// chatCompletion is an OpenAI chat completion, or a chunk of a streamed chat completion
+type chatCompletion struct {
+	ID      string          `json:"id"`
+	Model   string          `json:"model"`
+	Choices []chatChoice    `json:"choices"`
+	Usage   *handlers.Usage `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Message      chatMessage `json:"message"`
+	Delta        chatMessage `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+	// StopReason is set by vLLM to the stop sequence that ended the generation
+	StopReason interface{} `json:"stop_reason"`
+}
+
+type chatMessage struct {
+	Content   *string    `json:"content"`
+	ToolCalls []toolCall `json:"tool_calls"`
+}
+
+type toolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// Message is an Anthropic Messages API response
+type Message struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Role         string         `json:"role"`
+	Model        string         `json:"model"`
+	Content      []ContentBlock `json:"content"`
+	StopReason   *string        `json:"stop_reason"`
+	StopSequence *string        `json:"stop_sequence"`
+	Usage        Usage          `json:"usage"`
+}
+
+// ContentBlock is a text or tool_use content block of an Anthropic message
+type ContentBlock struct {
+	Type  string          `json:"type"`
+	Text  *string         `json:"text,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// Usage is the token usage of an Anthropic message
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ToMessagesResponse translates an OpenAI chat completion response body to an Anthropic message
+func ToMessagesResponse(body []byte) ([]byte, error) {
+	var completion chatCompletion
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, err
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("no choice in chat completion")
+	}
+	choice := completion.Choices[0]
+
+	message := Message{
+		ID:      completion.ID,
+		Type:    "message",
+		Role:    "assistant",
+		Model:   completion.Model,
+		Content: []ContentBlock{},
+	}
+	if content := choice.Message.Content; content != nil && *content != "" {
+		message.Content = append(message.Content, ContentBlock{Type: "text", Text: content})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		message.Content = append(message.Content, ContentBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: toolInput(call.Function.Arguments),
+		})
+	}
+	stopReason, stopSequence := convertFinishReason(choice.FinishReason, choice.StopReason)
+	message.StopReason = &stopReason
+	message.StopSequence = stopSequence
+	if completion.Usage != nil {
+		message.Usage = Usage{InputTokens: completion.Usage.PromptTokens, OutputTokens: completion.Usage.CompletionTokens}
+	}
+	return json.Marshal(message)
+}
+
+// toolInput returns the arguments of a tool call as a JSON object, invalid arguments give an empty object
+func toolInput(arguments string) json.RawMessage {
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(arguments), &input); err != nil || input == nil {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// convertFinishReason maps an OpenAI finish reason to an Anthropic stop reason and stop sequence
+func convertFinishReason(finishReason *string, stopReason interface{}) (string, *string) {
+	if finishReason == nil {
+		return "end_turn", nil
+	}
+	switch *finishReason {
+	case "length":
+		return "max_tokens", nil
+	case "tool_calls":
+		return "tool_use", nil
+	case "stop":
+		if sequence, ok := stopReason.(string); ok && sequence != "" {
+			return "stop_sequence", &sequence
+		}
+		return "end_turn", nil
+	default:
+		return "end_turn", nil
+	}
+}
+
+// ToErrorResponse wraps an error response body of the router in an Anthropic error
+func ToErrorResponse(statusCode int, body []byte) []byte {
+	message := strings.TrimSpace(string(body))
+	var text string
+	if err := json.Unmarshal(body, &text); err == nil {
+		message = text
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"type": "error",
+		"error": map[string]interface{}{
+			"type":    errorType(statusCode),
+			"message": message,
+		},
+	})
+	return data
+}
+
+func errorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+// StreamTranslator translates the server-sent events of a streamed OpenAI chat completion to the
+// events of a streamed Anthropic message: message_start, content_block_start, content_block_delta,
+// content_block_stop, message_delta and message_stop.
+type StreamTranslator struct {
+	started  bool
+	finished bool
+	// index is the index of the open content block, -1 if there is none
+	index     int
+	nextIndex int
+	// toolBlocks maps the index of the tool calls to the index of their content block
+	toolBlocks   map[int]int
+	stopReason   string
+	stopSequence *string
+	usage        Usage
+}
+
+// NewStreamTranslator returns a translator for one streamed response
+func NewStreamTranslator() *StreamTranslator {
+	return &StreamTranslator{index: -1, toolBlocks: map[int]int{}, stopReason: "end_turn"}
+}
+
+// Translate translates one line of the OpenAI stream, and returns the Anthropic events to send
+func (t *StreamTranslator) Translate(line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return nil
+	}
+	data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+	if bytes.Equal(data, []byte("[DONE]")) {
+		return t.Finish()
+	}
+
+	var chunk chatCompletion
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil
+	}
+
+	var out bytes.Buffer
+	if !t.started {
+		t.started = true
+		writeEvent(&out, "message_start", map[string]interface{}{
+			"type": "message_start",
+			"message": Message{
+				ID:      chunk.ID,
+				Type:    "message",
+				Role:    "assistant",
+				Model:   chunk.Model,
+				Content: []ContentBlock{},
+			},
+		})
+	}
+	if chunk.Usage != nil {
+		t.usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+	}
+	if len(chunk.Choices) == 0 {
+		return out.Bytes()
+	}
+
+	choice := chunk.Choices[0]
+	if content := choice.Delta.Content; content != nil && *content != "" {
+		if t.index < 0 || t.isToolBlock(t.index) {
+			empty := ""
+			t.startBlock(&out, ContentBlock{Type: "text", Text: &empty})
+		}
+		writeEvent(&out, "content_block_delta", map[string]interface{}{
+			"type":  "content_block_delta",
+			"index": t.index,
+			"delta": map[string]interface{}{"type": "text_delta", "text": *content},
+		})
+	}
+	for _, call := range choice.Delta.ToolCalls {
+		if _, ok := t.toolBlocks[call.Index]; !ok {
+			t.startBlock(&out, ContentBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: json.RawMessage("{}")})
+			t.toolBlocks[call.Index] = t.index
+		}
+		if call.Function.Arguments != "" {
+			writeEvent(&out, "content_block_delta", map[string]interface{}{
+				"type":  "content_block_delta",
+				"index": t.toolBlocks[call.Index],
+				"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": call.Function.Arguments},
+			})
+		}
+	}
+	if choice.FinishReason != nil {
+		t.stopReason, t.stopSequence = convertFinishReason(choice.FinishReason, choice.StopReason)
+		t.stopBlock(&out)
+	}
+	return out.Bytes()
+}
+
+// Finish returns the final events of the message. It is called when the OpenAI stream is done,
+// and may be called again when the response ends, in which case it returns nothing.
+func (t *StreamTranslator) Finish() []byte {
+	if !t.started || t.finished {
+		return nil
+	}
+	t.finished = true
+
+	var out bytes.Buffer
+	t.stopBlock(&out)
+	writeEvent(&out, "message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": t.stopReason, "stop_sequence": t.stopSequence},
+		"usage": t.usage,
+	})
+	writeEvent(&out, "message_stop", map[string]interface{}{"type": "message_stop"})
+	return out.Bytes()
+}
+
+func (t *StreamTranslator) isToolBlock(index int) bool {
+	for _, blockIndex := range t.toolBlocks {
+		if blockIndex == index {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *StreamTranslator) startBlock(out *bytes.Buffer, block ContentBlock) {
+	t.stopBlock(out)
+	t.index = t.nextIndex
+	t.nextIndex++
+	writeEvent(out, "content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         t.index,
+		"content_block": block,
+	})
+}
+
+func (t *StreamTranslator) stopBlock(out *bytes.Buffer) {
+	if t.index < 0 {
+		return
+	}
+	writeEvent(out, "content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": t.index})
+	t.index = -1
+}
+
+func writeEvent(out *bytes.Buffer, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(out, "event: %s\ndata: %s\n\n", event, payload)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMessagesResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "text",
+			body: `{"id": "chatcmpl-1", "model": "m", "choices": [{"message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop"}],
+				"usage": {"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7}}`,
+			want: `{"id": "chatcmpl-1", "type": "message", "role": "assistant", "model": "m",
+				"content": [{"type": "text", "text": "Hello"}],
+				"stop_reason": "end_turn", "stop_sequence": null, "usage": {"input_tokens": 5, "output_tokens": 2}}`,
+		},
+		{
+			name: "stop sequence",
+			body: `{"id": "chatcmpl-1", "model": "m", "choices": [{"message": {"content": "Hello"}, "finish_reason": "stop", "stop_reason": "END"}]}`,
+			want: `{"id": "chatcmpl-1", "type": "message", "role": "assistant", "model": "m",
+				"content": [{"type": "text", "text": "Hello"}],
+				"stop_reason": "stop_sequence", "stop_sequence": "END", "usage": {"input_tokens": 0, "output_tokens": 0}}`,
+		},
+		{
+			name: "tool calls",
+			body: `{"id": "chatcmpl-1", "model": "m", "choices": [{"message": {"content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "get_time", "arguments": "not json"}}
+			]}, "finish_reason": "tool_calls"}]}`,
+			want: `{"id": "chatcmpl-1", "type": "message", "role": "assistant", "model": "m",
+				"content": [
+					{"type": "tool_use", "id": "call_1", "name": "get_weather", "input": {"city": "Paris"}},
+					{"type": "tool_use", "id": "call_2", "name": "get_time", "input": {}}
+				],
+				"stop_reason": "tool_use", "stop_sequence": null, "usage": {"input_tokens": 0, "output_tokens": 0}}`,
+		},
+		{
+			name: "max tokens",
+			body: `{"id": "chatcmpl-1", "model": "m", "choices": [{"message": {"content": "Hel"}, "finish_reason": "length"}]}`,
+			want: `{"id": "chatcmpl-1", "type": "message", "role": "assistant", "model": "m",
+				"content": [{"type": "text", "text": "Hel"}],
+				"stop_reason": "max_tokens", "stop_sequence": null, "usage": {"input_tokens": 0, "output_tokens": 0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToMessagesResponse([]byte(tt.body))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := ToMessagesResponse([]byte(`{"id": "chatcmpl-1", "choices": []}`))
+	assert.Error(t, err)
+}
+
+func TestToErrorResponse(t *testing.T) {
+	assert.JSONEq(t, `{"type": "error", "error": {"type": "rate_limit_error", "message": "input token rate limit exceeded"}}`,
+		string(ToErrorResponse(http.StatusTooManyRequests, []byte(`"input token rate limit exceeded"`))))
+	assert.JSONEq(t, `{"type": "error", "error": {"type": "not_found_error", "message": "Not Found"}}`,
+		string(ToErrorResponse(http.StatusNotFound, nil)))
+}
+
+type event struct {
+	name string
+	data map[string]interface{}
+}
+
+// parseEvents parses the server-sent events written by the translator
+func parseEvents(t *testing.T, stream string) []event {
+	var events []event
+	var current event
+	scanner := bufio.NewScanner(strings.NewReader(stream))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current = event{name: strings.TrimPrefix(line, "event: ")}
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data))
+			assert.Equal(t, current.name, current.data["type"])
+			events = append(events, current)
+		}
+	}
+	return events
+}
+
+func eventNames(events []event) []string {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+func TestStreamTranslator(t *testing.T) {
+	chunks := []string{
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"role":"assistant","content":""}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"content":"Let me "}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"content":"check."}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`data: {"id":"chatcmpl-1","model":"m","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":12,"total_tokens":21}}`,
+		``,
+		`data: [DONE]`,
+	}
+
+	translator := NewStreamTranslator()
+	var out strings.Builder
+	for _, chunk := range chunks {
+		out.Write(translator.Translate([]byte(chunk + "\n")))
+	}
+	// The stream is already finished
+	assert.Empty(t, translator.Finish())
+
+	events := parseEvents(t, out.String())
+	assert.Equal(t, []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}, eventNames(events))
+
+	message := events[0].data["message"].(map[string]interface{})
+	assert.Equal(t, "chatcmpl-1", message["id"])
+	assert.Equal(t, "assistant", message["role"])
+
+	assert.Equal(t, map[string]interface{}{"type": "text", "text": ""}, events[1].data["content_block"])
+	assert.Equal(t, map[string]interface{}{"type": "text_delta", "text": "Let me "}, events[2].data["delta"])
+	assert.Equal(t, float64(0), events[4].data["index"])
+
+	assert.Equal(t, map[string]interface{}{"type": "tool_use", "id": "call_1", "name": "get_weather", "input": map[string]interface{}{}}, events[5].data["content_block"])
+	assert.Equal(t, float64(1), events[6].data["index"])
+	assert.Equal(t, map[string]interface{}{"type": "input_json_delta", "partial_json": `{"city":`}, events[6].data["delta"])
+
+	assert.Equal(t, map[string]interface{}{"stop_reason": "tool_use", "stop_sequence": nil}, events[9].data["delta"])
+	assert.Equal(t, map[string]interface{}{"input_tokens": float64(9), "output_tokens": float64(12)}, events[9].data["usage"])
+}
+
+func TestStreamTranslatorUnfinishedStream(t *testing.T) {
+	translator := NewStreamTranslator()
+	// Nothing is sent before the first chunk
+	assert.Empty(t, translator.Finish())
+
+	var out strings.Builder
+	out.Write(translator.Translate([]byte(`data: {"id":"chatcmpl-1","model":"m","choices":[{"delta":{"content":"Hel"}}]}` + "\n")))
+	// The upstream stream ended without [DONE]
+	out.Write(translator.Finish())
+
+	events := parseEvents(t, out.String())
+	assert.Equal(t, []string{
+		"message_start", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop",
+	}, eventNames(events))
+	assert.Equal(t, "end_turn", events[4].data["delta"].(map[string]interface{})["stop_reason"])
+}
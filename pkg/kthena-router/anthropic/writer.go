@@ -0,0 +1,118 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+// ResponseWriter translates the OpenAI chat completion written by the router to an Anthropic message.
+// Streamed responses are translated line by line as they are written, other responses are buffered
+// and translated by Close. Error responses are translated to Anthropic errors.
+type ResponseWriter struct {
+	gin.ResponseWriter
+
+	buf        bytes.Buffer
+	translator *StreamTranslator
+	closed     bool
+}
+
+var _ gin.ResponseWriter = &ResponseWriter{}
+
+// NewResponseWriter wraps the writer of a Messages API request
+func NewResponseWriter(w gin.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (w *ResponseWriter) Write(data []byte) (int, error) {
+	if w.closed {
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if !w.streaming() {
+		return len(data), nil
+	}
+
+	if w.translator == nil {
+		w.translator = NewStreamTranslator()
+		w.Header().Del("Content-Length")
+	}
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			w.buf.Reset()
+			w.buf.Write(line)
+			break
+		}
+		if events := w.translator.Translate(line); len(events) > 0 {
+			if _, err := w.ResponseWriter.Write(events); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(data), nil
+}
+
+func (w *ResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// streaming returns true if a successful event stream is being written
+func (w *ResponseWriter) streaming() bool {
+	return w.Status() < http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
+// Close translates and writes the buffered response. It must be called once the router has written the response.
+func (w *ResponseWriter) Close() {
+	if w.closed {
+		return
+	}
+	w.closed = true
+
+	if w.translator != nil {
+		if events := w.translator.Finish(); len(events) > 0 {
+			_, _ = w.ResponseWriter.Write(events)
+		}
+		return
+	}
+	if w.buf.Len() == 0 && w.Status() < http.StatusBadRequest {
+		return
+	}
+
+	body := w.buf.Bytes()
+	if w.Status() < http.StatusBadRequest {
+		translated, err := ToMessagesResponse(body)
+		if err != nil {
+			klog.Errorf("failed to translate chat completion to anthropic message: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			body = ToErrorResponse(http.StatusBadGateway, []byte("invalid response from the model server"))
+		} else {
+			body = translated
+		}
+	} else {
+		body = ToErrorResponse(w.Status(), body)
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.ResponseWriter.Write(body)
+}
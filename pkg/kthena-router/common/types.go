@@ -16,15 +16,74 @@ limitations under the License.
 
 package common
 
+import "slices"
+
 const (
 	UserIdKey     = "user_id"
 	TokenUsageKey = "token_usage"
+	// RequestBodyKey is the context key of the *RequestBody of a request
+	RequestBodyKey = "request_body"
+	// ResponseProcessorKey is the context key of the processor forwarding the response of a request
+	ResponseProcessorKey = "response_processor"
+	// ResponseDigestKey is the context key of the *handlers.ContentDigest the content of the response is added to,
+	// it is only set when the response of a mirrored request is compared
+	ResponseDigestKey = "response_digest"
 )
 
+// RequestBody is the body of an inference request as read by the router. The original bytes are sent
+// to the model servers, unless the router modified the decoded request.
+type RequestBody struct {
+	Raw []byte
+	// Modified is set when the decoded request no longer matches Raw and has to be encoded again
+	Modified bool
+}
+
 // Message represents a single message in a chat conversation
 type Message struct {
-	Role    string `json:"role"`
+	Role string `json:"role"`
+	// Content is the text of the message. For structured content, it is the concatenation of the text parts.
 	Content string `json:"content"`
+	// Parts are the structured content parts of the message, if the content is an array.
+	// They are not serialized, so that the message can still be sent to the tokenizer as plain text.
+	Parts []ContentPart `json:"-"`
+	// ToolCalls are the tool calls requested by the model in an assistant message
+	ToolCalls []ToolCall `json:"-"`
+	// ToolCallID is the id of the tool call answered by a tool message
+	ToolCallID string `json:"-"`
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	ID   string
+	Name string
+	// Arguments are the JSON encoded arguments of the call
+	Arguments string
+}
+
+// Modality is the kind of data of a content part
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityImage Modality = "image"
+	ModalityAudio Modality = "audio"
+	ModalityVideo Modality = "video"
+)
+
+// ContentPart is a part of the structured content of a chat message, such as OpenAI `text`,
+// `image_url` or `input_audio` parts.
+type ContentPart struct {
+	Modality Modality
+	// Text is the text of a text part
+	Text string
+	// URL is the url of an image, audio or video part. It may be a data url.
+	URL string
+	// Detail is the image detail level requested by the client, e.g. low or high
+	Detail string
+	// Format is the audio format of an input_audio part, e.g. wav or mp3
+	Format string
+	// DataSize is the size in bytes of the inline data of an input_audio part
+	DataSize int
 }
 
 // ChatMessage represents either a direct text prompt or structured chat messages
@@ -34,4 +93,64 @@ type ChatMessage struct {
 
 	// Messages is used for chat conversation input (chat mode)
 	Messages []Message `json:"messages,omitempty"`
+
+	// Tools are the JSON encoded definitions of the tools available to the model (chat mode)
+	Tools []string `json:"-"`
+}
+
+// Modalities returns the non-text modalities used by the chat messages, in order of appearance
+func (c ChatMessage) Modalities() []Modality {
+	var modalities []Modality
+	for _, msg := range c.Messages {
+		for _, part := range msg.Parts {
+			if part.Modality == ModalityText || slices.Contains(modalities, part.Modality) {
+				continue
+			}
+			modalities = append(modalities, part.Modality)
+		}
+	}
+	return modalities
+}
+
+// IsMultimodal returns true if the chat messages contain non-text content parts
+func (c ChatMessage) IsMultimodal() bool {
+	return len(c.Modalities()) > 0
+}
+
+// Endpoint is the kind of inference API a request is sent to
+type Endpoint string
+
+const (
+	EndpointCompletions     Endpoint = "completions"
+	EndpointChatCompletions Endpoint = "chat_completions"
+	EndpointResponses       Endpoint = "responses"
+	EndpointEmbeddings      Endpoint = "embeddings"
+	EndpointRerank          Endpoint = "rerank"
+	EndpointScore           Endpoint = "score"
+)
+
+// IsGenerative returns true if the endpoint generates output tokens. Pooling endpoints such as
+// embeddings, rerank and score only process the input, so they neither produce output tokens nor
+// benefit from prefix cache aware routing. An empty endpoint is considered generative.
+func (e Endpoint) IsGenerative() bool {
+	switch e {
+	case EndpointEmbeddings, EndpointRerank, EndpointScore:
+		return false
+	default:
+		return true
+	}
+}
+
+// RequestInput is the model input of an inference request, parsed according to its endpoint
+type RequestInput struct {
+	Endpoint Endpoint
+	// Prompt is the input used by the scheduler plugins, e.g. for prefix cache hashing
+	Prompt ChatMessage
+	// Sequences are the texts processed by the engine, e.g. each input of an embeddings request or
+	// each query and document pair of a rerank request. They are used to count the input tokens.
+	Sequences []string
+	// TokenIDs is the number of input tokens given directly as token ids
+	TokenIDs int
+	// MediaTokens is the estimated number of input tokens of the image, audio and video content parts
+	MediaTokens int
 }
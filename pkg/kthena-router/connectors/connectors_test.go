@@ -18,11 +18,15 @@ package connectors
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
@@ -529,3 +533,50 @@ func TestHTTPConnectorProxy(t *testing.T) {
 		}
 	})
 }
+
+func TestConnectorsClientCanceled(t *testing.T) {
+	for _, newConnector := range []func() KVConnector{NewHTTPConnector, NewNIXLConnector} {
+		connector := newConnector()
+		t.Run(connector.Name(), func(t *testing.T) {
+			var calls atomic.Int32
+			prefillCanceled := make(chan struct{})
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				calls.Add(1)
+				// The prefill takes long enough for the client to go away
+				select {
+				case <-r.Context().Done():
+					close(prefillCanceled)
+				case <-time.After(10 * time.Second):
+				}
+			}))
+			defer backend.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req, _ := http.NewRequestWithContext(ctx, "POST", "/v1/chat/completions", nil)
+			c, _ := gin.CreateTestContext(CreateTestResponseRecorder())
+			c.Request = req
+			go func() {
+				for calls.Load() == 0 {
+					time.Sleep(10 * time.Millisecond)
+				}
+				cancel()
+			}()
+
+			addr := strings.TrimPrefix(backend.URL, "http://")
+			_, err := connector.Proxy(c, map[string]interface{}{"model": "test-model", "prompt": "hi", "stream": true}, addr, addr)
+			if err == nil {
+				t.Fatal("Expected Proxy to fail when the client cancels the request")
+			}
+
+			select {
+			case <-prefillCanceled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the prefill request to be canceled")
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("Expected the decode request not to be sent, got %d upstream requests", got)
+			}
+		})
+	}
+}
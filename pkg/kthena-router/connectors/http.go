@@ -74,8 +74,6 @@ func (h *HTTPConnector) Proxy(c *gin.Context, reqBody map[string]interface{}, pr
 		h.decodeRequest = BuildDecodeRequest(c, c.Request, reqBody)
 	}
 
-	// build prefillRequest after decodeRequest, because prefillRequest needs to delete stream and stream_options
-	// and modify the max_tokens
 	if h.prefillRequest == nil {
 		h.prefillRequest = buildPrefillRequest(c.Request, reqBody)
 	}
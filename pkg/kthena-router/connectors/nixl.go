@@ -17,7 +17,6 @@ limitations under the License.
 package connectors
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -36,6 +35,8 @@ type NIXLConnector struct {
 	name              string
 	prefillRequest    *http.Request
 	decodeRequestBody map[string]interface{}
+	// decodeFields are the fields added to the original body for the decode requests
+	decodeFields map[string]interface{}
 }
 
 // NewNIXLConnector creates a new NIXL connector
@@ -66,7 +67,9 @@ func (n *NIXLConnector) Proxy(c *gin.Context, reqBody map[string]interface{}, pr
 		n.prefillRequest = n.buildPrefillRequest(req, prefillBody)
 	}
 	if n.decodeRequestBody == nil {
-		n.decodeRequestBody = addTokenUsage(c, reqBody)
+		n.decodeFields = addTokenUsage(c, reqBody)
+		n.decodeRequestBody = cloneReqBody(reqBody)
+		maps.Copy(n.decodeRequestBody, n.decodeFields)
 	}
 
 	// Start prefill phase metrics and increment upstream request
@@ -121,7 +124,10 @@ func (n *NIXLConnector) prefill(req *http.Request, prefillAddr string) (interfac
 	klog.V(4).Infof("%s prefill: sending to %s", n.name, req.URL.String())
 
 	// Send prefill request
-	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err := RewindBody(req); err != nil {
+		return nil, err
+	}
+	resp, err := UpstreamTransport().RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
@@ -148,8 +154,15 @@ func (n *NIXLConnector) prefill(req *http.Request, prefillAddr string) (interfac
 }
 
 func (n *NIXLConnector) buildDecodeRequest(c *gin.Context, reqBody map[string]interface{}, kvTransferParams interface{}) *http.Request {
-	reqBody["kv_transfer_params"] = kvTransferParams
-	body, err := json.Marshal(reqBody)
+	// The request body may be sent to another target if the request falls back
+	fields := maps.Clone(n.decodeFields)
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["kv_transfer_params"] = kvTransferParams
+	decodeBody := cloneReqBody(reqBody)
+	maps.Copy(decodeBody, fields)
+	body, err := EncodeRequestBody(c, decodeBody, fields)
 	if err != nil {
 		return nil
 	}
@@ -157,8 +170,7 @@ func (n *NIXLConnector) buildDecodeRequest(c *gin.Context, reqBody map[string]in
 	// build request
 	reqCopy := c.Request.Clone(c.Request.Context())
 	reqCopy.URL.Scheme = "http"
-	reqCopy.Body = io.NopCloser(bytes.NewBuffer(body))
-	reqCopy.ContentLength = int64(len(body))
+	setRequestBody(reqCopy, body)
 
 	return reqCopy
 }
@@ -200,8 +212,7 @@ func (n *NIXLConnector) buildPrefillRequest(req *http.Request, reqBody map[strin
 
 	prefillReq := req.Clone(req.Context())
 	prefillReq.URL.Scheme = "http"
-	prefillReq.Body = io.NopCloser(bytes.NewBuffer(body))
-	prefillReq.ContentLength = int64(len(body))
+	setRequestBody(prefillReq, body)
 
 	return prefillReq
 }
@@ -0,0 +1,214 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+// ResponseProcessor forwards the response of an inference engine to the client, for both the aggregated
+// and the PD disaggregated modes. It extracts the usage reported by the engine, removes the usage chunk of
+// streams when only the router asked for it, and measures the latency of the response.
+type ResponseProcessor struct {
+	c       *gin.Context
+	onUsage func(handlers.OpenAIResponse)
+	start   time.Time
+	// digest, if set, hashes the content of the response
+	digest *handlers.ContentDigest
+
+	firstToken time.Time
+	lastToken  time.Time
+	// skipBlankLine is set when a chunk is removed, to remove the blank line ending its event as well
+	skipBlankLine bool
+}
+
+// NewResponseProcessor creates the processor of the response to a request. onUsage, if set, is called
+// once with the final usage reported by the engine.
+func NewResponseProcessor(c *gin.Context, onUsage func(handlers.OpenAIResponse)) *ResponseProcessor {
+	start := time.Now()
+	if accessCtx := accesslog.GetAccessLogContext(c); accessCtx != nil {
+		start = accessCtx.StartTime
+	}
+	var digest *handlers.ContentDigest
+	if v, ok := c.Get(common.ResponseDigestKey); ok {
+		digest, _ = v.(*handlers.ContentDigest)
+	}
+	return &ResponseProcessor{
+		c:       c,
+		onUsage: onUsage,
+		start:   start,
+		digest:  digest,
+	}
+}
+
+// SetResponseProcessor sets the processor used by the KV connectors to forward the decode response
+func SetResponseProcessor(c *gin.Context, p *ResponseProcessor) {
+	c.Set(common.ResponseProcessorKey, p)
+}
+
+// getResponseProcessor returns the processor set for the request, or a processor which only extracts usage
+func getResponseProcessor(c *gin.Context) *ResponseProcessor {
+	if v, ok := c.Get(common.ResponseProcessorKey); ok {
+		if p, ok := v.(*ResponseProcessor); ok {
+			return p
+		}
+	}
+	return NewResponseProcessor(c, nil)
+}
+
+// Forward copies the response body to the client, and returns the usage reported by the engine,
+// or nil if there is none.
+func (p *ResponseProcessor) Forward(resp *http.Response, stream bool) (*handlers.OpenAIResponse, error) {
+	var usage *handlers.OpenAIResponse
+	var err error
+	if stream {
+		usage = p.forwardStream(resp.Body)
+	} else {
+		usage, err = p.forwardBody(resp.Body)
+	}
+	if usage != nil {
+		klog.V(4).Infof("Parsed usage: %+v", usage.Usage)
+		if p.onUsage != nil {
+			p.onUsage(*usage)
+		}
+	}
+	if err == nil {
+		p.recordLatency(usage)
+	}
+	return usage, err
+}
+
+func (p *ResponseProcessor) forwardBody(body io.Reader) (*handlers.OpenAIResponse, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(p.c.Writer, io.TeeReader(body, &buf)); err != nil {
+		klog.Errorf("copy response to downstream failed: %v", err)
+		return nil, err
+	}
+	parsed, _ := handlers.ParseOpenAIResponseBody(buf.Bytes())
+	if parsed != nil && p.digest != nil {
+		p.digest.Add(parsed.Choices)
+	}
+	// Pooling responses such as embeddings report usage without completion tokens
+	if parsed == nil || (parsed.Usage.TotalTokens <= 0 && parsed.Usage.CompletionTokens <= 0) {
+		return nil, nil
+	}
+	return parsed, nil
+}
+
+func (p *ResponseProcessor) forwardStream(body io.Reader) *handlers.OpenAIResponse {
+	var usage *handlers.OpenAIResponse
+	stripUsage := false
+	if v, ok := p.c.Get(common.TokenUsageKey); ok {
+		stripUsage, _ = v.(bool)
+	}
+
+	reader := bufio.NewReader(body)
+	p.c.Stream(func(w io.Writer) bool {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && p.processLine(line, stripUsage, &usage) {
+			_, _ = w.Write(line)
+		}
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, context.Canceled) {
+				klog.Errorf("error reading stream body: %v", err)
+			}
+			return false
+		}
+		return true
+	})
+	return usage
+}
+
+// processLine handles a line of a stream, and returns whether it is forwarded to the client
+func (p *ResponseProcessor) processLine(line []byte, stripUsage bool, usage **handlers.OpenAIResponse) bool {
+	if len(bytes.TrimSpace(line)) == 0 {
+		skip := p.skipBlankLine
+		p.skipBlankLine = false
+		return !skip
+	}
+	p.skipBlankLine = false
+
+	parsed := handlers.ParseStreamRespForUsage(string(line))
+	if p.digest != nil {
+		p.digest.Add(parsed.Choices)
+	}
+	if len(parsed.Choices) > 0 || strings.HasSuffix(parsed.Type, ".delta") {
+		now := time.Now()
+		if p.firstToken.IsZero() {
+			p.firstToken = now
+		}
+		p.lastToken = now
+	}
+	if parsed.Usage.TotalTokens <= 0 && parsed.Usage.CompletionTokens <= 0 {
+		return true
+	}
+	// The usage is cumulative, the last one reported is the usage of the whole request
+	*usage = &parsed
+	// The usage chunk was added because the router asked for it, the client does not expect it.
+	// Usage reported along with generated content is kept.
+	if stripUsage && len(parsed.Choices) == 0 && parsed.Type == "" {
+		p.skipBlankLine = true
+		return false
+	}
+	return true
+}
+
+// recordLatency records the latency of a response in the access log and in the metrics of the request.
+// The responses the client did not wait for are not recorded, their latency is not the one of the engine.
+func (p *ResponseProcessor) recordLatency(usage *handlers.OpenAIResponse) {
+	if p.c.Request != nil && p.c.Request.Context().Err() != nil {
+		return
+	}
+	ttft, tpot := p.TokenLatency(usage)
+	if accessCtx := accesslog.GetAccessLogContext(p.c); accessCtx != nil && ttft > 0 {
+		accessCtx.SetTokenLatency(ttft, tpot)
+	}
+	if v, ok := p.c.Get("metricsRecorder"); ok {
+		if recorder, ok := v.(*metrics.RequestMetricsRecorder); ok {
+			recorder.RecordResponseLatency(ttft, tpot, time.Since(p.start))
+		}
+	}
+}
+
+// TokenLatency returns the time to first token of the stream, measured from the arrival of the request at
+// the router, and the time per output token after the first one. They are zero if unknown.
+func (p *ResponseProcessor) TokenLatency(usage *handlers.OpenAIResponse) (time.Duration, time.Duration) {
+	if p.firstToken.IsZero() {
+		return 0, 0
+	}
+	ttft := p.firstToken.Sub(p.start)
+	var tpot time.Duration
+	if usage != nil && usage.Usage.CompletionTokens > 1 {
+		tpot = p.lastToken.Sub(p.firstToken) / time.Duration(usage.Usage.CompletionTokens-1)
+	}
+	return ttft, tpot
+}
@@ -0,0 +1,176 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+)
+
+func TestResponseProcessorStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	content := "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n"
+	usageOnly := "data: {\"id\":\"1\",\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\n"
+	usageWithContent := "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"!\"}}],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\n"
+	done := "data: [DONE]\n\n"
+
+	tests := []struct {
+		name           string
+		body           string
+		injectedUsage  bool
+		expectedBody   string
+		expectedOutput int
+	}{
+		{
+			name:           "usage requested by the client is forwarded",
+			body:           content + usageOnly + done,
+			expectedBody:   content + usageOnly + done,
+			expectedOutput: 4,
+		},
+		{
+			name:           "usage requested by the router is removed",
+			body:           content + usageOnly + done,
+			injectedUsage:  true,
+			expectedBody:   content + done,
+			expectedOutput: 4,
+		},
+		{
+			name:           "usage sent with content is kept",
+			body:           content + usageWithContent + done,
+			injectedUsage:  true,
+			expectedBody:   content + usageWithContent + done,
+			expectedOutput: 4,
+		},
+		{
+			name:         "stream without usage",
+			body:         content + done,
+			expectedBody: content + done,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tt.injectedUsage {
+				c.Set(common.TokenUsageKey, true)
+			}
+
+			var calls []handlers.OpenAIResponse
+			processor := NewResponseProcessor(c, func(u handlers.OpenAIResponse) {
+				calls = append(calls, u)
+			})
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+				Body:       io.NopCloser(bytes.NewBufferString(tt.body)),
+			}
+			usage, err := processor.Forward(resp, true)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+
+			if tt.expectedOutput == 0 {
+				assert.Nil(t, usage)
+				assert.Empty(t, calls)
+				return
+			}
+			require.NotNil(t, usage)
+			assert.Equal(t, tt.expectedOutput, usage.Usage.CompletionTokens)
+			// The usage is reported once, whether or not the client receives it
+			require.Len(t, calls, 1)
+			assert.Equal(t, tt.expectedOutput, calls[0].Usage.CompletionTokens)
+		})
+	}
+}
+
+func TestResponseProcessorTokenLatency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/completions", nil)
+	accessCtx := accesslog.NewAccessLogContext("id", "POST", "/v1/completions", "HTTP/1.1", "test-model")
+	c.Set(accesslog.AccessLogContextKey, accessCtx)
+
+	// The engine sends 3 tokens, 20ms apart, after a first token delayed by 30ms
+	reader, writer := io.Pipe()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			if i > 0 {
+				time.Sleep(20 * time.Millisecond)
+			}
+			fmt.Fprintf(writer, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"text\":\"%d\"}]}\n\n", i)
+		}
+		fmt.Fprint(writer, "data: {\"id\":\"1\",\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":3,\"total_tokens\":4}}\n\n")
+		writer.Close()
+	}()
+
+	processor := NewResponseProcessor(c, nil)
+	resp := &http.Response{StatusCode: http.StatusOK, Body: reader}
+	usage, err := processor.Forward(resp, true)
+	require.NoError(t, err)
+
+	ttft, tpot := processor.TokenLatency(usage)
+	assert.GreaterOrEqual(t, ttft, 30*time.Millisecond)
+	assert.GreaterOrEqual(t, tpot, 20*time.Millisecond)
+	assert.Less(t, tpot, 200*time.Millisecond)
+	assert.Equal(t, ttft, accessCtx.TTFT)
+	assert.Equal(t, tpot, accessCtx.TPOT)
+}
+
+func TestDecoderProxyUsesResponseProcessor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","usage":{"prompt_tokens":3,"completion_tokens":6,"total_tokens":9}}`)
+	}))
+	defer server.Close()
+
+	w := CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/completions", nil)
+	var reported *handlers.OpenAIResponse
+	SetResponseProcessor(c, NewResponseProcessor(c, func(u handlers.OpenAIResponse) {
+		reported = &u
+	}))
+
+	req, err := http.NewRequest("POST", server.URL, bytes.NewBufferString(`{"model":"test-model"}`))
+	require.NoError(t, err)
+	outputTokens, err := decoderProxy(c, req)
+	require.NoError(t, err)
+	assert.Equal(t, 6, outputTokens)
+	require.NotNil(t, reported)
+	assert.Equal(t, 3, reported.Usage.PromptTokens)
+	assert.Equal(t, 6, reported.Usage.CompletionTokens)
+}
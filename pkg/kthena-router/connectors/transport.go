@@ -17,21 +17,25 @@ limitations under the License.
 package connectors
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
 	"k8s.io/klog/v2"
 )
 
 func prefillerProxy(_ *gin.Context, req *http.Request) error {
-	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err := RewindBody(req); err != nil {
+		return fmt.Errorf("prefill request failed: %w", err)
+	}
+	resp, err := UpstreamTransport().RoundTrip(req)
 	if err != nil {
 		return fmt.Errorf("prefill request failed: %w", err)
 	}
@@ -46,7 +50,10 @@ func prefillerProxy(_ *gin.Context, req *http.Request) error {
 }
 
 func decoderProxy(c *gin.Context, req *http.Request) (int, error) {
-	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err := RewindBody(req); err != nil {
+		return 0, fmt.Errorf("decode request failed: %w", err)
+	}
+	resp, err := UpstreamTransport().RoundTrip(req)
 	if err != nil {
 		return 0, fmt.Errorf("decode request failed: %w", err)
 	}
@@ -87,13 +94,20 @@ func preparePrefillBody(reqBody map[string]interface{}) {
 	if reqBody["max_completion_tokens"] != nil {
 		reqBody["max_completion_tokens"] = 1
 	}
+	// Responses API
+	if reqBody["max_output_tokens"] != nil {
+		reqBody["max_output_tokens"] = 1
+	}
 }
 
+// buildPrefillRequest builds the request sent to the prefill pod. The model request is left unchanged, as it
+// is also sent to the decode pod, or to another target if the request falls back.
 func buildPrefillRequest(req *http.Request, modelRequest map[string]interface{}) *http.Request {
 	// In PD disaggregated mode, we need to send a prefill request to the prefill pod with non stream mode.
-	preparePrefillBody(modelRequest)
+	prefillBody := cloneReqBody(modelRequest)
+	preparePrefillBody(prefillBody)
 
-	body, err := json.Marshal(modelRequest)
+	body, err := json.Marshal(prefillBody)
 	if err != nil {
 		return nil
 	}
@@ -101,35 +115,83 @@ func buildPrefillRequest(req *http.Request, modelRequest map[string]interface{})
 	// build request
 	reqCopy := req.Clone(req.Context())
 	reqCopy.URL.Scheme = "http"
-	reqCopy.Body = io.NopCloser(bytes.NewBuffer(body))
-	reqCopy.ContentLength = int64(len(body))
+	setRequestBody(reqCopy, body)
 
 	return reqCopy
 }
 
+// BuildDecodeRequest sets the body of the request sent to the decode pod, or to the pod of the aggregated mode.
+// The original body is reused if the router did not modify the request. The model request is left unchanged,
+// as it may be sent to another target if the request falls back.
 func BuildDecodeRequest(c *gin.Context, req *http.Request, modelRequest map[string]interface{}) *http.Request {
-	modelRequest = addTokenUsage(c, modelRequest)
-	body, err := json.Marshal(modelRequest)
+	added := addTokenUsage(c, modelRequest)
+	decodeBody := cloneReqBody(modelRequest)
+	maps.Copy(decodeBody, added)
+	body, err := EncodeRequestBody(c, decodeBody, added)
 	if err != nil {
 		return nil
 	}
 
 	// build request
 	req.URL.Scheme = "http"
-	req.Body = io.NopCloser(bytes.NewBuffer(body))
-	req.ContentLength = int64(len(body))
+	setRequestBody(req, body)
 
 	return req
 }
 
-// addTokenUsage adds token usage to the request body if it is not already present
+// EncodeRequestBody returns the body to send upstream. If the request has not been modified by the router,
+// this is the original body with the added fields set, otherwise the model request is encoded.
+func EncodeRequestBody(c *gin.Context, modelRequest map[string]interface{}, added map[string]interface{}) ([]byte, error) {
+	if v, ok := c.Get(common.RequestBodyKey); ok {
+		if requestBody, ok := v.(*common.RequestBody); ok && !requestBody.Modified {
+			body, err := utils.SetJSONFields(requestBody.Raw, added)
+			if err == nil {
+				return body, nil
+			}
+			klog.Warningf("failed to reuse the original request body: %v", err)
+		}
+	}
+	return json.Marshal(modelRequest)
+}
+
+// setRequestBody sets the body of an upstream request. The request can be sent several times,
+// e.g. to another pod after a failure, as GetBody returns a new reader of the same bytes.
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// RewindBody resets the body of an upstream request before it is sent, as it may have been consumed by
+// a previous attempt.
+func RewindBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// addTokenUsage returns the fields to add to the request body to get the token usage, if it is not already
+// requested. The request body is not modified.
 // should be used for decode requests or non PD disaggregated mode
 func addTokenUsage(c *gin.Context, reqBody map[string]interface{}) map[string]interface{} {
+	// The Responses API always reports usage, and rejects the chat completions stream options
+	if c.Request != nil && utils.GetEndpoint(c.Request.URL.Path) == common.EndpointResponses {
+		return nil
+	}
+	added := map[string]interface{}{}
 	// Check if streaming is enabled
 	if isStreamingRequest(reqBody) {
 		if !isTokenUsageEnabled(reqBody) {
 			// For streaming requests, add stream_options to include token usage
-			reqBody["stream_options"] = map[string]interface{}{
+			added["stream_options"] = map[string]interface{}{
 				"include_usage": true,
 			}
 			// add stream token usage to context
@@ -139,9 +201,9 @@ func addTokenUsage(c *gin.Context, reqBody map[string]interface{}) map[string]in
 		// For non-streaming requests, ensure we request usage information
 		// Most OpenAI-compatible APIs return usage by default for non-streaming,
 		// but we can be explicit about it
-		reqBody["include_usage"] = true
+		added["include_usage"] = true
 	}
-	return reqBody
+	return added
 }
 
 // isStreaming checks if the given model request has streaming enabled
@@ -172,55 +234,21 @@ func isStreamingResponse(resp *http.Response) bool {
 	return contentType == "text/event-stream" || contentType == "application/x-ndjson"
 }
 
-// handleStreamingResponse handles streaming responses
+// handleStreamingResponse forwards a streaming response, and returns the number of output tokens
 func handleStreamingResponse(c *gin.Context, resp *http.Response) (int, error) {
-	totalOutputTokens := 0
-	reader := bufio.NewReader(resp.Body)
-	c.Stream(func(w io.Writer) bool {
-		line, err := reader.ReadBytes('\n')
-		if len(line) > 0 {
-			// Try to parse usage from this line
-			parsed := handlers.ParseStreamRespForUsage(string(line))
-			if parsed.Usage.CompletionTokens > 0 {
-				klog.V(4).Infof("Parsed usage: %+v", parsed.Usage)
-				// Accumulate output tokens
-				totalOutputTokens += parsed.Usage.CompletionTokens
-				// Check if token usage should be filtered
-				if v, ok := c.Get(common.TokenUsageKey); ok && v.(bool) {
-					return true
-				}
-			}
-			// Forward to downstream
-			_, _ = w.Write(line)
-		}
-		if err != nil {
-			if err != io.EOF {
-				klog.Errorf("error reading stream body: %v", err)
-			}
-			return false
-		}
-		return true
-	})
-	return totalOutputTokens, nil
+	usage, err := getResponseProcessor(c).Forward(resp, true)
+	return outputTokens(usage), err
 }
 
-// handleNonStreamingResponse handles non-streaming responses
+// handleNonStreamingResponse forwards a non-streaming response, and returns the number of output tokens
 func handleNonStreamingResponse(c *gin.Context, resp *http.Response) (int, error) {
-	var buf bytes.Buffer
-	teeReader := io.TeeReader(resp.Body, &buf)
-
-	_, err := io.Copy(c.Writer, teeReader)
-	if err != nil {
-		klog.Errorf("copy response to downstream failed: %v", err)
-		return 0, err
-	}
+	usage, err := getResponseProcessor(c).Forward(resp, false)
+	return outputTokens(usage), err
+}
 
-	// Parse usage if present
-	parsed, _ := handlers.ParseOpenAIResponseBody(buf.Bytes())
-	if parsed != nil && parsed.Usage.CompletionTokens > 0 {
-		klog.V(4).Infof("Parsed usage: %+v", parsed.Usage)
-		return parsed.Usage.CompletionTokens, nil
+func outputTokens(usage *handlers.OpenAIResponse) int {
+	if usage == nil {
+		return 0
 	}
-
-	return 0, nil
+	return usage.Usage.CompletionTokens
 }
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -256,8 +257,11 @@ func TestBuildPrefillRequest(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create a test HTTP request
 			originalReq := httptest.NewRequest("POST", "/test", nil)
+			modelRequest := maps.Clone(tt.modelRequest)
 
 			result := buildPrefillRequest(originalReq, tt.modelRequest)
+			// The model request is also sent to the decode pod
+			assert.Equal(t, modelRequest, tt.modelRequest)
 
 			if tt.expectNil {
 				assert.Nil(t, result)
@@ -646,3 +650,94 @@ func TestDecoderProxy(t *testing.T) {
 		})
 	}
 }
+
+func TestResponsesAPIUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Stream options are not added to Responses API requests, which always report usage
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/v1/responses", nil)
+	result := BuildDecodeRequest(c, c.Request, map[string]interface{}{"model": "test-model", "stream": true})
+	require.NotNil(t, result)
+	body, err := io.ReadAll(result.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model": "test-model", "stream": true}`, string(body))
+	_, exists := c.Get(common.TokenUsageKey)
+	assert.False(t, exists)
+
+	// Output tokens are read from the response.completed event
+	w := CreateTestResponseRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/responses", nil)
+	stream := "event: response.created\n" +
+		"data: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_1\",\"usage\":null}}\n\n" +
+		"event: response.output_text.delta\n" +
+		"data: {\"type\":\"response.output_text.delta\",\"delta\":\"Hi\"}\n\n" +
+		"event: response.completed\n" +
+		"data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"usage\":{\"input_tokens\":7,\"output_tokens\":10,\"total_tokens\":17}}}\n\n"
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(bytes.NewBufferString(stream)),
+	}
+	outputTokens, err := handleStreamingResponse(c, resp)
+	require.NoError(t, err)
+	assert.Equal(t, 10, outputTokens)
+	assert.Equal(t, stream, w.Body.String())
+
+	// And from the usage of non streaming responses
+	w = CreateTestResponseRecorder()
+	c, _ = gin.CreateTestContext(w)
+	resp = &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(`{"id":"resp_1","object":"response","usage":{"input_tokens":7,"output_tokens":12,"total_tokens":19}}`)),
+	}
+	outputTokens, err = handleNonStreamingResponse(c, resp)
+	require.NoError(t, err)
+	assert.Equal(t, 12, outputTokens)
+}
+
+func TestBuildDecodeRequestReusesOriginalBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	raw := `{"model": "test-model", "prompt": "hi", "temperature": 0.50}`
+
+	tests := []struct {
+		name     string
+		modified bool
+		expected string
+	}{
+		{
+			name:     "original body is reused",
+			expected: `{"model": "test-model", "prompt": "hi", "temperature": 0.50,"include_usage":true}`,
+		},
+		{
+			name:     "modified request is encoded again",
+			modified: true,
+			expected: `{"include_usage":true,"model":"rewritten-model","prompt":"hi","temperature":0.5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set(common.RequestBodyKey, &common.RequestBody{Raw: []byte(raw), Modified: tt.modified})
+			modelRequest := map[string]interface{}{"model": "rewritten-model", "prompt": "hi", "temperature": 0.5}
+			if !tt.modified {
+				modelRequest["model"] = "test-model"
+			}
+
+			req := BuildDecodeRequest(c, httptest.NewRequest("POST", "/v1/completions", nil), modelRequest)
+			require.NotNil(t, req)
+
+			// The body can be read again for another attempt
+			for i := 0; i < 2; i++ {
+				require.NoError(t, RewindBody(req))
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, string(body))
+				assert.Equal(t, int64(len(body)), req.ContentLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectors
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+// upstreamTransport is shared by all the requests sent to the inference engines, so that the connections
+// to a pod are pooled and reused by the next requests.
+var upstreamTransport atomic.Pointer[UpstreamRoundTripper]
+
+func init() {
+	transport, err := NewUpstreamTransport(conf.UpstreamConfiguration{})
+	if err != nil {
+		klog.Fatalf("failed to create the upstream transport: %v", err)
+	}
+	upstreamTransport.Store(transport)
+}
+
+// UpstreamTransport returns the transport used to send requests to the inference engines
+func UpstreamTransport() http.RoundTripper {
+	return upstreamTransport.Load()
+}
+
+// ConfigureUpstreamTransport replaces the upstream transport by one built from the router configuration.
+// The idle connections of the previous transport are closed.
+func ConfigureUpstreamTransport(config conf.UpstreamConfiguration) error {
+	transport, err := NewUpstreamTransport(config)
+	if err != nil {
+		return err
+	}
+	if previous := upstreamTransport.Swap(transport); previous != nil {
+		previous.CloseIdleConnections()
+	}
+	return nil
+}
+
+type upstreamProtocolKey struct{}
+
+// WithUpstreamProtocol returns a context whose requests are sent to the inference engines with the protocol
+// of the workload port of their ModelServer.
+func WithUpstreamProtocol(ctx context.Context, protocol string) context.Context {
+	return context.WithValue(ctx, upstreamProtocolKey{}, protocol)
+}
+
+// UpstreamRoundTripper is an http.Transport which records the usage of its connection pool.
+// The requests whose context asks for h2c are sent over a separate pool of HTTP/2 connections.
+type UpstreamRoundTripper struct {
+	*http.Transport
+	h2c       *http.Transport
+	openConns atomic.Int64
+}
+
+// NewUpstreamTransport creates a transport with the pool sizes and timeouts of the configuration
+func NewUpstreamTransport(config conf.UpstreamConfiguration) (*UpstreamRoundTripper, error) {
+	config, err := config.WithDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout.Duration,
+		KeepAlive: config.KeepAlive.Duration,
+	}
+	upstream := &UpstreamRoundTripper{}
+	upstream.Transport = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           upstream.instrumentedDial(dialer),
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout.Duration,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	// The engines are reached with plain http URLs, only HTTP/2 with prior knowledge is allowed for them
+	upstream.h2c = upstream.Transport.Clone()
+	upstream.h2c.Protocols = new(http.Protocols)
+	upstream.h2c.Protocols.SetUnencryptedHTTP2(true)
+	return upstream, nil
+}
+
+// CloseIdleConnections closes the idle connections of both pools
+func (t *UpstreamRoundTripper) CloseIdleConnections() {
+	t.Transport.CloseIdleConnections()
+	t.h2c.CloseIdleConnections()
+}
+
+// OpenConnections returns the number of connections currently open by this transport
+func (t *UpstreamRoundTripper) OpenConnections() int64 {
+	return t.openConns.Load()
+}
+
+// RoundTrip sends the request and records whether its connection was reused from the pool
+func (t *UpstreamRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.DefaultMetrics.RecordUpstreamConnection(info.Reused)
+		},
+	}
+	transport := t.Transport
+	if protocol, _ := req.Context().Value(upstreamProtocolKey{}).(string); protocol == v1alpha1.WorkloadPortProtocolH2C {
+		transport = t.h2c
+	}
+	return transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// instrumentedDial wraps the dialer to keep track of the open connections and the failed dials
+func (t *UpstreamRoundTripper) instrumentedDial(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			if ctx.Err() == nil {
+				metrics.DefaultMetrics.UpstreamDialErrors.Inc()
+			}
+			return nil, err
+		}
+		t.openConns.Add(1)
+		metrics.DefaultMetrics.UpstreamOpenConnections.Inc()
+		return &countedConn{Conn: conn, transport: t}, nil
+	}
+}
+
+// countedConn decrements the open connections count when it is closed
+type countedConn struct {
+	net.Conn
+	transport *UpstreamRoundTripper
+	closeOnce sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.transport.openConns.Add(-1)
+		metrics.DefaultMetrics.UpstreamOpenConnections.Dec()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connectors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+func TestNewUpstreamTransport(t *testing.T) {
+	transport, err := NewUpstreamTransport(conf.UpstreamConfiguration{
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     50,
+		IdleConnTimeout:     metav1.Duration{Duration: time.Minute},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, conf.DefaultUpstreamMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 50, transport.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Nil(t, transport.Protocols)
+
+	_, err = NewUpstreamTransport(conf.UpstreamConfiguration{MaxIdleConns: -1})
+	assert.Error(t, err)
+}
+
+func TestUpstreamTransportReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport, err := NewUpstreamTransport(conf.UpstreamConfiguration{})
+	require.NoError(t, err)
+	defer transport.CloseIdleConnections()
+
+	reusedBefore := testutil.ToFloat64(metrics.DefaultMetrics.UpstreamConnections.WithLabelValues("true"))
+	newBefore := testutil.ToFloat64(metrics.DefaultMetrics.UpstreamConnections.WithLabelValues("false"))
+
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		// The connection goes back to the pool once the body is read
+		_, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, newBefore+1, testutil.ToFloat64(metrics.DefaultMetrics.UpstreamConnections.WithLabelValues("false")))
+	assert.Equal(t, reusedBefore+2, testutil.ToFloat64(metrics.DefaultMetrics.UpstreamConnections.WithLabelValues("true")))
+	assert.Equal(t, int64(1), transport.OpenConnections())
+
+	transport.CloseIdleConnections()
+	assert.Eventually(t, func() bool {
+		return transport.OpenConnections() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUpstreamTransportH2C(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	transport, err := NewUpstreamTransport(conf.UpstreamConfiguration{})
+	require.NoError(t, err)
+	defer transport.CloseIdleConnections()
+
+	// HTTP/2 is only used for the requests of the ModelServers asking for it
+	for _, tc := range []struct {
+		protocol string
+		expected string
+	}{
+		{protocol: "", expected: "HTTP/1.1"},
+		{protocol: "http", expected: "HTTP/1.1"},
+		{protocol: v1alpha1.WorkloadPortProtocolH2C, expected: "HTTP/2.0"},
+	} {
+		req, err := http.NewRequestWithContext(WithUpstreamProtocol(context.Background(), tc.protocol), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, string(body), tc.protocol)
+	}
+	assert.Equal(t, int64(2), transport.OpenConnections())
+}
+
+func TestConfigureUpstreamTransport(t *testing.T) {
+	previous := upstreamTransport.Load()
+	defer upstreamTransport.Store(previous)
+
+	require.NoError(t, ConfigureUpstreamTransport(conf.UpstreamConfiguration{MaxConnsPerHost: 8}))
+	assert.Equal(t, 8, UpstreamTransport().(*UpstreamRoundTripper).MaxConnsPerHost)
+
+	assert.Error(t, ConfigureUpstreamTransport(conf.UpstreamConfiguration{MaxConnsPerHost: -1}))
+	assert.Equal(t, 8, UpstreamTransport().(*UpstreamRoundTripper).MaxConnsPerHost)
+}
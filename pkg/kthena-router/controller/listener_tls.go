@@ -0,0 +1,142 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+// ListenerTLSError describes why the TLS configuration of a listener cannot be used.
+// Reason is the Gateway API listener condition reason reported in the listener status.
+type ListenerTLSError struct {
+	Reason  gatewayv1.ListenerConditionReason
+	Message string
+}
+
+func (e *ListenerTLSError) Error() string {
+	return e.Message
+}
+
+// IsRefError returns true if the error is caused by an unresolvable certificateRef,
+// which is reported through the ResolvedRefs condition rather than Accepted.
+func (e *ListenerTLSError) IsRefError() bool {
+	return e.Reason == gatewayv1.ListenerReasonInvalidCertificateRef || e.Reason == gatewayv1.ListenerReasonRefNotPermitted
+}
+
+// CertificateRefKeys returns the namespace/name keys of the Secrets referenced by the listener.
+func CertificateRefKeys(gatewayNamespace string, listener *gatewayv1.Listener) []string {
+	if listener.TLS == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(listener.TLS.CertificateRefs))
+	for _, ref := range listener.TLS.CertificateRefs {
+		namespace := gatewayNamespace
+		if ref.Namespace != nil && *ref.Namespace != "" {
+			namespace = string(*ref.Namespace)
+		}
+		keys = append(keys, fmt.Sprintf("%s/%s", namespace, ref.Name))
+	}
+	return keys
+}
+
+// LoadListenerCertificates resolves the certificateRefs of an HTTPS listener against the
+// Secrets in the store and parses them into certificates usable for TLS termination.
+// The returned error is always a *ListenerTLSError.
+func LoadListenerCertificates(gatewayNamespace string, listener *gatewayv1.Listener, store datastore.Store) ([]tls.Certificate, error) {
+	if listener.TLS == nil {
+		return nil, &ListenerTLSError{
+			Reason:  gatewayv1.ListenerReasonInvalid,
+			Message: fmt.Sprintf("listener %s uses protocol HTTPS but has no tls configuration", listener.Name),
+		}
+	}
+	if listener.TLS.Mode != nil && *listener.TLS.Mode != gatewayv1.TLSModeTerminate {
+		return nil, &ListenerTLSError{
+			Reason:  gatewayv1.ListenerReasonInvalid,
+			Message: fmt.Sprintf("tls mode %s is not supported for HTTPS listener %s, only Terminate is supported", *listener.TLS.Mode, listener.Name),
+		}
+	}
+	if len(listener.TLS.CertificateRefs) == 0 {
+		return nil, &ListenerTLSError{
+			Reason:  gatewayv1.ListenerReasonInvalidCertificateRef,
+			Message: fmt.Sprintf("listener %s has no certificateRefs", listener.Name),
+		}
+	}
+
+	certificates := make([]tls.Certificate, 0, len(listener.TLS.CertificateRefs))
+	for _, ref := range listener.TLS.CertificateRefs {
+		if ref.Group != nil && *ref.Group != "" {
+			return nil, &ListenerTLSError{
+				Reason:  gatewayv1.ListenerReasonInvalidCertificateRef,
+				Message: fmt.Sprintf("certificateRef %s has unsupported group %s, only core Secrets are supported", ref.Name, *ref.Group),
+			}
+		}
+		if ref.Kind != nil && *ref.Kind != "Secret" {
+			return nil, &ListenerTLSError{
+				Reason:  gatewayv1.ListenerReasonInvalidCertificateRef,
+				Message: fmt.Sprintf("certificateRef %s has unsupported kind %s, only Secret is supported", ref.Name, *ref.Kind),
+			}
+		}
+		// ReferenceGrant is not supported, so only Secrets in the Gateway namespace may be referenced
+		if ref.Namespace != nil && *ref.Namespace != "" && string(*ref.Namespace) != gatewayNamespace {
+			return nil, &ListenerTLSError{
+				Reason:  gatewayv1.ListenerReasonRefNotPermitted,
+				Message: fmt.Sprintf("certificateRef %s/%s is not in the Gateway namespace %s", *ref.Namespace, ref.Name, gatewayNamespace),
+			}
+		}
+
+		key := fmt.Sprintf("%s/%s", gatewayNamespace, ref.Name)
+		secret := store.GetSecret(key)
+		if secret == nil {
+			return nil, &ListenerTLSError{
+				Reason:  gatewayv1.ListenerReasonInvalidCertificateRef,
+				Message: fmt.Sprintf("secret %s referenced by listener %s not found", key, listener.Name),
+			}
+		}
+		certificate, err := CertificateFromSecret(secret)
+		if err != nil {
+			return nil, &ListenerTLSError{
+				Reason:  gatewayv1.ListenerReasonInvalidCertificateRef,
+				Message: fmt.Sprintf("secret %s referenced by listener %s is invalid: %v", key, listener.Name, err),
+			}
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	return certificates, nil
+}
+
+// CertificateFromSecret parses the certificate and private key stored in a kubernetes.io/tls Secret.
+func CertificateFromSecret(secret *corev1.Secret) (tls.Certificate, error) {
+	if secret.Type != corev1.SecretTypeTLS {
+		return tls.Certificate{}, fmt.Errorf("secret type is %s, expected %s", secret.Type, corev1.SecretTypeTLS)
+	}
+	certPEM, ok := secret.Data[corev1.TLSCertKey]
+	if !ok || len(certPEM) == 0 {
+		return tls.Certificate{}, fmt.Errorf("missing %s", corev1.TLSCertKey)
+	}
+	keyPEM, ok := secret.Data[corev1.TLSPrivateKeyKey]
+	if !ok || len(keyPEM) == 0 {
+		return tls.Certificate{}, fmt.Errorf("missing %s", corev1.TLSPrivateKeyKey)
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+// newTLSSecret returns a kubernetes.io/tls Secret holding a self-signed certificate for the given DNS names.
+func newTLSSecret(t *testing.T, namespace, name string, dnsNames ...string) *corev1.Secret {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+func newHTTPSListener(refs ...gatewayv1.SecretObjectReference) gatewayv1.Listener {
+	return gatewayv1.Listener{
+		Name:     "https",
+		Port:     443,
+		Protocol: gatewayv1.HTTPSProtocolType,
+		TLS:      &gatewayv1.ListenerTLSConfig{CertificateRefs: refs},
+	}
+}
+
+func TestLoadListenerCertificates(t *testing.T) {
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateSecret(newTLSSecret(t, "default", "valid", "example.com")))
+	invalid := newTLSSecret(t, "default", "invalid", "example.com")
+	invalid.Data[corev1.TLSPrivateKeyKey] = []byte("garbage")
+	require.NoError(t, store.AddOrUpdateSecret(invalid))
+
+	otherNamespace := gatewayv1.Namespace("other")
+	configMapKind := gatewayv1.Kind("ConfigMap")
+	passthrough := gatewayv1.TLSModePassthrough
+
+	tests := []struct {
+		name     string
+		listener gatewayv1.Listener
+		reason   gatewayv1.ListenerConditionReason
+		refError bool
+	}{
+		{
+			name:     "valid secret",
+			listener: newHTTPSListener(gatewayv1.SecretObjectReference{Name: "valid"}),
+		},
+		{
+			name:     "missing tls config",
+			listener: gatewayv1.Listener{Name: "https", Protocol: gatewayv1.HTTPSProtocolType},
+			reason:   gatewayv1.ListenerReasonInvalid,
+		},
+		{
+			name: "passthrough mode",
+			listener: gatewayv1.Listener{Name: "https", Protocol: gatewayv1.HTTPSProtocolType, TLS: &gatewayv1.ListenerTLSConfig{
+				Mode: &passthrough,
+			}},
+			reason: gatewayv1.ListenerReasonInvalid,
+		},
+		{
+			name:     "no certificateRefs",
+			listener: newHTTPSListener(),
+			reason:   gatewayv1.ListenerReasonInvalidCertificateRef,
+			refError: true,
+		},
+		{
+			name:     "secret not found",
+			listener: newHTTPSListener(gatewayv1.SecretObjectReference{Name: "missing"}),
+			reason:   gatewayv1.ListenerReasonInvalidCertificateRef,
+			refError: true,
+		},
+		{
+			name:     "invalid key pair",
+			listener: newHTTPSListener(gatewayv1.SecretObjectReference{Name: "invalid"}),
+			reason:   gatewayv1.ListenerReasonInvalidCertificateRef,
+			refError: true,
+		},
+		{
+			name:     "unsupported kind",
+			listener: newHTTPSListener(gatewayv1.SecretObjectReference{Kind: &configMapKind, Name: "valid"}),
+			reason:   gatewayv1.ListenerReasonInvalidCertificateRef,
+			refError: true,
+		},
+		{
+			name:     "cross namespace reference",
+			listener: newHTTPSListener(gatewayv1.SecretObjectReference{Namespace: &otherNamespace, Name: "valid"}),
+			reason:   gatewayv1.ListenerReasonRefNotPermitted,
+			refError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certificates, err := LoadListenerCertificates("default", &tt.listener, store)
+			if tt.reason == "" {
+				require.NoError(t, err)
+				require.Len(t, certificates, 1)
+				require.NotNil(t, certificates[0].Leaf)
+				assert.Equal(t, []string{"example.com"}, certificates[0].Leaf.DNSNames)
+				return
+			}
+			require.Error(t, err)
+			tlsErr, ok := err.(*ListenerTLSError)
+			require.True(t, ok)
+			assert.Equal(t, tt.reason, tlsErr.Reason)
+			assert.Equal(t, tt.refError, tlsErr.IsRefError())
+		})
+	}
+}
+
+func TestCertificateRefKeys(t *testing.T) {
+	otherNamespace := gatewayv1.Namespace("other")
+	listener := newHTTPSListener(
+		gatewayv1.SecretObjectReference{Name: "a"},
+		gatewayv1.SecretObjectReference{Namespace: &otherNamespace, Name: "b"},
+	)
+	assert.Equal(t, []string{"default/a", "other/b"}, CertificateRefKeys("default", &listener))
+
+	httpListener := gatewayv1.Listener{Name: "http", Protocol: gatewayv1.HTTPProtocolType}
+	assert.Empty(t, CertificateRefKeys("default", &httpListener))
+}
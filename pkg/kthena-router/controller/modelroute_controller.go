@@ -21,6 +21,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -30,6 +31,7 @@ import (
 
 	informersv1alpha1 "github.com/volcano-sh/kthena/client-go/informers/externalversions"
 	listerv1alpha1 "github.com/volcano-sh/kthena/client-go/listers/networking/v1alpha1"
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
 )
 
@@ -60,6 +62,13 @@ func NewModelRouteController(
 	controller.registration, _ = modelRouteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueModelRoute,
 		UpdateFunc: func(old, new interface{}) {
+			oldMr, okOld := old.(*aiv1alpha1.ModelRoute)
+			newMr, okNew := new.(*aiv1alpha1.ModelRoute)
+			// Skip status-only updates, which are written by the router itself
+			// and would otherwise reset the rate limiters of the route.
+			if okOld && okNew && equality.Semantic.DeepEqual(oldMr.Spec, newMr.Spec) {
+				return
+			}
 			controller.enqueueModelRoute(new)
 		},
 		DeleteFunc: controller.enqueueModelRoute,
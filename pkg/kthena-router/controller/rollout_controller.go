@@ -0,0 +1,402 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	clientset "github.com/volcano-sh/kthena/client-go/clientset/versioned"
+	informersv1alpha1 "github.com/volcano-sh/kthena/client-go/informers/externalversions"
+	listerv1alpha1 "github.com/volcano-sh/kthena/client-go/listers/networking/v1alpha1"
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+const (
+	// rolloutSyncPeriod is the interval at which the rollouts are analyzed and stepped.
+	rolloutSyncPeriod = 10 * time.Second
+
+	defaultRolloutPause       = 5 * time.Minute
+	defaultRolloutMinRequests = 20
+)
+
+// RolloutController shifts the traffic of the ModelRoute rules with a rollout from their baseline target
+// to their canary target, by setting the weights of the two targets step by step. Each step is gated on
+// the traffic of the canary observed by the router, and the rollout is rolled back on regression.
+// Only one router replica should run it at a time, so it is expected to be started under leader election.
+type RolloutController struct {
+	kthenaClient     clientset.Interface
+	modelRouteLister listerv1alpha1.ModelRouteLister
+	synced           []cache.InformerSynced
+
+	// localMetrics measures the traffic served by this router replica, it is used by the rollouts
+	// without a Prometheus server.
+	localMetrics rolloutMetrics
+
+	mutex             sync.Mutex
+	prometheusMetrics map[string]rolloutMetrics
+
+	now func() time.Time
+}
+
+func NewRolloutController(
+	kthenaClient clientset.Interface,
+	kthenaInformerFactory informersv1alpha1.SharedInformerFactory,
+) *RolloutController {
+	modelRouteInformer := kthenaInformerFactory.Networking().V1alpha1().ModelRoutes()
+
+	return &RolloutController{
+		kthenaClient:      kthenaClient,
+		modelRouteLister:  modelRouteInformer.Lister(),
+		synced:            []cache.InformerSynced{modelRouteInformer.Informer().HasSynced},
+		localMetrics:      newGathererMetrics(nil),
+		prometheusMetrics: make(map[string]rolloutMetrics),
+		now:               time.Now,
+	}
+}
+
+func (c *RolloutController) Run(ctx context.Context) error {
+	defer utilruntime.HandleCrash()
+
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.synced...); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	klog.Info("Starting rollout controller")
+	wait.UntilWithContext(ctx, c.syncAll, rolloutSyncPeriod)
+	return nil
+}
+
+func (c *RolloutController) syncAll(ctx context.Context) {
+	modelRoutes, err := c.modelRouteLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model routes: %v", err)
+	}
+	for _, mr := range modelRoutes {
+		if !hasRollout(mr) && len(mr.Status.Rollouts) == 0 {
+			continue
+		}
+		if err := c.syncModelRoute(ctx, mr); err != nil {
+			klog.Errorf("failed to sync rollouts of ModelRoute %s/%s: %v", mr.Namespace, mr.Name, err)
+		}
+	}
+}
+
+func hasRollout(mr *aiv1alpha1.ModelRoute) bool {
+	for _, rule := range mr.Spec.Rules {
+		if rule != nil && rule.Rollout != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// syncModelRoute steps the rollouts of a ModelRoute, then sets the weights of their targets and publishes their status.
+func (c *RolloutController) syncModelRoute(ctx context.Context, mr *aiv1alpha1.ModelRoute) error {
+	mrCopy := mr.DeepCopy()
+	// The times are published with a precision of a second, the steps start at the published time
+	now := metav1.NewTime(c.now().Truncate(time.Second))
+
+	var rollouts []aiv1alpha1.RolloutStatus
+	// analyzedSteps are the rollouts starting a step with an analysis
+	var analyzedSteps []*aiv1alpha1.Rollout
+	for _, rule := range mrCopy.Spec.Rules {
+		if rule == nil || rule.Rollout == nil || rule.Name == "" {
+			continue
+		}
+		status := c.stepRollout(ctx, mr, rule.Name, rule.Rollout, findRolloutStatus(mr.Status.Rollouts, rule.Name), now)
+		setRolloutWeights(rule, rule.Rollout, status.CanaryWeight)
+		rollouts = append(rollouts, status)
+		if status.Phase == aiv1alpha1.RolloutProgressing && rule.Rollout.Analysis != nil && status.StepStartTime.Equal(&now) {
+			analyzedSteps = append(analyzedSteps, rule.Rollout)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(mr.Spec, mrCopy.Spec) {
+		updated, err := c.kthenaClient.NetworkingV1alpha1().ModelRoutes(mr.Namespace).Update(ctx, mrCopy, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		mrCopy = updated
+	}
+	// The traffic of a step is counted from the moment the weights of the step are written
+	for _, rollout := range analyzedSteps {
+		c.startStepAnalysis(mr, rollout, now.Time)
+	}
+
+	mrCopy.Status.Rollouts = rollouts
+	setRolloutConditions(&mrCopy.Status, rollouts, mrCopy.Generation)
+	if equality.Semantic.DeepEqual(mr.Status, mrCopy.Status) {
+		return nil
+	}
+	_, err := c.kthenaClient.NetworkingV1alpha1().ModelRoutes(mr.Namespace).UpdateStatus(ctx, mrCopy, metav1.UpdateOptions{})
+	return err
+}
+
+func findRolloutStatus(rollouts []aiv1alpha1.RolloutStatus, rule string) *aiv1alpha1.RolloutStatus {
+	for i := range rollouts {
+		if rollouts[i].Rule == rule {
+			return &rollouts[i]
+		}
+	}
+	return nil
+}
+
+// stepRollout returns the next state of the rollout of a rule. The rollout starts over when its baseline or
+// its canary changes.
+func (c *RolloutController) stepRollout(
+	ctx context.Context,
+	mr *aiv1alpha1.ModelRoute,
+	ruleName string,
+	rollout *aiv1alpha1.Rollout,
+	previous *aiv1alpha1.RolloutStatus,
+	now metav1.Time,
+) aiv1alpha1.RolloutStatus {
+	if previous == nil || previous.Baseline != rollout.Baseline || previous.Canary != rollout.Canary {
+		status := aiv1alpha1.RolloutStatus{
+			Rule:     ruleName,
+			Baseline: rollout.Baseline,
+			Canary:   rollout.Canary,
+			Phase:    aiv1alpha1.RolloutProgressing,
+		}
+		startRolloutStep(&status, rollout, 0, now)
+		return status
+	}
+
+	status := *previous.DeepCopy()
+	if status.Phase != aiv1alpha1.RolloutProgressing {
+		return status
+	}
+	// The steps may have been removed since the beginning of the rollout
+	if int(status.Step) >= len(rollout.Steps) {
+		startRolloutStep(&status, rollout, len(rollout.Steps), now)
+		return status
+	}
+
+	step := rollout.Steps[status.Step]
+	pause := defaultRolloutPause
+	if step.Pause != nil {
+		pause = step.Pause.Duration
+	}
+	if status.StepStartTime == nil {
+		status.StepStartTime = &now
+	}
+	elapsed := now.Sub(status.StepStartTime.Time)
+
+	if rollout.Analysis == nil {
+		if elapsed >= pause {
+			startRolloutStep(&status, rollout, int(status.Step)+1, now)
+		}
+		return status
+	}
+
+	analysis := rollout.Analysis
+	source, err := c.metricsSource(analysis)
+	if err == nil {
+		var traffic canaryTraffic
+		traffic, err = source.canaryTraffic(ctx, mr.Namespace+"/"+mr.Name, mr.Namespace+"/"+rollout.Canary, status.StepStartTime.Time, now.Time)
+		if err == nil {
+			status.Analysis = traffic.result()
+		}
+	}
+	if err != nil {
+		// The rollout holds its step until the canary can be analyzed again
+		status.Message = fmt.Sprintf("failed to analyze canary %s: %v", rollout.Canary, err)
+		return status
+	}
+
+	// Without Prometheus, the traffic served by the other router replicas is not analyzed, and the
+	// traffic of the step is counted again from zero when another replica becomes the leader
+	observer := "observed"
+	if analysis.PrometheusURL == "" {
+		observer = "observed by the leader router replica"
+	}
+	minRequests := int64(defaultRolloutMinRequests)
+	if analysis.MinRequests != nil {
+		minRequests = int64(*analysis.MinRequests)
+	}
+	if status.Analysis.Requests < minRequests {
+		status.Message = fmt.Sprintf("step %d/%d: waiting for %d canary requests, %d %s",
+			status.Step+1, len(rollout.Steps), minRequests, status.Analysis.Requests, observer)
+		return status
+	}
+	if regression := analysisRegression(analysis, status.Analysis); regression != "" {
+		status.Phase = aiv1alpha1.RolloutRolledBack
+		status.CanaryWeight = 0
+		status.Message = fmt.Sprintf("rolled back at step %d/%d: %s, %s", status.Step+1, len(rollout.Steps), regression, observer)
+		return status
+	}
+	if elapsed >= pause {
+		startRolloutStep(&status, rollout, int(status.Step)+1, now)
+	} else {
+		status.Message = rolloutStepMessage(&status, rollout)
+	}
+	return status
+}
+
+// startRolloutStep moves a rollout to a step, the rollout succeeds after its last step.
+func startRolloutStep(status *aiv1alpha1.RolloutStatus, rollout *aiv1alpha1.Rollout, step int, now metav1.Time) {
+	status.Step = int32(step)
+	status.StepStartTime = &now
+	status.Analysis = nil
+	if step >= len(rollout.Steps) {
+		status.Step = int32(len(rollout.Steps))
+		status.Phase = aiv1alpha1.RolloutSucceeded
+		status.CanaryWeight = 100
+		status.Message = fmt.Sprintf("canary %s receives all the traffic", rollout.Canary)
+		return
+	}
+	status.CanaryWeight = rollout.Steps[step].Weight
+	status.Message = rolloutStepMessage(status, rollout)
+}
+
+func rolloutStepMessage(status *aiv1alpha1.RolloutStatus, rollout *aiv1alpha1.Rollout) string {
+	return fmt.Sprintf("step %d/%d: %d%% of the traffic to canary %s", status.Step+1, len(rollout.Steps), status.CanaryWeight, rollout.Canary)
+}
+
+// analysisRegression returns the threshold of the analysis exceeded by the canary, empty if none is.
+func analysisRegression(analysis *aiv1alpha1.RolloutAnalysis, result *aiv1alpha1.RolloutAnalysisResult) string {
+	if analysis.MaxErrorPercent != nil && result.ErrorPercent != nil && result.ErrorPercent.Cmp(*analysis.MaxErrorPercent) > 0 {
+		return fmt.Sprintf("error rate %g%% exceeds %g%%", result.ErrorPercent.AsApproximateFloat64(), analysis.MaxErrorPercent.AsApproximateFloat64())
+	}
+	if analysis.MaxLatency != nil && result.LatencyP95 != nil && result.LatencyP95.Duration > analysis.MaxLatency.Duration {
+		return fmt.Sprintf("p95 latency %s exceeds %s", result.LatencyP95.Duration, analysis.MaxLatency.Duration)
+	}
+	return ""
+}
+
+// setRolloutWeights sends weight percents of the traffic of the rule to the canary, and the rest to the baseline.
+func setRolloutWeights(rule *aiv1alpha1.Rule, rollout *aiv1alpha1.Rollout, weight uint32) {
+	for _, target := range rule.TargetModels {
+		if target == nil {
+			continue
+		}
+		switch target.ModelServerName {
+		case rollout.Canary:
+			target.Weight = &weight
+		case rollout.Baseline:
+			baselineWeight := 100 - weight
+			target.Weight = &baselineWeight
+		}
+	}
+}
+
+// setRolloutConditions sets the rollout conditions of a ModelRoute, or removes them if it has no rollout.
+func setRolloutConditions(status *aiv1alpha1.ModelRouteStatus, rollouts []aiv1alpha1.RolloutStatus, generation int64) {
+	if len(rollouts) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutProgressing))
+		meta.RemoveStatusCondition(&status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutDegraded))
+		return
+	}
+
+	progressing := metav1.Condition{
+		Type:               string(aiv1alpha1.ModelRouteConditionRolloutProgressing),
+		Status:             metav1.ConditionFalse,
+		Reason:             aiv1alpha1.ModelRouteReasonRolloutSucceeded,
+		Message:            "All rollouts are complete",
+		ObservedGeneration: generation,
+	}
+	degraded := metav1.Condition{
+		Type:               string(aiv1alpha1.ModelRouteConditionRolloutDegraded),
+		Status:             metav1.ConditionFalse,
+		Reason:             aiv1alpha1.ModelRouteReasonAnalysisPassing,
+		Message:            "No canary regressed",
+		ObservedGeneration: generation,
+	}
+	for _, rollout := range rollouts {
+		switch rollout.Phase {
+		case aiv1alpha1.RolloutProgressing:
+			progressing.Status = metav1.ConditionTrue
+			progressing.Reason = aiv1alpha1.ModelRouteReasonRolloutStepping
+			progressing.Message = fmt.Sprintf("rule %s: %s", rollout.Rule, rollout.Message)
+		case aiv1alpha1.RolloutRolledBack:
+			if progressing.Status == metav1.ConditionFalse {
+				progressing.Reason = aiv1alpha1.ModelRouteReasonRolledBack
+				progressing.Message = fmt.Sprintf("rule %s: %s", rollout.Rule, rollout.Message)
+			}
+			degraded.Status = metav1.ConditionTrue
+			degraded.Reason = aiv1alpha1.ModelRouteReasonRolledBack
+			degraded.Message = fmt.Sprintf("rule %s: %s", rollout.Rule, rollout.Message)
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, progressing)
+	meta.SetStatusCondition(&status.Conditions, degraded)
+}
+
+// startStepAnalysis marks the beginning of the current step of a rollout for the source of its metrics.
+// If it fails, the traffic of the step is counted from its first analysis.
+func (c *RolloutController) startStepAnalysis(mr *aiv1alpha1.ModelRoute, rollout *aiv1alpha1.Rollout, since time.Time) {
+	source, err := c.metricsSource(rollout.Analysis)
+	if err == nil {
+		err = source.startStep(mr.Namespace+"/"+mr.Name, mr.Namespace+"/"+rollout.Canary, since)
+	}
+	if err != nil {
+		klog.Errorf("failed to start the analysis of canary %s of model route %s/%s: %v", rollout.Canary, mr.Namespace, mr.Name, err)
+	}
+}
+
+// metricsSource returns the source of the metrics of the canary of a rollout.
+func (c *RolloutController) metricsSource(analysis *aiv1alpha1.RolloutAnalysis) (rolloutMetrics, error) {
+	if analysis.PrometheusURL == "" {
+		return c.localMetrics, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if source, ok := c.prometheusMetrics[analysis.PrometheusURL]; ok {
+		return source, nil
+	}
+	source, err := newPrometheusMetrics(analysis.PrometheusURL)
+	if err != nil {
+		return nil, err
+	}
+	c.prometheusMetrics[analysis.PrometheusURL] = source
+	return source, nil
+}
+
+// canaryTraffic is the traffic of a ModelServer of a ModelRoute during a step of a rollout.
+type canaryTraffic struct {
+	requests int64
+	errors   int64
+	// latencyP95 is zero if unknown
+	latencyP95 time.Duration
+}
+
+func (t canaryTraffic) result() *aiv1alpha1.RolloutAnalysisResult {
+	result := &aiv1alpha1.RolloutAnalysisResult{Requests: t.requests}
+	if t.requests > 0 {
+		// In hundredths of a percent
+		errorPercent := resource.NewScaledQuantity(t.errors*10000/t.requests, -2)
+		result.ErrorPercent = errorPercent
+	}
+	if t.latencyP95 > 0 {
+		result.LatencyP95 = &metav1.Duration{Duration: t.latencyP95}
+	}
+	return result
+}
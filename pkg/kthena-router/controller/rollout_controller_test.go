@@ -0,0 +1,312 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kthenafake "github.com/volcano-sh/kthena/client-go/clientset/versioned/fake"
+	informersv1alpha1 "github.com/volcano-sh/kthena/client-go/informers/externalversions"
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+type fakeRolloutMetrics struct {
+	traffic canaryTraffic
+	// since is the beginning of the last analyzed step
+	since time.Time
+	// started is the beginning of the last started step
+	started time.Time
+}
+
+func (m *fakeRolloutMetrics) startStep(_, _ string, since time.Time) error {
+	m.started = since
+	return nil
+}
+
+func (m *fakeRolloutMetrics) canaryTraffic(_ context.Context, modelRoute, modelServer string, since, _ time.Time) (canaryTraffic, error) {
+	m.since = since
+	return m.traffic, nil
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func newRolloutTestModelRoute(analysis *aiv1alpha1.RolloutAnalysis) *aiv1alpha1.ModelRoute {
+	return &aiv1alpha1.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mr", Generation: 1},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "model",
+			Rules: []*aiv1alpha1.Rule{{
+				Name: "default",
+				TargetModels: []*aiv1alpha1.TargetModel{
+					{ModelServerName: "v1", Weight: uint32Ptr(100)},
+					{ModelServerName: "v2", Weight: uint32Ptr(100)},
+				},
+				Rollout: &aiv1alpha1.Rollout{
+					Baseline: "v1",
+					Canary:   "v2",
+					Steps: []aiv1alpha1.RolloutStep{
+						{Weight: 10, Pause: &metav1.Duration{Duration: time.Minute}},
+						{Weight: 50, Pause: &metav1.Duration{Duration: time.Minute}},
+					},
+					Analysis: analysis,
+				},
+			}},
+		},
+	}
+}
+
+type rolloutTest struct {
+	t          *testing.T
+	controller *RolloutController
+	client     *kthenafake.Clientset
+	metrics    *fakeRolloutMetrics
+	now        time.Time
+}
+
+func newRolloutTest(t *testing.T, mr *aiv1alpha1.ModelRoute) *rolloutTest {
+	client := kthenafake.NewSimpleClientset(mr)
+	informerFactory := informersv1alpha1.NewSharedInformerFactory(client, 0)
+	test := &rolloutTest{
+		t:          t,
+		controller: NewRolloutController(client, informerFactory),
+		client:     client,
+		metrics:    &fakeRolloutMetrics{},
+		now:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	test.controller.localMetrics = test.metrics
+	test.controller.now = func() time.Time { return test.now }
+	return test
+}
+
+// sync steps the rollouts of the ModelRoute, and returns its updated version.
+func (r *rolloutTest) sync() *aiv1alpha1.ModelRoute {
+	ctx := context.Background()
+	mr, err := r.client.NetworkingV1alpha1().ModelRoutes("default").Get(ctx, "mr", metav1.GetOptions{})
+	require.NoError(r.t, err)
+	require.NoError(r.t, r.controller.syncModelRoute(ctx, mr))
+	mr, err = r.client.NetworkingV1alpha1().ModelRoutes("default").Get(ctx, "mr", metav1.GetOptions{})
+	require.NoError(r.t, err)
+	return mr
+}
+
+func targetWeights(mr *aiv1alpha1.ModelRoute) []uint32 {
+	var weights []uint32
+	for _, target := range mr.Spec.Rules[0].TargetModels {
+		weights = append(weights, *target.Weight)
+	}
+	return weights
+}
+
+func TestRolloutControllerSteps(t *testing.T) {
+	test := newRolloutTest(t, newRolloutTestModelRoute(nil))
+
+	mr := test.sync()
+	require.Len(t, mr.Status.Rollouts, 1)
+	rollout := mr.Status.Rollouts[0]
+	assert.Equal(t, aiv1alpha1.RolloutProgressing, rollout.Phase)
+	assert.Equal(t, int32(0), rollout.Step)
+	assert.Equal(t, uint32(10), rollout.CanaryWeight)
+	assert.Equal(t, []uint32{90, 10}, targetWeights(mr))
+	assert.True(t, meta.IsStatusConditionTrue(mr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutProgressing)))
+	assert.True(t, meta.IsStatusConditionFalse(mr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutDegraded)))
+
+	// The step lasts its pause
+	test.now = test.now.Add(30 * time.Second)
+	mr = test.sync()
+	assert.Equal(t, int32(0), mr.Status.Rollouts[0].Step)
+
+	test.now = test.now.Add(30 * time.Second)
+	mr = test.sync()
+	assert.Equal(t, int32(1), mr.Status.Rollouts[0].Step)
+	assert.Equal(t, []uint32{50, 50}, targetWeights(mr))
+
+	test.now = test.now.Add(time.Minute)
+	mr = test.sync()
+	rollout = mr.Status.Rollouts[0]
+	assert.Equal(t, aiv1alpha1.RolloutSucceeded, rollout.Phase)
+	assert.Equal(t, int32(2), rollout.Step)
+	assert.Equal(t, []uint32{0, 100}, targetWeights(mr))
+	cond := meta.FindStatusCondition(mr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutProgressing))
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, aiv1alpha1.ModelRouteReasonRolloutSucceeded, cond.Reason)
+
+	// A new canary starts over
+	mr.Spec.Rules[0].Rollout.Baseline = "v2"
+	mr.Spec.Rules[0].Rollout.Canary = "v1"
+	_, err := test.client.NetworkingV1alpha1().ModelRoutes("default").Update(context.Background(), mr, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	mr = test.sync()
+	rollout = mr.Status.Rollouts[0]
+	assert.Equal(t, aiv1alpha1.RolloutProgressing, rollout.Phase)
+	assert.Equal(t, "v1", rollout.Canary)
+	assert.Equal(t, []uint32{10, 90}, targetWeights(mr))
+}
+
+func TestRolloutControllerAnalysis(t *testing.T) {
+	analysis := &aiv1alpha1.RolloutAnalysis{
+		MaxErrorPercent: resource.NewQuantity(5, resource.DecimalSI),
+		MaxLatency:      &metav1.Duration{Duration: 2 * time.Second},
+		MinRequests:     func(v int32) *int32 { return &v }(10),
+	}
+	test := newRolloutTest(t, newRolloutTestModelRoute(analysis))
+	mr := test.sync()
+	// The traffic of the step is counted from the moment its weights are written
+	assert.Equal(t, mr.Status.Rollouts[0].StepStartTime.Time, test.metrics.started)
+
+	// The step is held until enough canary requests are observed
+	test.now = test.now.Add(2 * time.Minute)
+	test.metrics.traffic = canaryTraffic{requests: 5}
+	mr = test.sync()
+	rollout := mr.Status.Rollouts[0]
+	assert.Equal(t, int32(0), rollout.Step)
+	require.NotNil(t, rollout.Analysis)
+	assert.Equal(t, int64(5), rollout.Analysis.Requests)
+	assert.Equal(t, "step 1/2: waiting for 10 canary requests, 5 observed by the leader router replica", rollout.Message)
+
+	test.metrics.traffic = canaryTraffic{requests: 100, errors: 2, latencyP95: time.Second}
+	mr = test.sync()
+	rollout = mr.Status.Rollouts[0]
+	assert.Equal(t, int32(1), rollout.Step)
+	assert.Nil(t, rollout.Analysis)
+	assert.Equal(t, []uint32{50, 50}, targetWeights(mr))
+	assert.Equal(t, rollout.StepStartTime.Time, test.metrics.started)
+
+	// The analysis covers the traffic of the current step only
+	test.now = test.now.Add(10 * time.Second)
+	test.metrics.traffic = canaryTraffic{requests: 100, errors: 8, latencyP95: time.Second}
+	mr = test.sync()
+	assert.Equal(t, rollout.StepStartTime.Time, test.metrics.since)
+	rollout = mr.Status.Rollouts[0]
+	assert.Equal(t, aiv1alpha1.RolloutRolledBack, rollout.Phase)
+	assert.Equal(t, uint32(0), rollout.CanaryWeight)
+	assert.Equal(t, "rolled back at step 2/2: error rate 8% exceeds 5%, observed by the leader router replica", rollout.Message)
+	assert.Equal(t, []uint32{100, 0}, targetWeights(mr))
+	cond := meta.FindStatusCondition(mr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutDegraded))
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, aiv1alpha1.ModelRouteReasonRolledBack, cond.Reason)
+
+	// A rolled back rollout stays rolled back
+	test.now = test.now.Add(time.Hour)
+	test.metrics.traffic = canaryTraffic{}
+	mr = test.sync()
+	assert.Equal(t, aiv1alpha1.RolloutRolledBack, mr.Status.Rollouts[0].Phase)
+	assert.Equal(t, []uint32{100, 0}, targetWeights(mr))
+}
+
+func TestRolloutControllerLatencyRegression(t *testing.T) {
+	analysis := &aiv1alpha1.RolloutAnalysis{MaxLatency: &metav1.Duration{Duration: 2 * time.Second}}
+	test := newRolloutTest(t, newRolloutTestModelRoute(analysis))
+	test.sync()
+
+	test.metrics.traffic = canaryTraffic{requests: 20, latencyP95: 3 * time.Second}
+	mr := test.sync()
+	rollout := mr.Status.Rollouts[0]
+	assert.Equal(t, aiv1alpha1.RolloutRolledBack, rollout.Phase)
+	assert.Equal(t, "rolled back at step 1/2: p95 latency 3s exceeds 2s, observed by the leader router replica", rollout.Message)
+}
+
+func TestRolloutControllerRemovedRollout(t *testing.T) {
+	test := newRolloutTest(t, newRolloutTestModelRoute(nil))
+	mr := test.sync()
+	require.Len(t, mr.Status.Rollouts, 1)
+
+	mr.Spec.Rules[0].Rollout = nil
+	_, err := test.client.NetworkingV1alpha1().ModelRoutes("default").Update(context.Background(), mr, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	mr = test.sync()
+	assert.Empty(t, mr.Status.Rollouts)
+	assert.Nil(t, meta.FindStatusCondition(mr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionRolloutProgressing)))
+	// The weights are left as they are
+	assert.Equal(t, []uint32{90, 10}, targetWeights(mr))
+}
+
+func TestBucketQuantile(t *testing.T) {
+	buckets := []histogramBucket{
+		{upperBound: 1, count: 50},
+		{upperBound: 2, count: 90},
+		{upperBound: 4, count: 100},
+		{upperBound: math.Inf(1), count: 100},
+	}
+	assert.InDelta(t, 0.5, bucketQuantile(0.25, buckets), 1e-9)
+	assert.InDelta(t, 2.5, bucketQuantile(0.925, buckets), 1e-9)
+	assert.Equal(t, 0.0, bucketQuantile(0.95, nil))
+	assert.Equal(t, 0.0, bucketQuantile(0.95, []histogramBucket{{upperBound: math.Inf(1)}}))
+
+	// The observations above the highest bound are reported at the highest bound
+	buckets = []histogramBucket{{upperBound: 1, count: 1}, {upperBound: math.Inf(1), count: 10}}
+	assert.Equal(t, 1.0, bucketQuantile(0.95, buckets))
+}
+
+func TestGathererMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: modelServerRequestsMetric},
+		[]string{metrics.LabelModelRoute, metrics.LabelModelServer, metrics.LabelResult})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: e2eLatencyMetric, Buckets: []float64{1, 2, 4}},
+		[]string{metrics.LabelModel, metrics.LabelModelServer, metrics.LabelModelRoute, metrics.LabelPod})
+	registry.MustRegister(requests, latency)
+
+	requests.WithLabelValues("default/mr", "default/v2", metrics.ResultSuccess).Add(10)
+	latency.WithLabelValues("model", "default/v2", "default/mr", "pod-1").Observe(3)
+
+	source := newGathererMetrics(registry)
+	since := time.Now()
+	traffic, err := source.canaryTraffic(context.Background(), "default/mr", "default/v2", since, since)
+	require.NoError(t, err)
+	assert.Equal(t, canaryTraffic{}, traffic)
+
+	requests.WithLabelValues("default/mr", "default/v2", metrics.ResultSuccess).Add(9)
+	requests.WithLabelValues("default/mr", "default/v2", metrics.ResultError).Add(1)
+	requests.WithLabelValues("default/mr", "default/v1", metrics.ResultError).Add(5)
+	for i := 0; i < 10; i++ {
+		latency.WithLabelValues("model", "default/v2", "default/mr", "pod-1").Observe(0.5)
+	}
+	latency.WithLabelValues("model", "default/v1", "default/mr", "pod-2").Observe(10)
+
+	traffic, err = source.canaryTraffic(context.Background(), "default/mr", "default/v2", since, since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), traffic.requests)
+	assert.Equal(t, int64(1), traffic.errors)
+	assert.Equal(t, 950*time.Millisecond, traffic.latencyP95)
+
+	// A new step starts from the current counters
+	traffic, err = source.canaryTraffic(context.Background(), "default/mr", "default/v2", since.Add(time.Minute), since)
+	require.NoError(t, err)
+	assert.Equal(t, canaryTraffic{}, traffic)
+
+	// The traffic served between the start of a step and its first analysis is counted
+	next := since.Add(2 * time.Minute)
+	require.NoError(t, source.startStep("default/mr", "default/v2", next))
+	requests.WithLabelValues("default/mr", "default/v2", metrics.ResultSuccess).Add(3)
+	traffic, err = source.canaryTraffic(context.Background(), "default/mr", "default/v2", next, next)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), traffic.requests)
+}
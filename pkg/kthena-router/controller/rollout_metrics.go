@@ -0,0 +1,291 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
+)
+
+const (
+	modelServerRequestsMetric = "kthena_router_model_server_requests_total"
+	e2eLatencyMetric          = "kthena_router_e2e_request_latency_seconds"
+
+	rolloutLatencyQuantile = 0.95
+	prometheusQueryTimeout = 10 * time.Second
+)
+
+// rolloutMetrics measures the traffic of the canary of a rollout.
+type rolloutMetrics interface {
+	// startStep is called when the weight of a ModelServer of a ModelRoute is set for a new step starting at since.
+	startStep(modelRoute, modelServer string, since time.Time) error
+	// canaryTraffic returns the traffic served by a ModelServer of a ModelRoute between since and now.
+	canaryTraffic(ctx context.Context, modelRoute, modelServer string, since, now time.Time) (canaryTraffic, error)
+}
+
+// gathererMetrics measures the traffic served by this router replica, from the metrics of its registry.
+// The counters are cumulative, so the traffic of a step is the difference with the counters gathered
+// when the step started, or the first time the step is analyzed if this replica did not start it.
+type gathererMetrics struct {
+	gatherer prometheus.Gatherer
+
+	mutex     sync.Mutex
+	baselines map[string]trafficSnapshot
+}
+
+// trafficSnapshot holds the counters of a ModelServer of a ModelRoute at the beginning of a rollout step.
+type trafficSnapshot struct {
+	since    time.Time
+	requests float64
+	errors   float64
+	buckets  []histogramBucket
+}
+
+type histogramBucket struct {
+	upperBound float64
+	count      float64
+}
+
+// newGathererMetrics returns a rolloutMetrics reading the given gatherer, the default registry if nil.
+func newGathererMetrics(gatherer prometheus.Gatherer) *gathererMetrics {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	return &gathererMetrics{
+		gatherer:  gatherer,
+		baselines: make(map[string]trafficSnapshot),
+	}
+}
+
+func (m *gathererMetrics) startStep(modelRoute, modelServer string, since time.Time) error {
+	current, err := m.snapshot(modelRoute, modelServer)
+	if err != nil {
+		return err
+	}
+	current.since = since
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.baselines[modelRoute+"|"+modelServer] = current
+	return nil
+}
+
+func (m *gathererMetrics) canaryTraffic(_ context.Context, modelRoute, modelServer string, since, _ time.Time) (canaryTraffic, error) {
+	current, err := m.snapshot(modelRoute, modelServer)
+	if err != nil {
+		return canaryTraffic{}, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := modelRoute + "|" + modelServer
+	baseline, ok := m.baselines[key]
+	if !ok || !baseline.since.Equal(since) {
+		current.since = since
+		m.baselines[key] = current
+		return canaryTraffic{}, nil
+	}
+
+	traffic := canaryTraffic{
+		requests: int64(current.requests - baseline.requests),
+		errors:   int64(current.errors - baseline.errors),
+	}
+	buckets := make([]histogramBucket, len(current.buckets))
+	for i, bucket := range current.buckets {
+		buckets[i] = histogramBucket{upperBound: bucket.upperBound, count: bucket.count - bucketCount(baseline.buckets, bucket.upperBound)}
+	}
+	if p95 := bucketQuantile(rolloutLatencyQuantile, buckets); p95 > 0 {
+		traffic.latencyP95 = time.Duration(p95 * float64(time.Second))
+	}
+	return traffic, nil
+}
+
+// snapshot gathers the counters of a ModelServer of a ModelRoute, summed over the models and the pods.
+func (m *gathererMetrics) snapshot(modelRoute, modelServer string) (trafficSnapshot, error) {
+	families, err := m.gatherer.Gather()
+	if err != nil {
+		return trafficSnapshot{}, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var snapshot trafficSnapshot
+	counts := map[float64]float64{}
+	for _, family := range families {
+		switch family.GetName() {
+		case modelServerRequestsMetric:
+			for _, metric := range family.GetMetric() {
+				if !hasLabels(metric, modelRoute, modelServer) {
+					continue
+				}
+				value := metric.GetCounter().GetValue()
+				snapshot.requests += value
+				if labelValue(metric, metrics.LabelResult) == metrics.ResultError {
+					snapshot.errors += value
+				}
+			}
+		case e2eLatencyMetric:
+			for _, metric := range family.GetMetric() {
+				if !hasLabels(metric, modelRoute, modelServer) {
+					continue
+				}
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						continue
+					}
+					counts[bucket.GetUpperBound()] += float64(bucket.GetCumulativeCount())
+				}
+				counts[math.Inf(1)] += float64(histogram.GetSampleCount())
+			}
+		}
+	}
+	for upperBound, count := range counts {
+		snapshot.buckets = append(snapshot.buckets, histogramBucket{upperBound: upperBound, count: count})
+	}
+	sort.Slice(snapshot.buckets, func(i, j int) bool {
+		return snapshot.buckets[i].upperBound < snapshot.buckets[j].upperBound
+	})
+	return snapshot, nil
+}
+
+func hasLabels(metric *dto.Metric, modelRoute, modelServer string) bool {
+	return labelValue(metric, metrics.LabelModelRoute) == modelRoute && labelValue(metric, metrics.LabelModelServer) == modelServer
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func bucketCount(buckets []histogramBucket, upperBound float64) float64 {
+	for _, bucket := range buckets {
+		if bucket.upperBound == upperBound {
+			return bucket.count
+		}
+	}
+	return 0
+}
+
+// bucketQuantile estimates the q-quantile of the observations of a histogram, the same way as the
+// histogram_quantile function of Prometheus: the observations are assumed to be evenly distributed in
+// their bucket. The buckets are cumulative, sorted by upper bound, and the last one is +Inf.
+// It returns 0 if there is no observation.
+func bucketQuantile(q float64, buckets []histogramBucket) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+	total := buckets[len(buckets)-1].count
+	if total <= 0 {
+		return 0
+	}
+
+	rank := q * total
+	var lowerBound, lowerCount float64
+	for _, bucket := range buckets {
+		if bucket.count >= rank {
+			if math.IsInf(bucket.upperBound, 1) {
+				// The upper bound of the observations is unknown
+				return lowerBound
+			}
+			return lowerBound + (bucket.upperBound-lowerBound)*(rank-lowerCount)/(bucket.count-lowerCount)
+		}
+		lowerBound, lowerCount = bucket.upperBound, bucket.count
+	}
+	return lowerBound
+}
+
+// prometheusMetrics measures the traffic served by all the router replicas, from a Prometheus server scraping them.
+type prometheusMetrics struct {
+	api promv1.API
+}
+
+func newPrometheusMetrics(address string) (*prometheusMetrics, error) {
+	client, err := promapi.NewClient(promapi.Config{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("invalid Prometheus address %q: %w", address, err)
+	}
+	return &prometheusMetrics{api: promv1.NewAPI(client)}, nil
+}
+
+// startStep does nothing, the traffic of a step is queried over the time elapsed since its beginning.
+func (m *prometheusMetrics) startStep(_, _ string, _ time.Time) error {
+	return nil
+}
+
+func (m *prometheusMetrics) canaryTraffic(ctx context.Context, modelRoute, modelServer string, since, now time.Time) (canaryTraffic, error) {
+	ctx, cancel := context.WithTimeout(ctx, prometheusQueryTimeout)
+	defer cancel()
+
+	window := fmt.Sprintf("%ds", int64(math.Ceil(now.Sub(since).Seconds())))
+	selector := fmt.Sprintf("%s=%q,%s=%q", metrics.LabelModelRoute, modelRoute, metrics.LabelModelServer, modelServer)
+
+	var traffic canaryTraffic
+	requests, err := m.query(ctx, fmt.Sprintf("sum(increase(%s{%s}[%s]))", modelServerRequestsMetric, selector, window), now)
+	if err != nil {
+		return traffic, err
+	}
+	errors, err := m.query(ctx, fmt.Sprintf("sum(increase(%s{%s,%s=%q}[%s]))", modelServerRequestsMetric, selector, metrics.LabelResult, metrics.ResultError, window), now)
+	if err != nil {
+		return traffic, err
+	}
+	p95, err := m.query(ctx, fmt.Sprintf("histogram_quantile(%g, sum by (le) (increase(%s_bucket{%s}[%s])))", rolloutLatencyQuantile, e2eLatencyMetric, selector, window), now)
+	if err != nil {
+		return traffic, err
+	}
+
+	traffic.requests = int64(math.Round(requests))
+	traffic.errors = int64(math.Round(errors))
+	if p95 > 0 {
+		traffic.latencyP95 = time.Duration(p95 * float64(time.Second))
+	}
+	return traffic, nil
+}
+
+// query returns the value of a query returning at most one sample, 0 if it returns none or NaN.
+func (m *prometheusMetrics) query(ctx context.Context, query string, now time.Time) (float64, error) {
+	result, _, err := m.api.Query(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return 0, fmt.Errorf("unexpected Prometheus result type %s", result.Type())
+	}
+	if len(vector) == 0 {
+		return 0, nil
+	}
+	value := float64(vector[0].Value)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, nil
+	}
+	return value, nil
+}
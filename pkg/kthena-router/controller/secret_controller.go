@@ -0,0 +1,175 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+// SecretController syncs TLS Secrets into the store so that HTTPS Gateway listeners
+// can load the certificates referenced by listener.TLS.CertificateRefs.
+type SecretController struct {
+	secretLister corelisters.SecretLister
+	registration cache.ResourceEventHandlerRegistration
+
+	workqueue   workqueue.TypedRateLimitingInterface[any]
+	initialSync *atomic.Bool
+	store       datastore.Store
+}
+
+func NewSecretController(
+	kubeInformerFactory informers.SharedInformerFactory,
+	store datastore.Store,
+) *SecretController {
+	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+
+	controller := &SecretController{
+		secretLister: secretInformer.Lister(),
+		workqueue:    workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]()),
+		initialSync:  &atomic.Bool{},
+		store:        store,
+	}
+
+	filterHandler := &cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			secret, ok := obj.(*corev1.Secret)
+			if !ok {
+				return false
+			}
+			return secret.Type == corev1.SecretTypeTLS
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: controller.enqueueSecret,
+			UpdateFunc: func(old, new interface{}) {
+				oldSecret, ok1 := old.(*corev1.Secret)
+				newSecret, ok2 := new.(*corev1.Secret)
+				if ok1 && ok2 && oldSecret.ResourceVersion == newSecret.ResourceVersion {
+					return
+				}
+				controller.enqueueSecret(new)
+			},
+			DeleteFunc: controller.enqueueSecret,
+		},
+	}
+
+	controller.registration, _ = secretInformer.Informer().AddEventHandler(filterHandler)
+
+	return controller
+}
+
+func (c *SecretController) Run(stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	if ok := cache.WaitForCacheSync(stopCh, c.registration.HasSynced); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	c.workqueue.Add(initialSyncSignal)
+
+	go wait.Until(c.runWorker, time.Second, stopCh)
+
+	<-stopCh
+	return nil
+}
+
+func (c *SecretController) HasSynced() bool {
+	return c.initialSync.Load()
+}
+
+func (c *SecretController) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *SecretController) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.workqueue.Done(obj)
+
+	if obj == initialSyncSignal {
+		klog.V(2).Info("initial secrets have been synced")
+		c.workqueue.Forget(obj)
+		c.initialSync.Store(true)
+		return true
+	}
+
+	var key string
+	var ok bool
+	if key, ok = obj.(string); !ok {
+		c.workqueue.Forget(obj)
+		utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+		return true
+	}
+
+	if err := c.syncHandler(key); err != nil {
+		if c.workqueue.NumRequeues(key) < maxRetries {
+			klog.Errorf("error syncing secret %q: %s, requeuing", key, err.Error())
+			c.workqueue.AddRateLimited(key)
+			return true
+		}
+		klog.Errorf("giving up on syncing secret %q after %d retries: %s", key, maxRetries, err)
+		c.workqueue.Forget(obj)
+	}
+	return true
+}
+
+func (c *SecretController) syncHandler(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	secret, err := c.secretLister.Secrets(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		_ = c.store.DeleteSecret(key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.store.AddOrUpdateSecret(secret)
+}
+
+func (c *SecretController) enqueueSecret(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
@@ -0,0 +1,944 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
+	gatewaylisters "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1"
+
+	clientset "github.com/volcano-sh/kthena/client-go/clientset/versioned"
+	informersv1alpha1 "github.com/volcano-sh/kthena/client-go/informers/externalversions"
+	listerv1alpha1 "github.com/volcano-sh/kthena/client-go/listers/networking/v1alpha1"
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// statusResyncPeriod is the interval at which the status of all resources is recomputed, to catch up with
+	// the changes of the store which are not observed through the informers, such as the readiness of the pods.
+	statusResyncPeriod = 30 * time.Second
+	// statusSyncDelay leaves the other controllers time to update the store with the event a status is synced for.
+	statusSyncDelay = time.Second
+
+	inferencePoolGroup = "inference.networking.k8s.io"
+	inferencePoolKind  = "InferencePool"
+)
+
+// statusKey identifies a resource whose status is published by the StatusController
+type statusKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+const (
+	statusKindModelServer = "ModelServer"
+	statusKindModelRoute  = "ModelRoute"
+	statusKindGateway     = "Gateway"
+	statusKindHTTPRoute   = "HTTPRoute"
+)
+
+// StatusController publishes the observed state of the resources handled by kthena-router:
+// ModelRoutes, ModelServers and, when the Gateway API is enabled, Gateways and HTTPRoutes.
+// The status of a resource is synced when it changes, or when a resource it depends on changes.
+// Only one router replica should run it at a time, so it is expected to be started under leader election.
+type StatusController struct {
+	kthenaClient      clientset.Interface
+	modelRouteLister  listerv1alpha1.ModelRouteLister
+	modelServerLister listerv1alpha1.ModelServerLister
+
+	gatewayClient   gatewayclientset.Interface
+	gatewayLister   gatewaylisters.GatewayLister
+	httpRouteLister gatewaylisters.HTTPRouteLister
+
+	synced []cache.InformerSynced
+	store  datastore.Store
+
+	// workqueue holds the resources to sync while the controller runs, it is nil otherwise
+	mutex     sync.Mutex
+	workqueue workqueue.TypedRateLimitingInterface[statusKey]
+}
+
+func NewStatusController(
+	kthenaClient clientset.Interface,
+	kthenaInformerFactory informersv1alpha1.SharedInformerFactory,
+	store datastore.Store,
+) *StatusController {
+	modelRouteInformer := kthenaInformerFactory.Networking().V1alpha1().ModelRoutes()
+	modelServerInformer := kthenaInformerFactory.Networking().V1alpha1().ModelServers()
+
+	c := &StatusController{
+		kthenaClient:      kthenaClient,
+		modelRouteLister:  modelRouteInformer.Lister(),
+		modelServerLister: modelServerInformer.Lister(),
+		synced: []cache.InformerSynced{
+			modelRouteInformer.Informer().HasSynced,
+			modelServerInformer.Informer().HasSynced,
+		},
+		store: store,
+	}
+
+	_, _ = modelServerInformer.Informer().AddEventHandler(c.eventHandler(func(obj metav1.Object) {
+		c.enqueue(statusKindModelServer, obj.GetNamespace(), obj.GetName())
+		// The ModelRoutes report the pods of their ModelServers
+		c.enqueueModelRoutes(obj.GetNamespace())
+	}))
+	_, _ = modelRouteInformer.Informer().AddEventHandler(c.eventHandler(func(obj metav1.Object) {
+		c.enqueue(statusKindModelRoute, obj.GetNamespace(), obj.GetName())
+		// The Gateways report the routes attached to their listeners
+		if mr, ok := obj.(*aiv1alpha1.ModelRoute); ok {
+			c.enqueueParentGateways(mr.Namespace, mr.Spec.ParentRefs)
+		}
+	}))
+	// The pods of the ModelServers are not watched by the controller, the store reports their deletion
+	store.RegisterCallback("Pod", func(data datastore.EventData) {
+		if data.EventType == datastore.EventDelete {
+			c.enqueueModelServers(data.Pod.Namespace)
+			c.enqueueModelRoutes(data.Pod.Namespace)
+		}
+	})
+
+	return c
+}
+
+// EnableGatewayAPI makes the controller also publish Gateway status and, if enableHTTPRoute is true, HTTPRoute status.
+// It must be called before the informer factory is started.
+func (c *StatusController) EnableGatewayAPI(
+	gatewayClient gatewayclientset.Interface,
+	gatewayInformerFactory gatewayinformers.SharedInformerFactory,
+	enableHTTPRoute bool,
+) {
+	gatewayInformer := gatewayInformerFactory.Gateway().V1().Gateways()
+	c.gatewayClient = gatewayClient
+	c.gatewayLister = gatewayInformer.Lister()
+	c.synced = append(c.synced, gatewayInformer.Informer().HasSynced)
+	_, _ = gatewayInformer.Informer().AddEventHandler(c.eventHandler(func(obj metav1.Object) {
+		c.enqueue(statusKindGateway, obj.GetNamespace(), obj.GetName())
+		// The routes report whether they are accepted by the listeners of their Gateways
+		c.enqueueChildRoutes(obj.GetNamespace() + "/" + obj.GetName())
+	}))
+
+	if enableHTTPRoute {
+		httpRouteInformer := gatewayInformerFactory.Gateway().V1().HTTPRoutes()
+		c.httpRouteLister = httpRouteInformer.Lister()
+		c.synced = append(c.synced, httpRouteInformer.Informer().HasSynced)
+		_, _ = httpRouteInformer.Informer().AddEventHandler(c.eventHandler(func(obj metav1.Object) {
+			c.enqueue(statusKindHTTPRoute, obj.GetNamespace(), obj.GetName())
+			if route, ok := obj.(*gatewayv1.HTTPRoute); ok {
+				c.enqueueParentGateways(route.Namespace, route.Spec.ParentRefs)
+			}
+		}))
+	}
+}
+
+// Run syncs the status of the resources until the context is done. It can be run again afterwards, e.g. when
+// the router replica becomes the leader again.
+func (c *StatusController) Run(ctx context.Context) error {
+	defer utilruntime.HandleCrash()
+
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.synced...); !ok {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[statusKey]())
+	c.mutex.Lock()
+	c.workqueue = queue
+	c.mutex.Unlock()
+	go func() {
+		<-ctx.Done()
+		c.mutex.Lock()
+		c.workqueue = nil
+		c.mutex.Unlock()
+		queue.ShutDown()
+	}()
+
+	klog.Info("Starting status controller")
+	go wait.UntilWithContext(ctx, func(context.Context) { c.enqueueAll() }, statusResyncPeriod)
+	for c.processNextWorkItem(ctx, queue) {
+	}
+	return nil
+}
+
+func (c *StatusController) processNextWorkItem(ctx context.Context, queue workqueue.TypedRateLimitingInterface[statusKey]) bool {
+	key, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(key)
+
+	if err := c.sync(ctx, key); err != nil {
+		if queue.NumRequeues(key) < maxRetries {
+			klog.Errorf("failed to update status of %s %s/%s, retrying: %v", key.kind, key.namespace, key.name, err)
+			queue.AddRateLimited(key)
+			return true
+		}
+		klog.Errorf("failed to update status of %s %s/%s: %v", key.kind, key.namespace, key.name, err)
+	}
+	queue.Forget(key)
+	return true
+}
+
+// sync publishes the status of a resource, nothing is done if it is deleted
+func (c *StatusController) sync(ctx context.Context, key statusKey) error {
+	switch key.kind {
+	case statusKindModelServer:
+		ms, err := c.modelServerLister.ModelServers(key.namespace).Get(key.name)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		return c.syncModelServerStatus(ctx, ms)
+	case statusKindModelRoute:
+		mr, err := c.modelRouteLister.ModelRoutes(key.namespace).Get(key.name)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		return c.syncModelRouteStatus(ctx, mr)
+	case statusKindGateway:
+		gw, err := c.gatewayLister.Gateways(key.namespace).Get(key.name)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		if string(gw.Spec.GatewayClassName) != DefaultGatewayClassName {
+			return nil
+		}
+		return c.syncGatewayStatus(ctx, gw)
+	case statusKindHTTPRoute:
+		route, err := c.httpRouteLister.HTTPRoutes(key.namespace).Get(key.name)
+		if err != nil {
+			return ignoreNotFound(err)
+		}
+		return c.syncHTTPRouteStatus(ctx, route)
+	}
+	return nil
+}
+
+func ignoreNotFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// eventHandler returns an informer event handler calling enqueue with the changed object
+func (c *StatusController) eventHandler(enqueue func(obj metav1.Object)) cache.ResourceEventHandler {
+	handle := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if object, ok := obj.(metav1.Object); ok {
+			enqueue(object)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: handle,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			handle(newObj)
+		},
+		DeleteFunc: handle,
+	}
+}
+
+// enqueue adds a resource to sync, after statusSyncDelay. Nothing is done if the controller is not running.
+func (c *StatusController) enqueue(kind, namespace, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.workqueue != nil {
+		c.workqueue.AddAfter(statusKey{kind: kind, namespace: namespace, name: name}, statusSyncDelay)
+	}
+}
+
+func (c *StatusController) running() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.workqueue != nil
+}
+
+func (c *StatusController) enqueueModelServers(namespace string) {
+	if !c.running() {
+		return
+	}
+	modelServers, err := c.modelServerLister.ModelServers(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model servers: %v", err)
+		return
+	}
+	for _, ms := range modelServers {
+		c.enqueue(statusKindModelServer, ms.Namespace, ms.Name)
+	}
+}
+
+func (c *StatusController) enqueueModelRoutes(namespace string) {
+	if !c.running() {
+		return
+	}
+	modelRoutes, err := c.modelRouteLister.ModelRoutes(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model routes: %v", err)
+		return
+	}
+	for _, mr := range modelRoutes {
+		c.enqueue(statusKindModelRoute, mr.Namespace, mr.Name)
+	}
+}
+
+func (c *StatusController) enqueueParentGateways(routeNamespace string, parentRefs []gatewayv1.ParentReference) {
+	if c.gatewayLister == nil {
+		return
+	}
+	for _, parentRef := range parentRefs {
+		if gatewayKey := parentGatewayKey(routeNamespace, parentRef); gatewayKey != "" {
+			namespace, name, _ := strings.Cut(gatewayKey, "/")
+			c.enqueue(statusKindGateway, namespace, name)
+		}
+	}
+}
+
+// enqueueChildRoutes adds the ModelRoutes and HTTPRoutes attached to a Gateway
+func (c *StatusController) enqueueChildRoutes(gatewayKey string) {
+	if !c.running() {
+		return
+	}
+	hasParent := func(routeNamespace string, parentRefs []gatewayv1.ParentReference) bool {
+		for _, parentRef := range parentRefs {
+			if parentGatewayKey(routeNamespace, parentRef) == gatewayKey {
+				return true
+			}
+		}
+		return false
+	}
+	modelRoutes, err := c.modelRouteLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model routes: %v", err)
+	}
+	for _, mr := range modelRoutes {
+		if hasParent(mr.Namespace, mr.Spec.ParentRefs) {
+			c.enqueue(statusKindModelRoute, mr.Namespace, mr.Name)
+		}
+	}
+	if c.httpRouteLister == nil {
+		return
+	}
+	httpRoutes, err := c.httpRouteLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list httproutes: %v", err)
+	}
+	for _, route := range httpRoutes {
+		if hasParent(route.Namespace, route.Spec.ParentRefs) {
+			c.enqueue(statusKindHTTPRoute, route.Namespace, route.Name)
+		}
+	}
+}
+
+// enqueueAll adds all the resources to sync
+func (c *StatusController) enqueueAll() {
+	modelServers, err := c.modelServerLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model servers: %v", err)
+	}
+	for _, ms := range modelServers {
+		c.enqueue(statusKindModelServer, ms.Namespace, ms.Name)
+	}
+
+	modelRoutes, err := c.modelRouteLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list model routes: %v", err)
+	}
+	for _, mr := range modelRoutes {
+		c.enqueue(statusKindModelRoute, mr.Namespace, mr.Name)
+	}
+
+	if c.gatewayLister != nil {
+		gateways, err := c.gatewayLister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("failed to list gateways: %v", err)
+		}
+		for _, gw := range gateways {
+			c.enqueue(statusKindGateway, gw.Namespace, gw.Name)
+		}
+	}
+
+	if c.httpRouteLister != nil {
+		httpRoutes, err := c.httpRouteLister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("failed to list httproutes: %v", err)
+		}
+		for _, route := range httpRoutes {
+			c.enqueue(statusKindHTTPRoute, route.Namespace, route.Name)
+		}
+	}
+}
+
+func (c *StatusController) syncModelServerStatus(ctx context.Context, ms *aiv1alpha1.ModelServer) error {
+	status := computeModelServerStatus(ms, c.store)
+	if equality.Semantic.DeepEqual(ms.Status, status) {
+		return nil
+	}
+	msCopy := ms.DeepCopy()
+	msCopy.Status = status
+	_, err := c.kthenaClient.NetworkingV1alpha1().ModelServers(ms.Namespace).UpdateStatus(ctx, msCopy, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *StatusController) syncModelRouteStatus(ctx context.Context, mr *aiv1alpha1.ModelRoute) error {
+	status := computeModelRouteStatus(mr, c.store)
+	if equality.Semantic.DeepEqual(mr.Status, status) {
+		return nil
+	}
+	mrCopy := mr.DeepCopy()
+	mrCopy.Status = status
+	_, err := c.kthenaClient.NetworkingV1alpha1().ModelRoutes(mr.Namespace).UpdateStatus(ctx, mrCopy, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *StatusController) syncGatewayStatus(ctx context.Context, gw *gatewayv1.Gateway) error {
+	status := computeGatewayStatus(gw, c.store)
+	if equality.Semantic.DeepEqual(gw.Status, status) {
+		return nil
+	}
+	gwCopy := gw.DeepCopy()
+	gwCopy.Status = status
+	_, err := c.gatewayClient.GatewayV1().Gateways(gw.Namespace).UpdateStatus(ctx, gwCopy, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *StatusController) syncHTTPRouteStatus(ctx context.Context, route *gatewayv1.HTTPRoute) error {
+	status, ok := computeHTTPRouteStatus(route, c.store)
+	if !ok || equality.Semantic.DeepEqual(route.Status, status) {
+		return nil
+	}
+	routeCopy := route.DeepCopy()
+	routeCopy.Status = status
+	_, err := c.gatewayClient.GatewayV1().HTTPRoutes(route.Namespace).UpdateStatus(ctx, routeCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// computeModelServerStatus returns the desired status of the ModelServer based on the pods known by the store.
+func computeModelServerStatus(ms *aiv1alpha1.ModelServer, store datastore.Store) aiv1alpha1.ModelServerStatus {
+	status := aiv1alpha1.ModelServerStatus{
+		Conditions:         copyConditions(ms.Status.Conditions),
+		ObservedGeneration: ms.Generation,
+	}
+
+	pods, _ := store.GetPodsByModelServer(types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name})
+	status.MatchedPods = int32(len(pods))
+
+	cond := metav1.Condition{
+		Type:               string(aiv1alpha1.ModelServerConditionReady),
+		Status:             metav1.ConditionTrue,
+		Reason:             aiv1alpha1.ModelServerReasonPodsAvailable,
+		Message:            fmt.Sprintf("%d pod(s) matched", len(pods)),
+		ObservedGeneration: ms.Generation,
+	}
+	if len(pods) == 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = aiv1alpha1.ModelServerReasonNoPods
+		cond.Message = "no pod matches the workload selector"
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+
+	return status
+}
+
+// computeModelRouteStatus returns the desired status of the ModelRoute based on the Gateways and ModelServers known by the store.
+func computeModelRouteStatus(mr *aiv1alpha1.ModelRoute, store datastore.Store) aiv1alpha1.ModelRouteStatus {
+	status := aiv1alpha1.ModelRouteStatus{
+		Conditions:         copyConditions(mr.Status.Conditions),
+		ObservedGeneration: mr.Generation,
+	}
+	// The rollouts are published by the RolloutController
+	for _, rollout := range mr.Status.Rollouts {
+		status.Rollouts = append(status.Rollouts, *rollout.DeepCopy())
+	}
+
+	accepted := metav1.Condition{
+		Type:               string(aiv1alpha1.ModelRouteConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             aiv1alpha1.ModelRouteReasonAccepted,
+		Message:            "Route is accepted",
+		ObservedGeneration: mr.Generation,
+	}
+	if len(mr.Spec.ParentRefs) > 0 {
+		var unresolved []string
+		attached := false
+		for _, parentRef := range mr.Spec.ParentRefs {
+			gatewayKey := parentGatewayKey(mr.Namespace, parentRef)
+			if gatewayKey != "" && gatewayHasListener(store.GetGateway(gatewayKey), parentRef.SectionName) {
+				attached = true
+				continue
+			}
+			unresolved = append(unresolved, parentRefString(mr.Namespace, parentRef))
+		}
+		if !attached {
+			accepted.Status = metav1.ConditionFalse
+			accepted.Reason = aiv1alpha1.ModelRouteReasonNoMatchingParent
+			accepted.Message = fmt.Sprintf("no kthena-router Gateway listener matches parentRefs: %s", strings.Join(unresolved, ", "))
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, accepted)
+
+	resolved := metav1.Condition{
+		Type:               string(aiv1alpha1.ModelRouteConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		Reason:             aiv1alpha1.ModelRouteReasonResolvedRefs,
+		Message:            "All references are resolved",
+		ObservedGeneration: mr.Generation,
+	}
+	var missing []string
+	seen := map[string]bool{}
+	var matchedPods int32
+	for _, rule := range mr.Spec.Rules {
+		if rule == nil {
+			continue
+		}
+		var modelServerNames []string
+		for _, target := range rule.TargetModels {
+			if target != nil {
+				modelServerNames = append(modelServerNames, target.ModelServerName)
+			}
+		}
+		if rule.Fallback != nil {
+			for _, target := range rule.Fallback.Targets {
+				modelServerNames = append(modelServerNames, target.ModelServerName)
+			}
+		}
+		if rule.Mirror != nil {
+			modelServerNames = append(modelServerNames, rule.Mirror.ModelServerName)
+		}
+		for _, modelServerName := range modelServerNames {
+			if seen[modelServerName] {
+				continue
+			}
+			seen[modelServerName] = true
+			name := types.NamespacedName{Namespace: mr.Namespace, Name: modelServerName}
+			if store.GetModelServer(name) == nil {
+				missing = append(missing, modelServerName)
+				continue
+			}
+			pods, _ := store.GetPodsByModelServer(name)
+			matchedPods += int32(len(pods))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		resolved.Status = metav1.ConditionFalse
+		resolved.Reason = aiv1alpha1.ModelRouteReasonBackendNotFound
+		resolved.Message = fmt.Sprintf("ModelServer(s) not found: %s", strings.Join(missing, ", "))
+	}
+	meta.SetStatusCondition(&status.Conditions, resolved)
+	status.MatchedPods = matchedPods
+
+	return status
+}
+
+// computeGatewayStatus returns the Gateway API conformant status of a kthena-router Gateway.
+func computeGatewayStatus(gw *gatewayv1.Gateway, store datastore.Store) gatewayv1.GatewayStatus {
+	gatewayKey := fmt.Sprintf("%s/%s", gw.Namespace, gw.Name)
+	status := gatewayv1.GatewayStatus{
+		Addresses:  gw.Status.Addresses,
+		Conditions: copyConditions(gw.Status.Conditions),
+	}
+
+	existingListeners := make(map[gatewayv1.SectionName]gatewayv1.ListenerStatus, len(gw.Status.Listeners))
+	for _, ls := range gw.Status.Listeners {
+		existingListeners[ls.Name] = ls
+	}
+
+	httpRoutes := store.GetHTTPRoutesByGateway(gatewayKey)
+	modelRoutes := store.GetModelRoutesByGateway(gatewayKey)
+
+	portProtocols := make(map[gatewayv1.PortNumber]sets.Set[gatewayv1.ProtocolType])
+	for _, listener := range gw.Spec.Listeners {
+		if portProtocols[listener.Port] == nil {
+			portProtocols[listener.Port] = sets.New[gatewayv1.ProtocolType]()
+		}
+		portProtocols[listener.Port].Insert(listener.Protocol)
+	}
+
+	programmedListeners := 0
+	for _, listener := range gw.Spec.Listeners {
+		listenerStatus := gatewayv1.ListenerStatus{
+			Name:       listener.Name,
+			Conditions: copyConditions(existingListeners[listener.Name].Conditions),
+		}
+		var tlsErr *ListenerTLSError
+		if listener.Protocol == gatewayv1.HTTPSProtocolType {
+			if _, err := LoadListenerCertificates(gw.Namespace, &listener, store); err != nil {
+				tlsErr = err.(*ListenerTLSError)
+			}
+		}
+		conflicted := portProtocols[listener.Port].Len() > 1
+		setListenerConditions(&listenerStatus, listener, gw.Generation, tlsErr, conflicted)
+		if meta.IsStatusConditionTrue(listenerStatus.Conditions, string(gatewayv1.ListenerConditionProgrammed)) {
+			programmedListeners++
+			listenerStatus.SupportedKinds = supportedRouteKinds()
+			listenerStatus.AttachedRoutes = countAttachedRoutes(gw, listener, httpRoutes, modelRoutes)
+		} else {
+			listenerStatus.SupportedKinds = []gatewayv1.RouteGroupKind{}
+		}
+		status.Listeners = append(status.Listeners, listenerStatus)
+	}
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               string(gatewayv1.GatewayConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.GatewayReasonAccepted),
+		Message:            "Gateway is accepted by kthena-router",
+		ObservedGeneration: gw.Generation,
+	})
+
+	programmed := metav1.Condition{
+		Type:               string(gatewayv1.GatewayConditionProgrammed),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.GatewayReasonProgrammed),
+		Message:            fmt.Sprintf("%d/%d listener(s) programmed", programmedListeners, len(gw.Spec.Listeners)),
+		ObservedGeneration: gw.Generation,
+	}
+	if programmedListeners == 0 {
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.GatewayReasonInvalid)
+		programmed.Message = "no listener could be programmed"
+	}
+	meta.SetStatusCondition(&status.Conditions, programmed)
+
+	return status
+}
+
+// setListenerConditions sets the Accepted, ResolvedRefs, Conflicted and Programmed conditions of a listener.
+// tlsErr is the result of resolving the TLS configuration of an HTTPS listener, and conflicted
+// reports whether listeners with different protocols share the port of this listener.
+func setListenerConditions(ls *gatewayv1.ListenerStatus, listener gatewayv1.Listener, generation int64, tlsErr *ListenerTLSError, conflicted bool) {
+	accepted := metav1.Condition{
+		Type:               string(gatewayv1.ListenerConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.ListenerReasonAccepted),
+		Message:            "Listener is accepted",
+		ObservedGeneration: generation,
+	}
+	resolved := metav1.Condition{
+		Type:               string(gatewayv1.ListenerConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.ListenerReasonResolvedRefs),
+		Message:            "All references are resolved",
+		ObservedGeneration: generation,
+	}
+	conflict := metav1.Condition{
+		Type:               string(gatewayv1.ListenerConditionConflicted),
+		Status:             metav1.ConditionFalse,
+		Reason:             string(gatewayv1.ListenerReasonNoConflicts),
+		Message:            "No conflicts",
+		ObservedGeneration: generation,
+	}
+	programmed := metav1.Condition{
+		Type:               string(gatewayv1.ListenerConditionProgrammed),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.ListenerReasonProgrammed),
+		Message:            "Listener is programmed",
+		ObservedGeneration: generation,
+	}
+	notProgrammed := func(message string) {
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = string(gatewayv1.ListenerReasonInvalid)
+		programmed.Message = message
+	}
+
+	switch {
+	case listener.Protocol != gatewayv1.HTTPProtocolType && listener.Protocol != gatewayv1.HTTPSProtocolType:
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(gatewayv1.ListenerReasonUnsupportedProtocol)
+		accepted.Message = fmt.Sprintf("protocol %s is not supported, only HTTP and HTTPS are supported", listener.Protocol)
+		notProgrammed(accepted.Message)
+	case conflicted:
+		conflict.Status = metav1.ConditionTrue
+		conflict.Reason = string(gatewayv1.ListenerReasonProtocolConflict)
+		conflict.Message = fmt.Sprintf("port %d is shared by listeners with different protocols", listener.Port)
+		notProgrammed(conflict.Message)
+	case tlsErr != nil && tlsErr.IsRefError():
+		resolved.Status = metav1.ConditionFalse
+		resolved.Reason = string(tlsErr.Reason)
+		resolved.Message = tlsErr.Message
+		notProgrammed(tlsErr.Message)
+	case tlsErr != nil:
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(tlsErr.Reason)
+		accepted.Message = tlsErr.Message
+		notProgrammed(tlsErr.Message)
+	}
+
+	meta.SetStatusCondition(&ls.Conditions, accepted)
+	meta.SetStatusCondition(&ls.Conditions, resolved)
+	meta.SetStatusCondition(&ls.Conditions, conflict)
+	meta.SetStatusCondition(&ls.Conditions, programmed)
+}
+
+func supportedRouteKinds() []gatewayv1.RouteGroupKind {
+	gatewayGroup := gatewayv1.Group(gatewayv1.GroupName)
+	kthenaGroup := gatewayv1.Group(aiv1alpha1.GroupName)
+	return []gatewayv1.RouteGroupKind{
+		{Group: &gatewayGroup, Kind: "HTTPRoute"},
+		{Group: &kthenaGroup, Kind: "ModelRoute"},
+	}
+}
+
+// countAttachedRoutes counts the HTTPRoutes and ModelRoutes attached to the given listener.
+func countAttachedRoutes(gw *gatewayv1.Gateway, listener gatewayv1.Listener, httpRoutes []*gatewayv1.HTTPRoute, modelRoutes []*aiv1alpha1.ModelRoute) int32 {
+	var count int32
+	for _, route := range httpRoutes {
+		if refsAttachToListener(route.Namespace, route.Spec.ParentRefs, gw, listener) &&
+			utils.HostnamesIntersect(listenerHostname(listener), routeHostnames(route)) {
+			count++
+		}
+	}
+	for _, route := range modelRoutes {
+		if refsAttachToListener(route.Namespace, route.Spec.ParentRefs, gw, listener) {
+			count++
+		}
+	}
+	return count
+}
+
+func refsAttachToListener(routeNamespace string, parentRefs []gatewayv1.ParentReference, gw *gatewayv1.Gateway, listener gatewayv1.Listener) bool {
+	gatewayKey := fmt.Sprintf("%s/%s", gw.Namespace, gw.Name)
+	for _, parentRef := range parentRefs {
+		if parentGatewayKey(routeNamespace, parentRef) != gatewayKey {
+			continue
+		}
+		if parentRef.SectionName == nil || *parentRef.SectionName == listener.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// computeHTTPRouteStatus returns the status of an HTTPRoute for the parents managed by kthena-router.
+// Parent statuses written by other controllers are preserved.
+// The second return value is false if the route has no parent managed by kthena-router.
+func computeHTTPRouteStatus(route *gatewayv1.HTTPRoute, store datastore.Store) (gatewayv1.HTTPRouteStatus, bool) {
+	existing := make(map[string]gatewayv1.RouteParentStatus)
+	var status gatewayv1.HTTPRouteStatus
+	for _, parent := range route.Status.Parents {
+		if parent.ControllerName != ControllerName {
+			status.Parents = append(status.Parents, parent)
+			continue
+		}
+		existing[parentRefString(route.Namespace, parent.ParentRef)] = parent
+	}
+
+	resolved := resolveHTTPRouteBackendRefs(route, store)
+
+	managed := false
+	for _, parentRef := range route.Spec.ParentRefs {
+		gatewayKey := parentGatewayKey(route.Namespace, parentRef)
+		if gatewayKey == "" {
+			continue
+		}
+		gw := store.GetGateway(gatewayKey)
+		if gw == nil {
+			// Not a kthena-router Gateway
+			continue
+		}
+		managed = true
+
+		parentStatus := gatewayv1.RouteParentStatus{
+			ParentRef:      parentRef,
+			ControllerName: ControllerName,
+			Conditions:     copyConditions(existing[parentRefString(route.Namespace, parentRef)].Conditions),
+		}
+		accepted := metav1.Condition{
+			Type:               string(gatewayv1.RouteConditionAccepted),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(gatewayv1.RouteReasonAccepted),
+			Message:            "Route is accepted",
+			ObservedGeneration: route.Generation,
+		}
+		if !gatewayHasListener(gw, parentRef.SectionName) {
+			accepted.Status = metav1.ConditionFalse
+			accepted.Reason = string(gatewayv1.RouteReasonNoMatchingParent)
+			accepted.Message = fmt.Sprintf("listener %s not found in Gateway %s", *parentRef.SectionName, gatewayKey)
+		} else if !hostnamesIntersectParent(route, gw, parentRef.SectionName) {
+			accepted.Status = metav1.ConditionFalse
+			accepted.Reason = string(gatewayv1.RouteReasonNoMatchingListenerHostname)
+			accepted.Message = fmt.Sprintf("route hostnames do not intersect with any listener hostname of Gateway %s", gatewayKey)
+		}
+		meta.SetStatusCondition(&parentStatus.Conditions, accepted)
+		resolved.ObservedGeneration = route.Generation
+		meta.SetStatusCondition(&parentStatus.Conditions, resolved)
+
+		status.Parents = append(status.Parents, parentStatus)
+	}
+
+	return status, managed
+}
+
+// resolveHTTPRouteBackendRefs checks that every backendRef of the HTTPRoute points to a supported and existing backend.
+func resolveHTTPRouteBackendRefs(route *gatewayv1.HTTPRoute, store datastore.Store) metav1.Condition {
+	cond := metav1.Condition{
+		Type:    string(gatewayv1.RouteConditionResolvedRefs),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.RouteReasonResolvedRefs),
+		Message: "All references are resolved",
+	}
+
+	for _, rule := range route.Spec.Rules {
+		for _, backendRef := range rule.BackendRefs {
+			group, kind := "", "Service"
+			if backendRef.Group != nil {
+				group = string(*backendRef.Group)
+			}
+			if backendRef.Kind != nil {
+				kind = string(*backendRef.Kind)
+			}
+			// Services are proxied through their cluster address and are not tracked by the router
+			if group == "" && kind == "Service" {
+				if backendRef.Port == nil {
+					cond.Status = metav1.ConditionFalse
+					cond.Reason = string(gatewayv1.RouteReasonUnsupportedValue)
+					cond.Message = fmt.Sprintf("Service backendRef %s has no port", backendRef.Name)
+					return cond
+				}
+				continue
+			}
+			if group != inferencePoolGroup || kind != inferencePoolKind {
+				cond.Status = metav1.ConditionFalse
+				cond.Reason = string(gatewayv1.RouteReasonInvalidKind)
+				cond.Message = fmt.Sprintf("backendRef %s is neither an InferencePool nor a Service", backendRef.Name)
+				return cond
+			}
+			namespace := route.Namespace
+			if backendRef.Namespace != nil {
+				namespace = string(*backendRef.Namespace)
+			}
+			key := fmt.Sprintf("%s/%s", namespace, backendRef.Name)
+			if store.GetInferencePool(key) == nil {
+				cond.Status = metav1.ConditionFalse
+				cond.Reason = string(gatewayv1.RouteReasonBackendNotFound)
+				cond.Message = fmt.Sprintf("InferencePool %s not found", key)
+				return cond
+			}
+		}
+	}
+	return cond
+}
+
+// parentGatewayKey returns the namespace/name key of the Gateway referenced by the parentRef,
+// or an empty string if the parentRef does not reference a Gateway.
+func parentGatewayKey(routeNamespace string, parentRef gatewayv1.ParentReference) string {
+	if parentRef.Kind != nil && *parentRef.Kind != "Gateway" {
+		return ""
+	}
+	if parentRef.Group != nil && *parentRef.Group != "" && *parentRef.Group != gatewayv1.GroupName {
+		return ""
+	}
+	namespace := routeNamespace
+	if parentRef.Namespace != nil {
+		namespace = string(*parentRef.Namespace)
+	}
+	return fmt.Sprintf("%s/%s", namespace, parentRef.Name)
+}
+
+func parentRefString(routeNamespace string, parentRef gatewayv1.ParentReference) string {
+	namespace := routeNamespace
+	if parentRef.Namespace != nil {
+		namespace = string(*parentRef.Namespace)
+	}
+	ref := fmt.Sprintf("%s/%s", namespace, parentRef.Name)
+	if parentRef.SectionName != nil {
+		ref += "/" + string(*parentRef.SectionName)
+	}
+	return ref
+}
+
+// hostnamesIntersectParent checks whether the route hostnames intersect with the hostname of at least
+// one listener targeted by the parentRef.
+func hostnamesIntersectParent(route *gatewayv1.HTTPRoute, gw *gatewayv1.Gateway, sectionName *gatewayv1.SectionName) bool {
+	hostnames := routeHostnames(route)
+	for _, listener := range gw.Spec.Listeners {
+		if sectionName != nil && listener.Name != *sectionName {
+			continue
+		}
+		// Only HTTP and HTTPS listeners serve HTTPRoutes
+		if listener.Protocol != gatewayv1.HTTPProtocolType && listener.Protocol != gatewayv1.HTTPSProtocolType {
+			continue
+		}
+		if utils.HostnamesIntersect(listenerHostname(listener), hostnames) {
+			return true
+		}
+	}
+	return false
+}
+
+func listenerHostname(listener gatewayv1.Listener) string {
+	if listener.Hostname == nil {
+		return ""
+	}
+	return string(*listener.Hostname)
+}
+
+func routeHostnames(route *gatewayv1.HTTPRoute) []string {
+	hostnames := make([]string, 0, len(route.Spec.Hostnames))
+	for _, hostname := range route.Spec.Hostnames {
+		hostnames = append(hostnames, string(hostname))
+	}
+	return hostnames
+}
+
+// gatewayHasListener returns true if the Gateway exists and contains the listener named sectionName.
+// A nil sectionName matches any listener.
+func gatewayHasListener(gw *gatewayv1.Gateway, sectionName *gatewayv1.SectionName) bool {
+	if gw == nil {
+		return false
+	}
+	if sectionName == nil {
+		return true
+	}
+	for _, listener := range gw.Spec.Listeners {
+		if listener.Name == *sectionName {
+			return true
+		}
+	}
+	return false
+}
+
+func copyConditions(conditions []metav1.Condition) []metav1.Condition {
+	if conditions == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(conditions))
+	copy(out, conditions)
+	return out
+}
@@ -0,0 +1,549 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	inferencev1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	kthenafake "github.com/volcano-sh/kthena/client-go/clientset/versioned/fake"
+	informersv1alpha1 "github.com/volcano-sh/kthena/client-go/informers/externalversions"
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+func newStatusTestModelServer(name string) *aiv1alpha1.ModelServer {
+	return &aiv1alpha1.ModelServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Generation: 2},
+		Spec: aiv1alpha1.ModelServerSpec{
+			InferenceEngine: aiv1alpha1.VLLM,
+			WorkloadSelector: &aiv1alpha1.WorkloadSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+		},
+	}
+}
+
+func addStatusTestPod(t *testing.T, store datastore.Store, ms *aiv1alpha1.ModelServer, podName string) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ms.Namespace, Name: podName, Labels: map[string]string{"app": ms.Name}},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	require.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{ms}))
+}
+
+func newStatusTestGateway() *gatewayv1.Gateway {
+	return &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gw", Generation: 1},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: DefaultGatewayClassName,
+			Listeners: []gatewayv1.Listener{
+				{Name: "http", Port: 80, Protocol: gatewayv1.HTTPProtocolType},
+				{Name: "tcp", Port: 9000, Protocol: gatewayv1.TCPProtocolType},
+			},
+		},
+	}
+}
+
+func TestComputeModelServerStatus(t *testing.T) {
+	store := datastore.New()
+	ms := newStatusTestModelServer("ms")
+	require.NoError(t, store.AddOrUpdateModelServer(ms, nil))
+
+	status := computeModelServerStatus(ms, store)
+	assert.Equal(t, int32(0), status.MatchedPods)
+	assert.Equal(t, int64(2), status.ObservedGeneration)
+	cond := meta.FindStatusCondition(status.Conditions, string(aiv1alpha1.ModelServerConditionReady))
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, aiv1alpha1.ModelServerReasonNoPods, cond.Reason)
+
+	addStatusTestPod(t, store, ms, "pod-1")
+	addStatusTestPod(t, store, ms, "pod-2")
+
+	status = computeModelServerStatus(ms, store)
+	assert.Equal(t, int32(2), status.MatchedPods)
+	cond = meta.FindStatusCondition(status.Conditions, string(aiv1alpha1.ModelServerConditionReady))
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, aiv1alpha1.ModelServerReasonPodsAvailable, cond.Reason)
+}
+
+func TestComputeModelRouteStatus(t *testing.T) {
+	store := datastore.New()
+	ms := newStatusTestModelServer("ms")
+	require.NoError(t, store.AddOrUpdateModelServer(ms, nil))
+	addStatusTestPod(t, store, ms, "pod-1")
+	require.NoError(t, store.AddOrUpdateGateway(newStatusTestGateway()))
+
+	gatewayKind := gatewayv1.Kind("Gateway")
+	missingSection := gatewayv1.SectionName("missing")
+
+	tests := []struct {
+		name             string
+		parentRefs       []gatewayv1.ParentReference
+		targets          []string
+		fallbackTargets  []string
+		mirror           string
+		expectAccepted   metav1.ConditionStatus
+		expectAcceptedRe string
+		expectResolved   metav1.ConditionStatus
+		expectResolvedRe string
+		expectPods       int32
+	}{
+		{
+			name:             "no parentRefs and existing backend",
+			targets:          []string{"ms"},
+			expectAccepted:   metav1.ConditionTrue,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonAccepted,
+			expectResolved:   metav1.ConditionTrue,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonResolvedRefs,
+			expectPods:       1,
+		},
+		{
+			name:             "parentRef to kthena gateway",
+			parentRefs:       []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw"}},
+			targets:          []string{"ms"},
+			expectAccepted:   metav1.ConditionTrue,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonAccepted,
+			expectResolved:   metav1.ConditionTrue,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonResolvedRefs,
+			expectPods:       1,
+		},
+		{
+			name:             "parentRef to unknown gateway",
+			parentRefs:       []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "other"}},
+			targets:          []string{"ms"},
+			expectAccepted:   metav1.ConditionFalse,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonNoMatchingParent,
+			expectResolved:   metav1.ConditionTrue,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonResolvedRefs,
+			expectPods:       1,
+		},
+		{
+			name:             "parentRef to unknown listener",
+			parentRefs:       []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw", SectionName: &missingSection}},
+			targets:          []string{"ms"},
+			expectAccepted:   metav1.ConditionFalse,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonNoMatchingParent,
+			expectResolved:   metav1.ConditionTrue,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonResolvedRefs,
+			expectPods:       1,
+		},
+		{
+			name:             "missing backend",
+			targets:          []string{"ms", "missing"},
+			expectAccepted:   metav1.ConditionTrue,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonAccepted,
+			expectResolved:   metav1.ConditionFalse,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonBackendNotFound,
+			expectPods:       1,
+		},
+		{
+			name:             "missing fallback target",
+			targets:          []string{"ms"},
+			fallbackTargets:  []string{"missing"},
+			expectAccepted:   metav1.ConditionTrue,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonAccepted,
+			expectResolved:   metav1.ConditionFalse,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonBackendNotFound,
+			expectPods:       1,
+		},
+		{
+			name:             "missing mirror target",
+			targets:          []string{"ms"},
+			mirror:           "missing",
+			expectAccepted:   metav1.ConditionTrue,
+			expectAcceptedRe: aiv1alpha1.ModelRouteReasonAccepted,
+			expectResolved:   metav1.ConditionFalse,
+			expectResolvedRe: aiv1alpha1.ModelRouteReasonBackendNotFound,
+			expectPods:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var targets []*aiv1alpha1.TargetModel
+			for _, target := range tt.targets {
+				targets = append(targets, &aiv1alpha1.TargetModel{ModelServerName: target})
+			}
+			rule := &aiv1alpha1.Rule{TargetModels: targets}
+			if len(tt.fallbackTargets) > 0 {
+				rule.Fallback = &aiv1alpha1.Fallback{}
+				for _, target := range tt.fallbackTargets {
+					rule.Fallback.Targets = append(rule.Fallback.Targets, aiv1alpha1.FallbackTarget{ModelServerName: target})
+				}
+			}
+			if tt.mirror != "" {
+				rule.Mirror = &aiv1alpha1.Mirror{ModelServerName: tt.mirror}
+			}
+			mr := &aiv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mr", Generation: 3},
+				Spec: aiv1alpha1.ModelRouteSpec{
+					ModelName:  "model",
+					ParentRefs: tt.parentRefs,
+					Rules:      []*aiv1alpha1.Rule{rule},
+				},
+			}
+
+			status := computeModelRouteStatus(mr, store)
+			assert.Equal(t, tt.expectPods, status.MatchedPods)
+			assert.Equal(t, int64(3), status.ObservedGeneration)
+
+			accepted := meta.FindStatusCondition(status.Conditions, string(aiv1alpha1.ModelRouteConditionAccepted))
+			require.NotNil(t, accepted)
+			assert.Equal(t, tt.expectAccepted, accepted.Status)
+			assert.Equal(t, tt.expectAcceptedRe, accepted.Reason)
+
+			resolved := meta.FindStatusCondition(status.Conditions, string(aiv1alpha1.ModelRouteConditionResolvedRefs))
+			require.NotNil(t, resolved)
+			assert.Equal(t, tt.expectResolved, resolved.Status)
+			assert.Equal(t, tt.expectResolvedRe, resolved.Reason)
+		})
+	}
+}
+
+func TestComputeModelRouteStatusKeepsTransitionTime(t *testing.T) {
+	store := datastore.New()
+	transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	mr := &aiv1alpha1.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mr"},
+		Spec:       aiv1alpha1.ModelRouteSpec{ModelName: "model"},
+		Status: aiv1alpha1.ModelRouteStatus{
+			Conditions: []metav1.Condition{{
+				Type:               string(aiv1alpha1.ModelRouteConditionAccepted),
+				Status:             metav1.ConditionTrue,
+				Reason:             aiv1alpha1.ModelRouteReasonAccepted,
+				LastTransitionTime: transition,
+			}},
+		},
+	}
+
+	status := computeModelRouteStatus(mr, store)
+	accepted := meta.FindStatusCondition(status.Conditions, string(aiv1alpha1.ModelRouteConditionAccepted))
+	require.NotNil(t, accepted)
+	assert.Equal(t, transition, accepted.LastTransitionTime)
+	// The original object must not be mutated
+	assert.Len(t, mr.Status.Conditions, 1)
+}
+
+func TestComputeGatewayStatus(t *testing.T) {
+	store := datastore.New()
+	gw := newStatusTestGateway()
+	require.NoError(t, store.AddOrUpdateGateway(gw))
+
+	gatewayKind := gatewayv1.Kind("Gateway")
+	httpSection := gatewayv1.SectionName("http")
+	require.NoError(t, store.AddOrUpdateHTTPRoute(&gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "route"},
+		Spec: gatewayv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw", SectionName: &httpSection}},
+			},
+		},
+	}))
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mr"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName:  "model",
+			ParentRefs: []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw"}},
+		},
+	}))
+
+	status := computeGatewayStatus(gw, store)
+
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, string(gatewayv1.GatewayConditionAccepted)))
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, string(gatewayv1.GatewayConditionProgrammed)))
+	require.Len(t, status.Listeners, 2)
+
+	httpListener := status.Listeners[0]
+	assert.Equal(t, gatewayv1.SectionName("http"), httpListener.Name)
+	assert.Equal(t, int32(2), httpListener.AttachedRoutes)
+	assert.Len(t, httpListener.SupportedKinds, 2)
+	assert.True(t, meta.IsStatusConditionTrue(httpListener.Conditions, string(gatewayv1.ListenerConditionAccepted)))
+	assert.True(t, meta.IsStatusConditionTrue(httpListener.Conditions, string(gatewayv1.ListenerConditionProgrammed)))
+
+	tcpListener := status.Listeners[1]
+	assert.Equal(t, int32(0), tcpListener.AttachedRoutes)
+	accepted := meta.FindStatusCondition(tcpListener.Conditions, string(gatewayv1.ListenerConditionAccepted))
+	require.NotNil(t, accepted)
+	assert.Equal(t, metav1.ConditionFalse, accepted.Status)
+	assert.Equal(t, string(gatewayv1.ListenerReasonUnsupportedProtocol), accepted.Reason)
+	assert.False(t, meta.IsStatusConditionTrue(tcpListener.Conditions, string(gatewayv1.ListenerConditionProgrammed)))
+}
+
+func TestComputeGatewayStatusHTTPSListeners(t *testing.T) {
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateSecret(newTLSSecret(t, "default", "cert", "example.com")))
+
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gw", Generation: 1},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: DefaultGatewayClassName,
+			Listeners: []gatewayv1.Listener{
+				newHTTPSListener(gatewayv1.SecretObjectReference{Name: "cert"}),
+				{
+					Name:     "https-missing",
+					Port:     8443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					TLS: &gatewayv1.ListenerTLSConfig{
+						CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "missing"}},
+					},
+				},
+				{Name: "mixed-http", Port: 9443, Protocol: gatewayv1.HTTPProtocolType},
+				{
+					Name:     "mixed-https",
+					Port:     9443,
+					Protocol: gatewayv1.HTTPSProtocolType,
+					TLS: &gatewayv1.ListenerTLSConfig{
+						CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "cert"}},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, store.AddOrUpdateGateway(gw))
+
+	status := computeGatewayStatus(gw, store)
+	require.Len(t, status.Listeners, 4)
+
+	valid := status.Listeners[0]
+	assert.True(t, meta.IsStatusConditionTrue(valid.Conditions, string(gatewayv1.ListenerConditionAccepted)))
+	assert.True(t, meta.IsStatusConditionTrue(valid.Conditions, string(gatewayv1.ListenerConditionResolvedRefs)))
+	assert.True(t, meta.IsStatusConditionFalse(valid.Conditions, string(gatewayv1.ListenerConditionConflicted)))
+	assert.True(t, meta.IsStatusConditionTrue(valid.Conditions, string(gatewayv1.ListenerConditionProgrammed)))
+
+	missing := status.Listeners[1]
+	assert.True(t, meta.IsStatusConditionTrue(missing.Conditions, string(gatewayv1.ListenerConditionAccepted)))
+	resolved := meta.FindStatusCondition(missing.Conditions, string(gatewayv1.ListenerConditionResolvedRefs))
+	require.NotNil(t, resolved)
+	assert.Equal(t, metav1.ConditionFalse, resolved.Status)
+	assert.Equal(t, string(gatewayv1.ListenerReasonInvalidCertificateRef), resolved.Reason)
+	assert.False(t, meta.IsStatusConditionTrue(missing.Conditions, string(gatewayv1.ListenerConditionProgrammed)))
+	assert.Empty(t, missing.SupportedKinds)
+
+	for _, ls := range status.Listeners[2:] {
+		conflicted := meta.FindStatusCondition(ls.Conditions, string(gatewayv1.ListenerConditionConflicted))
+		require.NotNil(t, conflicted)
+		assert.Equal(t, metav1.ConditionTrue, conflicted.Status)
+		assert.Equal(t, string(gatewayv1.ListenerReasonProtocolConflict), conflicted.Reason)
+		assert.False(t, meta.IsStatusConditionTrue(ls.Conditions, string(gatewayv1.ListenerConditionProgrammed)))
+	}
+
+	// The Gateway stays programmed as long as one listener is programmed
+	assert.True(t, meta.IsStatusConditionTrue(status.Conditions, string(gatewayv1.GatewayConditionProgrammed)))
+}
+
+func TestComputeHTTPRouteStatus(t *testing.T) {
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateGateway(newStatusTestGateway()))
+	require.NoError(t, store.AddOrUpdateInferencePool(&inferencev1.InferencePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pool"},
+	}))
+
+	gatewayKind := gatewayv1.Kind("Gateway")
+	poolGroup := gatewayv1.Group(inferencePoolGroup)
+	poolKind := gatewayv1.Kind(inferencePoolKind)
+	otherController := gatewayv1.GatewayController("example.com/other")
+
+	newRoute := func(backend string) *gatewayv1.HTTPRoute {
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "route", Generation: 4},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{
+						{Kind: &gatewayKind, Name: "gw"},
+						{Kind: &gatewayKind, Name: "foreign"},
+					},
+				},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1.HTTPBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Group: &poolGroup,
+								Kind:  &poolKind,
+								Name:  gatewayv1.ObjectName(backend),
+							},
+						},
+					}},
+				}},
+			},
+			Status: gatewayv1.HTTPRouteStatus{
+				RouteStatus: gatewayv1.RouteStatus{
+					Parents: []gatewayv1.RouteParentStatus{{
+						ParentRef:      gatewayv1.ParentReference{Kind: &gatewayKind, Name: "foreign"},
+						ControllerName: otherController,
+					}},
+				},
+			},
+		}
+	}
+
+	status, managed := computeHTTPRouteStatus(newRoute("pool"), store)
+	require.True(t, managed)
+	require.Len(t, status.Parents, 2)
+	// Status from other controllers is preserved
+	assert.Equal(t, otherController, status.Parents[0].ControllerName)
+	ours := status.Parents[1]
+	assert.Equal(t, gatewayv1.GatewayController(ControllerName), ours.ControllerName)
+	assert.Equal(t, gatewayv1.ObjectName("gw"), ours.ParentRef.Name)
+	assert.True(t, meta.IsStatusConditionTrue(ours.Conditions, string(gatewayv1.RouteConditionAccepted)))
+	assert.True(t, meta.IsStatusConditionTrue(ours.Conditions, string(gatewayv1.RouteConditionResolvedRefs)))
+
+	status, managed = computeHTTPRouteStatus(newRoute("missing"), store)
+	require.True(t, managed)
+	resolved := meta.FindStatusCondition(status.Parents[1].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	require.NotNil(t, resolved)
+	assert.Equal(t, metav1.ConditionFalse, resolved.Status)
+	assert.Equal(t, string(gatewayv1.RouteReasonBackendNotFound), resolved.Reason)
+	assert.Equal(t, int64(4), resolved.ObservedGeneration)
+
+	unmanaged := newRoute("pool")
+	unmanaged.Spec.ParentRefs = []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "foreign"}}
+	_, managed = computeHTTPRouteStatus(unmanaged, store)
+	assert.False(t, managed)
+}
+
+func TestComputeHTTPRouteStatusHostnames(t *testing.T) {
+	store := datastore.New()
+	gw := newStatusTestGateway()
+	wildcard := gatewayv1.Hostname("*.example.com")
+	gw.Spec.Listeners[0].Hostname = &wildcard
+	require.NoError(t, store.AddOrUpdateGateway(gw))
+
+	gatewayKind := gatewayv1.Kind("Gateway")
+	newRoute := func(hostnames ...gatewayv1.Hostname) *gatewayv1.HTTPRoute {
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "route"},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw"}},
+				},
+				Hostnames: hostnames,
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		hostnames []gatewayv1.Hostname
+		accepted  bool
+	}{
+		{name: "no hostnames", accepted: true},
+		{name: "precise hostname under wildcard", hostnames: []gatewayv1.Hostname{"foo.example.com"}, accepted: true},
+		{name: "narrower wildcard", hostnames: []gatewayv1.Hostname{"*.models.example.com"}, accepted: true},
+		{name: "one of several hostnames intersects", hostnames: []gatewayv1.Hostname{"other.org", "foo.example.com"}, accepted: true},
+		{name: "parent domain does not match wildcard", hostnames: []gatewayv1.Hostname{"example.com"}},
+		{name: "unrelated hostname", hostnames: []gatewayv1.Hostname{"other.org"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, managed := computeHTTPRouteStatus(newRoute(tt.hostnames...), store)
+			require.True(t, managed)
+			require.Len(t, status.Parents, 1)
+			accepted := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+			require.NotNil(t, accepted)
+			if tt.accepted {
+				assert.Equal(t, metav1.ConditionTrue, accepted.Status)
+			} else {
+				assert.Equal(t, metav1.ConditionFalse, accepted.Status)
+				assert.Equal(t, string(gatewayv1.RouteReasonNoMatchingListenerHostname), accepted.Reason)
+			}
+		})
+	}
+
+	// Only routes with intersecting hostnames are counted as attached to the listener
+	attached := countAttachedRoutes(gw, gw.Spec.Listeners[0], []*gatewayv1.HTTPRoute{
+		newRoute("foo.example.com"), newRoute("other.org"), newRoute(),
+	}, nil)
+	assert.Equal(t, int32(2), attached)
+
+	// Only the HTTP and HTTPS listeners serve HTTPRoutes, the hostnames of the other listeners are ignored
+	otherHostname := gatewayv1.Hostname("other.org")
+	gw.Spec.Listeners[1].Hostname = &otherHostname
+	gw.Spec.Listeners = append(gw.Spec.Listeners, gatewayv1.Listener{
+		Name: "tls", Port: 9443, Protocol: gatewayv1.TLSProtocolType, Hostname: &otherHostname,
+	})
+	require.NoError(t, store.AddOrUpdateGateway(gw))
+	status, managed := computeHTTPRouteStatus(newRoute("other.org"), store)
+	require.True(t, managed)
+	require.Len(t, status.Parents, 1)
+	accepted := meta.FindStatusCondition(status.Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+	require.NotNil(t, accepted)
+	assert.Equal(t, metav1.ConditionFalse, accepted.Status)
+	assert.Equal(t, string(gatewayv1.RouteReasonNoMatchingListenerHostname), accepted.Reason)
+}
+
+func TestStatusControllerRun(t *testing.T) {
+	ms := newStatusTestModelServer("ms")
+	mr := &aiv1alpha1.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mr"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "model",
+			Rules:     []*aiv1alpha1.Rule{{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms"}}}},
+		},
+	}
+	kthenaClient := kthenafake.NewSimpleClientset(ms, mr)
+	kthenaInformerFactory := informersv1alpha1.NewSharedInformerFactory(kthenaClient, 0)
+
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateModelServer(ms, nil))
+	addStatusTestPod(t, store, ms, "pod-1")
+
+	controller := NewStatusController(kthenaClient, kthenaInformerFactory, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kthenaInformerFactory.Start(ctx.Done())
+	go func() {
+		_ = controller.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		gotMs, err := kthenaClient.NetworkingV1alpha1().ModelServers("default").Get(ctx, "ms", metav1.GetOptions{})
+		return err == nil && gotMs.Status.MatchedPods == 1 &&
+			meta.IsStatusConditionTrue(gotMs.Status.Conditions, string(aiv1alpha1.ModelServerConditionReady))
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		gotMr, err := kthenaClient.NetworkingV1alpha1().ModelRoutes("default").Get(ctx, "mr", metav1.GetOptions{})
+		return err == nil && gotMr.Status.MatchedPods == 1 &&
+			meta.IsStatusConditionTrue(gotMr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionAccepted)) &&
+			meta.IsStatusConditionTrue(gotMr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionResolvedRefs))
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// A change of the ModelRoute is published without waiting for the periodic resync
+	gotMr, err := kthenaClient.NetworkingV1alpha1().ModelRoutes("default").Get(ctx, "mr", metav1.GetOptions{})
+	require.NoError(t, err)
+	gotMr.Spec.Rules[0].TargetModels[0].ModelServerName = "missing"
+	_, err = kthenaClient.NetworkingV1alpha1().ModelRoutes("default").Update(ctx, gotMr, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		gotMr, err := kthenaClient.NetworkingV1alpha1().ModelRoutes("default").Get(ctx, "mr", metav1.GetOptions{})
+		return err == nil && meta.IsStatusConditionFalse(gotMr.Status.Conditions, string(aiv1alpha1.ModelRouteConditionResolvedRefs))
+	}, statusResyncPeriod/2, 50*time.Millisecond)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+// matchBodyFields returns true if the body satisfies all the conditions on its fields
+func matchBodyFields(fields []aiv1alpha1.BodyFieldMatch, body map[string]interface{}) bool {
+	for i := range fields {
+		if !matchBodyField(&fields[i], body) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchBodyField(fm *aiv1alpha1.BodyFieldMatch, body map[string]interface{}) bool {
+	value, found := lookupBodyField(body, fm.Path)
+	if fm.Exists != nil {
+		return found == *fm.Exists
+	}
+	if !found {
+		return false
+	}
+
+	switch {
+	case fm.Exact != nil:
+		return bodyFieldString(value) == *fm.Exact
+	case fm.Prefix != nil:
+		return strings.HasPrefix(bodyFieldString(value), *fm.Prefix)
+	case fm.Regex != nil:
+		matched, _ := regexp.MatchString(*fm.Regex, bodyFieldString(value))
+		return matched
+	case fm.Range != nil:
+		number, ok := bodyFieldNumber(value)
+		if !ok {
+			return false
+		}
+		if fm.Range.Min != nil && number < fm.Range.Min.AsApproximateFloat64() {
+			return false
+		}
+		if fm.Range.Max != nil && number > fm.Range.Max.AsApproximateFloat64() {
+			return false
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// lookupBodyField returns the value at a dot-separated path of the body. A field set to null is not found.
+// The fields kept as raw JSON by the request parser are decoded, without modifying the body.
+func lookupBodyField(body map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = body
+	for _, key := range strings.Split(path, ".") {
+		object, ok := decodeBodyValue(value).(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	value = decodeBodyValue(value)
+	return value, value != nil
+}
+
+func decodeBodyValue(value interface{}) interface{} {
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// bodyFieldString returns a string field as is, and the JSON encoding of the other fields
+func bodyFieldString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// bodyFieldNumber returns the value of a number field, or the length of a string or an array field
+func bodyFieldNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		return float64(utf8.RuneCountInString(v)), true
+	case []interface{}:
+		return float64(len(v)), true
+	default:
+		return 0, false
+	}
+}
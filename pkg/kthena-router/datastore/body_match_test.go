@@ -0,0 +1,139 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datastore
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func TestMatchBodyField(t *testing.T) {
+	// user and temperature are kept as raw JSON by the request parser
+	body, err := utils.DecodeRequestBody([]byte(`{
+		"model": "m",
+		"stream": true,
+		"user": "team-a/alice",
+		"temperature": 0.2,
+		"prompt": "hello world",
+		"messages": [{"role": "user", "content": "hi"}],
+		"metadata": {"tier": "gold", "priority": 2},
+		"tools": null
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		match aiv1alpha1.BodyFieldMatch
+		want  bool
+	}{
+		{"exact string", aiv1alpha1.BodyFieldMatch{Path: "user", Exact: ptr("team-a/alice")}, true},
+		{"exact boolean", aiv1alpha1.BodyFieldMatch{Path: "stream", Exact: ptr("true")}, true},
+		{"exact mismatch", aiv1alpha1.BodyFieldMatch{Path: "stream", Exact: ptr("false")}, false},
+		{"exact missing field", aiv1alpha1.BodyFieldMatch{Path: "missing", Exact: ptr("")}, false},
+		{"prefix", aiv1alpha1.BodyFieldMatch{Path: "user", Prefix: ptr("team-a/")}, true},
+		{"regex", aiv1alpha1.BodyFieldMatch{Path: "user", Regex: ptr(`^team-[ab]/`)}, true},
+		{"nested field", aiv1alpha1.BodyFieldMatch{Path: "metadata.tier", Exact: ptr("gold")}, true},
+		{"nested number", aiv1alpha1.BodyFieldMatch{Path: "metadata.priority", Exact: ptr("2")}, true},
+		{"path through a string", aiv1alpha1.BodyFieldMatch{Path: "user.name", Exists: ptr(true)}, false},
+		{"number in range", aiv1alpha1.BodyFieldMatch{Path: "temperature", Range: &aiv1alpha1.NumberRange{Max: ptr(resource.MustParse("0.5"))}}, true},
+		{"number out of range", aiv1alpha1.BodyFieldMatch{Path: "temperature", Range: &aiv1alpha1.NumberRange{Min: ptr(resource.MustParse("0.5"))}}, false},
+		{"string length", aiv1alpha1.BodyFieldMatch{Path: "prompt", Range: &aiv1alpha1.NumberRange{Min: ptr(resource.MustParse("11")), Max: ptr(resource.MustParse("11"))}}, true},
+		{"array length", aiv1alpha1.BodyFieldMatch{Path: "messages", Range: &aiv1alpha1.NumberRange{Min: ptr(resource.MustParse("2"))}}, false},
+		{"range of an object", aiv1alpha1.BodyFieldMatch{Path: "metadata", Range: &aiv1alpha1.NumberRange{Min: ptr(resource.MustParse("0"))}}, false},
+		{"exists", aiv1alpha1.BodyFieldMatch{Path: "metadata.tier", Exists: ptr(true)}, true},
+		{"null does not exist", aiv1alpha1.BodyFieldMatch{Path: "tools", Exists: ptr(true)}, false},
+		{"not exists", aiv1alpha1.BodyFieldMatch{Path: "tools", Exists: ptr(false)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchBodyField(&tt.match, body))
+		})
+	}
+}
+
+func TestSelectRule_BodyFields(t *testing.T) {
+	rules := []*aiv1alpha1.Rule{
+		{
+			Name: "streaming-gold",
+			ModelMatch: &aiv1alpha1.ModelMatch{Body: &aiv1alpha1.BodyMatch{Fields: []aiv1alpha1.BodyFieldMatch{
+				{Path: "stream", Exact: ptr("true")},
+				{Path: "metadata.tier", Exact: ptr("gold")},
+			}}},
+		},
+		{
+			Name: "tools",
+			ModelMatch: &aiv1alpha1.ModelMatch{Body: &aiv1alpha1.BodyMatch{Fields: []aiv1alpha1.BodyFieldMatch{
+				{Path: "tools", Exists: ptr(true)},
+			}}},
+		},
+		{
+			Name: "default",
+		},
+	}
+	req := &http.Request{URL: &url.URL{Path: "/v1/chat/completions"}, Header: http.Header{}}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantRule string
+	}{
+		{
+			name:     "all the fields of a rule must match",
+			body:     `{"model": "m", "stream": true, "metadata": {"tier": "gold"}}`,
+			wantRule: "streaming-gold",
+		},
+		{
+			name:     "a partial match falls through to the next rule",
+			body:     `{"model": "m", "stream": false, "metadata": {"tier": "gold"}}`,
+			wantRule: "default",
+		},
+		{
+			name:     "the first matching rule wins",
+			body:     `{"model": "m", "stream": true, "metadata": {"tier": "gold"}, "tools": []}`,
+			wantRule: "streaming-gold",
+		},
+		{
+			name:     "later rule",
+			body:     `{"model": "m", "tools": [{"type": "function"}]}`,
+			wantRule: "tools",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := utils.DecodeRequestBody([]byte(tt.body))
+			require.NoError(t, err)
+			rule, err := SelectRule("m", req, body, rules)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRule, rule.Name)
+		})
+	}
+
+	// Without a body, e.g. when listing the models, the body fields are not checked
+	rule, err := SelectRule("m", req, nil, rules)
+	require.NoError(t, err)
+	assert.Equal(t, "streaming-gold", rule.Name)
+}
@@ -122,6 +122,7 @@ type EventData struct {
 	EventType EventType
 	Pod       types.NamespacedName
 	Gateway   types.NamespacedName
+	Secret    types.NamespacedName
 
 	ModelName  string
 	ModelRoute *aiv1alpha1.ModelRoute
@@ -148,7 +149,9 @@ type Store interface {
 	DeletePod(podName types.NamespacedName) error
 
 	// New methods for routing functionality
-	MatchModelServer(modelName string, request *http.Request, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, error)
+	// The body is the decoded request body, it is nil if the rules are matched without a request body.
+	// It returns the selected ModelServer, whether the model is a LoRA adapter, and the matched ModelRoute and rule.
+	MatchModelServer(modelName string, request *http.Request, body map[string]interface{}, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, *aiv1alpha1.Rule, error)
 
 	// Model routing methods
 	AddOrUpdateModelRoute(mr *aiv1alpha1.ModelRoute) error
@@ -202,6 +205,14 @@ type Store interface {
 	GetAllHTTPRoutes() []*gatewayv1.HTTPRoute
 	GetHTTPRoutesByGateway(gatewayKey string) []*gatewayv1.HTTPRoute
 	GetModelRoutesByGateway(gatewayKey string) []*aiv1alpha1.ModelRoute
+	// MaxRequestBodySize returns the largest MaxRequestBodySize of the ModelRoutes matched by the requests
+	// received by a Gateway, or false if one of them is not limited
+	MaxRequestBodySize(gatewayKey string) (int64, bool)
+
+	// Secret methods, all the TLS Secrets of the cluster are stored, the Gateway listeners look up the ones they reference
+	AddOrUpdateSecret(secret *corev1.Secret) error
+	DeleteSecret(key string) error
+	GetSecret(key string) *corev1.Secret
 
 	// Debug interface methods
 	GetAllModelRoutes() map[string]*aiv1alpha1.ModelRoute
@@ -270,6 +281,11 @@ type store struct {
 	httpRouteMutex sync.RWMutex
 	httpRoutes     map[string]*gatewayv1.HTTPRoute // key: namespace/name, value: *gatewayv1.HTTPRoute
 	gatewayRoutes  map[string]sets.Set[string]     // key: gateway key (namespace/name), value: set of HTTPRoute keys
+
+	// Secret fields, used to terminate TLS on Gateway listeners
+	secretMutex sync.RWMutex
+	secrets     map[string]*corev1.Secret // key: namespace/name, value: *corev1.Secret
+
 	// New fields for callback management
 	callbacks map[string][]CallbackFunc
 
@@ -292,6 +308,7 @@ func New() Store {
 		inferencePools:      make(map[string]*inferencev1.InferencePool),
 		httpRoutes:          make(map[string]*gatewayv1.HTTPRoute),
 		gatewayRoutes:       make(map[string]sets.Set[string]),
+		secrets:             make(map[string]*corev1.Secret),
 		callbacks:           make(map[string][]CallbackFunc),
 		initialSynced:       &atomic.Bool{},
 		requestWaitingQueue: sync.Map{},
@@ -776,7 +793,44 @@ func (s *store) DeleteModelRoute(namespacedName string) error {
 	return nil
 }
 
-func (s *store) MatchModelServer(model string, req *http.Request, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, error) {
+// servedByGateway returns true if the requests received by a Gateway can match a ModelRoute.
+// The requests received without a Gateway, whose key is empty, match the ModelRoutes without parentRefs.
+func (s *store) servedByGateway(mr *aiv1alpha1.ModelRoute, gatewayKey string) bool {
+	// Check parentRefs if specified
+	if len(mr.Spec.ParentRefs) > 0 {
+		// If gatewayKey is provided (not empty), check if ModelRoute matches the specific gateway
+		// If ModelRoute has parentRefs but gatewayKey is empty, skip it
+		return gatewayKey != "" && s.matchesSpecificGateway(mr, gatewayKey)
+	}
+	// If gatewayKey is specified, we only match ModelRoute with parentRefs
+	// ModelRoute without parentRefs should not match when gatewayKey is provided
+	return gatewayKey == ""
+}
+
+// MaxRequestBodySize returns the largest MaxRequestBodySize of the ModelRoutes the requests received by a Gateway
+// can match. It returns false if one of them has no limit.
+func (s *store) MaxRequestBodySize(gatewayKey string) (int64, bool) {
+	s.routeMutex.RLock()
+	defer s.routeMutex.RUnlock()
+
+	var maxSize int64
+	for _, routesByModel := range []map[string][]*aiv1alpha1.ModelRoute{s.routes, s.loraRoutes} {
+		for _, routes := range routesByModel {
+			for _, mr := range routes {
+				if !s.servedByGateway(mr, gatewayKey) {
+					continue
+				}
+				if mr.Spec.MaxRequestBodySize == nil {
+					return 0, false
+				}
+				maxSize = max(maxSize, mr.Spec.MaxRequestBodySize.Value())
+			}
+		}
+	}
+	return maxSize, maxSize > 0
+}
+
+func (s *store) MatchModelServer(model string, req *http.Request, body map[string]interface{}, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, *aiv1alpha1.Rule, error) {
 	s.routeMutex.RLock()
 	defer s.routeMutex.RUnlock()
 
@@ -792,7 +846,7 @@ func (s *store) MatchModelServer(model string, req *http.Request, gatewayKey str
 		// Try to find routes by lora name
 		loraRoutes, ok := s.loraRoutes[model]
 		if !ok {
-			return types.NamespacedName{}, false, nil, fmt.Errorf("not found route rules for model %s", model)
+			return types.NamespacedName{}, false, nil, nil, fmt.Errorf("not found route rules for model %s", model)
 		}
 		candidateRoutes = loraRoutes
 		isLora = true
@@ -800,44 +854,27 @@ func (s *store) MatchModelServer(model string, req *http.Request, gatewayKey str
 
 	// Try each ModelRoute until we find one that matches
 	for _, mr := range candidateRoutes {
-		// Check parentRefs if specified
-		if len(mr.Spec.ParentRefs) > 0 {
-			// If gatewayKey is provided (not empty), check if ModelRoute matches the specific gateway
-			if gatewayKey != "" {
-				if !s.matchesSpecificGateway(mr, gatewayKey) {
-					continue // Try next ModelRoute
-				}
-			} else {
-				// If ModelRoute has parentRefs but gatewayKey is empty, skip it
-				continue // Skip ModelRoute with parentRefs when gatewayKey is not specified
-			}
-		} else {
-			// If gatewayKey is specified, we only match ModelRoute with parentRefs
-			// ModelRoute without parentRefs should not match when gatewayKey is provided
-			if gatewayKey != "" {
-				continue // Skip ModelRoute without parentRefs when gatewayKey is specified
-			}
-			// If gatewayKey is empty, ModelRoute without parentRefs can match
-			// (ModelRoute without parentRefs attaches to all Gateways in the same namespace)
+		if !s.servedByGateway(mr, gatewayKey) {
+			continue // Try next ModelRoute
 		}
 
 		// Try to match rules
-		rule, err := s.selectRule(model, req, mr.Spec.Rules)
+		rule, err := SelectRule(model, req, body, mr.Spec.Rules)
 		if err != nil {
 			continue // Try next ModelRoute
 		}
 
-		dst, err := s.selectDestination(rule.TargetModels)
+		dst, err := SelectDestination(rule.TargetModels)
 		if err != nil {
 			continue // Try next ModelRoute
 		}
 
 		// Found a matching ModelRoute
-		return types.NamespacedName{Namespace: mr.Namespace, Name: dst.ModelServerName}, isLora, mr, nil
+		return types.NamespacedName{Namespace: mr.Namespace, Name: dst.ModelServerName}, isLora, mr, rule, nil
 	}
 
 	// No matching ModelRoute found
-	return types.NamespacedName{}, false, nil, fmt.Errorf("no matching ModelRoute found for model %s", model)
+	return types.NamespacedName{}, false, nil, nil, fmt.Errorf("no matching ModelRoute found for model %s", model)
 }
 
 // matchesSpecificGateway checks if the ModelRoute matches a specific gateway
@@ -881,7 +918,9 @@ func (s *store) matchesSpecificGateway(mr *aiv1alpha1.ModelRoute, gatewayKey str
 	return false
 }
 
-func (s *store) selectRule(modelName string, req *http.Request, rules []*aiv1alpha1.Rule) (*aiv1alpha1.Rule, error) {
+// SelectRule returns the first of the rules of a ModelRoute matching the request.
+// The conditions on the fields of the body are not checked if the body is nil, e.g. when listing the models.
+func SelectRule(modelName string, req *http.Request, body map[string]interface{}, rules []*aiv1alpha1.Rule) (*aiv1alpha1.Rule, error) {
 	for _, rule := range rules {
 		if rule.ModelMatch == nil {
 			return rule, nil
@@ -895,6 +934,10 @@ func (s *store) selectRule(modelName string, req *http.Request, rules []*aiv1alp
 			}
 		}
 
+		if rule.ModelMatch.Body != nil && body != nil && !matchBodyFields(rule.ModelMatch.Body.Fields, body) {
+			continue
+		}
+
 		headersMatched := true
 		for key, sm := range rule.ModelMatch.Headers {
 			reqValue := req.Header.Get(key)
@@ -938,7 +981,8 @@ func matchString(sm *aiv1alpha1.StringMatch, value string) bool {
 	}
 }
 
-func (s *store) selectDestination(targets []*aiv1alpha1.TargetModel) (*aiv1alpha1.TargetModel, error) {
+// SelectDestination picks one of the target models of a rule according to their weights
+func SelectDestination(targets []*aiv1alpha1.TargetModel) (*aiv1alpha1.TargetModel, error) {
 	weightedSlice, err := toWeightedSlice(targets)
 	if err != nil {
 		return nil, err
@@ -1410,6 +1454,56 @@ func (s *store) GetAllGateways() []*gatewayv1.Gateway {
 	return result
 }
 
+// Secret methods
+
+func (s *store) AddOrUpdateSecret(secret *corev1.Secret) error {
+	key := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+
+	s.secretMutex.Lock()
+	s.secrets[key] = secret
+	s.secretMutex.Unlock()
+
+	klog.V(4).Infof("Added or updated Secret: %s", key)
+
+	s.triggerCallbacks("Secret", EventData{
+		EventType: EventAdd,
+		Secret:    types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+	})
+
+	return nil
+}
+
+func (s *store) DeleteSecret(key string) error {
+	namespace, name, found := strings.Cut(key, "/")
+	if !found {
+		return fmt.Errorf("invalid secret key %q", key)
+	}
+
+	s.secretMutex.Lock()
+	_, exists := s.secrets[key]
+	delete(s.secrets, key)
+	s.secretMutex.Unlock()
+
+	if !exists {
+		return nil
+	}
+	klog.V(4).Infof("Deleted Secret: %s", key)
+
+	s.triggerCallbacks("Secret", EventData{
+		EventType: EventDelete,
+		Secret:    types.NamespacedName{Namespace: namespace, Name: name},
+	})
+
+	return nil
+}
+
+func (s *store) GetSecret(key string) *corev1.Secret {
+	s.secretMutex.RLock()
+	defer s.secretMutex.RUnlock()
+
+	return s.secrets[key]
+}
+
 // InferencePool methods (using Gateway API Inference Extension)
 
 func (s *store) AddOrUpdateInferencePool(inferencePool *inferencev1.InferencePool) error {
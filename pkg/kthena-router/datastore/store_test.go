@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -28,13 +29,16 @@ import (
 	"github.com/agiledragon/gomonkey/v2"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/backend"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
 	"istio.io/istio/pkg/util/sets"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 // ptr is a helper function to get pointer to a value
@@ -817,6 +821,40 @@ func createComplexModelRoute() *aiv1alpha1.ModelRoute {
 	}
 }
 
+func TestStoreMaxRequestBodySize(t *testing.T) {
+	newRoute := func(name, model, limit string, parentRefs ...gatewayv1.ParentReference) *aiv1alpha1.ModelRoute {
+		mr := &aiv1alpha1.ModelRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: aiv1alpha1.ModelRouteSpec{
+				ModelName:  model,
+				ParentRefs: parentRefs,
+				Rules:      []*aiv1alpha1.Rule{{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms"}}}},
+			},
+		}
+		if limit != "" {
+			size := resource.MustParse(limit)
+			mr.Spec.MaxRequestBodySize = &size
+		}
+		return mr
+	}
+
+	s := New()
+	_, ok := s.MaxRequestBodySize("")
+	assert.False(t, ok)
+
+	require.NoError(t, s.AddOrUpdateModelRoute(newRoute("small", "model-a", "1Ki")))
+	require.NoError(t, s.AddOrUpdateModelRoute(newRoute("large", "model-b", "1Mi")))
+	// The routes attached to a Gateway are not matched by the requests received without one
+	require.NoError(t, s.AddOrUpdateModelRoute(newRoute("gateway", "model-c", "", gatewayv1.ParentReference{Name: "gw"})))
+	size, ok := s.MaxRequestBodySize("")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1<<20), size)
+
+	require.NoError(t, s.AddOrUpdateModelRoute(newRoute("unlimited", "model-d", "")))
+	_, ok = s.MaxRequestBodySize("")
+	assert.False(t, ok)
+}
+
 func TestStoreMatchModelServer(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -1204,7 +1242,7 @@ func TestStoreMatchModelServer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := tt.setupStore()
-			server, isLora, _, err := s.MatchModelServer(tt.modelName, tt.request, "")
+			server, isLora, _, rule, err := s.MatchModelServer(tt.modelName, tt.request, nil, "")
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -1214,6 +1252,12 @@ func TestStoreMatchModelServer(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedIsLora, isLora)
 			assert.Equal(t, tt.expectedServer, server)
+			// The returned rule is the one the ModelServer is selected from
+			if assert.NotNil(t, rule) {
+				assert.True(t, slices.ContainsFunc(rule.TargetModels, func(target *aiv1alpha1.TargetModel) bool {
+					return target.ModelServerName == server.Name
+				}))
+			}
 		})
 	}
 }
@@ -87,13 +87,17 @@ func (m *MockStore) DeletePod(podName types.NamespacedName) error {
 	return args.Error(0)
 }
 
-func (m *MockStore) MatchModelServer(modelName string, request *http.Request, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, error) {
+func (m *MockStore) MatchModelServer(modelName string, request *http.Request, body map[string]interface{}, gatewayKey string) (types.NamespacedName, bool, *aiv1alpha1.ModelRoute, *aiv1alpha1.Rule, error) {
 	args := m.Called(modelName, request, gatewayKey)
 	var modelRoute *aiv1alpha1.ModelRoute
 	if args.Get(2) != nil {
 		modelRoute = args.Get(2).(*aiv1alpha1.ModelRoute)
 	}
-	return args.Get(0).(types.NamespacedName), args.Bool(1), modelRoute, args.Error(3)
+	var rule *aiv1alpha1.Rule
+	if args.Get(3) != nil {
+		rule = args.Get(3).(*aiv1alpha1.Rule)
+	}
+	return args.Get(0).(types.NamespacedName), args.Bool(1), modelRoute, rule, args.Error(4)
 }
 
 func (m *MockStore) AddOrUpdateModelRoute(mr *aiv1alpha1.ModelRoute) error {
@@ -207,6 +211,25 @@ func (m *MockStore) GetModelRoute(namespacedName string) *aiv1alpha1.ModelRoute
 	return args.Get(0).(*aiv1alpha1.ModelRoute)
 }
 
+// Secret methods
+func (m *MockStore) AddOrUpdateSecret(secret *corev1.Secret) error {
+	args := m.Called(secret)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteSecret(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetSecret(key string) *corev1.Secret {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*corev1.Secret)
+}
+
 // Gateway methods (using standard Gateway API)
 func (m *MockStore) AddOrUpdateGateway(gateway *gatewayv1.Gateway) error {
 	args := m.Called(gateway)
@@ -302,6 +325,11 @@ func (m *MockStore) GetModelRoutesByGateway(gatewayKey string) []*aiv1alpha1.Mod
 	return args.Get(0).([]*aiv1alpha1.ModelRoute)
 }
 
+func (m *MockStore) MaxRequestBodySize(gatewayKey string) (int64, bool) {
+	args := m.Called(gatewayKey)
+	return args.Get(0).(int64), args.Bool(1)
+}
+
 func (m *MockStore) GetAllHTTPRoutes() []*gatewayv1.HTTPRoute {
 	args := m.Called()
 	if args.Get(0) == nil {
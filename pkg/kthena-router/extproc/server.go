@@ -0,0 +1,320 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package extproc implements the Endpoint Picker (EPP) protocol of the Gateway API Inference Extension
+// as an Envoy external processing (ext_proc) gRPC server, so the kthena scheduler can pick the endpoints
+// of an InferencePool for Envoy based gateways.
+package extproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"istio.io/istio/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// DestinationEndpointKey is the header and metadata key carrying the selected endpoints
+	DestinationEndpointKey = "x-gateway-destination-endpoint"
+	// DestinationEndpointNamespace is the dynamic metadata namespace read by the Envoy load balancer
+	DestinationEndpointNamespace = "envoy.lb"
+	// SubsetHintNamespace is the filter metadata namespace the gateway uses to restrict candidate endpoints
+	SubsetHintNamespace = "envoy.lb.subset_hint"
+	// SubsetHintKey is the key holding the list of candidate endpoints in the subset hint namespace
+	SubsetHintKey = "x-gateway-destination-endpoint-subset"
+)
+
+// Server is an Envoy ext_proc server picking the endpoints of a single InferencePool.
+// Envoy must send the request body in BUFFERED mode, the selected endpoint is returned in the body response.
+type Server struct {
+	extprocv3.UnimplementedExternalProcessorServer
+
+	store     datastore.Store
+	scheduler scheduler.Scheduler
+	pool      types.NamespacedName
+}
+
+// NewServer creates an ext_proc server scheduling requests to the pods of the given InferencePool
+func NewServer(store datastore.Store, scheduler scheduler.Scheduler, pool types.NamespacedName) *Server {
+	return &Server{
+		store:     store,
+		scheduler: scheduler,
+		pool:      pool,
+	}
+}
+
+// Run serves the ext_proc gRPC service on the listener until the context is cancelled
+func (s *Server) Run(ctx context.Context, lis net.Listener) error {
+	grpcServer := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(grpcServer, s)
+
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	klog.Infof("Starting ext_proc endpoint picker for InferencePool %s on %s", s.pool, lis.Addr())
+	if err := grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Process handles one ext_proc stream, which corresponds to one HTTP request proxied by Envoy
+func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	var body []byte
+	var path string
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF || status.Code(err) == codes.Canceled {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Unknown, "failed to receive ext_proc request: %v", err)
+		}
+
+		var resp *extprocv3.ProcessingResponse
+		switch v := req.Request.(type) {
+		case *extprocv3.ProcessingRequest_RequestHeaders:
+			path = requestPath(v.RequestHeaders.GetHeaders())
+			if v.RequestHeaders.EndOfStream {
+				resp = immediateResponse(typev3.StatusCode_BadRequest, "request body is required")
+				break
+			}
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+			}
+		case *extprocv3.ProcessingRequest_RequestBody:
+			body = append(body, v.RequestBody.Body...)
+			if !v.RequestBody.EndOfStream {
+				// Wait for the complete body before scheduling
+				continue
+			}
+			resp = s.handleRequestBody(path, body, req.GetMetadataContext())
+		case *extprocv3.ProcessingRequest_RequestTrailers:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_RequestTrailers{RequestTrailers: &extprocv3.TrailersResponse{}},
+			}
+		case *extprocv3.ProcessingRequest_ResponseHeaders:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}},
+			}
+		case *extprocv3.ProcessingRequest_ResponseBody:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}},
+			}
+		case *extprocv3.ProcessingRequest_ResponseTrailers:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseTrailers{ResponseTrailers: &extprocv3.TrailersResponse{}},
+			}
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown ext_proc request type %T", v)
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return status.Errorf(codes.Unknown, "failed to send ext_proc response: %v", err)
+		}
+	}
+}
+
+// pickError is a scheduling failure reported to the client with an HTTP status code
+type pickError struct {
+	code    typev3.StatusCode
+	message string
+}
+
+func (e *pickError) Error() string {
+	return e.message
+}
+
+func (s *Server) handleRequestBody(path string, body []byte, metadata *corev3.Metadata) *extprocv3.ProcessingResponse {
+	endpoints, err := s.pickEndpoints(path, body, subsetHint(metadata))
+	if err != nil {
+		klog.Errorf("ext_proc failed to pick endpoint for InferencePool %s: %v", s.pool, err)
+		var pe *pickError
+		if errors.As(err, &pe) {
+			return immediateResponse(pe.code, pe.message)
+		}
+		return immediateResponse(typev3.StatusCode_ServiceUnavailable, err.Error())
+	}
+
+	klog.V(4).Infof("ext_proc picked endpoints %v for InferencePool %s", endpoints, s.pool)
+	metadataValue, err := structpb.NewStruct(map[string]interface{}{
+		DestinationEndpointNamespace: map[string]interface{}{
+			DestinationEndpointKey: strings.Join(endpoints, ","),
+		},
+	})
+	if err != nil {
+		return immediateResponse(typev3.StatusCode_InternalServerError, err.Error())
+	}
+
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: &extprocv3.HeaderMutation{
+						SetHeaders: []*corev3.HeaderValueOption{{
+							Header: &corev3.HeaderValue{
+								Key:      DestinationEndpointKey,
+								RawValue: []byte(endpoints[0]),
+							},
+						}},
+					},
+					ClearRouteCache: true,
+				},
+			},
+		},
+		DynamicMetadata: metadataValue,
+	}
+}
+
+// pickEndpoints schedules the request and returns the selected endpoints as "ip:port", ordered by preference.
+// If subset is not empty, only the pods whose address is in the subset are candidates.
+// The request input is parsed according to the API of the request path.
+func (s *Server) pickEndpoints(path string, body []byte, subset sets.Set[string]) ([]string, error) {
+	modelRequest, err := utils.DecodeRequestBody(body)
+	if err != nil {
+		return nil, &pickError{code: typev3.StatusCode_BadRequest, message: fmt.Sprintf("invalid request body: %v", err)}
+	}
+	model, ok := modelRequest["model"].(string)
+	if !ok {
+		return nil, &pickError{code: typev3.StatusCode_BadRequest, message: "model not found"}
+	}
+	input, err := utils.ParseRequestInput(path, modelRequest)
+	if err != nil {
+		return nil, &pickError{code: typev3.StatusCode_BadRequest, message: err.Error()}
+	}
+
+	inferencePool := s.store.GetInferencePool(s.pool.String())
+	if inferencePool == nil {
+		return nil, &pickError{code: typev3.StatusCode_NotFound, message: fmt.Sprintf("can't find inference pool: %v", s.pool)}
+	}
+	if len(inferencePool.Spec.TargetPorts) == 0 {
+		return nil, fmt.Errorf("inference pool %v has no target ports", s.pool)
+	}
+	// Use the first target port, as the router does
+	port := int32(inferencePool.Spec.TargetPorts[0].Number)
+
+	pods, err := s.store.GetPodsByInferencePool(s.pool)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*datastore.PodInfo, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Pod.Status.PodIP == "" {
+			continue
+		}
+		if subset.Len() > 0 && !subset.Contains(endpoint(pod.Pod.Status.PodIP, port)) && !subset.Contains(pod.Pod.Status.PodIP) {
+			continue
+		}
+		candidates = append(candidates, pod)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("can't find pods for inference pool: %v", s.pool)
+	}
+
+	ctx := &framework.Context{
+		Model:      model,
+		Prompt:     input.Prompt,
+		Endpoint:   input.Endpoint,
+		Modalities: input.Prompt.Modalities(),
+	}
+	if err := s.scheduler.Schedule(ctx, candidates); err != nil {
+		return nil, fmt.Errorf("can't schedule to target pod: %v", err)
+	}
+	if len(ctx.BestPods) == 0 {
+		return nil, fmt.Errorf("no pod selected for inference pool: %v", s.pool)
+	}
+
+	endpoints := make([]string, 0, len(ctx.BestPods))
+	for _, pod := range ctx.BestPods {
+		endpoints = append(endpoints, endpoint(pod.Pod.Status.PodIP, port))
+	}
+	// Envoy sends the request to the first endpoint unless it is unavailable, so record it in the prefix cache
+	s.scheduler.RunPostHooks(ctx, 0)
+	return endpoints, nil
+}
+
+// requestPath returns the path of the request, without query string, from its pseudo headers
+func requestPath(headers *corev3.HeaderMap) string {
+	for _, header := range headers.GetHeaders() {
+		if header.GetKey() != ":path" {
+			continue
+		}
+		path := header.GetValue()
+		if path == "" {
+			path = string(header.GetRawValue())
+		}
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		return path
+	}
+	return ""
+}
+
+// subsetHint returns the candidate endpoints the gateway restricted the request to, if any
+func subsetHint(metadata *corev3.Metadata) sets.Set[string] {
+	subset := sets.New[string]()
+	hint, ok := metadata.GetFilterMetadata()[SubsetHintNamespace]
+	if !ok {
+		return subset
+	}
+	value, ok := hint.GetFields()[SubsetHintKey]
+	if !ok {
+		return subset
+	}
+	for _, v := range value.GetListValue().GetValues() {
+		if s := v.GetStringValue(); s != "" {
+			subset.Insert(s)
+		}
+	}
+	return subset
+}
+
+func endpoint(ip string, port int32) string {
+	return net.JoinHostPort(ip, fmt.Sprintf("%d", port))
+}
+
+func immediateResponse(code typev3.StatusCode, message string) *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: code},
+				Body:    []byte(message),
+				Details: message,
+			},
+		},
+	}
+}
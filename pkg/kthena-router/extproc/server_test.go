@@ -0,0 +1,213 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extproc
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	inferencev1 "sigs.k8s.io/gateway-api-inference-extension/api/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+// fakeScheduler prefers pods by name in reverse order and records the post hooks
+type fakeScheduler struct {
+	models    []string
+	postHooks int
+}
+
+func (f *fakeScheduler) Schedule(ctx *framework.Context, pods []*datastore.PodInfo) error {
+	f.models = append(f.models, ctx.Model)
+	best := append([]*datastore.PodInfo{}, pods...)
+	sort.Slice(best, func(i, j int) bool { return best[i].Pod.Name > best[j].Pod.Name })
+	ctx.BestPods = best
+	return nil
+}
+
+func (f *fakeScheduler) RunPostHooks(ctx *framework.Context, index int) {
+	f.postHooks++
+}
+
+func newTestPod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": "llm"}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func startTestServer(t *testing.T, sched *fakeScheduler) extprocv3.ExternalProcessorClient {
+	store := datastore.New()
+	require.NoError(t, store.AddOrUpdateInferencePool(&inferencev1.InferencePool{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pool"},
+		Spec: inferencev1.InferencePoolSpec{
+			Selector:    inferencev1.LabelSelector{MatchLabels: map[inferencev1.LabelKey]inferencev1.LabelValue{"app": "llm"}},
+			TargetPorts: []inferencev1.Port{{Number: 8000}},
+		},
+	}))
+	require.NoError(t, store.AddOrUpdatePod(newTestPod("pod-a", "10.0.0.1"), nil))
+	require.NoError(t, store.AddOrUpdatePod(newTestPod("pod-b", "10.0.0.2"), nil))
+	require.NoError(t, store.AddOrUpdatePod(newTestPod("pod-pending", ""), nil))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	server := NewServer(store, sched, types.NamespacedName{Namespace: "default", Name: "pool"})
+	go func() {
+		_ = server.Run(ctx, lis)
+	}()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return extprocv3.NewExternalProcessorClient(conn)
+}
+
+// process sends the request headers and the body in chunks, and returns the response to the last chunk
+func process(t *testing.T, client extprocv3.ExternalProcessorClient, metadata *corev3.Metadata, chunks ...string) *extprocv3.ProcessingResponse {
+	stream, err := client.Process(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = stream.CloseSend() }()
+
+	require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{}},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetRequestHeaders())
+
+	for i, chunk := range chunks {
+		require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+				Body:        []byte(chunk),
+				EndOfStream: i == len(chunks)-1,
+			}},
+			MetadataContext: metadata,
+		}))
+	}
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	return resp
+}
+
+func TestProcessPicksEndpoint(t *testing.T) {
+	sched := &fakeScheduler{}
+	client := startTestServer(t, sched)
+
+	resp := process(t, client, nil, `{"model": "llama", `, `"prompt": "hello"}`)
+	body := resp.GetRequestBody()
+	require.NotNil(t, body, "unexpected response %v", resp)
+
+	headers := body.GetResponse().GetHeaderMutation().GetSetHeaders()
+	require.Len(t, headers, 1)
+	assert.Equal(t, DestinationEndpointKey, headers[0].GetHeader().GetKey())
+	assert.Equal(t, "10.0.0.2:8000", string(headers[0].GetHeader().GetRawValue()))
+	assert.True(t, body.GetResponse().GetClearRouteCache())
+
+	lb := resp.GetDynamicMetadata().GetFields()[DestinationEndpointNamespace].GetStructValue()
+	require.NotNil(t, lb)
+	assert.Equal(t, "10.0.0.2:8000,10.0.0.1:8000", lb.GetFields()[DestinationEndpointKey].GetStringValue())
+
+	assert.Equal(t, []string{"llama"}, sched.models)
+	assert.Equal(t, 1, sched.postHooks)
+}
+
+func TestProcessSubsetHint(t *testing.T) {
+	client := startTestServer(t, &fakeScheduler{})
+
+	hint, err := structpb.NewStruct(map[string]interface{}{
+		SubsetHintKey: []interface{}{"10.0.0.1:8000"},
+	})
+	require.NoError(t, err)
+	metadata := &corev3.Metadata{FilterMetadata: map[string]*structpb.Struct{SubsetHintNamespace: hint}}
+
+	resp := process(t, client, metadata, `{"model": "llama", "messages": [{"role": "user", "content": "hi"}]}`)
+	headers := resp.GetRequestBody().GetResponse().GetHeaderMutation().GetSetHeaders()
+	require.Len(t, headers, 1)
+	assert.Equal(t, "10.0.0.1:8000", string(headers[0].GetHeader().GetRawValue()))
+}
+
+func TestProcessErrors(t *testing.T) {
+	client := startTestServer(t, &fakeScheduler{})
+
+	tests := []struct {
+		name     string
+		body     string
+		metadata *corev3.Metadata
+		wantCode typev3.StatusCode
+	}{
+		{name: "invalid json", body: `{"model":`, wantCode: typev3.StatusCode_BadRequest},
+		{name: "missing model", body: `{"prompt": "hello"}`, wantCode: typev3.StatusCode_BadRequest},
+		{name: "missing prompt", body: `{"model": "llama"}`, wantCode: typev3.StatusCode_BadRequest},
+		{
+			name: "no endpoint in subset",
+			body: `{"model": "llama", "prompt": "hello"}`,
+			metadata: &corev3.Metadata{FilterMetadata: map[string]*structpb.Struct{SubsetHintNamespace: {
+				Fields: map[string]*structpb.Value{SubsetHintKey: structpb.NewListValue(&structpb.ListValue{
+					Values: []*structpb.Value{structpb.NewStringValue("10.9.9.9:8000")},
+				})},
+			}}},
+			wantCode: typev3.StatusCode_ServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := process(t, client, tt.metadata, tt.body)
+			immediate := resp.GetImmediateResponse()
+			require.NotNil(t, immediate, "unexpected response %v", resp)
+			assert.Equal(t, tt.wantCode, immediate.GetStatus().GetCode())
+		})
+	}
+}
+
+func TestProcessPassesThroughResponse(t *testing.T) {
+	client := startTestServer(t, &fakeScheduler{})
+
+	stream, err := client.Process(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = stream.CloseSend() }()
+
+	require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{}},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.NotNil(t, resp.GetResponseHeaders())
+
+	require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{EndOfStream: true}},
+	}))
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.NotNil(t, resp.GetResponseBody())
+}
@@ -106,6 +106,12 @@ func (r *TokenRateLimiter) RateLimit(model, prompt string) error {
 		tokens = len(prompt) / 4 // fallback estimation
 	}
 
+	return r.RateLimitTokens(model, tokens, true)
+}
+
+// RateLimitTokens checks if a request with the given number of input tokens is within rate limits.
+// The output token limit is only checked for requests generating output tokens.
+func (r *TokenRateLimiter) RateLimitTokens(model string, tokens int, generative bool) error {
 	r.mutex.RLock()
 	inputLimiter, hasInputLimit := r.inputLimiter[model]
 	outputLimiter, hasOutputLimit := r.outputLimiter[model]
@@ -118,7 +124,7 @@ func (r *TokenRateLimiter) RateLimit(model, prompt string) error {
 
 	// Check output token rate limit - we conservatively check if there's at least 1 token available
 	// This prevents starting requests that likely won't be able to complete
-	if hasOutputLimit && outputLimiter.Tokens() < 1.0 {
+	if generative && hasOutputLimit && outputLimiter.Tokens() < 1.0 {
 		return &OutputRateLimitExceededError{}
 	}
 
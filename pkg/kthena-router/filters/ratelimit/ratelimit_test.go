@@ -276,3 +276,32 @@ func TestTokenRateLimiter_InputAndOutputErrors(t *testing.T) {
 		t.Fatalf("expected OutputRateLimitExceededError, got %T: %v", err, err)
 	}
 }
+
+func TestTokenRateLimiter_RateLimitTokens(t *testing.T) {
+	rl := NewTokenRateLimiter()
+	model := "test-model"
+	inputTokens := uint32(10)
+	outputTokens := uint32(1)
+
+	rl.AddOrUpdateLimiter(model, &networkingv1alpha1.RateLimit{
+		InputTokensPerUnit:  &inputTokens,
+		OutputTokensPerUnit: &outputTokens,
+		Unit:                networkingv1alpha1.Minute,
+	})
+
+	if err := rl.RateLimitTokens(model, 4, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Exhaust the output tokens
+	rl.RecordOutputTokens(model, 1)
+	if _, ok := rl.RateLimitTokens(model, 1, true).(*OutputRateLimitExceededError); !ok {
+		t.Fatalf("expected OutputRateLimitExceededError for a generative request")
+	}
+	// Pooling requests do not generate output tokens, so only the input limit applies
+	if err := rl.RateLimitTokens(model, 5, false); err != nil {
+		t.Fatalf("unexpected error for a pooling request: %v", err)
+	}
+	if _, ok := rl.RateLimitTokens(model, 1, false).(*InputRateLimitExceededError); !ok {
+		t.Fatalf("expected InputRateLimitExceededError")
+	}
+}
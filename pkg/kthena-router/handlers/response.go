@@ -18,7 +18,12 @@ limitations under the License.
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"hash"
+	"slices"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -28,6 +33,10 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// InputTokens and OutputTokens are reported by the Responses API instead of prompt and completion tokens
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
 }
 
 // Define a struct to represent the OpenAI response body
@@ -37,6 +46,31 @@ type OpenAIResponse struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 	Usage   Usage  `json:"usage"`
+
+	// Choices are kept undecoded, only their presence is checked to tell content chunks from usage chunks
+	Choices []json.RawMessage `json:"choices,omitempty"`
+
+	// Type and Response are set by Responses API streaming events, e.g. response.completed
+	// events carry the final response with its usage.
+	Type     string          `json:"type,omitempty"`
+	Response *OpenAIResponse `json:"response,omitempty"`
+}
+
+// normalize lifts the usage of a Responses API streaming event, and maps the Responses API usage to
+// prompt and completion tokens, so that callers only need to look at one set of fields.
+func (r *OpenAIResponse) normalize() {
+	if r.Response != nil {
+		r.Response.normalize()
+		if r.Usage.TotalTokens == 0 {
+			r.Usage = r.Response.Usage
+		}
+	}
+	if r.Usage.PromptTokens == 0 {
+		r.Usage.PromptTokens = r.Usage.InputTokens
+	}
+	if r.Usage.CompletionTokens == 0 {
+		r.Usage.CompletionTokens = r.Usage.OutputTokens
+	}
 }
 
 // Function to parse the OpenAI response body
@@ -47,6 +81,7 @@ func ParseOpenAIResponseBody(resp []byte) (*OpenAIResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	responseBody.normalize()
 
 	return &responseBody, nil
 }
@@ -67,6 +102,10 @@ const (
 //
 // If include_usage is not included in the request, `data: [DONE]` is returned separately, which
 // indicates end of streaming.
+//
+// Responses API streams always report the usage in the final event:
+// event: response.completed
+// data: {"type":"response.completed","response":{"id":"...","usage":{"input_tokens":7,"output_tokens":10,"total_tokens":17}}}
 func ParseStreamRespForUsage(
 	responseText string,
 ) OpenAIResponse {
@@ -81,6 +120,69 @@ func ParseStreamRespForUsage(
 		klog.Error(err, "unmarshaling response body ", content)
 		return response
 	}
+	response.normalize()
 
 	return response
 }
+
+// ContentDigest hashes the content generated for each choice of a response, so that the responses of two
+// model servers to the same request can be compared without keeping them. The chunks of a stream and the
+// body of a response with the same content have the same digest.
+type ContentDigest struct {
+	choices map[int]hash.Hash
+}
+
+// choiceContent is the generated text of a choice of a completion, a chat completion or a chunk of their streams
+type choiceContent struct {
+	Index   int    `json:"index"`
+	Text    string `json:"text"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Delta struct {
+		Content string `json:"content"`
+	} `json:"delta"`
+}
+
+// NewContentDigest creates the digest of a response without content
+func NewContentDigest() *ContentDigest {
+	return &ContentDigest{choices: make(map[int]hash.Hash)}
+}
+
+// Add adds the content of the choices of a response, or of a chunk of a stream
+func (d *ContentDigest) Add(choices []json.RawMessage) {
+	for _, raw := range choices {
+		var choice choiceContent
+		if err := json.Unmarshal(raw, &choice); err != nil {
+			// e.g. structured content, which is not compared
+			continue
+		}
+		h, ok := d.choices[choice.Index]
+		if !ok {
+			h = sha256.New()
+			d.choices[choice.Index] = h
+		}
+		h.Write([]byte(choice.Text))
+		h.Write([]byte(choice.Message.Content))
+		h.Write([]byte(choice.Delta.Content))
+	}
+}
+
+// Sum returns the hex encoded digest of the content added so far, or an empty string if there is none
+func (d *ContentDigest) Sum() string {
+	if len(d.choices) == 0 {
+		return ""
+	}
+	indexes := make([]int, 0, len(d.choices))
+	for index := range d.choices {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	h := sha256.New()
+	for _, index := range indexes {
+		_ = binary.Write(h, binary.BigEndian, int64(index))
+		h.Write(d.choices[index].Sum(nil))
+	}
+	// A shorter digest is enough to compare two responses
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
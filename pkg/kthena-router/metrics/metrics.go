@@ -17,6 +17,7 @@ limitations under the License.
 package metrics
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -36,6 +37,16 @@ const (
 	LabelModelRoute  = "model_route"
 	LabelModelServer = "model_server"
 	LabelUserID      = "user_id"
+	LabelReused      = "reused"
+	LabelPod         = "pod"
+	LabelSLO         = "slo"
+	LabelAttained    = "attained"
+	LabelClassifier  = "classifier"
+	LabelCategory    = "category"
+	LabelResult      = "result"
+	LabelGuardrail   = "guardrail"
+	LabelPhase       = "phase"
+	LabelAction      = "action"
 
 	// Token type values
 	TokenTypeInput  = "input"
@@ -49,6 +60,15 @@ const (
 	LimitTypeInputTokens  = "input_tokens"
 	LimitTypeOutputTokens = "output_tokens"
 	LimitTypeRequests     = "requests"
+
+	// Result values of the requests of a ModelServer
+	ResultSuccess = "success"
+	ResultError   = "error"
+
+	// SLO values
+	SLOTimeToFirstToken   = "ttft"
+	SLOTimePerOutputToken = "tpot"
+	SLOEndToEnd           = "e2e"
 )
 
 // Metrics holds all Prometheus metrics for the kthena-router
@@ -75,6 +95,38 @@ type Metrics struct {
 	ActiveUpstreamRequests   prometheus.GaugeVec
 	FairnessQueueSize        prometheus.GaugeVec
 	FairnessQueueDuration    prometheus.HistogramVec
+
+	// Requests abandoned by the client before the response was complete
+	ClientCanceledRequests prometheus.CounterVec
+
+	// Latency observed by the router, per upstream pod
+	TimeToFirstToken   prometheus.HistogramVec
+	TimePerOutputToken prometheus.HistogramVec
+	E2ERequestLatency  prometheus.HistogramVec
+
+	// Requests meeting or missing the latency objectives of their route
+	SLORequests prometheus.CounterVec
+
+	// Prompt classification of the requests of the ModelRoute rules with a classifier
+	PromptClassifications        prometheus.CounterVec
+	PromptClassificationDuration prometheus.HistogramVec
+	PromptClassifierErrors       prometheus.CounterVec
+
+	// Lookups of the response cache of the ModelRoutes caching their responses
+	ResponseCacheRequests prometheus.CounterVec
+	ResponseCacheErrors   prometheus.CounterVec
+
+	// Outcome of the requests served by the ModelServers of the ModelRoutes, observed by rollouts
+	ModelServerRequests prometheus.CounterVec
+
+	// Checks of the guardrails of the ModelRoutes
+	GuardrailChecks        prometheus.CounterVec
+	GuardrailCheckDuration prometheus.HistogramVec
+
+	// Connection pool to the inference engines
+	UpstreamOpenConnections prometheus.Gauge
+	UpstreamConnections     prometheus.CounterVec
+	UpstreamDialErrors      prometheus.Counter
 }
 
 // NewMetrics creates a new Metrics instance with all Prometheus metrics registered
@@ -172,6 +224,137 @@ func NewMetrics() *Metrics {
 			},
 			[]string{LabelModel, LabelUserID},
 		),
+
+		ClientCanceledRequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_client_canceled_requests_total",
+				Help: "Number of requests canceled by the client before the response was complete",
+			},
+			[]string{LabelModel, LabelPath},
+		),
+
+		TimeToFirstToken: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_time_to_first_token_seconds",
+				Help:    "Time from the arrival of a streaming request at the router to its first token",
+				Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10, 20, 40, 80},
+			},
+			[]string{LabelModel, LabelModelServer, LabelModelRoute, LabelPod},
+		),
+
+		TimePerOutputToken: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_time_per_output_token_seconds",
+				Help:    "Average time between the output tokens of a streaming request, after the first one",
+				Buckets: []float64{0.005, 0.01, 0.015, 0.02, 0.025, 0.03, 0.04, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.5, 1},
+			},
+			[]string{LabelModel, LabelModelServer, LabelModelRoute, LabelPod},
+		),
+
+		E2ERequestLatency: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_e2e_request_latency_seconds",
+				Help:    "Time from the arrival of a request at the router to the end of its response",
+				Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 15, 20, 30, 40, 60, 120, 240},
+			},
+			[]string{LabelModel, LabelModelServer, LabelModelRoute, LabelPod},
+		),
+
+		SLORequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_slo_requests_total",
+				Help: "Number of requests checked against a latency objective of their ModelRoute, by whether it was attained",
+			},
+			[]string{LabelModel, LabelModelRoute, LabelSLO, LabelAttained},
+		),
+
+		PromptClassifications: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_prompt_classifications_total",
+				Help: "Number of requests classified by the prompt classifier of their ModelRoute rule, by category",
+			},
+			[]string{LabelModel, LabelModelRoute, LabelClassifier, LabelCategory},
+		),
+
+		PromptClassificationDuration: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_prompt_classification_duration_seconds",
+				Help:    "Time spent classifying the prompt of a request",
+				Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+			},
+			[]string{LabelModelRoute, LabelClassifier},
+		),
+
+		PromptClassifierErrors: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_prompt_classifier_errors_total",
+				Help: "Number of requests the prompt classifier failed to classify, which were assigned the default category",
+			},
+			[]string{LabelModelRoute, LabelClassifier},
+		),
+
+		ResponseCacheRequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_response_cache_requests_total",
+				Help: "Number of cacheable requests, by result of the response cache lookup (hit, miss or bypass)",
+			},
+			[]string{LabelModel, LabelModelRoute, LabelResult},
+		),
+
+		ResponseCacheErrors: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_response_cache_errors_total",
+				Help: "Number of responses which could not be read from or written to the response cache",
+			},
+			[]string{LabelModelRoute},
+		),
+
+		ModelServerRequests: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_model_server_requests_total",
+				Help: "Number of requests served by a ModelServer of a ModelRoute, by result (success or error)",
+			},
+			[]string{LabelModelRoute, LabelModelServer, LabelResult},
+		),
+
+		GuardrailChecks: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_guardrail_checks_total",
+				Help: "Number of checks of the guardrails of the ModelRoutes, by phase and action (allow, annotate, redact, block or error)",
+			},
+			[]string{LabelModel, LabelModelRoute, LabelGuardrail, LabelPhase, LabelAction},
+		),
+
+		GuardrailCheckDuration: *promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kthena_router_guardrail_check_duration_seconds",
+				Help:    "Time spent waiting for the verdict of a guardrail",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+			},
+			[]string{LabelModelRoute, LabelGuardrail, LabelPhase},
+		),
+
+		UpstreamOpenConnections: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "kthena_router_upstream_open_connections",
+				Help: "Number of connections currently open to the inference engines",
+			},
+		),
+
+		UpstreamConnections: *promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kthena_router_upstream_connections_total",
+				Help: "Number of connections obtained for upstream requests, reused from the pool or newly opened",
+			},
+			[]string{LabelReused},
+		),
+
+		UpstreamDialErrors: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Name: "kthena_router_upstream_dial_errors_total",
+				Help: "Number of connections to the inference engines that could not be opened",
+			},
+		),
 	}
 }
 
@@ -191,6 +374,74 @@ func (m *Metrics) RecordDecodeDuration(model, path, statusCode string, duration
 	m.RequestDecodeDuration.WithLabelValues(model, path, statusCode).Observe(duration.Seconds())
 }
 
+// RecordClientCanceled records a request canceled by the client
+func (m *Metrics) RecordClientCanceled(model, path string) {
+	m.ClientCanceledRequests.WithLabelValues(model, path).Inc()
+}
+
+// RecordResponseLatency records the latency of a response served by a pod. ttft and tpot are zero if unknown.
+func (m *Metrics) RecordResponseLatency(model, modelServer, modelRoute, pod string, ttft, tpot, e2e time.Duration) {
+	if ttft > 0 {
+		m.TimeToFirstToken.WithLabelValues(model, modelServer, modelRoute, pod).Observe(ttft.Seconds())
+	}
+	if tpot > 0 {
+		m.TimePerOutputToken.WithLabelValues(model, modelServer, modelRoute, pod).Observe(tpot.Seconds())
+	}
+	m.E2ERequestLatency.WithLabelValues(model, modelServer, modelRoute, pod).Observe(e2e.Seconds())
+}
+
+// DeletePod removes the latency series of a pod, once it is deleted
+func (m *Metrics) DeletePod(pod string) {
+	labels := prometheus.Labels{LabelPod: pod}
+	m.TimeToFirstToken.DeletePartialMatch(labels)
+	m.TimePerOutputToken.DeletePartialMatch(labels)
+	m.E2ERequestLatency.DeletePartialMatch(labels)
+}
+
+// RecordSLO records whether a request attained a latency objective of its route
+func (m *Metrics) RecordSLO(model, modelRoute, slo string, attained bool) {
+	m.SLORequests.WithLabelValues(model, modelRoute, slo, strconv.FormatBool(attained)).Inc()
+}
+
+// RecordPromptClassification records the category assigned to the prompt of a request, and the time spent classifying it
+func (m *Metrics) RecordPromptClassification(model, modelRoute, classifier, category string, failed bool, duration time.Duration) {
+	m.PromptClassifications.WithLabelValues(model, modelRoute, classifier, category).Inc()
+	m.PromptClassificationDuration.WithLabelValues(modelRoute, classifier).Observe(duration.Seconds())
+	if failed {
+		m.PromptClassifierErrors.WithLabelValues(modelRoute, classifier).Inc()
+	}
+}
+
+// RecordResponseCache records the result of the response cache lookup of a request
+func (m *Metrics) RecordResponseCache(model, modelRoute, result string) {
+	m.ResponseCacheRequests.WithLabelValues(model, modelRoute, result).Inc()
+}
+
+// RecordResponseCacheError records a failure to read or write a cached response
+func (m *Metrics) RecordResponseCacheError(modelRoute string) {
+	m.ResponseCacheErrors.WithLabelValues(modelRoute).Inc()
+}
+
+// RecordModelServerRequest records whether a ModelServer of a ModelRoute served a request or failed it
+func (m *Metrics) RecordModelServerRequest(modelRoute, modelServer string, failed bool) {
+	result := ResultSuccess
+	if failed {
+		result = ResultError
+	}
+	m.ModelServerRequests.WithLabelValues(modelRoute, modelServer, result).Inc()
+}
+
+// RecordGuardrailCheck records the action of a guardrail check, error if the guard service failed, and the time spent on it
+func (m *Metrics) RecordGuardrailCheck(model, modelRoute, guardrail, phase, action string, duration time.Duration) {
+	m.GuardrailChecks.WithLabelValues(model, modelRoute, guardrail, phase, action).Inc()
+	m.GuardrailCheckDuration.WithLabelValues(modelRoute, guardrail, phase).Observe(duration.Seconds())
+}
+
+// RecordUpstreamConnection records a connection obtained for an upstream request
+func (m *Metrics) RecordUpstreamConnection(reused bool) {
+	m.UpstreamConnections.WithLabelValues(strconv.FormatBool(reused)).Inc()
+}
+
 // RecordTokens records input and output token counts
 func (m *Metrics) RecordTokens(model, path string, inputTokens, outputTokens int) {
 	if inputTokens > 0 {
@@ -268,11 +519,20 @@ type RequestMetricsRecorder struct {
 	path             string
 	modelServer      string
 	modelRoute       string
+	pod              string
+	slo              LatencySLO
 	startTime        time.Time
 	prefillStartTime *time.Time
 	decodeStartTime  *time.Time
 }
 
+// LatencySLO are the latency thresholds of a request, a zero value means no threshold
+type LatencySLO struct {
+	TimeToFirstToken   time.Duration
+	TimePerOutputToken time.Duration
+	EndToEnd           time.Duration
+}
+
 // NewRequestMetricsRecorder creates a new recorder for a specific request
 func NewRequestMetricsRecorder(metrics *Metrics, model, path string) *RequestMetricsRecorder {
 	return &RequestMetricsRecorder{
@@ -289,6 +549,32 @@ func (r *RequestMetricsRecorder) SetUpstreamConnectionInfo(modelServer, modelRou
 	r.modelRoute = modelRoute
 }
 
+// SetPod sets the pod serving this request, the decode pod in PD disaggregated mode
+func (r *RequestMetricsRecorder) SetPod(pod string) {
+	r.pod = pod
+}
+
+// SetSLO sets the latency objectives of the route of this request
+func (r *RequestMetricsRecorder) SetSLO(slo LatencySLO) {
+	r.slo = slo
+}
+
+// RecordResponseLatency records the latency of the response, and checks it against the objectives of the route.
+// ttft and tpot are zero for non streaming responses, they are not checked then.
+func (r *RequestMetricsRecorder) RecordResponseLatency(ttft, tpot, e2e time.Duration) {
+	r.metrics.RecordResponseLatency(r.model, r.modelServer, r.modelRoute, r.pod, ttft, tpot, e2e)
+
+	if r.slo.TimeToFirstToken > 0 && ttft > 0 {
+		r.metrics.RecordSLO(r.model, r.modelRoute, SLOTimeToFirstToken, ttft <= r.slo.TimeToFirstToken)
+	}
+	if r.slo.TimePerOutputToken > 0 && tpot > 0 {
+		r.metrics.RecordSLO(r.model, r.modelRoute, SLOTimePerOutputToken, tpot <= r.slo.TimePerOutputToken)
+	}
+	if r.slo.EndToEnd > 0 {
+		r.metrics.RecordSLO(r.model, r.modelRoute, SLOEndToEnd, e2e <= r.slo.EndToEnd)
+	}
+}
+
 // RecordInputTokens records input token usage for this request
 func (r *RequestMetricsRecorder) RecordInputTokens(tokens int) {
 	if tokens > 0 {
@@ -342,6 +628,11 @@ func (r *RequestMetricsRecorder) Finish(statusCode, errorType string) {
 	r.metrics.RecordRequest(r.model, r.path, statusCode, errorType, duration)
 }
 
+// RecordClientCanceled records that the client canceled this request
+func (r *RequestMetricsRecorder) RecordClientCanceled() {
+	r.metrics.RecordClientCanceled(r.model, r.path)
+}
+
 // RecordSchedulerPluginDuration records the execution time for a scheduler plugin
 func (r *RequestMetricsRecorder) RecordSchedulerPluginDuration(pluginName, pluginType string, duration time.Duration) {
 	r.metrics.RecordSchedulerPluginDuration(r.model, pluginName, pluginType, duration)
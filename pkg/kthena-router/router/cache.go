@@ -0,0 +1,385 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// CacheStatusHeader is the response header telling whether the response of a cacheable request
+	// was served from the response cache of its ModelRoute: hit, miss or bypass
+	CacheStatusHeader = "X-Kthena-Cache"
+
+	cacheHit    = "hit"
+	cacheMiss   = "miss"
+	cacheBypass = "bypass"
+
+	// defaultCacheTTL is how long a response is cached if the ModelRoute does not set a TTL
+	defaultCacheTTL = 5 * time.Minute
+	// redisCacheTimeout bounds the time a request waits for Redis
+	redisCacheTimeout = 200 * time.Millisecond
+	// redisCacheKeyPrefix is the prefix of the keys of the cached responses in Redis
+	redisCacheKeyPrefix = "kthena:response-cache:"
+)
+
+// cachedResponse is a successful response stored in the cache, as written to the client
+type cachedResponse struct {
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// responseStore holds the cached responses
+type responseStore interface {
+	// Get returns the response cached for a key, nil if there is none
+	Get(ctx context.Context, key string) (*cachedResponse, error)
+	// Set caches a response for a key until the TTL expires
+	Set(ctx context.Context, key string, response *cachedResponse, ttl time.Duration) error
+}
+
+// memoryEntry is a response cached in memory
+type memoryEntry struct {
+	response *cachedResponse
+	expires  time.Time
+}
+
+// memoryStore caches the responses in the memory of the router, the least recently used response
+// is evicted once the store is full
+type memoryStore struct {
+	entries *lru.Cache[string, memoryEntry]
+}
+
+func newMemoryStore(maxEntries int) (*memoryStore, error) {
+	entries, err := lru.New[string, memoryEntry](maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryStore{entries: entries}, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (*cachedResponse, error) {
+	entry, ok := s.entries.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(entry.expires) {
+		s.entries.Remove(key)
+		return nil, nil
+	}
+	return entry.response, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, response *cachedResponse, ttl time.Duration) error {
+	s.entries.Add(key, memoryEntry{response: response, expires: time.Now().Add(ttl)})
+	return nil
+}
+
+// redisStore caches the responses in Redis, shared by the router replicas
+type redisStore struct {
+	client *redis.Client
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (*cachedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+	data, err := s.client.Get(ctx, redisCacheKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var response cachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid cached response: %v", err)
+	}
+	return &response, nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, response *cachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisCacheTimeout)
+	defer cancel()
+	return s.client.Set(ctx, redisCacheKeyPrefix+key, data, ttl).Err()
+}
+
+// responseCache caches the responses of the ModelRoutes with a cache, in memory or in the Redis of the ModelRoute
+type responseCache struct {
+	memory          *memoryStore
+	maxResponseSize int64
+
+	mutex sync.Mutex
+	// redisStores are the Redis stores by address
+	redisStores map[string]*redisStore
+}
+
+func newResponseCache(config conf.ResponseCacheConfiguration) (*responseCache, error) {
+	maxResponseSize, err := config.MaxResponseBytes()
+	if err != nil {
+		return nil, err
+	}
+	memory, err := newMemoryStore(config.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return &responseCache{
+		memory:          memory,
+		maxResponseSize: maxResponseSize,
+		redisStores:     make(map[string]*redisStore),
+	}, nil
+}
+
+// store returns the store of the responses of a ModelRoute
+func (rc *responseCache) store(cache *v1alpha1.ResponseCache) responseStore {
+	if cache.Redis == nil {
+		return rc.memory
+	}
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	store, ok := rc.redisStores[cache.Redis.Address]
+	if !ok {
+		store = &redisStore{client: redis.NewClient(&redis.Options{Addr: cache.Redis.Address})}
+		rc.redisStores[cache.Redis.Address] = store
+	}
+	return store
+}
+
+// responseCapture copies the response written to the client, unless it is larger than limit
+type responseCapture struct {
+	gin.ResponseWriter
+
+	body     bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+var _ gin.ResponseWriter = &responseCapture{}
+
+func (w *responseCapture) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCapture) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseCapture) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if int64(w.body.Len()+len(data)) > w.limit {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// serveFromCache answers a cacheable request of a ModelRoute with a cache from its cached response.
+// On a miss, it returns a function caching the response once the request is served, to be called
+// after the response is complete.
+func (r *Router) serveFromCache(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelRoute *v1alpha1.ModelRoute,
+	modelServerName types.NamespacedName,
+) (bool, func()) {
+	cache := modelRoute.Spec.Cache
+	if !isCacheable(c.Request.URL.Path, modelRequest) {
+		return false, nil
+	}
+	modelName := modelRequest["model"].(string)
+	routeName := fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name)
+
+	noCache, noStore := cacheControl(c.Request.Header)
+	if noStore {
+		r.metrics.RecordResponseCache(modelName, routeName, cacheBypass)
+		c.Header(CacheStatusHeader, cacheBypass)
+		return false, nil
+	}
+	key, err := responseCacheKey(c.Request.URL.Path, modelRequest, modelServerName)
+	if err != nil {
+		klog.Errorf("failed to compute the cache key of request %s: %v", c.Request.Header.Get("x-request-id"), err)
+		return false, nil
+	}
+	store := r.responseCache.store(cache)
+
+	if noCache {
+		r.metrics.RecordResponseCache(modelName, routeName, cacheBypass)
+		c.Header(CacheStatusHeader, cacheBypass)
+	} else {
+		response, err := store.Get(c.Request.Context(), key)
+		if err != nil {
+			klog.Errorf("failed to read the response cache of model route %s: %v", routeName, err)
+			r.metrics.RecordResponseCacheError(routeName)
+		}
+		if response != nil {
+			r.metrics.RecordResponseCache(modelName, routeName, cacheHit)
+			accesslog.SetRequestRouting(c, routeName, modelServerName.String(), "")
+			c.Header(CacheStatusHeader, cacheHit)
+			writeCachedResponse(c, response)
+			return true, nil
+		}
+		r.metrics.RecordResponseCache(modelName, routeName, cacheMiss)
+		c.Header(CacheStatusHeader, cacheMiss)
+	}
+
+	ttl := defaultCacheTTL
+	if cache.TTL != nil {
+		ttl = cache.TTL.Duration
+	}
+	capture := &responseCapture{ResponseWriter: c.Writer, limit: r.responseCache.maxResponseSize}
+	c.Writer = capture
+	return false, func() {
+		c.Writer = capture.ResponseWriter
+		response := completeResponse(c, capture)
+		if response == nil {
+			return
+		}
+		// The response is complete, it is cached even if the client has gone away meanwhile
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := store.Set(ctx, key, response, ttl); err != nil {
+			klog.Errorf("failed to cache the response of model route %s: %v", routeName, err)
+			r.metrics.RecordResponseCacheError(routeName)
+		}
+	}
+}
+
+// isCacheable returns true for the deterministic completion requests, with a temperature of 0
+func isCacheable(path string, modelRequest ModelRequest) bool {
+	switch utils.GetEndpoint(path) {
+	case common.EndpointCompletions, common.EndpointChatCompletions:
+	default:
+		return false
+	}
+	temperature, err := decodedValue(modelRequest, "temperature")
+	if err != nil {
+		return false
+	}
+	number, ok := temperature.(float64)
+	return ok && number == 0
+}
+
+// cacheControl parses the Cache-Control header of a request. A request with no-cache is not answered
+// from the cache, but its response is cached. A request with no-store bypasses the cache entirely.
+func cacheControl(header http.Header) (noCache, noStore bool) {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-cache":
+				noCache = true
+			case "no-store":
+				noStore = true
+			}
+		}
+	}
+	return noCache, noStore
+}
+
+// responseCacheKey returns the key of the response of a request served by a ModelServer.
+// The body is normalized, so that requests differing only by the order of their fields or by
+// their white space have the same key.
+func responseCacheKey(path string, modelRequest ModelRequest, modelServerName types.NamespacedName) (string, error) {
+	body, err := json.Marshal(modelRequest)
+	if err != nil {
+		return "", err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(body, &normalized); err != nil {
+		return "", err
+	}
+	// Maps are encoded with sorted keys
+	body, err = json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write([]byte(modelServerName.String()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// completeResponse returns the response captured for a request, nil if it can not be cached:
+// a failed, aborted, truncated or too large response, or a response of a fallback target, as the
+// request is cached under the ModelServer it was routed to
+func completeResponse(c *gin.Context, capture *responseCapture) *cachedResponse {
+	if capture.Status() != http.StatusOK || c.IsAborted() || clientCanceled(c) || capture.overflow || capture.body.Len() == 0 {
+		return nil
+	}
+	if capture.Header().Get(FallbackReasonHeader) != "" {
+		return nil
+	}
+	contentType := capture.Header().Get("Content-Type")
+	body := capture.body.Bytes()
+	// A stream cut short by a failure of the pod has no end
+	if strings.HasPrefix(contentType, "text/event-stream") && !bytes.Contains(body, []byte("data: [DONE]")) {
+		return nil
+	}
+	return &cachedResponse{ContentType: contentType, Body: bytes.Clone(body)}
+}
+
+// writeCachedResponse writes a cached response to the client. The events of a stream are re-streamed
+// one at a time.
+func writeCachedResponse(c *gin.Context, response *cachedResponse) {
+	if response.ContentType != "" {
+		c.Header("Content-Type", response.ContentType)
+	}
+	c.Status(http.StatusOK)
+	if !strings.HasPrefix(response.ContentType, "text/event-stream") {
+		_, _ = c.Writer.Write(response.Body)
+		return
+	}
+	for _, event := range bytes.SplitAfter(response.Body, []byte("\n\n")) {
+		if len(event) == 0 {
+			continue
+		}
+		if _, err := c.Writer.Write(event); err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
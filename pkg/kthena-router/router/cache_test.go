@@ -0,0 +1,297 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func decodeModelRequest(t *testing.T, body string) ModelRequest {
+	decoded, err := utils.DecodeRequestBody([]byte(body))
+	require.NoError(t, err)
+	return ModelRequest(decoded)
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	modelServer := types.NamespacedName{Namespace: "default", Name: "ms-1"}
+	key := func(path, body string, modelServer types.NamespacedName) string {
+		k, err := responseCacheKey(path, decodeModelRequest(t, body), modelServer)
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key("/v1/chat/completions", `{"model":"m","temperature":0,"metadata":{"a":1,"b":2}}`, modelServer)
+	assert.Equal(t, base, key("/v1/chat/completions", `{"metadata": {"b": 2, "a": 1}, "temperature": 0, "model": "m"}`, modelServer))
+	assert.NotEqual(t, base, key("/v1/chat/completions", `{"model":"m","temperature":0,"metadata":{"a":1,"b":3}}`, modelServer))
+	assert.NotEqual(t, base, key("/v1/completions", `{"model":"m","temperature":0,"metadata":{"a":1,"b":2}}`, modelServer))
+	assert.NotEqual(t, base, key("/v1/chat/completions", `{"model":"m","temperature":0,"metadata":{"a":1,"b":2}}`,
+		types.NamespacedName{Namespace: "default", Name: "ms-2"}))
+}
+
+func TestIsCacheable(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		want bool
+	}{
+		{"chat with a temperature of 0", "/v1/chat/completions", `{"model":"m","temperature":0}`, true},
+		{"completion with a temperature of 0", "/v1/completions", `{"model":"m","temperature":0.0}`, true},
+		{"positive temperature", "/v1/chat/completions", `{"model":"m","temperature":0.7}`, false},
+		{"default temperature", "/v1/chat/completions", `{"model":"m"}`, false},
+		{"embeddings", "/v1/embeddings", `{"model":"m","temperature":0}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isCacheable(tt.path, decodeModelRequest(t, tt.body)))
+		})
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	noCache, noStore := cacheControl(http.Header{"Cache-Control": []string{"max-age=0, No-Cache"}})
+	assert.True(t, noCache)
+	assert.False(t, noStore)
+
+	noCache, noStore = cacheControl(http.Header{"Cache-Control": []string{"no-store"}})
+	assert.False(t, noCache)
+	assert.True(t, noStore)
+
+	noCache, noStore = cacheControl(http.Header{})
+	assert.False(t, noCache)
+	assert.False(t, noStore)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := newMemoryStore(1)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", &cachedResponse{Body: []byte("a")}, time.Minute))
+	response, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	assert.Equal(t, []byte("a"), response.Body)
+
+	// The least recently used response is evicted
+	require.NoError(t, store.Set(ctx, "b", &cachedResponse{Body: []byte("b")}, time.Minute))
+	response, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	// Expired responses are not served
+	require.NoError(t, store.Set(ctx, "c", &cachedResponse{Body: []byte("c")}, -time.Second))
+	response, err = store.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := &redisStore{client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+	ctx := context.Background()
+
+	response, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	cached := &cachedResponse{ContentType: "application/json", Body: []byte(`{"choices":[]}`)}
+	require.NoError(t, store.Set(ctx, "key", cached, time.Minute))
+	assert.True(t, mr.Exists(redisCacheKeyPrefix+"key"))
+	response, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, cached, response)
+
+	mr.FastForward(2 * time.Minute)
+	response, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, response)
+}
+
+func TestRouter_HandlerFunc_ResponseCache(t *testing.T) {
+	requests := make(chan ModelRequest, 10)
+	router, store, backend := setupTestRouter(chatCompletionHandler("hello", 0, requests))
+	defer backend.Close()
+
+	addModelServerWithBackends(t, store, "ms-1", "served-model", backend)
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+			},
+			Cache: &aiv1alpha1.ResponseCache{TTL: &v1.Duration{Duration: time.Minute}},
+		},
+	}))
+
+	send := func(body, cacheControl string) (int, http.Header, string) {
+		// Streaming needs a recorder implementing CloseNotify
+		w := connectors.CreateTestResponseRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if cacheControl != "" {
+			c.Request.Header.Set("Cache-Control", cacheControl)
+		}
+		router.HandlerFunc()(c)
+		return w.Code, w.Header(), w.Body.String()
+	}
+	upstreamRequests := func() int {
+		count := 0
+		for {
+			select {
+			case <-requests:
+				count++
+			default:
+				return count
+			}
+		}
+	}
+
+	t.Run("non-streaming", func(t *testing.T) {
+		body := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "temperature": 0}`
+		code, header, first := send(body, "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, cacheMiss, header.Get(CacheStatusHeader))
+		assert.Equal(t, 1, upstreamRequests())
+
+		code, header, second := send(body, "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, cacheHit, header.Get(CacheStatusHeader))
+		assert.Equal(t, first, second)
+		assert.Equal(t, 0, upstreamRequests())
+
+		code, header, _ = send(body, "no-cache")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, cacheBypass, header.Get(CacheStatusHeader))
+		assert.Equal(t, 1, upstreamRequests())
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		body := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "temperature": 0, "stream": true}`
+		_, header, first := send(body, "")
+		assert.Equal(t, cacheMiss, header.Get(CacheStatusHeader))
+		assert.Contains(t, first, "data: [DONE]")
+		assert.Equal(t, 1, upstreamRequests())
+
+		_, header, second := send(body, "")
+		assert.Equal(t, cacheHit, header.Get(CacheStatusHeader))
+		assert.Equal(t, "text/event-stream", header.Get("Content-Type"))
+		assert.Equal(t, first, second)
+		assert.Equal(t, 0, upstreamRequests())
+	})
+
+	t.Run("no-store", func(t *testing.T) {
+		body := `{"model": "test-model", "messages": [{"role": "user", "content": "no store"}], "temperature": 0}`
+		_, header, _ := send(body, "no-store")
+		assert.Equal(t, cacheBypass, header.Get(CacheStatusHeader))
+		_, header, _ = send(body, "")
+		assert.Equal(t, cacheMiss, header.Get(CacheStatusHeader))
+		assert.Equal(t, 2, upstreamRequests())
+	})
+
+	t.Run("non-deterministic", func(t *testing.T) {
+		body := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "temperature": 0.7}`
+		_, header, _ := send(body, "")
+		assert.Empty(t, header.Get(CacheStatusHeader))
+		_, header, _ = send(body, "")
+		assert.Empty(t, header.Get(CacheStatusHeader))
+		assert.Equal(t, 2, upstreamRequests())
+	})
+}
+
+func TestRouter_HandlerFunc_ResponseCacheFallback(t *testing.T) {
+	var primaryDown atomic.Bool
+	primaryDown.Store(true)
+	primaryHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if primaryDown.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"primary"}`)
+	})
+	router, store, primaryBackend := setupTestRouter(primaryHandler)
+	defer primaryBackend.Close()
+	fallbackBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"fallback"}`)
+	}))
+	defer fallbackBackend.Close()
+
+	addModelServerWithBackends(t, store, "ms-primary", "primary-model", primaryBackend)
+	addModelServerWithBackends(t, store, "ms-fallback", "fallback-model", fallbackBackend)
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+					Fallback:     &aiv1alpha1.Fallback{Targets: []aiv1alpha1.FallbackTarget{{ModelServerName: "ms-fallback"}}},
+				},
+			},
+			Cache: &aiv1alpha1.ResponseCache{TTL: &v1.Duration{Duration: time.Minute}},
+		},
+	}))
+
+	send := func() (http.Header, string) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "temperature": 0}`
+		c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		router.HandlerFunc()(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Header(), w.Body.String()
+	}
+
+	// The response of the fallback target is not cached under the primary ModelServer
+	header, body := send()
+	assert.Equal(t, cacheMiss, header.Get(CacheStatusHeader))
+	assert.Contains(t, body, `"id":"fallback"`)
+
+	primaryDown.Store(false)
+	header, body = send()
+	assert.Equal(t, cacheMiss, header.Get(CacheStatusHeader))
+	assert.Contains(t, body, `"id":"primary"`)
+
+	header, body = send()
+	assert.Equal(t, cacheHit, header.Get(CacheStatusHeader))
+	assert.Contains(t, body, `"id":"primary"`)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// defaultClassifierTimeout is the time to wait for the answer of an HTTP classifier without a timeout
+	defaultClassifierTimeout = time.Second
+	// maxClassifierResponseSize is the maximum size of the answer of an HTTP classifier
+	maxClassifierResponseSize = 64 * 1024
+)
+
+// classifierClient sends the requests to the HTTP classifiers
+var classifierClient = &http.Client{}
+
+// classifyRequest is the body of the requests sent to an HTTP classifier
+type classifyRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// classifyResponse is the answer of an HTTP classifier
+type classifyResponse struct {
+	Category string `json:"category"`
+}
+
+// classifyModelServer assigns a category to the prompt of a request matched by a rule with a classifier,
+// and returns the ModelServer of one of the targets of the category. It fails if no target serves the
+// category, neither an uncategorized target nor a target of the default category.
+func (r *Router) classifyModelServer(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelRoute *v1alpha1.ModelRoute,
+	rule *v1alpha1.Rule,
+) (types.NamespacedName, error) {
+	classifier := rule.Classifier
+	classifierType := classifier.Type
+	if classifierType == "" {
+		classifierType = v1alpha1.PromptClassifierHeuristic
+	}
+	modelName := modelRequest["model"].(string)
+	routeName := fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name)
+
+	start := time.Now()
+	category, err := classifyPrompt(c.Request.Context(), c.Request.URL.Path, modelRequest, classifierType, classifier)
+	if err != nil {
+		klog.V(2).Infof("failed to classify the prompt of request %s for model route %s: %v",
+			c.Request.Header.Get("x-request-id"), routeName, err)
+	}
+	if category == "" {
+		category = classifier.DefaultCategory
+	}
+	r.metrics.RecordPromptClassification(modelName, routeName, string(classifierType), category, err != nil, time.Since(start))
+	accesslog.SetPromptCategory(c, category)
+
+	targets := targetsOfCategory(rule.TargetModels, category, classifier.DefaultCategory)
+	if len(targets) == 0 {
+		return types.NamespacedName{}, fmt.Errorf("no target of model route %s serves the prompts of category %q", routeName, category)
+	}
+	target, err := datastore.SelectDestination(targets)
+	if err != nil {
+		return types.NamespacedName{}, fmt.Errorf("failed to select a target of category %q of model route %s: %v", category, routeName, err)
+	}
+	klog.V(4).Infof("prompt classified as %q, model server is %s", category, target.ModelServerName)
+	return types.NamespacedName{Namespace: modelRoute.Namespace, Name: target.ModelServerName}, nil
+}
+
+// classifyPrompt returns the category of the prompt of a request, empty if it matches no category
+func classifyPrompt(
+	ctx context.Context,
+	path string,
+	modelRequest ModelRequest,
+	classifierType v1alpha1.PromptClassifierType,
+	classifier *v1alpha1.PromptClassifier,
+) (string, error) {
+	input, err := utils.ParseRequestInput(path, modelRequest)
+	if err != nil {
+		return "", err
+	}
+	prompt := classifierPrompt(input)
+
+	switch classifierType {
+	case v1alpha1.PromptClassifierHeuristic:
+		return classifyHeuristic(classifier.Categories, prompt), nil
+	case v1alpha1.PromptClassifierHTTP:
+		model, _ := modelRequest["model"].(string)
+		return classifyHTTP(ctx, classifier, model, prompt)
+	default:
+		return "", fmt.Errorf("unknown classifier type %q", classifierType)
+	}
+}
+
+// classifierPrompt returns the text classified for a request: the prompt of completion requests,
+// or the content of the messages of chat requests
+func classifierPrompt(input *common.RequestInput) string {
+	if input.Prompt.Text != "" {
+		return input.Prompt.Text
+	}
+	contents := make([]string, 0, len(input.Prompt.Messages))
+	for _, msg := range input.Prompt.Messages {
+		contents = append(contents, msg.Content)
+	}
+	return strings.Join(contents, "\n")
+}
+
+// classifyHeuristic returns the first category whose conditions the prompt satisfies
+func classifyHeuristic(categories []v1alpha1.PromptCategory, prompt string) string {
+	length := int32(utf8.RuneCountInString(prompt))
+	lowerPrompt := strings.ToLower(prompt)
+	for i := range categories {
+		category := &categories[i]
+		if category.MinLength != nil && length < *category.MinLength {
+			continue
+		}
+		if category.MaxLength != nil && length > *category.MaxLength {
+			continue
+		}
+		if len(category.Keywords) > 0 && !containsAnyKeyword(lowerPrompt, category.Keywords) {
+			continue
+		}
+		if category.Regex != nil {
+			if matched, _ := regexp.MatchString(*category.Regex, prompt); !matched {
+				continue
+			}
+		}
+		return category.Name
+	}
+	return ""
+}
+
+func containsAnyKeyword(lowerPrompt string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(lowerPrompt, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyHTTP asks an HTTP classifier for the category of a prompt
+func classifyHTTP(ctx context.Context, classifier *v1alpha1.PromptClassifier, model, prompt string) (string, error) {
+	timeout := defaultClassifierTimeout
+	if classifier.Timeout != nil {
+		timeout = classifier.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(classifyRequest{Model: model, Prompt: prompt})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, classifier.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := classifierClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("classifier answered with status code %d", resp.StatusCode)
+	}
+	var result classifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxClassifierResponseSize)).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid classifier response: %v", err)
+	}
+	return result.Category, nil
+}
+
+// targetsOfCategory returns the targets serving a category: the targets of the category, or the
+// uncategorized targets if there are none, or else the targets of the default category
+func targetsOfCategory(targets []*v1alpha1.TargetModel, category, defaultCategory string) []*v1alpha1.TargetModel {
+	var matched, uncategorized, defaults []*v1alpha1.TargetModel
+	for _, target := range targets {
+		switch target.Category {
+		case "":
+			uncategorized = append(uncategorized, target)
+		case category:
+			matched = append(matched, target)
+		}
+		if target.Category != "" && target.Category == defaultCategory {
+			defaults = append(defaults, target)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	if len(uncategorized) > 0 {
+		return uncategorized
+	}
+	return defaults
+}
@@ -0,0 +1,246 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+)
+
+func TestClassifyHeuristic(t *testing.T) {
+	categories := []aiv1alpha1.PromptCategory{
+		{Name: "code", Keywords: []string{"Python", "golang"}},
+		{Name: "math", Regex: ptr.To(`\d+\s*[+*/-]\s*\d+`)},
+		{Name: "short", MaxLength: ptr.To(int32(10))},
+		{Name: "long", MinLength: ptr.To(int32(100))},
+	}
+
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{"keywords are case insensitive", "write a python script", "code"},
+		{"regex", "how much is 12 * 7?", "math"},
+		{"the first matching category wins", "python: 1+1", "code"},
+		{"max length in characters", "héllo wörld", ""},
+		{"short prompt", "hi there", "short"},
+		{"long prompt", strings.Repeat("a", 100), "long"},
+		{"no category", "tell me a story", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyHeuristic(categories, tt.prompt))
+		})
+	}
+}
+
+func TestTargetsOfCategory(t *testing.T) {
+	small := &aiv1alpha1.TargetModel{ModelServerName: "small", Category: "simple"}
+	large := &aiv1alpha1.TargetModel{ModelServerName: "large", Category: "complex"}
+	uncategorized := &aiv1alpha1.TargetModel{ModelServerName: "any"}
+	targets := []*aiv1alpha1.TargetModel{small, large, uncategorized}
+
+	assert.Equal(t, []*aiv1alpha1.TargetModel{small}, targetsOfCategory(targets, "simple", "complex"))
+	assert.Equal(t, []*aiv1alpha1.TargetModel{uncategorized}, targetsOfCategory(targets, "unknown", "complex"))
+	assert.Equal(t, []*aiv1alpha1.TargetModel{uncategorized}, targetsOfCategory(targets, "", ""))
+	assert.Equal(t, []*aiv1alpha1.TargetModel{large}, targetsOfCategory([]*aiv1alpha1.TargetModel{small, large}, "unknown", "complex"))
+	assert.Empty(t, targetsOfCategory([]*aiv1alpha1.TargetModel{small, large}, "unknown", "other"))
+	assert.Empty(t, targetsOfCategory([]*aiv1alpha1.TargetModel{small, large}, "unknown", ""))
+}
+
+func TestRouter_HandlerFunc_PromptClassifier(t *testing.T) {
+	classifierServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req classifyRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case strings.Contains(req.Prompt, "fail"):
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.Contains(req.Prompt, "translate"):
+			fmt.Fprint(w, `{"category": "translation"}`)
+		case strings.Contains(req.Prompt, "slow"):
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"category": "complex"}`)
+		case len(req.Prompt) > 20:
+			fmt.Fprint(w, `{"category": "complex"}`)
+		default:
+			fmt.Fprint(w, `{"category": "simple"}`)
+		}
+	}))
+	defer classifierServer.Close()
+
+	tests := []struct {
+		name         string
+		classifier   *aiv1alpha1.PromptClassifier
+		prompt       string
+		wantCode     int
+		wantModel    string
+		wantCategory string
+	}{
+		{
+			name: "heuristic short prompt",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:            aiv1alpha1.PromptClassifierHeuristic,
+				Categories:      []aiv1alpha1.PromptCategory{{Name: "simple", MaxLength: ptr.To(int32(20))}},
+				DefaultCategory: "complex",
+			},
+			prompt:       "hi",
+			wantModel:    "small-model",
+			wantCategory: "simple",
+		},
+		{
+			name: "heuristic default category",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:            aiv1alpha1.PromptClassifierHeuristic,
+				Categories:      []aiv1alpha1.PromptCategory{{Name: "simple", MaxLength: ptr.To(int32(20))}},
+				DefaultCategory: "complex",
+			},
+			prompt:       "explain the theory of general relativity",
+			wantModel:    "large-model",
+			wantCategory: "complex",
+		},
+		{
+			name: "http classifier",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:     aiv1alpha1.PromptClassifierHTTP,
+				Endpoint: classifierServer.URL,
+			},
+			prompt:       "explain the theory of general relativity",
+			wantModel:    "large-model",
+			wantCategory: "complex",
+		},
+		{
+			name: "http classifier failure uses the default category",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:            aiv1alpha1.PromptClassifierHTTP,
+				Endpoint:        classifierServer.URL,
+				DefaultCategory: "simple",
+			},
+			prompt:       "this request makes the classifier fail",
+			wantModel:    "small-model",
+			wantCategory: "simple",
+		},
+		{
+			name: "http classifier timeout uses the default category",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:            aiv1alpha1.PromptClassifierHTTP,
+				Endpoint:        classifierServer.URL,
+				Timeout:         &v1.Duration{Duration: 50 * time.Millisecond},
+				DefaultCategory: "simple",
+			},
+			prompt:       "a slow classification of a long prompt",
+			wantModel:    "small-model",
+			wantCategory: "simple",
+		},
+		{
+			name: "category without target uses the targets of the default category",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:            aiv1alpha1.PromptClassifierHTTP,
+				Endpoint:        classifierServer.URL,
+				DefaultCategory: "complex",
+			},
+			prompt:       "translate this text",
+			wantModel:    "large-model",
+			wantCategory: "translation",
+		},
+		{
+			name: "category without target and without default category",
+			classifier: &aiv1alpha1.PromptClassifier{
+				Type:     aiv1alpha1.PromptClassifierHTTP,
+				Endpoint: classifierServer.URL,
+			},
+			prompt:       "translate this text",
+			wantCode:     http.StatusNotFound,
+			wantCategory: "translation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smallRequests := make(chan ModelRequest, 1)
+			router, store, smallBackend := setupTestRouter(chatCompletionHandler("hello", 0, smallRequests))
+			defer smallBackend.Close()
+			largeRequests := make(chan ModelRequest, 1)
+			largeBackend := httptest.NewServer(chatCompletionHandler("hello", 0, largeRequests))
+			defer largeBackend.Close()
+
+			addModelServerWithBackends(t, store, "ms-small", "small-model", smallBackend)
+			addModelServerWithBackends(t, store, "ms-large", "large-model", largeBackend)
+			require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+				ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+				Spec: aiv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*aiv1alpha1.Rule{
+						{
+							TargetModels: []*aiv1alpha1.TargetModel{
+								{ModelServerName: "ms-small", Category: "simple"},
+								{ModelServerName: "ms-large", Category: "complex"},
+							},
+							Classifier: tt.classifier,
+						},
+					},
+				},
+			}))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := fmt.Sprintf(`{"model": "test-model", "messages": [{"role": "user", "content": %q}]}`, tt.prompt)
+			c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			logCtx := accesslog.NewAccessLogContext("req-1", "POST", "/v1/chat/completions", "HTTP/1.1", "")
+			c.Set(accesslog.AccessLogContextKey, logCtx)
+
+			router.HandlerFunc()(c)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, w.Code)
+				assert.Contains(t, w.Body.String(), `no target of model route default/mr-1 serves the prompts of category \"translation\"`)
+				assert.Equal(t, tt.wantCategory, logCtx.PromptCategory)
+				assert.Empty(t, smallRequests)
+				assert.Empty(t, largeRequests)
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var upstream ModelRequest
+			select {
+			case upstream = <-smallRequests:
+			case upstream = <-largeRequests:
+			default:
+				t.Fatal("the request was not proxied")
+			}
+			assert.Equal(t, tt.wantModel, upstream["model"])
+			assert.Equal(t, tt.wantCategory, logCtx.PromptCategory)
+			assert.Equal(t, tt.wantCategory, logCtx.ToAccessLogEntry(http.StatusOK).PromptCategory)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// ModelServerHeader is the response header naming the ModelServer which served a request,
+	// it is set for the rules with a fallback
+	ModelServerHeader = "X-Kthena-Model-Server"
+	// FallbackReasonHeader is the response header set when a fallback target served a request,
+	// with the condition which triggered the last fallback
+	FallbackReasonHeader = "X-Kthena-Fallback-Reason"
+
+	// responseTimeoutKey is the context key of the time to wait for the response headers of a pod
+	responseTimeoutKey = "response_timeout"
+)
+
+// errUpstreamTimeout is returned when a pod did not respond within the timeout of the fallback
+var errUpstreamTimeout = errors.New("timed out waiting for the upstream response")
+
+// upstreamStatusError is returned when a pod answers with an unsuccessful status code
+type upstreamStatusError struct {
+	statusCode int
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("http resp error, http code is %d", e.statusCode)
+}
+
+// attemptsFailedError is returned when a request failed on all the pods it was scheduled to
+type attemptsFailedError struct {
+	// status is answered to the client if no fallback target serves the request
+	status  int
+	message string
+	// last is the error of the last pod
+	last error
+}
+
+func (e *attemptsFailedError) Error() string {
+	return e.message
+}
+
+func (e *attemptsFailedError) Unwrap() error {
+	return e.last
+}
+
+// serveModelRoute serves a request matched by a ModelRoute with the ModelServer selected by the rule.
+// If it fails with one of the conditions of the fallback of the rule, the fallback targets are tried in order.
+func (r *Router) serveModelRoute(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelRoute *v1alpha1.ModelRoute,
+	modelServerName types.NamespacedName,
+	isLora bool,
+	fallback *v1alpha1.Fallback,
+) {
+	modelName := modelRequest["model"].(string)
+	targets := []types.NamespacedName{modelServerName}
+	if fallback != nil {
+		for _, target := range fallback.Targets {
+			targets = append(targets, types.NamespacedName{Namespace: modelRoute.Namespace, Name: target.ModelServerName})
+		}
+	}
+
+	var input *common.RequestInput
+	var failure *routingError
+	for i, target := range targets {
+		if i > 0 {
+			if failure.answered || !fallbackOn(fallback, failure.condition) {
+				break
+			}
+			klog.V(2).Infof("model server %v failed with %s, falling back to %v", targets[i-1], failure.condition, target)
+			c.Header(FallbackReasonHeader, string(failure.condition))
+		}
+		if fallback != nil {
+			c.Header(ModelServerHeader, target.String())
+			// There is no point in giving up on the last target
+			var timeout time.Duration
+			if fallback.Timeout != nil && i < len(targets)-1 && fallbackOn(fallback, v1alpha1.FallbackOnTimeout) {
+				timeout = fallback.Timeout.Duration
+			}
+			c.Set(responseTimeoutKey, timeout)
+		}
+
+		failure = r.serveModelServer(c, modelRequest, modelName, &input, modelRoute, target, isLora)
+		if failed, counted := modelServerFailed(failure); counted {
+			r.metrics.RecordModelServerRequest(modelRoute.Namespace+"/"+modelRoute.Name, target.String(), failed)
+		}
+		if failure == nil {
+			return
+		}
+	}
+	r.abortRouting(c, failure)
+}
+
+// serveModelServer serves a request with the pods of a ModelServer. The input of the request is parsed
+// the first time it is needed.
+func (r *Router) serveModelServer(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelName string,
+	input **common.RequestInput,
+	modelRoute *v1alpha1.ModelRoute,
+	modelServerName types.NamespacedName,
+	isLora bool,
+) *routingError {
+	pods, modelServer, err := r.getPodsAndServer(modelServerName)
+	if err != nil || len(pods) == 0 {
+		klog.Errorf("failed to get pods and model server: %v, %v", modelServerName, err)
+		return &routingError{
+			status:    http.StatusNotFound,
+			reason:    "pod_discovery",
+			message:   fmt.Sprintf("can't find model server: %v", modelServerName),
+			condition: v1alpha1.FallbackOnNoEndpoints,
+		}
+	}
+
+	// The model is renamed after the model of the ModelServer, the fallback targets may serve another model
+	servedModel := modelName
+	if model := modelServer.Spec.Model; model != nil && !isLora {
+		servedModel = *model
+	}
+	if current, _ := modelRequest["model"].(string); current != servedModel {
+		modelRequest["model"] = servedModel
+		markRequestModified(c)
+	}
+
+	if *input == nil {
+		parsed, err := utils.ParseRequestInput(c.Request.URL.Path, modelRequest)
+		if err != nil {
+			return &routingError{status: http.StatusNotFound, reason: "prompt_parsing", message: "prompt not found"}
+		}
+		*input = parsed
+	}
+
+	target := &upstreamTarget{
+		pods:            pods,
+		port:            modelServer.Spec.WorkloadPort.Port,
+		modelServer:     modelServer,
+		modelServerName: modelServerName,
+	}
+	return r.scheduleAndProxy(c, modelRequest, modelName, *input, modelRoute, target, isLora)
+}
+
+// fallbackOn returns true if a failure with the condition falls back to the next target
+func fallbackOn(fallback *v1alpha1.Fallback, condition v1alpha1.FallbackCondition) bool {
+	if fallback == nil || condition == "" {
+		return false
+	}
+	return len(fallback.Conditions) == 0 || slices.Contains(fallback.Conditions, condition)
+}
+
+// modelServerFailed tells whether a ModelServer is to blame for the failure of a request. Requests canceled
+// by the client or rejected as invalid are not counted against the ModelServer.
+func modelServerFailed(failure *routingError) (failed bool, counted bool) {
+	switch {
+	case failure == nil:
+		return false, true
+	case failure.reason == "":
+		return false, false
+	case failure.condition == v1alpha1.FallbackOnServerError, failure.condition == v1alpha1.FallbackOnTimeout,
+		failure.condition == v1alpha1.FallbackOnNoEndpoints:
+		return true, true
+	case failure.reason != "proxy" && failure.status >= http.StatusInternalServerError:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// upstreamFailureCondition returns the fallback condition matched by the failure of the pods of a target
+func upstreamFailureCondition(err error) v1alpha1.FallbackCondition {
+	var statusErr *upstreamStatusError
+	switch {
+	case errors.Is(err, errUpstreamTimeout):
+		return v1alpha1.FallbackOnTimeout
+	case errors.As(err, &statusErr) && statusErr.statusCode == http.StatusTooManyRequests:
+		return v1alpha1.FallbackOnRateLimited
+	case errors.As(err, &statusErr) && statusErr.statusCode < http.StatusInternalServerError:
+		// The request itself is rejected, the next targets would reject it as well
+		return ""
+	default:
+		// 5xx responses and connection failures
+		return v1alpha1.FallbackOnServerError
+	}
+}
+
+// responseTimeout returns the time to wait for the response headers of a pod, zero if there is no timeout
+func responseTimeout(c *gin.Context) time.Duration {
+	if v, ok := c.Get(responseTimeoutKey); ok {
+		if timeout, ok := v.(time.Duration); ok {
+			return timeout
+		}
+	}
+	return 0
+}
@@ -0,0 +1,386 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+// addModelServerWithBackends adds a ModelServer serving model, with a pod for each of the backends
+func addModelServerWithBackends(t *testing.T, store datastore.Store, name, model string, backends ...*httptest.Server) {
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           &model,
+			InferenceEngine: "vLLM",
+		},
+	}
+	podNames := sets.New[types.NamespacedName]()
+	var pods []*corev1.Pod
+	for i, backend := range backends {
+		backendURL, err := url.Parse(backend.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(backendURL.Port())
+		require.NoError(t, err)
+		// The pods of a ModelServer share its port
+		modelServer.Spec.WorkloadPort = aiv1alpha1.WorkloadPort{Port: int32(port)}
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: fmt.Sprintf("%s-pod-%d", name, i), Namespace: "default"},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		}
+		podNames.Insert(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+		pods = append(pods, pod)
+	}
+	require.NoError(t, store.AddOrUpdateModelServer(modelServer, podNames))
+	for _, pod := range pods {
+		require.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer}))
+	}
+}
+
+func TestRouter_HandlerFunc_Fallback(t *testing.T) {
+	respondWith := func(status int, delay time.Duration) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"id":"primary"}`)
+		})
+	}
+
+	tests := []struct {
+		name string
+		// primary is nil when the primary ModelServer has no pods
+		primary        http.Handler
+		conditions     []aiv1alpha1.FallbackCondition
+		timeout        time.Duration
+		wantCode       int
+		wantBody       string
+		wantServer     string
+		wantReason     string
+		wantFallbackOK bool
+	}{
+		{
+			name:           "no endpoints",
+			wantCode:       http.StatusOK,
+			wantServer:     "default/ms-fallback",
+			wantReason:     string(aiv1alpha1.FallbackOnNoEndpoints),
+			wantFallbackOK: true,
+		},
+		{
+			name:           "server error",
+			primary:        respondWith(http.StatusServiceUnavailable, 0),
+			wantCode:       http.StatusOK,
+			wantServer:     "default/ms-fallback",
+			wantReason:     string(aiv1alpha1.FallbackOnServerError),
+			wantFallbackOK: true,
+		},
+		{
+			name:           "rate limited",
+			primary:        respondWith(http.StatusTooManyRequests, 0),
+			conditions:     []aiv1alpha1.FallbackCondition{aiv1alpha1.FallbackOnRateLimited},
+			wantCode:       http.StatusOK,
+			wantServer:     "default/ms-fallback",
+			wantReason:     string(aiv1alpha1.FallbackOnRateLimited),
+			wantFallbackOK: true,
+		},
+		{
+			name:           "timeout",
+			primary:        respondWith(http.StatusOK, 300*time.Millisecond),
+			conditions:     []aiv1alpha1.FallbackCondition{aiv1alpha1.FallbackOnTimeout},
+			timeout:        50 * time.Millisecond,
+			wantCode:       http.StatusOK,
+			wantServer:     "default/ms-fallback",
+			wantReason:     string(aiv1alpha1.FallbackOnTimeout),
+			wantFallbackOK: true,
+		},
+		{
+			name:       "condition not enabled",
+			primary:    respondWith(http.StatusServiceUnavailable, 0),
+			conditions: []aiv1alpha1.FallbackCondition{aiv1alpha1.FallbackOnRateLimited},
+			wantCode:   http.StatusNotFound,
+			wantBody:   "request to all pods failed",
+			wantServer: "default/ms-primary",
+		},
+		{
+			name:       "client error is not retried",
+			primary:    respondWith(http.StatusBadRequest, 0),
+			wantCode:   http.StatusNotFound,
+			wantBody:   "request to all pods failed",
+			wantServer: "default/ms-primary",
+		},
+		{
+			name:       "primary serves the request",
+			primary:    respondWith(http.StatusOK, 0),
+			wantCode:   http.StatusOK,
+			wantBody:   `"id":"primary"`,
+			wantServer: "default/ms-primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var reqBody ModelRequest
+				_ = json.Unmarshal(body, &reqBody)
+				// The model is renamed after the model of the fallback ModelServer
+				assert.Equal(t, "fallback-model", reqBody["model"])
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"id":"fallback"}`)
+			})
+			router, store, fallbackBackend := setupTestRouter(fallbackHandler)
+			defer fallbackBackend.Close()
+
+			var primaryBackends []*httptest.Server
+			if tt.primary != nil {
+				primaryBackend := httptest.NewServer(tt.primary)
+				defer primaryBackend.Close()
+				primaryBackends = append(primaryBackends, primaryBackend)
+			}
+			addModelServerWithBackends(t, store, "ms-primary", "primary-model", primaryBackends...)
+			addModelServerWithBackends(t, store, "ms-fallback", "fallback-model", fallbackBackend)
+
+			fallback := &aiv1alpha1.Fallback{
+				Targets:    []aiv1alpha1.FallbackTarget{{ModelServerName: "ms-fallback"}},
+				Conditions: tt.conditions,
+			}
+			if tt.timeout > 0 {
+				fallback.Timeout = &v1.Duration{Duration: tt.timeout}
+			}
+			require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+				ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+				Spec: aiv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*aiv1alpha1.Rule{
+						{
+							TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+							Fallback:     fallback,
+						},
+					},
+				},
+			}))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			router.HandlerFunc()(c)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantFallbackOK {
+				assert.Contains(t, w.Body.String(), `"id":"fallback"`)
+			} else {
+				assert.Contains(t, w.Body.String(), tt.wantBody)
+			}
+			assert.Equal(t, tt.wantServer, w.Header().Get(ModelServerHeader))
+			assert.Equal(t, tt.wantReason, w.Header().Get(FallbackReasonHeader))
+		})
+	}
+}
+
+func TestRouter_HandlerFunc_FallbackFromPDDisaggregation(t *testing.T) {
+	fallbackHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var reqBody ModelRequest
+		_ = json.Unmarshal(body, &reqBody)
+		// The request is sent as received, not as the prefill request of the primary
+		assert.Equal(t, "fallback-model", reqBody["model"])
+		assert.Equal(t, true, reqBody["stream"])
+		assert.Equal(t, float64(100), reqBody["max_tokens"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"fallback"}`)
+	})
+	router, store, fallbackBackend := setupTestRouter(fallbackHandler)
+	defer fallbackBackend.Close()
+	addModelServerWithBackends(t, store, "ms-fallback", "fallback-model", fallbackBackend)
+
+	// The prefill pod of the primary fails
+	primaryBackend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primaryBackend.Close()
+	primaryURL, err := url.Parse(primaryBackend.URL)
+	require.NoError(t, err)
+	primaryPort, err := strconv.Atoi(primaryURL.Port())
+	require.NoError(t, err)
+	model := "primary-model"
+	primary := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-primary", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			Model:           &model,
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(primaryPort)},
+			InferenceEngine: "vLLM",
+			WorkloadSelector: &aiv1alpha1.WorkloadSelector{
+				PDGroup: &aiv1alpha1.PDGroup{
+					GroupKey:      "group",
+					DecodeLabels:  map[string]string{"app": "decode"},
+					PrefillLabels: map[string]string{"app": "prefill"},
+				},
+			},
+		},
+	}
+	podNames := sets.New[types.NamespacedName]()
+	var pods []*corev1.Pod
+	for _, role := range []string{"prefill", "decode"} {
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      role + "-pod",
+				Namespace: "default",
+				Labels:    map[string]string{"app": role, "group": "group-1"},
+			},
+			Status: corev1.PodStatus{PodIP: primaryURL.Hostname(), Phase: corev1.PodRunning},
+		}
+		podNames.Insert(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+		pods = append(pods, pod)
+	}
+	require.NoError(t, store.AddOrUpdateModelServer(primary, podNames))
+	for _, pod := range pods {
+		require.NoError(t, store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{primary}))
+	}
+
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+					Fallback:     &aiv1alpha1.Fallback{Targets: []aiv1alpha1.FallbackTarget{{ModelServerName: "ms-fallback"}}},
+				},
+			},
+		},
+	}))
+
+	w := connectors.CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions",
+		bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "stream": true, "max_tokens": 100}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `data: {"id":"fallback"}`)
+	assert.Equal(t, "default/ms-fallback", w.Header().Get(ModelServerHeader))
+	assert.Equal(t, string(aiv1alpha1.FallbackOnServerError), w.Header().Get(FallbackReasonHeader))
+}
+
+func TestRouter_HandlerFunc_FallbackRequestsStreamUsage(t *testing.T) {
+	// The original body is forwarded to both targets, each of them is asked for the usage of the stream
+	var received []map[string]interface{}
+	var mu sync.Mutex
+	record := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var reqBody map[string]interface{}
+			_ = json.Unmarshal(body, &reqBody)
+			mu.Lock()
+			received = append(received, reqBody)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(status)
+			fmt.Fprint(w, `data: {"id":"response"}`)
+		})
+	}
+	router, store, primaryBackend := setupTestRouter(record(http.StatusServiceUnavailable))
+	defer primaryBackend.Close()
+	fallbackBackend := httptest.NewServer(record(http.StatusOK))
+	defer fallbackBackend.Close()
+	addModelServerWithBackends(t, store, "ms-primary", "test-model", primaryBackend)
+	addModelServerWithBackends(t, store, "ms-fallback", "test-model", fallbackBackend)
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+					Fallback:     &aiv1alpha1.Fallback{Targets: []aiv1alpha1.FallbackTarget{{ModelServerName: "ms-fallback"}}},
+				},
+			},
+		},
+	}))
+
+	w := connectors.CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions",
+		bytes.NewBufferString(`{"model": "test-model", "prompt": "hello", "stream": true}`))
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 2)
+	for _, reqBody := range received {
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, reqBody["stream_options"])
+	}
+}
+
+func TestUpstreamFailureCondition(t *testing.T) {
+	assert.Equal(t, aiv1alpha1.FallbackOnTimeout, upstreamFailureCondition(fmt.Errorf("decode request error: %w", errUpstreamTimeout)))
+	assert.Equal(t, aiv1alpha1.FallbackOnRateLimited, upstreamFailureCondition(&attemptsFailedError{last: &upstreamStatusError{statusCode: http.StatusTooManyRequests}}))
+	assert.Equal(t, aiv1alpha1.FallbackOnServerError, upstreamFailureCondition(&upstreamStatusError{statusCode: http.StatusBadGateway}))
+	assert.Equal(t, aiv1alpha1.FallbackCondition(""), upstreamFailureCondition(&upstreamStatusError{statusCode: http.StatusNotFound}))
+	assert.Equal(t, aiv1alpha1.FallbackOnServerError, upstreamFailureCondition(fmt.Errorf("connection refused")))
+}
+
+func TestModelServerFailed(t *testing.T) {
+	tests := []struct {
+		name        string
+		failure     *routingError
+		wantFailed  bool
+		wantCounted bool
+	}{
+		{name: "served", wantCounted: true},
+		{name: "client canceled", failure: &routingError{answered: true}},
+		{name: "server error", failure: &routingError{status: http.StatusInternalServerError, reason: "proxy", condition: aiv1alpha1.FallbackOnServerError}, wantFailed: true, wantCounted: true},
+		{name: "timeout", failure: &routingError{status: http.StatusInternalServerError, reason: "proxy", condition: aiv1alpha1.FallbackOnTimeout}, wantFailed: true, wantCounted: true},
+		{name: "no endpoints", failure: &routingError{status: http.StatusNotFound, reason: "pod_discovery", condition: aiv1alpha1.FallbackOnNoEndpoints}, wantFailed: true, wantCounted: true},
+		{name: "scheduling", failure: &routingError{status: http.StatusInternalServerError, reason: "scheduling"}, wantFailed: true, wantCounted: true},
+		{name: "rejected request", failure: &routingError{status: http.StatusBadRequest, reason: "proxy"}},
+		{name: "rate limited", failure: &routingError{status: http.StatusTooManyRequests, reason: "proxy", condition: aiv1alpha1.FallbackOnRateLimited}},
+		{name: "prompt not found", failure: &routingError{status: http.StatusNotFound, reason: "prompt_parsing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed, counted := modelServerFailed(tt.failure)
+			assert.Equal(t, tt.wantFailed, failed)
+			assert.Equal(t, tt.wantCounted, counted)
+		})
+	}
+}
@@ -0,0 +1,804 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// GuardrailCheckMethod is the gRPC method called on the gRPC guard services. Its request and its
+	// response are google.protobuf.Struct messages holding the JSON check request and verdict.
+	GuardrailCheckMethod = "/kthena.guardrail.v1.Guard/Check"
+
+	// defaultGuardrailTimeout is the time to wait for the verdict of a guardrail without a timeout
+	defaultGuardrailTimeout = time.Second
+	// defaultGuardrailStreamChunkSize is the number of characters of a stream checked at once
+	// by a guardrail without a chunk size
+	defaultGuardrailStreamChunkSize = 200
+	// maxGuardrailResponseSize is the maximum size of the verdict of an HTTP guard service
+	maxGuardrailResponseSize = 1024 * 1024
+
+	guardrailAllow    = "allow"
+	guardrailAnnotate = "annotate"
+	guardrailRedact   = "redact"
+	guardrailBlock    = "block"
+	// guardrailError is the action recorded in the metrics when a guard service fails
+	guardrailError = "error"
+)
+
+// guardrailCheckRequest is the content checked by a guard service
+type guardrailCheckRequest struct {
+	// Phase is request or response
+	Phase      string `json:"phase"`
+	Model      string `json:"model"`
+	ModelRoute string `json:"modelRoute"`
+	RequestID  string `json:"requestId,omitempty"`
+	// Content are the texts of the prompt, or of the completion choices
+	Content []string `json:"content"`
+}
+
+// guardrailVerdict is the answer of a guard service
+type guardrailVerdict struct {
+	// Action is allow, annotate, redact or block
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	// Content are the redacted texts, one per checked text, for the redact action
+	Content     []string          `json:"content,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// guardrailRejection is returned when the checked content is blocked, or when a guardrail failed
+// and does not fail open
+type guardrailRejection struct {
+	status  int
+	message string
+}
+
+func (e *guardrailRejection) Error() string {
+	return e.message
+}
+
+// guardrailClients sends the check requests to the guard services
+type guardrailClients struct {
+	http *http.Client
+
+	mutex sync.Mutex
+	// grpcConns are the connections to the gRPC guard services by endpoint
+	grpcConns map[string]*grpc.ClientConn
+}
+
+func newGuardrailClients() *guardrailClients {
+	return &guardrailClients{
+		http:      &http.Client{},
+		grpcConns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// check asks a guard service for its verdict on some content
+func (gc *guardrailClients) check(
+	ctx context.Context,
+	guardrail *v1alpha1.Guardrail,
+	request *guardrailCheckRequest,
+) (*guardrailVerdict, error) {
+	timeout := defaultGuardrailTimeout
+	if guardrail.Timeout != nil {
+		timeout = guardrail.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var verdict *guardrailVerdict
+	var err error
+	switch guardrail.Protocol {
+	case "", v1alpha1.GuardrailHTTP:
+		verdict, err = gc.checkHTTP(ctx, guardrail.Endpoint, request)
+	case v1alpha1.GuardrailGRPC:
+		verdict, err = gc.checkGRPC(ctx, guardrail.Endpoint, request)
+	default:
+		err = fmt.Errorf("unknown guardrail protocol %q", guardrail.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch verdict.Action {
+	case guardrailAllow, guardrailAnnotate, guardrailBlock:
+	case guardrailRedact:
+		if len(verdict.Content) != len(request.Content) {
+			return nil, fmt.Errorf("redact verdict with %d texts for %d checked texts", len(verdict.Content), len(request.Content))
+		}
+	default:
+		return nil, fmt.Errorf("unknown guardrail action %q", verdict.Action)
+	}
+	return verdict, nil
+}
+
+// checkHTTP posts the check request to an HTTP guard service, which answers with the verdict
+func (gc *guardrailClients) checkHTTP(ctx context.Context, endpoint string, request *guardrailCheckRequest) (*guardrailVerdict, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gc.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("guard service returned status %d", resp.StatusCode)
+	}
+	var verdict guardrailVerdict
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxGuardrailResponseSize)).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("invalid verdict: %v", err)
+	}
+	return &verdict, nil
+}
+
+// checkGRPC calls the check method of a gRPC guard service
+func (gc *guardrailClients) checkGRPC(ctx context.Context, endpoint string, request *guardrailCheckRequest) (*guardrailVerdict, error) {
+	conn, err := gc.grpcConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	in := &structpb.Struct{}
+	if err := protojson.Unmarshal(body, in); err != nil {
+		return nil, err
+	}
+	out := &structpb.Struct{}
+	if err := conn.Invoke(ctx, GuardrailCheckMethod, in, out); err != nil {
+		return nil, err
+	}
+	body, err = protojson.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	var verdict guardrailVerdict
+	if err := json.Unmarshal(body, &verdict); err != nil {
+		return nil, fmt.Errorf("invalid verdict: %v", err)
+	}
+	return &verdict, nil
+}
+
+// grpcConn returns the connection to a gRPC guard service, created on first use
+func (gc *guardrailClients) grpcConn(endpoint string) (*grpc.ClientConn, error) {
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+	if conn, ok := gc.grpcConns[endpoint]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	gc.grpcConns[endpoint] = conn
+	return conn, nil
+}
+
+// guardrailsOfPhase returns the guardrails of a ModelRoute checking a phase. A guardrail without
+// phases checks both the requests and the responses.
+func guardrailsOfPhase(guardrails []v1alpha1.Guardrail, phase v1alpha1.GuardrailPhase) []*v1alpha1.Guardrail {
+	var selected []*v1alpha1.Guardrail
+	for i := range guardrails {
+		if len(guardrails[i].Phases) == 0 || slices.Contains(guardrails[i].Phases, phase) {
+			selected = append(selected, &guardrails[i])
+		}
+	}
+	return selected
+}
+
+// runGuardrails checks texts with guardrails, in order, and records their verdicts. It returns the texts,
+// redacted by the guardrails, and a *guardrailRejection if a guardrail blocks them or fails without
+// failing open.
+func (r *Router) runGuardrails(
+	c *gin.Context,
+	modelRoute *v1alpha1.ModelRoute,
+	guardrails []*v1alpha1.Guardrail,
+	phase v1alpha1.GuardrailPhase,
+	model string,
+	texts []string,
+) ([]string, error) {
+	routeName := fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name)
+	phaseName := strings.ToLower(string(phase))
+	requestID := c.Request.Header.Get("x-request-id")
+
+	for _, guardrail := range guardrails {
+		request := &guardrailCheckRequest{
+			Phase:      phaseName,
+			Model:      model,
+			ModelRoute: routeName,
+			RequestID:  requestID,
+			Content:    texts,
+		}
+		start := time.Now()
+		verdict, err := r.guardrails.check(c.Request.Context(), guardrail, request)
+		if err != nil {
+			r.metrics.RecordGuardrailCheck(model, routeName, guardrail.Name, phaseName, guardrailError, time.Since(start))
+			klog.V(2).Infof("guardrail %s of model route %s failed to check the %s of request %s: %v",
+				guardrail.Name, routeName, phaseName, requestID, err)
+			action := guardrailAllow
+			if !guardrail.FailOpen {
+				action = guardrailBlock
+			}
+			accesslog.AddGuardrailVerdict(c, accesslog.GuardrailVerdict{
+				Name:   guardrail.Name,
+				Phase:  phaseName,
+				Action: action,
+				Error:  err.Error(),
+			})
+			if guardrail.FailOpen {
+				continue
+			}
+			return texts, &guardrailRejection{
+				status:  http.StatusServiceUnavailable,
+				message: fmt.Sprintf("guardrail %s is unavailable", guardrail.Name),
+			}
+		}
+
+		r.metrics.RecordGuardrailCheck(model, routeName, guardrail.Name, phaseName, verdict.Action, time.Since(start))
+		accesslog.AddGuardrailVerdict(c, accesslog.GuardrailVerdict{
+			Name:        guardrail.Name,
+			Phase:       phaseName,
+			Action:      verdict.Action,
+			Reason:      verdict.Reason,
+			Annotations: verdict.Annotations,
+		})
+		switch verdict.Action {
+		case guardrailBlock:
+			message := fmt.Sprintf("%s blocked by guardrail %s", phaseName, guardrail.Name)
+			if verdict.Reason != "" {
+				message += ": " + verdict.Reason
+			}
+			return texts, &guardrailRejection{status: http.StatusForbidden, message: message}
+		case guardrailRedact:
+			texts = verdict.Content
+		}
+	}
+	return texts, nil
+}
+
+// rejectGuardedRequest answers a request blocked by a guardrail, or whose guardrail failed
+func rejectGuardedRequest(c *gin.Context, err error) {
+	accesslog.SetError(c, "guardrail", err.Error())
+	c.Set("finishReason", "guardrail")
+	status := http.StatusForbidden
+	if rejection, ok := err.(*guardrailRejection); ok {
+		status = rejection.status
+	}
+	c.AbortWithStatusJSON(status, err.Error())
+}
+
+// promptText is a text of the prompt of a request, which can be replaced by its redacted version
+type promptText struct {
+	text string
+	set  func(string)
+}
+
+// promptTexts returns the texts of the prompt of a request: the content of the messages of chat
+// requests, including their text parts, or the prompts of completion requests
+func promptTexts(modelRequest ModelRequest) []promptText {
+	var texts []promptText
+	if messages, ok := modelRequest["messages"].([]interface{}); ok {
+		for _, message := range messages {
+			m, ok := message.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch content := m["content"].(type) {
+			case string:
+				texts = append(texts, promptText{text: content, set: func(s string) { m["content"] = s }})
+			case []interface{}:
+				for _, part := range content {
+					p, ok := part.(map[string]interface{})
+					if !ok || p["type"] != "text" {
+						continue
+					}
+					if text, ok := p["text"].(string); ok {
+						texts = append(texts, promptText{text: text, set: func(s string) { p["text"] = s }})
+					}
+				}
+			}
+		}
+		return texts
+	}
+
+	switch prompt := modelRequest["prompt"].(type) {
+	case string:
+		texts = append(texts, promptText{text: prompt, set: func(s string) { modelRequest["prompt"] = s }})
+	case []interface{}:
+		for i, item := range prompt {
+			if text, ok := item.(string); ok {
+				texts = append(texts, promptText{text: text, set: func(s string) { prompt[i] = s }})
+			}
+		}
+	}
+	return texts
+}
+
+// checkRequestGuardrails runs the request guardrails of a ModelRoute on the prompt of a request, and
+// redacts it if a guardrail asks for it. It returns false if the request is rejected, its response is
+// then written.
+func (r *Router) checkRequestGuardrails(c *gin.Context, modelRequest ModelRequest, modelRoute *v1alpha1.ModelRoute) bool {
+	guardrails := guardrailsOfPhase(modelRoute.Spec.Guardrails, v1alpha1.GuardrailRequest)
+	if len(guardrails) == 0 {
+		return true
+	}
+	prompt := promptTexts(modelRequest)
+	if len(prompt) == 0 {
+		return true
+	}
+	texts := make([]string, len(prompt))
+	for i := range prompt {
+		texts[i] = prompt[i].text
+	}
+
+	model, _ := modelRequest["model"].(string)
+	checked, err := r.runGuardrails(c, modelRoute, guardrails, v1alpha1.GuardrailRequest, model, texts)
+	if err != nil {
+		rejectGuardedRequest(c, err)
+		return false
+	}
+	redacted := false
+	for i := range prompt {
+		if checked[i] != prompt[i].text {
+			prompt[i].set(checked[i])
+			redacted = true
+		}
+	}
+	if redacted {
+		markRequestModified(c)
+	}
+	return true
+}
+
+// guardResponse installs a writer running the response guardrails of a ModelRoute on the completion
+// of a request. It returns a function flushing the guarded response, to be called once the request is
+// served, or nil if the response is not guarded.
+func (r *Router) guardResponse(c *gin.Context, modelRequest ModelRequest, modelRoute *v1alpha1.ModelRoute) func() {
+	guardrails := guardrailsOfPhase(modelRoute.Spec.Guardrails, v1alpha1.GuardrailResponse)
+	if len(guardrails) == 0 {
+		return nil
+	}
+	switch utils.GetEndpoint(c.Request.URL.Path) {
+	case common.EndpointCompletions, common.EndpointChatCompletions:
+	default:
+		return nil
+	}
+
+	model, _ := modelRequest["model"].(string)
+	chunkSize := defaultGuardrailStreamChunkSize
+	for _, guardrail := range guardrails {
+		// The stream is checked in chunks of the smallest size asked for
+		if guardrail.StreamChunkSize != nil && int(*guardrail.StreamChunkSize) < chunkSize {
+			chunkSize = int(*guardrail.StreamChunkSize)
+		}
+	}
+	writer := &guardrailWriter{
+		ResponseWriter: c.Writer,
+		chunkSize:      chunkSize,
+		check: func(texts []string) ([]string, error) {
+			return r.runGuardrails(c, modelRoute, guardrails, v1alpha1.GuardrailResponse, model, texts)
+		},
+		reject: func(err error) {
+			accesslog.SetError(c, "guardrail", err.Error())
+			c.Set("finishReason", "guardrail")
+			// A rejected response is not cached
+			c.Abort()
+		},
+	}
+	c.Writer = writer
+	return func() {
+		writer.Close()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+type guardrailWriterMode int
+
+const (
+	guardrailUndecided guardrailWriterMode = iota
+	// guardrailPassthrough writes the failed responses unchecked
+	guardrailPassthrough
+	// guardrailBuffered checks a response as a whole once it is complete
+	guardrailBuffered
+	// guardrailStreaming checks the events of a stream in chunks
+	guardrailStreaming
+)
+
+// guardedEvent is an event of a stream held until its text is checked
+type guardedEvent struct {
+	raw []byte
+	// chunk is the decoded completion chunk, nil for the other events
+	chunk map[string]interface{}
+	// texts are the texts of the chunk by choice index
+	texts map[int]string
+}
+
+// guardrailWriter holds the completion written to the client until the response guardrails have
+// checked it. A non-streaming response is checked once complete. A stream is checked in chunks, every
+// time the events held have at least chunkSize characters of text, and at its end.
+type guardrailWriter struct {
+	gin.ResponseWriter
+
+	chunkSize int
+	check     func(texts []string) ([]string, error)
+	reject    func(err error)
+
+	mode guardrailWriterMode
+	// buf holds a non-streaming response, or the incomplete event of a stream
+	buf bytes.Buffer
+	// pending are the events of a stream held for the next check
+	pending     []*guardedEvent
+	pendingSize int
+	blocked     bool
+	closed      bool
+}
+
+var _ gin.ResponseWriter = &guardrailWriter{}
+
+// decide selects how the response is checked once its status and headers are known
+func (w *guardrailWriter) decide() {
+	if w.mode != guardrailUndecided {
+		return
+	}
+	switch {
+	case w.Status() != http.StatusOK:
+		w.mode = guardrailPassthrough
+	case strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"):
+		w.mode = guardrailStreaming
+	default:
+		w.mode = guardrailBuffered
+		// The checked response may be rewritten
+		w.Header().Del("Content-Length")
+	}
+}
+
+func (w *guardrailWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.closed || w.mode == guardrailPassthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	if w.blocked {
+		// The rest of a blocked stream is dropped
+		return len(data), nil
+	}
+	w.buf.Write(data)
+	if w.mode == guardrailBuffered {
+		return len(data), nil
+	}
+
+	for {
+		event, complete := nextEvent(&w.buf)
+		if !complete {
+			break
+		}
+		if err := w.addEvent(event); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *guardrailWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends the checked data only, a non-streaming response is held until it is complete
+func (w *guardrailWriter) Flush() {
+	w.decide()
+	if w.mode == guardrailBuffered && !w.closed {
+		return
+	}
+	w.ResponseWriter.Flush()
+}
+
+// nextEvent removes the next complete event of a stream from a buffer
+func nextEvent(buf *bytes.Buffer) ([]byte, bool) {
+	data := buf.Bytes()
+	end := bytes.Index(data, []byte("\n\n"))
+	if end < 0 {
+		return nil, false
+	}
+	event := bytes.Clone(data[:end+2])
+	buf.Next(end + 2)
+	return event, true
+}
+
+// addEvent holds an event of a stream, and checks the events held once they have enough text
+func (w *guardrailWriter) addEvent(raw []byte) error {
+	payload, ok := eventData(raw)
+	if ok && payload == "[DONE]" {
+		if err := w.release(); err != nil || w.blocked {
+			return err
+		}
+		return w.writeEvents([][]byte{raw})
+	}
+
+	event := &guardedEvent{raw: raw}
+	if ok {
+		var chunk map[string]interface{}
+		if json.Unmarshal([]byte(payload), &chunk) == nil {
+			event.chunk = chunk
+			event.texts = choiceTexts(chunk)
+		}
+	}
+	w.pending = append(w.pending, event)
+	for _, text := range event.texts {
+		w.pendingSize += len([]rune(text))
+	}
+	if w.pendingSize >= w.chunkSize {
+		return w.release()
+	}
+	return nil
+}
+
+// release checks the text of the events held, and writes them redacted, or blocks the stream
+func (w *guardrailWriter) release() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	pending := w.pending
+	w.pending = nil
+	w.pendingSize = 0
+
+	// The texts of every choice are checked
+	var indexes []int
+	texts := make(map[int]string)
+	for _, event := range pending {
+		for index, text := range event.texts {
+			if _, ok := texts[index]; !ok {
+				indexes = append(indexes, index)
+			}
+			texts[index] += text
+		}
+	}
+	slices.Sort(indexes)
+	checkedTexts := make([]string, len(indexes))
+	for i, index := range indexes {
+		checkedTexts[i] = texts[index]
+	}
+
+	events := make([][]byte, 0, len(pending))
+	if len(indexes) > 0 {
+		checked, err := w.check(checkedTexts)
+		if err != nil {
+			w.blocked = true
+			w.reject(err)
+			errorEvent, _ := json.Marshal(map[string]interface{}{
+				"error": map[string]interface{}{"message": err.Error(), "type": "guardrail_blocked"},
+			})
+			return w.writeEvents([][]byte{
+				[]byte("data: " + string(errorEvent) + "\n\n"),
+				[]byte("data: [DONE]\n\n"),
+			})
+		}
+		// The redacted text of a choice replaces the text of its first event, the text of the others is removed
+		redacted := make(map[int]string)
+		for i, index := range indexes {
+			if checked[i] != checkedTexts[i] {
+				redacted[index] = checked[i]
+			}
+		}
+		for _, event := range pending {
+			rewritten := false
+			for index := range event.texts {
+				text, ok := redacted[index]
+				if !ok {
+					continue
+				}
+				setChoiceText(event.chunk, index, text)
+				redacted[index] = ""
+				rewritten = true
+			}
+			if rewritten {
+				payload, err := json.Marshal(event.chunk)
+				if err != nil {
+					return err
+				}
+				event.raw = []byte("data: " + string(payload) + "\n\n")
+			}
+		}
+	}
+	for _, event := range pending {
+		events = append(events, event.raw)
+	}
+	return w.writeEvents(events)
+}
+
+func (w *guardrailWriter) writeEvents(events [][]byte) error {
+	for _, event := range events {
+		if _, err := w.ResponseWriter.Write(event); err != nil {
+			return err
+		}
+	}
+	w.ResponseWriter.Flush()
+	return nil
+}
+
+// Close checks and writes the rest of the response. It must be called once the router has written the response.
+func (w *guardrailWriter) Close() {
+	if w.closed {
+		return
+	}
+	defer func() { w.closed = true }()
+
+	switch w.mode {
+	case guardrailStreaming:
+		if w.blocked {
+			return
+		}
+		// A stream cut short may end with an incomplete event
+		if w.buf.Len() > 0 {
+			event := bytes.Clone(w.buf.Bytes())
+			w.buf.Reset()
+			if err := w.addEvent(event); err != nil {
+				return
+			}
+		}
+		_ = w.release()
+	case guardrailBuffered:
+		body := w.buf.Bytes()
+		checked, err := w.checkResponse(body)
+		if err != nil {
+			w.reject(err)
+			status := http.StatusForbidden
+			if rejection, ok := err.(*guardrailRejection); ok {
+				status = rejection.status
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			checked, _ = json.Marshal(err.Error())
+		}
+		_, _ = w.ResponseWriter.Write(checked)
+	}
+}
+
+// checkResponse checks the texts of the choices of a completion, and returns it redacted
+func (w *guardrailWriter) checkResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body, nil
+	}
+	texts := choiceTexts(response)
+	if len(texts) == 0 {
+		return body, nil
+	}
+	indexes := make([]int, 0, len(texts))
+	for index := range texts {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	checkedTexts := make([]string, len(indexes))
+	for i, index := range indexes {
+		checkedTexts[i] = texts[index]
+	}
+
+	checked, err := w.check(checkedTexts)
+	if err != nil {
+		return nil, err
+	}
+	redacted := false
+	for i, index := range indexes {
+		if checked[i] != checkedTexts[i] {
+			setChoiceText(response, index, checked[i])
+			redacted = true
+		}
+	}
+	if !redacted {
+		return body, nil
+	}
+	return json.Marshal(response)
+}
+
+// eventData returns the data of a server-sent event
+func eventData(event []byte) (string, bool) {
+	for _, line := range strings.Split(string(event), "\n") {
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			return strings.TrimSpace(data), true
+		}
+	}
+	return "", false
+}
+
+// choiceTexts returns the texts of the choices of a completion or of a completion chunk by index:
+// the content of the message or of the delta of chat completions, the text of completions
+func choiceTexts(completion map[string]interface{}) map[int]string {
+	choices, _ := completion["choices"].([]interface{})
+	texts := make(map[int]string)
+	for i, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		index := i
+		if value, ok := choice["index"].(float64); ok {
+			index = int(value)
+		}
+		if text, ok := choiceContent(choice); ok && text != "" {
+			texts[index] += text
+		}
+	}
+	return texts
+}
+
+func choiceContent(choice map[string]interface{}) (string, bool) {
+	for _, key := range []string{"message", "delta"} {
+		if message, ok := choice[key].(map[string]interface{}); ok {
+			content, ok := message["content"].(string)
+			return content, ok
+		}
+	}
+	text, ok := choice["text"].(string)
+	return text, ok
+}
+
+// setChoiceText replaces the text of the choice of a completion with an index
+func setChoiceText(completion map[string]interface{}, index int, text string) {
+	choices, _ := completion["choices"].([]interface{})
+	for i, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		choiceIndex := i
+		if value, ok := choice["index"].(float64); ok {
+			choiceIndex = int(value)
+		}
+		if choiceIndex != index {
+			continue
+		}
+		for _, key := range []string{"message", "delta"} {
+			if message, ok := choice[key].(map[string]interface{}); ok {
+				message["content"] = text
+				return
+			}
+		}
+		choice["text"] = text
+		return
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+)
+
+// stubGuardVerdict is the verdict of the stub guard services: texts with "forbidden" are blocked,
+// "secret" is redacted, and the other texts are annotated with their phase
+func stubGuardVerdict(request *guardrailCheckRequest) *guardrailVerdict {
+	content := strings.Join(request.Content, "\n")
+	switch {
+	case strings.Contains(content, "forbidden"):
+		return &guardrailVerdict{Action: guardrailBlock, Reason: "forbidden content"}
+	case strings.Contains(content, "secret"):
+		redacted := make([]string, len(request.Content))
+		for i, text := range request.Content {
+			redacted[i] = strings.ReplaceAll(text, "secret", "******")
+		}
+		return &guardrailVerdict{Action: guardrailRedact, Content: redacted}
+	default:
+		return &guardrailVerdict{Action: guardrailAnnotate, Annotations: map[string]string{"checked": request.Phase}}
+	}
+}
+
+// startGRPCGuard starts a gRPC guard service answering with the stub verdicts
+func startGRPCGuard(t *testing.T) string {
+	check := func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := &structpb.Struct{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		body, err := protojson.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		var request guardrailCheckRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			return nil, err
+		}
+		body, err = json.Marshal(stubGuardVerdict(&request))
+		if err != nil {
+			return nil, err
+		}
+		out := &structpb.Struct{}
+		return out, protojson.Unmarshal(body, out)
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "kthena.guardrail.v1.Guard",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Check", Handler: check}},
+	}, struct{}{})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestPromptTexts(t *testing.T) {
+	chat := decodeModelRequest(t, `{"model": "m", "messages": [
+		{"role": "system", "content": "be nice"},
+		{"role": "user", "content": [{"type": "text", "text": "hello"}, {"type": "image_url", "image_url": {"url": "x"}}]}
+	]}`)
+	texts := promptTexts(chat)
+	require.Len(t, texts, 2)
+	assert.Equal(t, "be nice", texts[0].text)
+	assert.Equal(t, "hello", texts[1].text)
+	texts[1].set("redacted")
+	parts := chat["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, "redacted", parts[0].(map[string]interface{})["text"])
+
+	completion := decodeModelRequest(t, `{"model": "m", "prompt": ["a", "b"]}`)
+	texts = promptTexts(completion)
+	require.Len(t, texts, 2)
+	texts[1].set("c")
+	assert.Equal(t, []interface{}{"a", "c"}, completion["prompt"])
+
+	assert.Empty(t, promptTexts(decodeModelRequest(t, `{"model": "m", "input": "embed me"}`)))
+}
+
+func TestRouter_HandlerFunc_Guardrails(t *testing.T) {
+	checks := make(chan *guardrailCheckRequest, 100)
+	httpGuard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request guardrailCheckRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		checks <- &request
+		if strings.Contains(strings.Join(request.Content, "\n"), "unavailable") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(stubGuardVerdict(&request))
+	}))
+	defer httpGuard.Close()
+	grpcGuard := startGRPCGuard(t)
+
+	tests := []struct {
+		name         string
+		guardrail    aiv1alpha1.Guardrail
+		prompt       string
+		completion   string
+		stream       bool
+		wantStatus   int
+		wantUpstream string
+		wantBody     []string
+		notWantBody  []string
+		wantVerdicts []string
+		wantChecks   int
+	}{
+		{
+			name:         "annotated request and response",
+			prompt:       "hi",
+			completion:   "hello world",
+			wantStatus:   http.StatusOK,
+			wantUpstream: "hi",
+			wantBody:     []string{"hello world"},
+			wantVerdicts: []string{"moderation:request:annotate", "moderation:response:annotate"},
+			wantChecks:   2,
+		},
+		{
+			name:         "blocked request",
+			prompt:       "a forbidden question",
+			completion:   "hello world",
+			wantStatus:   http.StatusForbidden,
+			wantBody:     []string{"request blocked by guardrail moderation: forbidden content"},
+			wantVerdicts: []string{"moderation:request:block"},
+			wantChecks:   1,
+		},
+		{
+			name:         "redacted request",
+			prompt:       "my secret",
+			completion:   "hello world",
+			wantStatus:   http.StatusOK,
+			wantUpstream: "my ******",
+			wantVerdicts: []string{"moderation:request:redact", "moderation:response:annotate"},
+			wantChecks:   2,
+		},
+		{
+			name:         "redacted response",
+			prompt:       "hi",
+			completion:   "the secret word",
+			wantStatus:   http.StatusOK,
+			wantUpstream: "hi",
+			wantBody:     []string{"the ****** word"},
+			notWantBody:  []string{"secret"},
+			wantVerdicts: []string{"moderation:request:annotate", "moderation:response:redact"},
+			wantChecks:   2,
+		},
+		{
+			name:         "blocked response",
+			prompt:       "hi",
+			completion:   "a forbidden answer",
+			wantStatus:   http.StatusForbidden,
+			wantUpstream: "hi",
+			wantBody:     []string{"response blocked by guardrail moderation"},
+			notWantBody:  []string{"a forbidden answer"},
+			wantVerdicts: []string{"moderation:request:annotate", "moderation:response:block"},
+			wantChecks:   2,
+		},
+		{
+			name:         "redacted stream",
+			prompt:       "hi",
+			completion:   "a secret",
+			stream:       true,
+			wantStatus:   http.StatusOK,
+			wantUpstream: "hi",
+			wantBody:     []string{`"content":"a ******"`, "data: [DONE]"},
+			notWantBody:  []string{"secret"},
+			wantVerdicts: []string{"moderation:request:annotate", "moderation:response:redact"},
+			wantChecks:   2,
+		},
+		{
+			name:         "blocked stream",
+			prompt:       "hi",
+			completion:   "a forbidden answer",
+			stream:       true,
+			wantStatus:   http.StatusOK,
+			wantUpstream: "hi",
+			wantBody:     []string{"guardrail_blocked", "data: [DONE]"},
+			notWantBody:  []string{"forbidden answer"},
+			wantVerdicts: []string{"moderation:request:annotate", "moderation:response:block"},
+			wantChecks:   2,
+		},
+		{
+			name:         "stream checked in chunks",
+			guardrail:    aiv1alpha1.Guardrail{StreamChunkSize: ptr.To(int32(2)), Phases: []aiv1alpha1.GuardrailPhase{aiv1alpha1.GuardrailResponse}},
+			prompt:       "hi",
+			completion:   "hello world",
+			stream:       true,
+			wantStatus:   http.StatusOK,
+			wantUpstream: "hi",
+			wantBody:     []string{`"content":"he"`, `"content":"llo world"`, "data: [DONE]"},
+			wantVerdicts: []string{"moderation:response:annotate"},
+			wantChecks:   2,
+		},
+		{
+			name:         "unavailable guard",
+			prompt:       "unavailable",
+			completion:   "hello world",
+			wantStatus:   http.StatusServiceUnavailable,
+			wantVerdicts: []string{"moderation:request:block"},
+			wantChecks:   1,
+		},
+		{
+			name:         "unavailable guard failing open",
+			guardrail:    aiv1alpha1.Guardrail{FailOpen: true, Phases: []aiv1alpha1.GuardrailPhase{aiv1alpha1.GuardrailRequest}},
+			prompt:       "unavailable",
+			completion:   "hello world",
+			wantStatus:   http.StatusOK,
+			wantUpstream: "unavailable",
+			wantVerdicts: []string{"moderation:request:allow"},
+			wantChecks:   1,
+		},
+		{
+			name:         "grpc guard",
+			guardrail:    aiv1alpha1.Guardrail{Protocol: aiv1alpha1.GuardrailGRPC, Endpoint: grpcGuard},
+			prompt:       "my secret",
+			completion:   "the secret word",
+			wantStatus:   http.StatusOK,
+			wantUpstream: "my ******",
+			wantBody:     []string{"the ****** word"},
+			wantVerdicts: []string{"moderation:request:redact", "moderation:response:redact"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan ModelRequest, 1)
+			router, store, backend := setupTestRouter(chatCompletionHandler(tt.completion, 0, requests))
+			defer backend.Close()
+
+			guardrail := tt.guardrail
+			guardrail.Name = "moderation"
+			if guardrail.Endpoint == "" {
+				guardrail.Endpoint = httpGuard.URL
+			}
+			addModelServerWithBackends(t, store, "ms-1", "served-model", backend)
+			require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+				ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+				Spec: aiv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*aiv1alpha1.Rule{
+						{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+					},
+					Guardrails: []aiv1alpha1.Guardrail{guardrail},
+				},
+			}))
+
+			// Streaming needs a recorder implementing CloseNotify
+			w := connectors.CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := fmt.Sprintf(`{"model": "test-model", "messages": [{"role": "user", "content": %q}], "stream": %t}`, tt.prompt, tt.stream)
+			c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			logCtx := accesslog.NewAccessLogContext("req-1", "POST", "/v1/chat/completions", "HTTP/1.1", "")
+			c.Set(accesslog.AccessLogContextKey, logCtx)
+
+			router.HandlerFunc()(c)
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			select {
+			case upstream := <-requests:
+				messages := upstream["messages"].([]interface{})
+				assert.Equal(t, tt.wantUpstream, messages[0].(map[string]interface{})["content"])
+			default:
+				assert.Empty(t, tt.wantUpstream, "the request was not proxied")
+			}
+			for _, want := range tt.wantBody {
+				assert.Contains(t, w.Body.String(), want)
+			}
+			for _, notWant := range tt.notWantBody {
+				assert.NotContains(t, w.Body.String(), notWant)
+			}
+
+			verdicts := make([]string, 0, len(logCtx.Guardrails))
+			for _, verdict := range logCtx.Guardrails {
+				verdicts = append(verdicts, fmt.Sprintf("%s:%s:%s", verdict.Name, verdict.Phase, verdict.Action))
+			}
+			assert.Equal(t, tt.wantVerdicts, verdicts)
+			assert.Equal(t, logCtx.Guardrails, logCtx.ToAccessLogEntry(w.Code).Guardrails)
+
+			if tt.guardrail.Protocol != aiv1alpha1.GuardrailGRPC {
+				assert.Len(t, checks, tt.wantChecks)
+			}
+			for len(checks) > 0 {
+				check := <-checks
+				assert.Equal(t, "test-model", check.Model)
+				assert.Equal(t, "default/mr-1", check.ModelRoute)
+			}
+		})
+	}
+}
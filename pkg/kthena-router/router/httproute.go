@@ -0,0 +1,714 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+const (
+	// Context keys used while handling HTTPRoutes
+	matchedPrefixKey         = "matchedPrefix"
+	responseHeaderFiltersKey = "responseHeaderFilters"
+
+	inferencePoolGroup = "inference.networking.k8s.io"
+	inferencePoolKind  = "InferencePool"
+	serviceKind        = "Service"
+
+	// mirrorRequestTimeout bounds the lifetime of a mirrored request, its response is always discarded
+	mirrorRequestTimeout = 60 * time.Second
+)
+
+// httpRouteBackend is the backend selected for a request matched by an HTTPRoute
+type httpRouteBackend struct {
+	// Kind is either InferencePool or Service
+	Kind string
+	Name types.NamespacedName
+	// Port is the Service port, InferencePools use their target port instead
+	Port int32
+}
+
+// httpRouteMatch is a candidate match of a request against a single HTTPRouteMatch
+type httpRouteMatch struct {
+	route      *gatewayv1.HTTPRoute
+	rule       *gatewayv1.HTTPRouteRule
+	ruleIndex  int
+	matchIndex int
+	// match is the matched HTTPRouteMatch, a rule without matches is treated as a "/" prefix match
+	match *gatewayv1.HTTPRouteMatch
+	// matchedPrefix is the path prefix matched by a PathPrefix match, used by URL rewrites
+	matchedPrefix string
+	// hostname is the most specific route hostname matching the request host, empty if the route has no hostnames
+	hostname string
+}
+
+// regexCache caches compiled regular expressions of HTTPRoute matches
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// handleHTTPRoute matches the request against the HTTPRoutes attached to the Gateway, applies the
+// filters of the matched rule and selects one of its weighted backendRefs.
+// Returns false if no HTTPRoute matched. If a route matched but the request could not be routed,
+// the request is aborted and a nil backend is returned.
+func (r *Router) handleHTTPRoute(c *gin.Context, gatewayKey string, modelRequest ModelRequest) (*httpRouteBackend, bool) {
+	// Find HTTPRoutes for this Gateway
+	httpRoutes := r.store.GetHTTPRoutesByGateway(gatewayKey)
+	if len(httpRoutes) == 0 {
+		return nil, false
+	}
+
+	// Only routes attached to the listener that accepted the request are considered
+	if listenerName := c.GetString(ListenerKey); listenerName != "" {
+		attached := make([]*gatewayv1.HTTPRoute, 0, len(httpRoutes))
+		for _, route := range httpRoutes {
+			if route != nil && routeAttachedToListener(route, gatewayKey, listenerName) {
+				attached = append(attached, route)
+			}
+		}
+		httpRoutes = attached
+	}
+
+	matched := matchHTTPRoutes(c.Request, httpRoutes)
+	if matched == nil {
+		return nil, false
+	}
+	klog.V(4).Infof("Request %s %s matched HTTPRoute %s/%s rule %d", c.Request.Method, c.Request.URL.Path,
+		matched.route.Namespace, matched.route.Name, matched.ruleIndex)
+
+	// Store the matched prefix in context for URL rewriting
+	if matched.matchedPrefix != "" {
+		c.Set(matchedPrefixKey, matched.matchedPrefix)
+	}
+
+	backendRef, err := selectHTTPBackendRef(matched.rule.BackendRefs)
+	if err != nil {
+		klog.Errorf("HTTPRoute %s/%s rule %d: %v", matched.route.Namespace, matched.route.Name, matched.ruleIndex, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return nil, true
+	}
+	backend, err := resolveHTTPBackend(matched.route.Namespace, &backendRef.BackendRef)
+	if err != nil {
+		klog.Errorf("HTTPRoute %s/%s rule %d: %v", matched.route.Namespace, matched.route.Name, matched.ruleIndex, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return nil, true
+	}
+
+	// Rule filters are applied first, then the filters of the selected backendRef
+	filters := make([]gatewayv1.HTTPRouteFilter, 0, len(matched.rule.Filters)+len(backendRef.Filters))
+	filters = append(filters, matched.rule.Filters...)
+	filters = append(filters, backendRef.Filters...)
+	r.applyHTTPRouteFilters(c, matched.route.Namespace, filters, modelRequest)
+
+	return backend, true
+}
+
+// routeAttachedToListener checks whether one of the parentRefs of the route targets the listener
+func routeAttachedToListener(route *gatewayv1.HTTPRoute, gatewayKey, listenerName string) bool {
+	for _, parentRef := range route.Spec.ParentRefs {
+		if parentRef.Kind != nil && *parentRef.Kind != "Gateway" {
+			continue
+		}
+		namespace := route.Namespace
+		if parentRef.Namespace != nil {
+			namespace = string(*parentRef.Namespace)
+		}
+		if fmt.Sprintf("%s/%s", namespace, parentRef.Name) != gatewayKey {
+			continue
+		}
+		if parentRef.SectionName == nil || string(*parentRef.SectionName) == listenerName {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRouteHostname returns the most specific hostname of the route matching the request host.
+// A route without hostnames matches every host.
+func matchRouteHostname(route *gatewayv1.HTTPRoute, host string) (string, bool) {
+	if len(route.Spec.Hostnames) == 0 {
+		return "", true
+	}
+	best, found := "", false
+	for _, hostname := range route.Spec.Hostnames {
+		h := string(hostname)
+		if !utils.HostnameMatches(h, host) {
+			continue
+		}
+		if !found || utils.HostnameSpecificity(h) > utils.HostnameSpecificity(best) {
+			best, found = h, true
+		}
+	}
+	return best, found
+}
+
+// matchHTTPRoutes returns the most precise match of the request across all rules of all routes,
+// following the Gateway API precedence rules. Returns nil if nothing matched.
+func matchHTTPRoutes(req *http.Request, routes []*gatewayv1.HTTPRoute) *httpRouteMatch {
+	host := utils.NormalizeHost(req.Host)
+	var candidates []*httpRouteMatch
+	for _, route := range routes {
+		if route == nil {
+			continue
+		}
+		hostname, ok := matchRouteHostname(route, host)
+		if !ok {
+			continue
+		}
+		for i := range route.Spec.Rules {
+			rule := &route.Spec.Rules[i]
+			if len(rule.Matches) == 0 {
+				// An empty match list is equivalent to a single "/" prefix match
+				candidates = append(candidates, &httpRouteMatch{route: route, rule: rule, ruleIndex: i, hostname: hostname})
+				continue
+			}
+			for j := range rule.Matches {
+				match := &rule.Matches[j]
+				ok, prefix := matchHTTPRouteMatch(req, match)
+				if !ok {
+					continue
+				}
+				candidates = append(candidates, &httpRouteMatch{
+					route:         route,
+					rule:          rule,
+					ruleIndex:     i,
+					matchIndex:    j,
+					match:         match,
+					matchedPrefix: prefix,
+					hostname:      hostname,
+				})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return morePreciseMatch(candidates[i], candidates[j])
+	})
+	return candidates[0]
+}
+
+// matchHTTPRouteMatch checks whether the request satisfies all conditions of the match.
+// It also returns the matched path prefix for PathPrefix matches.
+func matchHTTPRouteMatch(req *http.Request, match *gatewayv1.HTTPRouteMatch) (bool, string) {
+	ok, prefix := matchPath(req.URL.Path, match.Path)
+	if !ok {
+		return false, ""
+	}
+
+	if match.Method != nil && req.Method != string(*match.Method) {
+		return false, ""
+	}
+
+	for _, headerMatch := range match.Headers {
+		// Header names are case-insensitive
+		values, exists := req.Header[http.CanonicalHeaderKey(string(headerMatch.Name))]
+		if !exists || len(values) == 0 {
+			return false, ""
+		}
+		if !matchValue(headerMatch.Type, headerMatch.Value, values[0]) {
+			return false, ""
+		}
+	}
+
+	if len(match.QueryParams) > 0 {
+		query := req.URL.Query()
+		for _, queryMatch := range match.QueryParams {
+			// Query parameter names are case-sensitive
+			values, exists := query[string(queryMatch.Name)]
+			if !exists || len(values) == 0 {
+				return false, ""
+			}
+			if !matchValue(queryMatch.Type, queryMatch.Value, values[0]) {
+				return false, ""
+			}
+		}
+	}
+
+	return true, prefix
+}
+
+// matchPath checks the request path against an HTTPPathMatch, a nil match defaults to the "/" prefix.
+func matchPath(path string, pathMatch *gatewayv1.HTTPPathMatch) (bool, string) {
+	matchType := gatewayv1.PathMatchPathPrefix
+	value := "/"
+	if pathMatch != nil {
+		if pathMatch.Type != nil {
+			matchType = *pathMatch.Type
+		}
+		if pathMatch.Value != nil {
+			value = *pathMatch.Value
+		}
+	}
+
+	switch matchType {
+	case gatewayv1.PathMatchExact:
+		return path == value, ""
+	case gatewayv1.PathMatchPathPrefix:
+		// Prefixes are matched element-wise, "/foo" matches "/foo" and "/foo/bar" but not "/foobar"
+		prefix := strings.TrimSuffix(value, "/")
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true, value
+		}
+		return false, ""
+	case gatewayv1.PathMatchRegularExpression:
+		re, err := compileRegex(value)
+		if err != nil {
+			klog.Warningf("Invalid regex pattern '%s' in HTTPRoute path match: %v", value, err)
+			return false, ""
+		}
+		return re.MatchString(path), ""
+	default:
+		return false, ""
+	}
+}
+
+// matchValue matches a header or query parameter value. Both header and query parameter
+// match types share the Exact and RegularExpression values.
+func matchValue[T ~string](matchType *T, expected, actual string) bool {
+	if matchType != nil && string(*matchType) == string(gatewayv1.HeaderMatchRegularExpression) {
+		re, err := compileRegex(expected)
+		if err != nil {
+			klog.Warningf("Invalid regex pattern '%s' in HTTPRoute match: %v", expected, err)
+			return false
+		}
+		return re.MatchString(actual)
+	}
+	return actual == expected
+}
+
+// morePreciseMatch reports whether a takes precedence over b, as defined by the Gateway API:
+//  1. Largest number of characters in a matching non-wildcard hostname
+//  2. Largest number of characters in a matching wildcard hostname
+//  3. Exact path match
+//  4. Prefix path match with the largest number of characters
+//  5. Method match
+//  6. Largest number of header matches
+//  7. Largest number of query param matches
+//
+// Ties are broken by the oldest route, then by route namespace/name, then by rule and match order.
+// Regular expression path matches are implementation specific and rank below prefix matches.
+func morePreciseMatch(a, b *httpRouteMatch) bool {
+	if aHost, bHost := utils.HostnameSpecificity(a.hostname), utils.HostnameSpecificity(b.hostname); aHost != bHost {
+		return aHost > bHost
+	}
+
+	aRank, aLen := pathPrecedence(a.match)
+	bRank, bLen := pathPrecedence(b.match)
+	if aRank != bRank {
+		return aRank > bRank
+	}
+	if aLen != bLen {
+		return aLen > bLen
+	}
+
+	aMethod := a.match != nil && a.match.Method != nil
+	bMethod := b.match != nil && b.match.Method != nil
+	if aMethod != bMethod {
+		return aMethod
+	}
+
+	if aHeaders, bHeaders := countHeaderMatches(a.match), countHeaderMatches(b.match); aHeaders != bHeaders {
+		return aHeaders > bHeaders
+	}
+	if aQuery, bQuery := countQueryParamMatches(a.match), countQueryParamMatches(b.match); aQuery != bQuery {
+		return aQuery > bQuery
+	}
+
+	if a.route != b.route {
+		aTime, bTime := a.route.CreationTimestamp, b.route.CreationTimestamp
+		if !aTime.Equal(&bTime) {
+			return aTime.Before(&bTime)
+		}
+		aKey := a.route.Namespace + "/" + a.route.Name
+		bKey := b.route.Namespace + "/" + b.route.Name
+		if aKey != bKey {
+			return aKey < bKey
+		}
+	}
+	if a.ruleIndex != b.ruleIndex {
+		return a.ruleIndex < b.ruleIndex
+	}
+	return a.matchIndex < b.matchIndex
+}
+
+// pathPrecedence returns the rank of the path match type and the length of its value
+func pathPrecedence(match *gatewayv1.HTTPRouteMatch) (int, int) {
+	if match == nil || match.Path == nil {
+		return 2, 1 // default "/" prefix
+	}
+	value := "/"
+	if match.Path.Value != nil {
+		value = *match.Path.Value
+	}
+	matchType := gatewayv1.PathMatchPathPrefix
+	if match.Path.Type != nil {
+		matchType = *match.Path.Type
+	}
+	switch matchType {
+	case gatewayv1.PathMatchExact:
+		return 3, len(value)
+	case gatewayv1.PathMatchPathPrefix:
+		return 2, len(value)
+	default:
+		return 1, len(value)
+	}
+}
+
+func countHeaderMatches(match *gatewayv1.HTTPRouteMatch) int {
+	if match == nil {
+		return 0
+	}
+	return len(match.Headers)
+}
+
+func countQueryParamMatches(match *gatewayv1.HTTPRouteMatch) int {
+	if match == nil {
+		return 0
+	}
+	return len(match.QueryParams)
+}
+
+// selectHTTPBackendRef picks one of the backendRefs randomly, proportionally to their weights.
+// A backendRef without weight has weight 1, and a backendRef with weight 0 never receives traffic.
+func selectHTTPBackendRef(backendRefs []gatewayv1.HTTPBackendRef) (*gatewayv1.HTTPBackendRef, error) {
+	if len(backendRefs) == 0 {
+		return nil, fmt.Errorf("no backendRefs configured")
+	}
+
+	total := int64(0)
+	for i := range backendRefs {
+		total += int64(backendWeight(&backendRefs[i]))
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("all backendRefs have zero weight")
+	}
+
+	pick := rand.Int63n(total)
+	for i := range backendRefs {
+		pick -= int64(backendWeight(&backendRefs[i]))
+		if pick < 0 {
+			return &backendRefs[i], nil
+		}
+	}
+	return &backendRefs[len(backendRefs)-1], nil
+}
+
+func backendWeight(backendRef *gatewayv1.HTTPBackendRef) int32 {
+	if backendRef.Weight == nil {
+		return 1
+	}
+	if *backendRef.Weight < 0 {
+		return 0
+	}
+	return *backendRef.Weight
+}
+
+// resolveHTTPBackend converts a BackendRef to an InferencePool or Service backend
+func resolveHTTPBackend(routeNamespace string, ref *gatewayv1.BackendRef) (*httpRouteBackend, error) {
+	namespace := routeNamespace
+	if ref.Namespace != nil && *ref.Namespace != "" {
+		namespace = string(*ref.Namespace)
+	}
+	group := ""
+	if ref.Group != nil {
+		group = string(*ref.Group)
+	}
+	kind := serviceKind
+	if ref.Kind != nil {
+		kind = string(*ref.Kind)
+	}
+
+	backend := &httpRouteBackend{
+		Name: types.NamespacedName{Namespace: namespace, Name: string(ref.Name)},
+	}
+	switch {
+	case group == inferencePoolGroup && kind == inferencePoolKind:
+		backend.Kind = inferencePoolKind
+	case group == "" && kind == serviceKind:
+		if ref.Port == nil {
+			return nil, fmt.Errorf("port is required for Service backend %s", backend.Name)
+		}
+		backend.Kind = serviceKind
+		backend.Port = int32(*ref.Port)
+	default:
+		return nil, fmt.Errorf("unsupported backend %s/%s %s", group, kind, backend.Name)
+	}
+	return backend, nil
+}
+
+// serviceHost returns the in-cluster DNS name of a Service
+func serviceHost(name types.NamespacedName) string {
+	return fmt.Sprintf("%s.%s.svc", name.Name, name.Namespace)
+}
+
+// applyHTTPRouteFilters applies the supported HTTPRoute filters to the request.
+// ResponseHeaderModifier filters are stored in the context and applied once the upstream response arrives.
+func (r *Router) applyHTTPRouteFilters(c *gin.Context, routeNamespace string, filters []gatewayv1.HTTPRouteFilter, modelRequest ModelRequest) {
+	var responseHeaderFilters []*gatewayv1.HTTPHeaderFilter
+	var mirrors []*gatewayv1.HTTPRequestMirrorFilter
+	for i := range filters {
+		filter := &filters[i]
+		switch filter.Type {
+		case gatewayv1.HTTPRouteFilterRequestHeaderModifier:
+			if filter.RequestHeaderModifier != nil {
+				applyHeaderFilter(c.Request.Header, filter.RequestHeaderModifier)
+			}
+		case gatewayv1.HTTPRouteFilterResponseHeaderModifier:
+			if filter.ResponseHeaderModifier != nil {
+				responseHeaderFilters = append(responseHeaderFilters, filter.ResponseHeaderModifier)
+			}
+		case gatewayv1.HTTPRouteFilterURLRewrite:
+			if filter.URLRewrite != nil {
+				r.applyURLRewrite(c, filter.URLRewrite)
+			}
+		case gatewayv1.HTTPRouteFilterRequestMirror:
+			if filter.RequestMirror != nil {
+				mirrors = append(mirrors, filter.RequestMirror)
+			}
+		default:
+			klog.V(4).Infof("HTTPRoute filter %s is not supported, ignoring", filter.Type)
+		}
+	}
+
+	if len(responseHeaderFilters) > 0 {
+		c.Set(responseHeaderFiltersKey, responseHeaderFilters)
+	}
+
+	// Mirrors see the request after all other request filters have been applied
+	for _, mirror := range mirrors {
+		r.mirrorRequest(c, routeNamespace, mirror, modelRequest)
+	}
+}
+
+// applyHeaderFilter applies the set, add and remove operations of an HTTPHeaderFilter
+func applyHeaderFilter(header http.Header, filter *gatewayv1.HTTPHeaderFilter) {
+	for _, h := range filter.Set {
+		header.Set(string(h.Name), h.Value)
+	}
+	for _, h := range filter.Add {
+		header.Add(string(h.Name), h.Value)
+	}
+	for _, name := range filter.Remove {
+		header.Del(name)
+	}
+}
+
+// applyResponseHeaderFilters applies the ResponseHeaderModifier filters of the matched HTTPRoute rule
+// to the downstream response headers. Must be called before the response status is written.
+func applyResponseHeaderFilters(c *gin.Context) {
+	v, exists := c.Get(responseHeaderFiltersKey)
+	if !exists {
+		return
+	}
+	filters, ok := v.([]*gatewayv1.HTTPHeaderFilter)
+	if !ok {
+		return
+	}
+	for _, filter := range filters {
+		applyHeaderFilter(c.Writer.Header(), filter)
+	}
+}
+
+// mirrorRequest sends a copy of the request to the mirror backend in the background.
+// The mirrored response is discarded and never affects the original request.
+func (r *Router) mirrorRequest(c *gin.Context, routeNamespace string, mirror *gatewayv1.HTTPRequestMirrorFilter, modelRequest ModelRequest) {
+	if !shouldMirror(mirror) {
+		return
+	}
+
+	backend, err := resolveHTTPBackend(routeNamespace, &gatewayv1.BackendRef{BackendObjectReference: mirror.BackendRef})
+	if err != nil {
+		klog.Errorf("failed to resolve mirror backend: %v", err)
+		return
+	}
+
+	host, err := r.resolveMirrorHost(backend)
+	if err != nil {
+		klog.Errorf("failed to resolve mirror backend %s: %v", backend.Name, err)
+		return
+	}
+
+	// Encode synchronously, the model request is modified later while proxying the original request
+	body, err := connectors.EncodeRequestBody(c, modelRequest, nil)
+	if err != nil {
+		klog.Errorf("failed to marshal mirrored request: %v", err)
+		return
+	}
+
+	mirrorURL := *c.Request.URL
+	mirrorURL.Scheme = "http"
+	mirrorURL.Host = host
+	header := c.Request.Header.Clone()
+	method := c.Request.Method
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mirrorRequestTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, method, mirrorURL.String(), bytes.NewReader(body))
+		if err != nil {
+			klog.Errorf("failed to build mirrored request: %v", err)
+			return
+		}
+		req.Header = header
+		req.ContentLength = int64(len(body))
+		resp, err := connectors.UpstreamTransport().RoundTrip(req)
+		if err != nil {
+			klog.V(4).Infof("mirrored request to %s failed: %v", host, err)
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		klog.V(4).Infof("mirrored request to %s returned %d", host, resp.StatusCode)
+	}()
+}
+
+// shouldMirror decides whether this request is mirrored according to the mirror percentage or fraction
+func shouldMirror(mirror *gatewayv1.HTTPRequestMirrorFilter) bool {
+	switch {
+	case mirror.Percent != nil:
+		return rand.Int31n(100) < *mirror.Percent
+	case mirror.Fraction != nil:
+		denominator := int32(100)
+		if mirror.Fraction.Denominator != nil {
+			denominator = *mirror.Fraction.Denominator
+		}
+		if denominator <= 0 {
+			return false
+		}
+		return rand.Int31n(denominator) < mirror.Fraction.Numerator
+	default:
+		return true
+	}
+}
+
+// resolveMirrorHost returns the host:port a mirrored request is sent to.
+// InferencePool mirrors go to a random ready pod of the pool, without running the scheduler.
+func (r *Router) resolveMirrorHost(backend *httpRouteBackend) (string, error) {
+	if backend.Kind == serviceKind {
+		return fmt.Sprintf("%s:%d", serviceHost(backend.Name), backend.Port), nil
+	}
+
+	inferencePool := r.store.GetInferencePool(backend.Name.String())
+	if inferencePool == nil {
+		return "", fmt.Errorf("inference pool not found")
+	}
+	if len(inferencePool.Spec.TargetPorts) == 0 {
+		return "", fmt.Errorf("inference pool has no target ports")
+	}
+	pods, err := r.store.GetPodsByInferencePool(backend.Name)
+	if err != nil || len(pods) == 0 {
+		return "", fmt.Errorf("no pods available: %v", err)
+	}
+	pod := pods[rand.Intn(len(pods))]
+	return fmt.Sprintf("%s:%d", pod.Pod.Status.PodIP, inferencePool.Spec.TargetPorts[0].Number), nil
+}
+
+// applyURLRewrite applies HTTPURLRewriteFilter to the request
+func (r *Router) applyURLRewrite(c *gin.Context, urlRewrite *gatewayv1.HTTPURLRewriteFilter) {
+	// Apply hostname rewrite
+	if urlRewrite.Hostname != nil {
+		newHostname := string(*urlRewrite.Hostname)
+		c.Request.Host = newHostname
+		klog.V(4).Infof("Rewrote hostname to: %s", newHostname)
+	}
+
+	// Apply path rewrite
+	if urlRewrite.Path != nil {
+		originalPath := c.Request.URL.Path
+		newPath := originalPath
+
+		switch urlRewrite.Path.Type {
+		case gatewayv1.FullPathHTTPPathModifier:
+			// Replace the full path
+			if urlRewrite.Path.ReplaceFullPath != nil {
+				newPath = *urlRewrite.Path.ReplaceFullPath
+				klog.V(4).Infof("Rewrote full path from %s to %s", originalPath, newPath)
+			}
+
+		case gatewayv1.PrefixMatchHTTPPathModifier:
+			// Replace the matched prefix with the specified replacement
+			if urlRewrite.Path.ReplacePrefixMatch != nil {
+				// Get the matched prefix from context
+				prefix, exists := c.Get(matchedPrefixKey)
+				if !exists {
+					klog.Errorf("matchedPrefix not found in context for path rewrite")
+					break
+				}
+				matchedPrefix, ok := prefix.(string)
+				if !ok || matchedPrefix == "" {
+					klog.Errorf("matchedPrefix is not a valid string in context")
+					break
+				}
+				newPath = replacePathPrefix(originalPath, matchedPrefix, *urlRewrite.Path.ReplacePrefixMatch)
+				klog.V(4).Infof("Rewrote path prefix from %s to %s (matched prefix: %s)", originalPath, newPath, matchedPrefix)
+			}
+		}
+
+		// Update the request path
+		c.Request.URL.Path = newPath
+		// Also update the raw path to maintain consistency
+		c.Request.URL.RawPath = ""
+	}
+}
+
+// replacePathPrefix replaces the matched prefix of the path, avoiding duplicated or missing slashes
+// at the boundary, e.g. prefix "/foo" replaced by "/" turns "/foo/bar" into "/bar".
+func replacePathPrefix(path, prefix, replacement string) string {
+	rest := strings.TrimPrefix(path, strings.TrimSuffix(prefix, "/"))
+	switch {
+	case rest == "":
+		if replacement == "" {
+			return "/"
+		}
+		return replacement
+	case strings.HasSuffix(replacement, "/") && strings.HasPrefix(rest, "/"):
+		return replacement + rest[1:]
+	default:
+		return replacement + rest
+	}
+}
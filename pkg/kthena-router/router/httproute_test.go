@@ -0,0 +1,375 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+func pathMatch(matchType gatewayv1.PathMatchType, value string) *gatewayv1.HTTPPathMatch {
+	return &gatewayv1.HTTPPathMatch{Type: &matchType, Value: &value}
+}
+
+func poolBackendRef(name string, weight *int32) gatewayv1.HTTPBackendRef {
+	group := gatewayv1.Group(inferencePoolGroup)
+	kind := gatewayv1.Kind(inferencePoolKind)
+	return gatewayv1.HTTPBackendRef{BackendRef: gatewayv1.BackendRef{
+		BackendObjectReference: gatewayv1.BackendObjectReference{Group: &group, Kind: &kind, Name: gatewayv1.ObjectName(name)},
+		Weight:                 weight,
+	}}
+}
+
+func newTestHTTPRoute(name string, created time.Time, rules ...gatewayv1.HTTPRouteRule) *gatewayv1.HTTPRoute {
+	gatewayKind := gatewayv1.Kind("Gateway")
+	return &gatewayv1.HTTPRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: name, CreationTimestamp: v1.NewTime(created)},
+		Spec: gatewayv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Kind: &gatewayKind, Name: "gw"}},
+			},
+			Rules: rules,
+		},
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		match      *gatewayv1.HTTPPathMatch
+		want       bool
+		wantPrefix string
+	}{
+		{name: "nil match defaults to root prefix", path: "/anything", match: nil, want: true, wantPrefix: "/"},
+		{name: "exact", path: "/foo", match: pathMatch(gatewayv1.PathMatchExact, "/foo"), want: true},
+		{name: "exact mismatch", path: "/foo/", match: pathMatch(gatewayv1.PathMatchExact, "/foo"), want: false},
+		{name: "prefix equal", path: "/foo", match: pathMatch(gatewayv1.PathMatchPathPrefix, "/foo"), want: true, wantPrefix: "/foo"},
+		{name: "prefix element", path: "/foo/bar", match: pathMatch(gatewayv1.PathMatchPathPrefix, "/foo"), want: true, wantPrefix: "/foo"},
+		{name: "prefix trailing slash", path: "/foo/bar", match: pathMatch(gatewayv1.PathMatchPathPrefix, "/foo/"), want: true, wantPrefix: "/foo/"},
+		{name: "prefix is not a string prefix", path: "/foobar", match: pathMatch(gatewayv1.PathMatchPathPrefix, "/foo"), want: false},
+		{name: "regex", path: "/v1/models/abc", match: pathMatch(gatewayv1.PathMatchRegularExpression, "^/v1/models/[a-z]+$"), want: true},
+		{name: "invalid regex", path: "/v1", match: pathMatch(gatewayv1.PathMatchRegularExpression, "("), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, prefix := matchPath(tt.path, tt.match)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPrefix, prefix)
+		})
+	}
+}
+
+func TestMatchHTTPRoutesPrecedence(t *testing.T) {
+	now := time.Now()
+	get := gatewayv1.HTTPMethodGet
+	regexType := gatewayv1.HeaderMatchRegularExpression
+
+	routes := []*gatewayv1.HTTPRoute{
+		newTestHTTPRoute("newer", now, gatewayv1.HTTPRouteRule{
+			Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchPathPrefix, "/api")}},
+		}),
+		newTestHTTPRoute("older", now.Add(-time.Hour),
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchPathPrefix, "/api")}},
+			},
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchPathPrefix, "/api/v2")}},
+			},
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchExact, "/api/v2/exact")}},
+			},
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchPathPrefix, "/api/v2"), Method: &get}},
+			},
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{
+					Path:    pathMatch(gatewayv1.PathMatchPathPrefix, "/api/v2"),
+					Headers: []gatewayv1.HTTPHeaderMatch{{Name: "x-tenant", Value: "^gold-.*", Type: &regexType}},
+				}},
+			},
+			gatewayv1.HTTPRouteRule{
+				Matches: []gatewayv1.HTTPRouteMatch{{
+					Path:        pathMatch(gatewayv1.PathMatchPathPrefix, "/api/v2"),
+					QueryParams: []gatewayv1.HTTPQueryParamMatch{{Name: "debug", Value: "true"}},
+				}},
+			},
+		),
+		newTestHTTPRoute("catch-all", now.Add(-2*time.Hour), gatewayv1.HTTPRouteRule{}),
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		header    http.Header
+		wantRoute string
+		wantRule  int
+	}{
+		{name: "oldest route wins a tie", method: http.MethodPost, target: "/api/v1", wantRoute: "older", wantRule: 0},
+		{name: "longest prefix wins", method: http.MethodPost, target: "/api/v2/chat", wantRoute: "older", wantRule: 1},
+		{name: "exact path wins over prefix", method: http.MethodGet, target: "/api/v2/exact", wantRoute: "older", wantRule: 2},
+		{name: "method match wins over headers", method: http.MethodGet, target: "/api/v2/chat?debug=true", header: http.Header{"X-Tenant": {"gold-a"}}, wantRoute: "older", wantRule: 3},
+		{name: "header match wins over query params", method: http.MethodPost, target: "/api/v2/chat?debug=true", header: http.Header{"X-Tenant": {"gold-a"}}, wantRoute: "older", wantRule: 4},
+		{name: "header regex mismatch", method: http.MethodPost, target: "/api/v2/chat?debug=true", header: http.Header{"X-Tenant": {"silver"}}, wantRoute: "older", wantRule: 5},
+		{name: "rule without matches catches everything else", method: http.MethodPost, target: "/other", wantRoute: "catch-all", wantRule: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			matched := matchHTTPRoutes(req, routes)
+			require.NotNil(t, matched)
+			assert.Equal(t, tt.wantRoute, matched.route.Name)
+			assert.Equal(t, tt.wantRule, matched.ruleIndex)
+		})
+	}
+
+	assert.Nil(t, matchHTTPRoutes(httptest.NewRequest(http.MethodGet, "/api", nil), nil))
+}
+
+func TestMatchHTTPRoutesHostnames(t *testing.T) {
+	now := time.Now()
+	withHostnames := func(route *gatewayv1.HTTPRoute, hostnames ...gatewayv1.Hostname) *gatewayv1.HTTPRoute {
+		route.Spec.Hostnames = hostnames
+		return route
+	}
+	exactPath := gatewayv1.HTTPRouteRule{
+		Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchExact, "/v1/chat/completions")}},
+	}
+
+	routes := []*gatewayv1.HTTPRoute{
+		// The oldest route has the most precise path but no hostname, so any hostname match beats it
+		newTestHTTPRoute("no-hostname", now.Add(-time.Hour), exactPath),
+		withHostnames(newTestHTTPRoute("wildcard", now, gatewayv1.HTTPRouteRule{}), "*.example.com"),
+		withHostnames(newTestHTTPRoute("longer-wildcard", now, gatewayv1.HTTPRouteRule{}), "*.models.example.com"),
+		withHostnames(newTestHTTPRoute("exact", now, gatewayv1.HTTPRouteRule{}), "other.org", "llama.models.example.com"),
+	}
+
+	tests := []struct {
+		host      string
+		wantRoute string
+	}{
+		{host: "llama.models.example.com", wantRoute: "exact"},
+		{host: "llama.models.example.com:8080", wantRoute: "exact"},
+		{host: "qwen.models.example.com", wantRoute: "longer-wildcard"},
+		{host: "models.example.com", wantRoute: "wildcard"},
+		{host: "example.com", wantRoute: "no-hostname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			req.Host = tt.host
+			matched := matchHTTPRoutes(req, routes)
+			require.NotNil(t, matched)
+			assert.Equal(t, tt.wantRoute, matched.route.Name)
+		})
+	}
+
+	// Routes whose hostnames do not match the request host are ignored
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Host = "example.com"
+	assert.Nil(t, matchHTTPRoutes(req, routes[1:]))
+}
+
+func TestRouteAttachedToListener(t *testing.T) {
+	section := gatewayv1.SectionName("https")
+	otherNamespace := gatewayv1.Namespace("infra")
+
+	route := newTestHTTPRoute("route", time.Now())
+	assert.True(t, routeAttachedToListener(route, "default/gw", "http"))
+	assert.True(t, routeAttachedToListener(route, "default/gw", "https"))
+	assert.False(t, routeAttachedToListener(route, "default/other", "http"))
+
+	route.Spec.ParentRefs[0].SectionName = &section
+	assert.False(t, routeAttachedToListener(route, "default/gw", "http"))
+	assert.True(t, routeAttachedToListener(route, "default/gw", "https"))
+
+	route.Spec.ParentRefs[0].Namespace = &otherNamespace
+	assert.False(t, routeAttachedToListener(route, "default/gw", "https"))
+	assert.True(t, routeAttachedToListener(route, "infra/gw", "https"))
+}
+
+func TestSelectHTTPBackendRef(t *testing.T) {
+	zero, one, three := int32(0), int32(1), int32(3)
+
+	_, err := selectHTTPBackendRef(nil)
+	assert.Error(t, err)
+
+	_, err = selectHTTPBackendRef([]gatewayv1.HTTPBackendRef{poolBackendRef("a", &zero)})
+	assert.Error(t, err)
+
+	counts := map[gatewayv1.ObjectName]int{}
+	backendRefs := []gatewayv1.HTTPBackendRef{
+		poolBackendRef("a", &one),
+		poolBackendRef("b", &three),
+		poolBackendRef("never", &zero),
+	}
+	for i := 0; i < 4000; i++ {
+		backendRef, err := selectHTTPBackendRef(backendRefs)
+		require.NoError(t, err)
+		counts[backendRef.Name]++
+	}
+	assert.Zero(t, counts["never"])
+	assert.InDelta(t, 1000, counts["a"], 200)
+	assert.InDelta(t, 3000, counts["b"], 200)
+
+	// A missing weight defaults to 1
+	backendRef, err := selectHTTPBackendRef([]gatewayv1.HTTPBackendRef{poolBackendRef("default", nil)})
+	require.NoError(t, err)
+	assert.Equal(t, gatewayv1.ObjectName("default"), backendRef.Name)
+}
+
+func TestResolveHTTPBackend(t *testing.T) {
+	port := gatewayv1.PortNumber(8000)
+	otherNamespace := gatewayv1.Namespace("other")
+
+	poolRef := poolBackendRef("pool", nil)
+	backend, err := resolveHTTPBackend("default", &poolRef.BackendRef)
+	require.NoError(t, err)
+	assert.Equal(t, inferencePoolKind, backend.Kind)
+	assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "pool"}, backend.Name)
+
+	backend, err = resolveHTTPBackend("default", &gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{
+		Name: "svc", Namespace: &otherNamespace, Port: &port,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, serviceKind, backend.Kind)
+	assert.Equal(t, int32(8000), backend.Port)
+	assert.Equal(t, "svc.other.svc", serviceHost(backend.Name))
+
+	_, err = resolveHTTPBackend("default", &gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{Name: "svc"}})
+	assert.Error(t, err, "Service backends require a port")
+
+	unknownKind := gatewayv1.Kind("ConfigMap")
+	_, err = resolveHTTPBackend("default", &gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{Name: "cm", Kind: &unknownKind}})
+	assert.Error(t, err)
+}
+
+func TestApplyHeaderFilter(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Keep", "keep")
+	header.Set("X-Replace", "old")
+	header.Set("X-Remove", "remove")
+
+	applyHeaderFilter(header, &gatewayv1.HTTPHeaderFilter{
+		Set:    []gatewayv1.HTTPHeader{{Name: "x-replace", Value: "new"}},
+		Add:    []gatewayv1.HTTPHeader{{Name: "X-Keep", Value: "added"}},
+		Remove: []string{"x-remove"},
+	})
+
+	assert.Equal(t, []string{"keep", "added"}, header.Values("X-Keep"))
+	assert.Equal(t, "new", header.Get("X-Replace"))
+	assert.Empty(t, header.Values("X-Remove"))
+}
+
+func TestReplacePathPrefix(t *testing.T) {
+	assert.Equal(t, "/v2/chat", replacePathPrefix("/api/chat", "/api", "/v2"))
+	assert.Equal(t, "/v2/chat", replacePathPrefix("/api/chat", "/api", "/v2/"))
+	assert.Equal(t, "/chat", replacePathPrefix("/api/chat", "/api", "/"))
+	assert.Equal(t, "/", replacePathPrefix("/api", "/api", ""))
+	assert.Equal(t, "/v2/chat", replacePathPrefix("/chat", "/", "/v2"))
+}
+
+func TestShouldMirror(t *testing.T) {
+	zero, hundred := int32(0), int32(100)
+	assert.True(t, shouldMirror(&gatewayv1.HTTPRequestMirrorFilter{}))
+	assert.True(t, shouldMirror(&gatewayv1.HTTPRequestMirrorFilter{Percent: &hundred}))
+	assert.False(t, shouldMirror(&gatewayv1.HTTPRequestMirrorFilter{Percent: &zero}))
+	assert.False(t, shouldMirror(&gatewayv1.HTTPRequestMirrorFilter{Fraction: &gatewayv1.Fraction{Numerator: 0}}))
+	assert.True(t, shouldMirror(&gatewayv1.HTTPRequestMirrorFilter{Fraction: &gatewayv1.Fraction{Numerator: 10, Denominator: ptrInt32(10)}}))
+}
+
+func ptrInt32(v int32) *int32 {
+	return &v
+}
+
+func TestHandleHTTPRouteAppliesFilters(t *testing.T) {
+	store := datastore.New()
+	r := &Router{store: store}
+
+	route := newTestHTTPRoute("route", time.Now(), gatewayv1.HTTPRouteRule{
+		Matches: []gatewayv1.HTTPRouteMatch{{Path: pathMatch(gatewayv1.PathMatchPathPrefix, "/api")}},
+		Filters: []gatewayv1.HTTPRouteFilter{
+			{
+				Type: gatewayv1.HTTPRouteFilterRequestHeaderModifier,
+				RequestHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+					Set:    []gatewayv1.HTTPHeader{{Name: "x-route", Value: "route"}},
+					Remove: []string{"x-secret"},
+				},
+			},
+			{
+				Type: gatewayv1.HTTPRouteFilterURLRewrite,
+				URLRewrite: &gatewayv1.HTTPURLRewriteFilter{Path: &gatewayv1.HTTPPathModifier{
+					Type:               gatewayv1.PrefixMatchHTTPPathModifier,
+					ReplacePrefixMatch: func(s string) *string { return &s }("/v1"),
+				}},
+			},
+			{
+				Type: gatewayv1.HTTPRouteFilterResponseHeaderModifier,
+				ResponseHeaderModifier: &gatewayv1.HTTPHeaderFilter{
+					Set:    []gatewayv1.HTTPHeader{{Name: "x-served-by", Value: "kthena"}},
+					Remove: []string{"server"},
+				},
+			},
+		},
+		BackendRefs: []gatewayv1.HTTPBackendRef{poolBackendRef("pool", nil)},
+	})
+	require.NoError(t, store.AddOrUpdateHTTPRoute(route))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/chat/completions", nil)
+	c.Request.Header.Set("x-secret", "secret")
+
+	backend, matched := r.handleHTTPRoute(c, "default/gw", ModelRequest{"model": "m"})
+	require.True(t, matched)
+	require.NotNil(t, backend)
+	assert.Equal(t, inferencePoolKind, backend.Kind)
+	assert.Equal(t, types.NamespacedName{Namespace: "default", Name: "pool"}, backend.Name)
+
+	assert.Equal(t, "route", c.Request.Header.Get("x-route"))
+	assert.Empty(t, c.Request.Header.Get("x-secret"))
+	assert.Equal(t, "/v1/chat/completions", c.Request.URL.Path)
+
+	// Response headers are modified after the upstream headers are copied
+	c.Header("Server", "vllm")
+	applyResponseHeaderFilters(c)
+	assert.Equal(t, "kthena", w.Header().Get("x-served-by"))
+	assert.Empty(t, w.Header().Get("Server"))
+
+	// Requests not matching any route fall through
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/other", nil)
+	_, matched = r.handleHTTPRoute(c, "default/gw", ModelRequest{"model": "m"})
+	assert.False(t, matched)
+}
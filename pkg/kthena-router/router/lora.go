@@ -0,0 +1,397 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workload "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+const (
+	loadLoraAdapterPath   = "/v1/load_lora_adapter"
+	unloadLoraAdapterPath = "/v1/unload_lora_adapter"
+	// loraPollInterval is how often the models of a pod are listed while waiting for an adapter to be loaded
+	loraPollInterval = 500 * time.Millisecond
+)
+
+// loraLoader loads the LoRA adapters of the ModelRoutes on the pods on demand, through the adapter API of
+// the engine. When a pod holds the maximum number of adapters, its least recently used adapters are
+// unloaded to make room for the new one. The adapters serving requests and the adapters declared on a
+// ModelBooster, which the ModelBooster controller places on the pods, are never unloaded.
+type loraLoader struct {
+	config       conf.LoraConfiguration
+	store        datastore.Store
+	client       *http.Client
+	pollInterval time.Duration
+
+	mu sync.Mutex
+	// lastUsed is the last time a request was sent to an adapter of a pod
+	lastUsed map[types.NamespacedName]map[string]time.Time
+	// inFlight is the number of requests being served by an adapter of a pod
+	inFlight map[types.NamespacedName]map[string]int
+	// loading holds the loads in progress, the requests for an adapter being loaded on a pod wait for the same load
+	loading map[loraLoadKey]*loraLoad
+	// evicting holds the adapters being unloaded from a pod, they are not used by new requests
+	evicting map[types.NamespacedName]sets.Set[string]
+	// podLocks serializes the loads on a pod, so that the adapters they evict and load are counted together
+	podLocks map[types.NamespacedName]*sync.Mutex
+}
+
+type loraLoadKey struct {
+	pod     types.NamespacedName
+	adapter string
+}
+
+type loraLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// loraAdapterRequest is the body of the load and unload requests of the vLLM adapter API
+type loraAdapterRequest struct {
+	LoraName string `json:"lora_name"`
+	LoraPath string `json:"lora_path,omitempty"`
+}
+
+func newLoraLoader(config conf.LoraConfiguration, store datastore.Store) *loraLoader {
+	l := &loraLoader{
+		config:       config,
+		store:        store,
+		client:       &http.Client{Transport: connectors.UpstreamTransport()},
+		pollInterval: loraPollInterval,
+		lastUsed:     make(map[types.NamespacedName]map[string]time.Time),
+		inFlight:     make(map[types.NamespacedName]map[string]int),
+		loading:      make(map[loraLoadKey]*loraLoad),
+		evicting:     make(map[types.NamespacedName]sets.Set[string]),
+		podLocks:     make(map[types.NamespacedName]*sync.Mutex),
+	}
+	store.RegisterCallback("Pod", l.onPodDeleted)
+	return l
+}
+
+// onPodDeleted forgets the adapters of a deleted pod
+func (l *loraLoader) onPodDeleted(data datastore.EventData) {
+	if data.EventType != datastore.EventDelete {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.lastUsed, data.Pod)
+	delete(l.inFlight, data.Pod)
+	delete(l.evicting, data.Pod)
+	delete(l.podLocks, data.Pod)
+}
+
+// Loaded returns true if an adapter is loaded on a pod, and is not being unloaded from it
+func (l *loraLoader) Loaded(pod *datastore.PodInfo, adapter string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return pod.Contains(adapter) && !l.evicting[podName(pod)].Contains(adapter)
+}
+
+// lockPod locks the loads on a pod, it returns the function unlocking them
+func (l *loraLoader) lockPod(name types.NamespacedName) func() {
+	l.mu.Lock()
+	podLock, ok := l.podLocks[name]
+	if !ok {
+		podLock = &sync.Mutex{}
+		l.podLocks[name] = podLock
+	}
+	l.mu.Unlock()
+	podLock.Lock()
+	return podLock.Unlock
+}
+
+// Load loads an adapter on a pod, and waits until the pod lists it in its models, or the load timeout expires.
+// Concurrent requests for the same adapter on the same pod share a single load.
+func (l *loraLoader) Load(ctx context.Context, pod *datastore.PodInfo, port int32, adapter string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.config.LoadTimeout.Duration)
+	defer cancel()
+
+	key := loraLoadKey{pod: podName(pod), adapter: adapter}
+	l.mu.Lock()
+	load, ok := l.loading[key]
+	if !ok {
+		load = &loraLoad{done: make(chan struct{})}
+		l.loading[key] = load
+		go func() {
+			// The load goes on if the request which started it is canceled, other requests may wait for it
+			loadCtx, cancel := context.WithTimeout(context.Background(), l.config.LoadTimeout.Duration)
+			defer cancel()
+			load.err = l.load(loadCtx, pod, port, adapter)
+
+			l.mu.Lock()
+			delete(l.loading, key)
+			l.mu.Unlock()
+			close(load.done)
+		}()
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-load.done:
+		return load.err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for lora adapter %s on pod %s: %w", adapter, key.pod, ctx.Err())
+	}
+}
+
+// Acquire records that a request is sent to an adapter of a pod. The adapter is not unloaded from the pod
+// until the returned function is called, when the request is done.
+func (l *loraLoader) Acquire(pod *datastore.PodInfo, adapter string) func() {
+	name := podName(pod)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[name] == nil {
+		l.inFlight[name] = make(map[string]int)
+	}
+	l.inFlight[name][adapter]++
+	l.touchLocked(name, adapter)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			inFlight, ok := l.inFlight[name]
+			if !ok {
+				// The pod is deleted
+				return
+			}
+			if inFlight[adapter]--; inFlight[adapter] <= 0 {
+				delete(inFlight, adapter)
+			}
+			l.touchLocked(name, adapter)
+		})
+	}
+}
+
+// touch records that a request is sent to an adapter of a pod
+func (l *loraLoader) touch(pod *datastore.PodInfo, adapter string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.touchLocked(podName(pod), adapter)
+}
+
+func (l *loraLoader) touchLocked(name types.NamespacedName, adapter string) {
+	if l.lastUsed[name] == nil {
+		l.lastUsed[name] = make(map[string]time.Time)
+	}
+	l.lastUsed[name][adapter] = time.Now()
+}
+
+func (l *loraLoader) load(ctx context.Context, pod *datastore.PodInfo, port int32, adapter string) error {
+	name := podName(pod)
+	unlock := l.lockPod(name)
+	defer unlock()
+
+	models, err := l.listModels(ctx, pod, port)
+	if err != nil {
+		return fmt.Errorf("failed to list the models of pod %s: %v", name, err)
+	}
+	pod.UpdateModels(modelCardIDs(models))
+	if pod.Contains(adapter) {
+		// Loaded since the models of the pod were last refreshed
+		l.touch(pod, adapter)
+		return nil
+	}
+	if err := l.evict(ctx, pod, port, models); err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("loading lora adapter %s on pod %s", adapter, name)
+	request := loraAdapterRequest{LoraName: adapter, LoraPath: l.adapterPath(adapter)}
+	if err := l.post(ctx, pod, port, loadLoraAdapterPath, request); err != nil {
+		return fmt.Errorf("failed to load lora adapter %s on pod %s: %v", adapter, name, err)
+	}
+
+	// The engine may report the adapter some time after it accepted the load
+	for {
+		models, err := l.listModels(ctx, pod, port)
+		if err == nil {
+			pod.UpdateModels(modelCardIDs(models))
+			if pod.Contains(adapter) {
+				l.touch(pod, adapter)
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for lora adapter %s on pod %s", adapter, name)
+		case <-time.After(l.pollInterval):
+		}
+	}
+}
+
+// evict unloads the least recently used adapters of a pod, so that it holds less than the maximum number of
+// adapters. Adapters which are not known to the router are considered unused. The adapters serving requests
+// and the adapters declared on a ModelBooster are kept, the load fails if there are not enough other adapters.
+func (l *loraLoader) evict(ctx context.Context, pod *datastore.PodInfo, port int32, models []ModelCard) error {
+	name := podName(pod)
+	var adapters []string
+	for _, model := range models {
+		// vLLM reports the base model of the adapters as their parent
+		if model.Parent != "" {
+			adapters = append(adapters, model.ID)
+		}
+	}
+	excess := len(adapters) - l.config.MaxAdaptersPerPod + 1
+	if excess <= 0 {
+		return nil
+	}
+
+	pinned := l.pinnedAdapters(name.Namespace)
+	l.mu.Lock()
+	lastUsed := l.lastUsed[name]
+	inFlight := l.inFlight[name]
+	evictable := make([]string, 0, len(adapters))
+	for _, adapter := range adapters {
+		if inFlight[adapter] == 0 && !pinned.Contains(adapter) {
+			evictable = append(evictable, adapter)
+		}
+	}
+	sort.SliceStable(evictable, func(i, j int) bool {
+		return lastUsed[evictable[i]].Before(lastUsed[evictable[j]])
+	})
+	if len(evictable) < excess {
+		l.mu.Unlock()
+		return fmt.Errorf("pod %s holds %d lora adapters, only %d of them can be unloaded", name, len(adapters), len(evictable))
+	}
+	// Marked in the same critical section as the adapters in flight are checked, so that the requests
+	// acquiring them from now on load them again instead of being sent to the pod while they are unloaded
+	evicted := evictable[:excess]
+	l.evicting[name] = sets.New(evicted...)
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.evicting, name)
+		l.mu.Unlock()
+	}()
+
+	for _, adapter := range evicted {
+		klog.V(2).Infof("unloading least recently used lora adapter %s from pod %s", adapter, name)
+		if err := l.post(ctx, pod, port, unloadLoraAdapterPath, loraAdapterRequest{LoraName: adapter}); err != nil {
+			return fmt.Errorf("failed to unload lora adapter %s from pod %s: %v", adapter, name, err)
+		}
+		pod.RemoveModel(adapter)
+		l.mu.Lock()
+		delete(l.lastUsed[name], adapter)
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// pinnedAdapters returns the adapters declared on the ModelBoosters of a namespace, which are placed on the
+// pods by the ModelBooster controller and must not be unloaded by the router.
+func (l *loraLoader) pinnedAdapters(namespace string) sets.Set[string] {
+	pinned := sets.New[string]()
+	for _, modelRoute := range l.store.GetAllModelRoutes() {
+		if modelRoute.Namespace != namespace {
+			continue
+		}
+		for _, owner := range modelRoute.OwnerReferences {
+			if owner.Kind == workload.ModelKind.Kind {
+				pinned.InsertAll(modelRoute.Spec.LoraAdapters...)
+				break
+			}
+		}
+	}
+	return pinned
+}
+
+// adapterPath returns the path of an adapter sent to the engine
+func (l *loraLoader) adapterPath(adapter string) string {
+	if l.config.AdapterPathPrefix == "" {
+		return adapter
+	}
+	return path.Join(l.config.AdapterPathPrefix, adapter)
+}
+
+func (l *loraLoader) post(ctx context.Context, pod *datastore.PodInfo, port int32, apiPath string, body loraAdapterRequest) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, podURL(pod, port, apiPath), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+func (l *loraLoader) listModels(ctx context.Context, pod *datastore.PodInfo, port int32) ([]ModelCard, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, podURL(pod, port, modelsPath), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+func modelCardIDs(models []ModelCard) []string {
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		ids = append(ids, model.ID)
+	}
+	return ids
+}
+
+func podName(pod *datastore.PodInfo) types.NamespacedName {
+	return types.NamespacedName{Namespace: pod.Pod.Namespace, Name: pod.Pod.Name}
+}
+
+func podURL(pod *datastore.PodInfo, port int32, apiPath string) string {
+	return fmt.Sprintf("http://%s:%d%s", pod.Pod.Status.PodIP, port, apiPath)
+}
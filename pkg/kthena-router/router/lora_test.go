@@ -0,0 +1,284 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networking "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	workload "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
+)
+
+// fakeLoraEngine serves the models and adapter APIs of vLLM. The loaded adapters are listed after loadDelay.
+type fakeLoraEngine struct {
+	mu        sync.Mutex
+	adapters  []string
+	loadDelay time.Duration
+	loads     []loraAdapterRequest
+	unloads   []string
+}
+
+func (e *fakeLoraEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch r.URL.Path {
+	case modelsPath:
+		list := ModelList{Object: modelsListObject, Data: []ModelCard{{ID: "base", Root: "base"}}}
+		for _, adapter := range e.adapters {
+			list.Data = append(list.Data, ModelCard{ID: adapter, Root: "/adapters/" + adapter, Parent: "base"})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case loadLoraAdapterPath:
+		var body loraAdapterRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		e.loads = append(e.loads, body)
+		time.AfterFunc(e.loadDelay, func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.adapters = append(e.adapters, body.LoraName)
+		})
+	case unloadLoraAdapterPath:
+		var body loraAdapterRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		e.unloads = append(e.unloads, body.LoraName)
+		for i, adapter := range e.adapters {
+			if adapter == body.LoraName {
+				e.adapters = append(e.adapters[:i], e.adapters[i+1:]...)
+				break
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (e *fakeLoraEngine) requests() ([]loraAdapterRequest, []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]loraAdapterRequest(nil), e.loads...), append([]string(nil), e.unloads...)
+}
+
+func newLoraTestPod(t *testing.T, engine *fakeLoraEngine) (*datastore.PodInfo, int32) {
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	pod := &datastore.PodInfo{Pod: &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: host},
+	}}
+	pod.UpdateModels([]string{"base"})
+	return pod, int32(portNum)
+}
+
+func TestLoraLoaderLoad(t *testing.T) {
+	engine := &fakeLoraEngine{loadDelay: 50 * time.Millisecond}
+	pod, port := newLoraTestPod(t, engine)
+	loader := newLoraLoader(conf.LoraConfiguration{
+		MaxAdaptersPerPod: 2,
+		LoadTimeout:       metav1.Duration{Duration: 5 * time.Second},
+		AdapterPathPrefix: "/adapters",
+	}, datastore.New())
+	loader.pollInterval = 10 * time.Millisecond
+
+	// Concurrent requests for the adapter wait for a single load
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = loader.Load(context.Background(), pod, port, "sql-lora")
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.True(t, pod.Contains("sql-lora"))
+	loads, _ := engine.requests()
+	assert.Equal(t, []loraAdapterRequest{{LoraName: "sql-lora", LoraPath: "/adapters/sql-lora"}}, loads)
+
+	require.NoError(t, loader.Load(context.Background(), pod, port, "chat-lora"))
+	assert.ElementsMatch(t, []string{"base", "sql-lora", "chat-lora"}, pod.GetModelsList())
+
+	// The pod is full, the least recently used adapter is unloaded to load a new one
+	loader.Acquire(pod, "sql-lora")()
+	require.NoError(t, loader.Load(context.Background(), pod, port, "math-lora"))
+	_, unloads := engine.requests()
+	assert.Equal(t, []string{"chat-lora"}, unloads)
+	assert.ElementsMatch(t, []string{"base", "sql-lora", "math-lora"}, pod.GetModelsList())
+}
+
+func TestLoraLoaderTimeout(t *testing.T) {
+	engine := &fakeLoraEngine{loadDelay: time.Hour}
+	pod, port := newLoraTestPod(t, engine)
+	loader := newLoraLoader(conf.LoraConfiguration{
+		MaxAdaptersPerPod: 2,
+		LoadTimeout:       metav1.Duration{Duration: 100 * time.Millisecond},
+	}, datastore.New())
+	loader.pollInterval = 10 * time.Millisecond
+
+	err := loader.Load(context.Background(), pod, port, "sql-lora")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out waiting for lora adapter sql-lora")
+	assert.False(t, pod.Contains("sql-lora"))
+	// Without a prefix, the engine resolves the adapter by its name
+	loads, _ := engine.requests()
+	assert.Equal(t, []loraAdapterRequest{{LoraName: "sql-lora", LoraPath: "sql-lora"}}, loads)
+}
+
+func TestLoraLoaderConcurrentLoads(t *testing.T) {
+	engine := &fakeLoraEngine{adapters: []string{"sql-lora"}, loadDelay: 20 * time.Millisecond}
+	pod, port := newLoraTestPod(t, engine)
+	loader := newLoraLoader(conf.LoraConfiguration{
+		MaxAdaptersPerPod: 2,
+		LoadTimeout:       metav1.Duration{Duration: 5 * time.Second},
+	}, datastore.New())
+	loader.pollInterval = 10 * time.Millisecond
+
+	// The loads of different adapters on a pod are serialized, the second one makes room for its adapter
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, adapter := range []string{"chat-lora", "math-lora"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = loader.Load(context.Background(), pod, port, adapter)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	_, unloads := engine.requests()
+	assert.Equal(t, []string{"sql-lora"}, unloads)
+	engine.mu.Lock()
+	assert.ElementsMatch(t, []string{"chat-lora", "math-lora"}, engine.adapters)
+	engine.mu.Unlock()
+}
+
+func TestLoraLoaderEvictingNotLoaded(t *testing.T) {
+	engine := &fakeLoraEngine{adapters: []string{"sql-lora"}}
+	unloading := make(chan struct{})
+	unblock := make(chan struct{})
+	// The unload requests are held until the test checks the adapter being unloaded
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == unloadLoraAdapterPath {
+			close(unloading)
+			<-unblock
+		}
+		engine.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	pod, _ := newLoraTestPod(t, engine)
+	_, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	pod.UpdateModels([]string{"base", "sql-lora"})
+
+	loader := newLoraLoader(conf.LoraConfiguration{
+		MaxAdaptersPerPod: 1,
+		LoadTimeout:       metav1.Duration{Duration: 5 * time.Second},
+	}, datastore.New())
+	loader.pollInterval = 10 * time.Millisecond
+	assert.True(t, loader.Loaded(pod, "sql-lora"))
+
+	loaded := make(chan error)
+	go func() {
+		loaded <- loader.Load(context.Background(), pod, int32(port), "chat-lora")
+	}()
+	<-unloading
+	// A request acquiring the adapter being unloaded must load it again
+	release := loader.Acquire(pod, "sql-lora")
+	defer release()
+	assert.True(t, pod.Contains("sql-lora"))
+	assert.False(t, loader.Loaded(pod, "sql-lora"))
+	close(unblock)
+	require.NoError(t, <-loaded)
+	assert.False(t, loader.Loaded(pod, "sql-lora"))
+	assert.True(t, loader.Loaded(pod, "chat-lora"))
+}
+
+func TestLoraLoaderEvictKeepsAdapters(t *testing.T) {
+	engine := &fakeLoraEngine{adapters: []string{"booster-lora", "sql-lora", "chat-lora"}}
+	pod, port := newLoraTestPod(t, engine)
+	store := datastore.New()
+	// The adapters of the ModelBooster are placed by its controller
+	require.NoError(t, store.AddOrUpdateModelRoute(&networking.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "booster",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Kind: workload.ModelKind.Kind, Name: "booster"}},
+		},
+		Spec: networking.ModelRouteSpec{ModelName: "booster", LoraAdapters: []string{"booster-lora"}},
+	}))
+	require.NoError(t, store.AddOrUpdateModelRoute(&networking.ModelRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "default"},
+		Spec:       networking.ModelRouteSpec{ModelName: "base", LoraAdapters: []string{"sql-lora", "chat-lora", "math-lora"}},
+	}))
+	loader := newLoraLoader(conf.LoraConfiguration{
+		MaxAdaptersPerPod: 3,
+		LoadTimeout:       metav1.Duration{Duration: 5 * time.Second},
+	}, store)
+	loader.pollInterval = 10 * time.Millisecond
+
+	// The least recently used adapter serves a request, the other one is unloaded
+	releaseSQL := loader.Acquire(pod, "sql-lora")
+	loader.Acquire(pod, "chat-lora")()
+	require.NoError(t, loader.Load(context.Background(), pod, port, "math-lora"))
+	_, unloads := engine.requests()
+	assert.Equal(t, []string{"chat-lora"}, unloads)
+
+	// All the adapters are in use or declared on a ModelBooster
+	releaseMath := loader.Acquire(pod, "math-lora")
+	err := loader.Load(context.Background(), pod, port, "chat-lora")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only 0 of them can be unloaded")
+
+	// The requests are done
+	releaseSQL()
+	releaseMath()
+	require.NoError(t, loader.Load(context.Background(), pod, port, "chat-lora"))
+	_, unloads = engine.requests()
+	assert.Equal(t, []string{"chat-lora", "sql-lora"}, unloads)
+
+	loader.onPodDeleted(datastore.EventData{EventType: datastore.EventDelete, Pod: podName(pod)})
+	loader.mu.Lock()
+	defer loader.mu.Unlock()
+	assert.Empty(t, loader.lastUsed)
+	assert.Empty(t, loader.inFlight)
+}
@@ -0,0 +1,279 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/handlers"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+// shadowRequest is a copy of a request mirrored to the shadow ModelServer of a ModelRoute rule.
+// It holds everything needed to send it, as the original request may be gone by then.
+type shadowRequest struct {
+	method   string
+	url      string
+	protocol string
+	header   http.Header
+	body     []byte
+	stream   bool
+
+	modelServerName types.NamespacedName
+	pod             string
+
+	// entry is the access log entry of the shadow request, it is only logged if the rule asks for it
+	entry *accesslog.AccessLogEntry
+	// primaryDigest hashes the content of the original response, it is complete once primaryDone is closed
+	primaryDigest *handlers.ContentDigest
+	primaryDone   chan struct{}
+}
+
+// shadowResponse is what is kept of the response to a shadow request
+type shadowResponse struct {
+	digest     *handlers.ContentDigest
+	usage      *handlers.OpenAIResponse
+	firstToken time.Time
+	lastToken  time.Time
+}
+
+// mirrorModelRoute sends a copy of a request matched by a ModelRoute rule to its shadow ModelServer in the
+// background, if the request is sampled. The shadow pod is picked at random without running the scheduler,
+// so that the shadow traffic does not affect the scheduling of the original requests.
+// It returns the function to call once the original response has been forwarded.
+func (r *Router) mirrorModelRoute(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelRoute *v1alpha1.ModelRoute,
+	mirror *v1alpha1.Mirror,
+	isLora bool,
+) func() {
+	noop := func() {}
+	if mirror.Percent != nil && rand.Int31n(100) >= *mirror.Percent {
+		return noop
+	}
+
+	modelServerName := types.NamespacedName{Namespace: modelRoute.Namespace, Name: mirror.ModelServerName}
+	pods, modelServer, err := r.getPodsAndServer(modelServerName)
+	if err != nil {
+		klog.V(4).Infof("failed to mirror request to %v: %v", modelServerName, err)
+		return noop
+	}
+	pod := pods[rand.Intn(len(pods))]
+
+	// Encode synchronously, the model request is modified later while proxying the original request
+	body, err := shadowRequestBody(c, modelRequest, modelServer, isLora)
+	if err != nil {
+		klog.Errorf("failed to marshal mirrored request: %v", err)
+		return noop
+	}
+
+	shadow := &shadowRequest{
+		method:          c.Request.Method,
+		url:             podURL(pod, modelServer.Spec.WorkloadPort.Port, c.Request.URL.RequestURI()),
+		protocol:        modelServer.Spec.WorkloadPort.Protocol,
+		header:          c.Request.Header.Clone(),
+		body:            body,
+		stream:          isStreaming(modelRequest),
+		modelServerName: modelServerName,
+		pod:             pod.Pod.Name,
+	}
+	if mirror.LogResponse {
+		shadow.entry = &accesslog.AccessLogEntry{
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Protocol:    c.Request.Proto,
+			ModelName:   modelRequest["model"].(string),
+			ModelRoute:  fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name),
+			ModelServer: modelServerName.String(),
+			SelectedPod: pod.Pod.Name,
+			RequestID:   c.Request.Header.Get("x-request-id"),
+		}
+		// The original response is hashed as it is forwarded, to be compared with the shadow response
+		shadow.primaryDigest = handlers.NewContentDigest()
+		shadow.primaryDone = make(chan struct{})
+		c.Set(common.ResponseDigestKey, shadow.primaryDigest)
+	}
+
+	go r.sendShadowRequest(shadow)
+
+	if shadow.primaryDone == nil {
+		return noop
+	}
+	return func() { close(shadow.primaryDone) }
+}
+
+// shadowRequestBody returns the body of the shadow request, with the model of the shadow ModelServer.
+// The usage of streams is requested, to log the token counts of the shadow response.
+func shadowRequestBody(c *gin.Context, modelRequest ModelRequest, modelServer *v1alpha1.ModelServer, isLora bool) ([]byte, error) {
+	added := map[string]interface{}{}
+	if model := modelServer.Spec.Model; model != nil && !isLora && *model != modelRequest["model"] {
+		added["model"] = *model
+	}
+	if isStreaming(modelRequest) && utils.GetEndpoint(c.Request.URL.Path) != common.EndpointResponses {
+		streamOptions := map[string]interface{}{}
+		if v, ok := modelRequest["stream_options"].(map[string]interface{}); ok {
+			streamOptions = maps.Clone(v)
+		}
+		if includeUsage, _ := streamOptions["include_usage"].(bool); !includeUsage {
+			streamOptions["include_usage"] = true
+			added["stream_options"] = streamOptions
+		}
+	}
+
+	shadowRequest := maps.Clone(modelRequest)
+	maps.Copy(shadowRequest, added)
+	return connectors.EncodeRequestBody(c, shadowRequest, added)
+}
+
+// sendShadowRequest sends a shadow request and discards its response. If the rule asks for it,
+// the shadow response is compared with the original response in an access log entry.
+func (r *Router) sendShadowRequest(shadow *shadowRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorRequestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := doShadowRequest(ctx, shadow)
+	if err != nil {
+		klog.V(4).Infof("mirrored request to %v failed: %v", shadow.modelServerName, err)
+		r.logShadowRequest(ctx, shadow, start, 0, nil, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if shadow.entry == nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		klog.V(4).Infof("mirrored request to %v returned %d", shadow.modelServerName, resp.StatusCode)
+		r.logShadowRequest(ctx, shadow, start, resp.StatusCode, nil, nil)
+		return
+	}
+	result, err := readShadowResponse(resp.Body, shadow.stream)
+	r.logShadowRequest(ctx, shadow, start, resp.StatusCode, result, err)
+}
+
+func doShadowRequest(ctx context.Context, shadow *shadowRequest) (*http.Response, error) {
+	ctx = connectors.WithUpstreamProtocol(ctx, shadow.protocol)
+	req, err := http.NewRequestWithContext(ctx, shadow.method, shadow.url, bytes.NewReader(shadow.body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = shadow.header
+	req.ContentLength = int64(len(shadow.body))
+	return connectors.UpstreamTransport().RoundTrip(req)
+}
+
+// readShadowResponse reads the body of a shadow response, and keeps its content digest, usage and token timing
+func readShadowResponse(body io.Reader, stream bool) (*shadowResponse, error) {
+	result := &shadowResponse{digest: handlers.NewContentDigest()}
+	if !stream {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := handlers.ParseOpenAIResponseBody(data)
+		if err != nil {
+			return nil, err
+		}
+		result.digest.Add(parsed.Choices)
+		result.usage = parsed
+		return result, nil
+	}
+
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			parsed := handlers.ParseStreamRespForUsage(line)
+			if len(parsed.Choices) > 0 {
+				now := time.Now()
+				if result.firstToken.IsZero() {
+					result.firstToken = now
+				}
+				result.lastToken = now
+				result.digest.Add(parsed.Choices)
+			}
+			if parsed.Usage.TotalTokens > 0 || parsed.Usage.CompletionTokens > 0 {
+				result.usage = &parsed
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// logShadowRequest logs the access log entry of a shadow request, once the original response has been forwarded
+func (r *Router) logShadowRequest(ctx context.Context, shadow *shadowRequest, start time.Time, statusCode int, result *shadowResponse, err error) {
+	if shadow.entry == nil {
+		return
+	}
+	entry := shadow.entry
+	entry.Timestamp = start
+	entry.StatusCode = statusCode
+	entry.DurationTotal = time.Since(start).Milliseconds()
+	entry.DurationUpstreamProcessing = entry.DurationTotal
+	if err != nil {
+		entry.Error = &accesslog.ErrorInfo{Type: "mirror", Message: err.Error()}
+	}
+
+	mirrorInfo := &accesslog.MirrorInfo{}
+	if result != nil {
+		mirrorInfo.ResponseHash = result.digest.Sum()
+		if result.usage != nil {
+			entry.InputTokens = result.usage.Usage.PromptTokens
+			entry.OutputTokens = result.usage.Usage.CompletionTokens
+		}
+		if !result.firstToken.IsZero() {
+			entry.TTFT = result.firstToken.Sub(start).Milliseconds()
+			if entry.OutputTokens > 1 {
+				entry.TPOT = (result.lastToken.Sub(result.firstToken) / time.Duration(entry.OutputTokens-1)).Milliseconds()
+			}
+		}
+	}
+	select {
+	case <-shadow.primaryDone:
+		mirrorInfo.PrimaryResponseHash = shadow.primaryDigest.Sum()
+	case <-ctx.Done():
+		klog.V(4).Infof("original response of mirrored request %s did not complete in time", entry.RequestID)
+	}
+	mirrorInfo.ResponseMatch = mirrorInfo.ResponseHash != "" && mirrorInfo.ResponseHash == mirrorInfo.PrimaryResponseHash
+	entry.Mirror = mirrorInfo
+
+	if err := r.accessLogger.Log(entry); err != nil {
+		klog.Errorf("Failed to write access log: %v", err)
+	}
+}
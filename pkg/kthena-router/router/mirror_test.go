@@ -0,0 +1,206 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
+)
+
+// captureAccessLogger sends the logged entries to a channel
+type captureAccessLogger struct {
+	entries chan *accesslog.AccessLogEntry
+}
+
+func (l *captureAccessLogger) Log(entry *accesslog.AccessLogEntry) error {
+	l.entries <- entry
+	return nil
+}
+
+func (l *captureAccessLogger) Close() error {
+	return nil
+}
+
+// chatCompletionHandler answers chat completions with content, after delay
+func chatCompletionHandler(content string, delay time.Duration, requests chan<- ModelRequest) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var reqBody ModelRequest
+		_ = json.Unmarshal(body, &reqBody)
+		if requests != nil {
+			requests <- reqBody
+		}
+		time.Sleep(delay)
+		if isStreaming(reqBody) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content[:2])
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content[2:])
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"content":%q}}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`, content)
+	})
+}
+
+func TestRouter_HandlerFunc_Mirror(t *testing.T) {
+	tests := []struct {
+		name          string
+		stream        bool
+		shadowContent string
+		wantMatch     bool
+	}{
+		{
+			name:          "same response",
+			shadowContent: "hello",
+			wantMatch:     true,
+		},
+		{
+			name:          "different response",
+			shadowContent: "world",
+		},
+		{
+			name:          "stream with the same content",
+			stream:        true,
+			shadowContent: "hello",
+			wantMatch:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, store, primaryBackend := setupTestRouter(chatCompletionHandler("hello", 0, nil))
+			defer primaryBackend.Close()
+			logger := &captureAccessLogger{entries: make(chan *accesslog.AccessLogEntry, 1)}
+			router.accessLogger = logger
+
+			shadowRequests := make(chan ModelRequest, 1)
+			shadowBackend := httptest.NewServer(chatCompletionHandler(tt.shadowContent, 200*time.Millisecond, shadowRequests))
+			defer shadowBackend.Close()
+
+			addModelServerWithBackends(t, store, "ms-primary", "primary-model", primaryBackend)
+			addModelServerWithBackends(t, store, "ms-shadow", "shadow-model", shadowBackend)
+			require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+				ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+				Spec: aiv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*aiv1alpha1.Rule{
+						{
+							TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+							Mirror:       &aiv1alpha1.Mirror{ModelServerName: "ms-shadow", LogResponse: true},
+						},
+					},
+				},
+			}))
+
+			// Streaming needs a recorder implementing CloseNotify
+			w := connectors.CreateTestResponseRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := fmt.Sprintf(`{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "stream": %t}`, tt.stream)
+			c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("x-request-id", "req-1")
+
+			start := time.Now()
+			router.HandlerFunc()(c)
+			// The original response does not wait for the shadow response
+			assert.Less(t, time.Since(start), 200*time.Millisecond)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), `"he`)
+
+			select {
+			case shadowRequest := <-shadowRequests:
+				assert.Equal(t, "shadow-model", shadowRequest["model"])
+				if tt.stream {
+					assert.Equal(t, map[string]interface{}{"include_usage": true}, shadowRequest["stream_options"])
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the request was not mirrored")
+			}
+
+			var entry *accesslog.AccessLogEntry
+			select {
+			case entry = <-logger.entries:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the mirrored request was not logged")
+			}
+			assert.Equal(t, "req-1", entry.RequestID)
+			assert.Equal(t, "test-model", entry.ModelName)
+			assert.Equal(t, "default/mr-1", entry.ModelRoute)
+			assert.Equal(t, "default/ms-shadow", entry.ModelServer)
+			assert.Equal(t, http.StatusOK, entry.StatusCode)
+			assert.Equal(t, 3, entry.InputTokens)
+			assert.Equal(t, 2, entry.OutputTokens)
+			assert.GreaterOrEqual(t, entry.DurationTotal, int64(200))
+			require.NotNil(t, entry.Mirror)
+			assert.NotEmpty(t, entry.Mirror.ResponseHash)
+			assert.NotEmpty(t, entry.Mirror.PrimaryResponseHash)
+			assert.Equal(t, tt.wantMatch, entry.Mirror.ResponseMatch)
+		})
+	}
+}
+
+func TestRouter_HandlerFunc_MirrorPercent(t *testing.T) {
+	router, store, primaryBackend := setupTestRouter(chatCompletionHandler("hello", 0, nil))
+	defer primaryBackend.Close()
+	shadowRequests := make(chan ModelRequest, 1)
+	shadowBackend := httptest.NewServer(chatCompletionHandler("hello", 0, shadowRequests))
+	defer shadowBackend.Close()
+
+	addModelServerWithBackends(t, store, "ms-primary", "primary-model", primaryBackend)
+	addModelServerWithBackends(t, store, "ms-shadow", "shadow-model", shadowBackend)
+	percent := int32(0)
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-primary"}},
+					Mirror:       &aiv1alpha1.Mirror{ModelServerName: "ms-shadow", Percent: &percent},
+				},
+			},
+		},
+	}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hi"}`))
+	router.HandlerFunc()(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	select {
+	case <-shadowRequests:
+		t.Fatal("the request should not be mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
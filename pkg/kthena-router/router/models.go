@@ -0,0 +1,173 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"istio.io/istio/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+const (
+	modelsPath       = "/v1/models"
+	modelsOwner      = "kthena"
+	modelObject      = "model"
+	modelsListObject = "list"
+)
+
+// ModelCard describes a model served by the router, in the format of the OpenAI models API.
+// Ready and ReadyEndpoints are extensions telling whether pods are actually serving the model.
+type ModelCard struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	// Root and Parent are the base model of a LoRA adapter, as reported by vLLM
+	Root   string `json:"root,omitempty"`
+	Parent string `json:"parent,omitempty"`
+
+	Ready          bool `json:"ready"`
+	ReadyEndpoints int  `json:"ready_endpoints"`
+}
+
+// ModelList is the response of GET /v1/models
+type ModelList struct {
+	Object string      `json:"object"`
+	Data   []ModelCard `json:"data"`
+}
+
+// isModelsRequest returns true for the model listing requests answered by the router itself
+func isModelsRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	return req.URL.Path == modelsPath || strings.HasPrefix(req.URL.Path, modelsPath+"/")
+}
+
+// handleModels serves GET /v1/models and GET /v1/models/{id} from the ModelRoutes visible to the caller.
+// A model is visible if the caller's request, with its gateway and headers, would be routed by a ModelRoute.
+func (r *Router) handleModels(c *gin.Context) {
+	gatewayKey := c.GetString(GatewayKey)
+
+	id := strings.TrimPrefix(strings.TrimPrefix(c.Request.URL.Path, modelsPath), "/")
+	if id != "" {
+		card, ok := r.modelCard(c.Request, gatewayKey, id)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("model %s not found", id))
+			return
+		}
+		c.JSON(http.StatusOK, card)
+		return
+	}
+
+	names := sets.New[string]()
+	for _, mr := range r.store.GetAllModelRoutes() {
+		if mr.Spec.ModelName != "" {
+			names.Insert(mr.Spec.ModelName)
+		}
+		names.InsertAll(mr.Spec.LoraAdapters...)
+	}
+
+	list := ModelList{Object: modelsListObject, Data: []ModelCard{}}
+	for _, name := range sets.SortedList(names) {
+		if card, ok := r.modelCard(c.Request, gatewayKey, name); ok {
+			list.Data = append(list.Data, card)
+		}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// modelCard returns the description of the model if it is routable for the request. The body conditions of
+// the rules are checked against a body holding only the model, so the models only routed for some request
+// bodies, e.g. for long prompts, are not listed.
+func (r *Router) modelCard(req *http.Request, gatewayKey, model string) (ModelCard, bool) {
+	body := map[string]interface{}{"model": model}
+	_, isLora, mr, _, err := r.store.MatchModelServer(model, req, body, gatewayKey)
+	if err != nil || mr == nil {
+		return ModelCard{}, false
+	}
+
+	card := ModelCard{
+		ID:      model,
+		Object:  modelObject,
+		Created: mr.CreationTimestamp.Unix(),
+		OwnedBy: modelsOwner,
+	}
+	if isLora && mr.Spec.ModelName != "" {
+		card.Root = mr.Spec.ModelName
+		card.Parent = mr.Spec.ModelName
+	}
+	card.ReadyEndpoints = r.countServingPods(mr, model, isLora)
+	card.Ready = card.ReadyEndpoints > 0
+	return card, true
+}
+
+// countServingPods counts the pods of the ModelServers targeted by the ModelRoute that report serving the model
+func (r *Router) countServingPods(mr *v1alpha1.ModelRoute, model string, isLora bool) int {
+	serving := sets.New[types.NamespacedName]()
+	for _, name := range targetModelServers(mr) {
+		servedModel := model
+		if !isLora {
+			// The ModelServer may serve the model under another name, the request model is rewritten to it
+			if ms := r.store.GetModelServer(name); ms != nil && ms.Spec.Model != nil {
+				servedModel = *ms.Spec.Model
+			}
+		}
+		pods, err := r.store.GetPodsByModelServer(name)
+		if err != nil {
+			continue
+		}
+		for _, pod := range pods {
+			if pod.Pod != nil && pod.Contains(servedModel) {
+				serving.Insert(types.NamespacedName{Namespace: pod.Pod.Namespace, Name: pod.Pod.Name})
+			}
+		}
+	}
+	return serving.Len()
+}
+
+// targetModelServers returns the ModelServers targeted by all the rules of the ModelRoute, including their
+// fallback targets, sorted by name
+func targetModelServers(mr *v1alpha1.ModelRoute) []types.NamespacedName {
+	names := sets.New[string]()
+	for _, rule := range mr.Spec.Rules {
+		if rule == nil {
+			continue
+		}
+		for _, target := range rule.TargetModels {
+			if target != nil {
+				names.Insert(target.ModelServerName)
+			}
+		}
+		if rule.Fallback != nil {
+			for _, target := range rule.Fallback.Targets {
+				names.Insert(target.ModelServerName)
+			}
+		}
+	}
+	result := make([]types.NamespacedName, 0, names.Len())
+	for _, name := range sets.SortedList(names) {
+		result = append(result, types.NamespacedName{Namespace: mr.Namespace, Name: name})
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"istio.io/istio/pkg/util/sets"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+)
+
+func newModelsTestStore(t *testing.T) datastore.Store {
+	store := datastore.New()
+
+	servedModel := "meta-llama/Llama-3-8B"
+	ms := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "llama"},
+		Spec:       aiv1alpha1.ModelServerSpec{Model: &servedModel},
+	}
+	ready := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "llama-0"}}
+	loading := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "llama-1"}}
+	require.NoError(t, store.AddOrUpdateModelServer(ms, sets.New(
+		types.NamespacedName{Namespace: "default", Name: "llama-0"},
+		types.NamespacedName{Namespace: "default", Name: "llama-1"},
+	)))
+	require.NoError(t, store.AddOrUpdatePod(ready, []*aiv1alpha1.ModelServer{ms}))
+	require.NoError(t, store.AddOrUpdatePod(loading, []*aiv1alpha1.ModelServer{ms}))
+	store.GetPodInfo(types.NamespacedName{Namespace: "default", Name: "llama-0"}).UpdateModels([]string{servedModel, "sql-lora"})
+
+	rules := []*aiv1alpha1.Rule{{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "llama"}}}}
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "llama", CreationTimestamp: v1.Unix(1700000000, 0)},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName:    "llama",
+			LoraAdapters: []string{"sql-lora", "chat-lora"},
+			Rules:        rules,
+		},
+	}))
+	// Only routable with the tenant header
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "private"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "private",
+			Rules: []*aiv1alpha1.Rule{{
+				ModelMatch: &aiv1alpha1.ModelMatch{Headers: map[string]*aiv1alpha1.StringMatch{
+					"x-tenant": {Exact: ptrTo("gold")},
+				}},
+				TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "llama"}},
+			}},
+		},
+	}))
+	// Only visible through the Gateway it is bound to
+	require.NoError(t, store.AddOrUpdateGateway(&gatewayv1.Gateway{ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "gw"}}))
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "gateway-only"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName:  "gateway-only",
+			ParentRefs: []gatewayv1.ParentReference{{Name: "gw"}},
+			Rules:      rules,
+		},
+	}))
+	return store
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func serveModels(r *Router, target, gatewayKey string, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		c.Request.Header[k] = v
+	}
+	if gatewayKey != "" {
+		c.Set(GatewayKey, gatewayKey)
+	}
+	r.HandlerFunc()(c)
+	return w
+}
+
+func modelIDs(t *testing.T, w *httptest.ResponseRecorder) []string {
+	require.Equal(t, http.StatusOK, w.Code)
+	var list ModelList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, "list", list.Object)
+	ids := make([]string, 0, len(list.Data))
+	for _, card := range list.Data {
+		ids = append(ids, card.ID)
+	}
+	return ids
+}
+
+func TestHandleModelsList(t *testing.T) {
+	r := NewRouter(newModelsTestStore(t), "")
+
+	w := serveModels(r, "/v1/models", "", nil)
+	assert.Equal(t, []string{"chat-lora", "llama", "sql-lora"}, modelIDs(t, w))
+
+	var list ModelList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	cards := map[string]ModelCard{}
+	for _, card := range list.Data {
+		cards[card.ID] = card
+	}
+	assert.Equal(t, ModelCard{ID: "llama", Object: "model", Created: 1700000000, OwnedBy: "kthena", Ready: true, ReadyEndpoints: 1}, cards["llama"])
+	assert.Equal(t, "llama", cards["sql-lora"].Parent)
+	assert.True(t, cards["sql-lora"].Ready)
+	// No pod has loaded the adapter yet
+	assert.False(t, cards["chat-lora"].Ready)
+	assert.Equal(t, 0, cards["chat-lora"].ReadyEndpoints)
+
+	// Header based rules make models visible to some callers only
+	w = serveModels(r, "/v1/models", "", http.Header{"X-Tenant": {"gold"}})
+	assert.Equal(t, []string{"chat-lora", "llama", "private", "sql-lora"}, modelIDs(t, w))
+
+	// Only ModelRoutes bound to the Gateway are visible through it
+	w = serveModels(r, "/v1/models", "default/gw", nil)
+	assert.Equal(t, []string{"gateway-only"}, modelIDs(t, w))
+}
+
+func TestHandleModelsGet(t *testing.T) {
+	r := NewRouter(newModelsTestStore(t), "")
+
+	w := serveModels(r, "/v1/models/llama", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var card ModelCard
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &card))
+	assert.Equal(t, "llama", card.ID)
+	assert.True(t, card.Ready)
+
+	w = serveModels(r, "/v1/models/private", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveModels(r, "/v1/models/unknown", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleModelsBodyConditions(t *testing.T) {
+	store := newModelsTestStore(t)
+	// Only routable for some request bodies
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "long-context"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "long-context",
+			Rules: []*aiv1alpha1.Rule{{
+				ModelMatch: &aiv1alpha1.ModelMatch{Body: &aiv1alpha1.BodyMatch{Fields: []aiv1alpha1.BodyFieldMatch{
+					{Path: "messages", Range: &aiv1alpha1.NumberRange{Min: resource.NewQuantity(100, resource.DecimalSI)}},
+				}}},
+				TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "llama"}},
+			}},
+		},
+	}))
+	// Routable for the requests without tools, such as a request holding only the model
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "no-tools", CreationTimestamp: v1.Unix(1700000000, 0)},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "no-tools",
+			Rules: []*aiv1alpha1.Rule{{
+				ModelMatch: &aiv1alpha1.ModelMatch{Body: &aiv1alpha1.BodyMatch{Fields: []aiv1alpha1.BodyFieldMatch{
+					{Path: "tools", Exists: ptrTo(false)},
+				}}},
+				TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "llama"}},
+			}},
+		},
+	}))
+	r := NewRouter(store, "")
+
+	// The body conditions are checked against a body holding only the model
+	assert.Equal(t, []string{"chat-lora", "llama", "no-tools", "sql-lora"}, modelIDs(t, serveModels(r, "/v1/models", "", nil)))
+	assert.Equal(t, http.StatusNotFound, serveModels(r, "/v1/models/long-context", "", nil).Code)
+	assert.Equal(t, http.StatusOK, serveModels(r, "/v1/models/no-tools", "", nil).Code)
+}
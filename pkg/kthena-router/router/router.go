@@ -17,14 +17,13 @@ limitations under the License.
 package router
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -33,10 +32,10 @@ import (
 	"github.com/google/uuid"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
-	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/accesslog"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/anthropic"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
@@ -54,6 +53,15 @@ import (
 const (
 	// Context keys for gin context
 	GatewayKey = "gatewayKey"
+	// ListenerKey is the name of the Gateway listener that accepted the request
+	ListenerKey = "listenerName"
+)
+
+const (
+	// clientCanceledReason is the finish reason of the requests canceled by the client
+	clientCanceledReason = "client_canceled"
+	// statusClientClosedRequest is the status code recorded for the requests canceled by the client
+	statusClientClosedRequest = 499
 )
 
 func getEnvBool(key string, fallback bool) bool {
@@ -75,6 +83,14 @@ type Router struct {
 	accessLogger    accesslog.AccessLogger
 	metrics         *metrics.Metrics
 	tokenizer       tokenizer.Tokenizer
+	// maxBodySize is the maximum size of a request body in bytes
+	maxBodySize int64
+	// loraLoader loads the LoRA adapters on demand, it is nil if on-demand loading is disabled
+	loraLoader *loraLoader
+	// responseCache caches the responses of the ModelRoutes with a cache
+	responseCache *responseCache
+	// guardrails sends the content of the requests to the guardrails of their ModelRoute
+	guardrails *guardrailClients
 
 	// KV Connector management
 	connectorFactory *connectors.Factory
@@ -109,10 +125,40 @@ func NewRouter(store datastore.Store, routerConfigPath string) *Router {
 		}
 	})
 
+	// The latency series of a deleted pod would be exported forever
+	store.RegisterCallback("Pod", func(data datastore.EventData) {
+		if data.EventType == datastore.EventDelete {
+			metricsInstance.DeletePod(data.Pod.Name)
+		}
+	})
+
 	routerConfig, err := conf.ParseRouterConfig(routerConfigPath)
 	if err != nil {
 		klog.Fatalf("failed to parse router config: %v", err)
 	}
+	maxBodySize, err := routerConfig.Request.MaxBodyBytes()
+	if err != nil {
+		klog.Fatalf("failed to parse router config: %v", err)
+	}
+	if err := connectors.ConfigureUpstreamTransport(routerConfig.Upstream); err != nil {
+		klog.Fatalf("failed to parse router config: %v", err)
+	}
+	loraConfig, err := routerConfig.Lora.WithDefaults()
+	if err != nil {
+		klog.Fatalf("failed to parse router config: %v", err)
+	}
+	var loader *loraLoader
+	if loraConfig.OnDemandLoading {
+		loader = newLoraLoader(loraConfig, store)
+	}
+	responseCacheConfig, err := routerConfig.ResponseCache.WithDefaults()
+	if err != nil {
+		klog.Fatalf("failed to parse router config: %v", err)
+	}
+	cache, err := newResponseCache(responseCacheConfig)
+	if err != nil {
+		klog.Fatalf("failed to create the response cache: %v", err)
+	}
 
 	// Initialize access logger with configuration from environment variables
 	accessLogConfig := &accesslog.AccessLoggerConfig{
@@ -153,21 +199,57 @@ func NewRouter(store datastore.Store, routerConfigPath string) *Router {
 		accessLogger:     accessLogger,
 		metrics:          metricsInstance,
 		tokenizer:        tokenizerInstance,
+		maxBodySize:      maxBodySize,
+		loraLoader:       loader,
+		responseCache:    cache,
+		guardrails:       newGuardrailClients(),
 		connectorFactory: connectors.NewDefaultFactory(),
 	}
 }
 
+// Scheduler returns the scheduler used by the router, so other frontends such as the
+// ext_proc endpoint picker share its state (e.g. the prefix cache).
+func (r *Router) Scheduler() scheduler.Scheduler {
+	return r.scheduler
+}
+
 type ModelRequest map[string]interface{}
 
 func (r *Router) HandlerFunc() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Model listing requests are answered by the router itself
+		if isModelsRequest(c.Request) {
+			r.handleModels(c)
+			return
+		}
+
+		// Anthropic Messages API responses, including errors, are translated from the OpenAI ones
+		isMessagesRequest := anthropic.IsMessagesRequest(c.Request.URL.Path)
+		if isMessagesRequest {
+			writer := anthropic.NewResponseWriter(c.Writer)
+			c.Writer = writer
+			defer writer.Close()
+		}
+
 		// Step 1: Parse and validate request
-		modelRequest, err := ParseModelRequest(c)
+		modelRequest, err := ParseModelRequest(c, r.requestBodyLimit(c))
 		if err != nil {
 			accesslog.SetError(c, "request_parsing", err.Error())
 			return
 		}
 
+		// Anthropic Messages API requests are served by the chat completions API of the model servers
+		if isMessagesRequest {
+			modelRequest, err = anthropic.ToChatCompletionsRequest(modelRequest)
+			if err != nil {
+				accesslog.SetError(c, "request_parsing", err.Error())
+				c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+				return
+			}
+			c.Request.URL.Path = anthropic.ChatCompletionsPath
+			markRequestModified(c)
+		}
+
 		// step 2: Detection of rate limit
 		modelName := modelRequest["model"].(string)
 
@@ -188,6 +270,10 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 			r.metrics.DecActiveDownstreamRequests(modelName)
 			if metricsRecorder != nil {
 				statusCode := strconv.Itoa(c.Writer.Status())
+				if clientCanceled(c) {
+					recordClientCanceled(c, metricsRecorder)
+					statusCode = strconv.Itoa(statusClientClosedRequest)
+				}
 				reason := "successful_request"
 				if r, exists := c.Get("finishReason"); exists {
 					reason = r.(string)
@@ -196,21 +282,21 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 			}
 		}()
 
-		prompt, err := utils.ParsePrompt(modelRequest)
+		// The input is parsed according to the endpoint, e.g. embeddings use "input" instead of "prompt"
+		input, err := utils.ParseRequestInput(path, modelRequest)
 		if err != nil {
-			accesslog.SetError(c, "prompt_parsing", "prompt not found")
-			c.AbortWithStatusJSON(http.StatusNotFound, "prompt not found")
+			errorMsg := "prompt not found"
+			if endpoint := utils.GetEndpoint(path); !endpoint.IsGenerative() || endpoint == common.EndpointResponses {
+				errorMsg = err.Error()
+			}
+			accesslog.SetError(c, "prompt_parsing", errorMsg)
+			c.AbortWithStatusJSON(http.StatusNotFound, errorMsg)
 			c.Set("finishReason", "prompt_parsing")
 			return
 		}
-		promptStr := utils.GetPromptString(prompt)
 
 		// Calculate input tokens for metrics using tokenizer
-		inputTokens, err := r.tokenizer.CalculateTokenNum(promptStr)
-		if err != nil {
-			klog.Errorf("failed to calculate token number: %v", err)
-			inputTokens = len(promptStr) / 4 // fallback estimation
-		}
+		inputTokens := r.countInputTokens(input)
 
 		// Calculate and set input tokens for access log
 		accesslog.SetTokenCounts(c, inputTokens, 0)
@@ -222,7 +308,7 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 		metricsRecorder.RecordInputTokens(inputTokens)
 
 		// Apply rate limiting using the unified rate limiter
-		if err := r.loadRateLimiter.RateLimit(modelName, promptStr); err != nil {
+		if err := r.loadRateLimiter.RateLimitTokens(modelName, inputTokens, input.Endpoint.IsGenerative()); err != nil {
 			var errorMsg string
 			var errorType string
 			var tokenType string
@@ -265,6 +351,9 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 
 		// step 3.2: load balancing for Fairness scheduling enabled case
 		if err := r.handleFairnessScheduling(c, modelRequest, requestID, modelName); err != nil {
+			if clientCanceled(c) {
+				return
+			}
 			accesslog.SetError(c, "scheduling", err.Error())
 			c.Set("finishReason", "scheduling")
 			return
@@ -275,13 +364,6 @@ func (r *Router) HandlerFunc() gin.HandlerFunc {
 func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 	modelName := modelRequest["model"].(string)
 
-	// Check if this is an InferencePool request from HTTPRoute
-	var pods []*datastore.PodInfo
-	var port int32
-	var modelServerName types.NamespacedName
-	var modelRoute *v1alpha1.ModelRoute
-	var modelServer *v1alpha1.ModelServer
-
 	// Get gateway key from context if available (set by Gateway listener)
 	var gatewayKey string
 	if key, exists := c.Get(GatewayKey); exists {
@@ -290,35 +372,77 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 		}
 	}
 
-	var isLora bool
-	var err error
 	// Try to match ModelRoute first
-	modelServerName, isLora, modelRoute, err = r.store.MatchModelServer(modelName, c.Request, gatewayKey)
+	modelServerName, isLora, modelRoute, rule, err := r.store.MatchModelServer(modelName, c.Request, modelRequest, gatewayKey)
 	if err != nil {
 		accesslog.SetError(c, "model_server_matching", fmt.Sprintf("can't find corresponding model server: %v", err))
 	}
 
 	if err == nil && strings.HasPrefix(c.Request.URL.Path, "/v1/") {
+		// The body was read up to the largest limit of the routes of the Gateway, the route itself may be stricter
+		if modelRoute != nil && modelRoute.Spec.MaxRequestBodySize != nil {
+			if limit := modelRoute.Spec.MaxRequestBodySize.Value(); requestBodySize(c) > limit {
+				errorMsg := fmt.Sprintf("request body exceeds the limit of %d bytes", limit)
+				accesslog.SetError(c, "request_too_large", errorMsg)
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorMsg)
+				return
+			}
+		}
+
 		// Regular ModelServer request
 		// step 3: Find pods and model server details
 		klog.V(4).Infof("modelServer is %v, is_lora: %v", modelServerName, isLora)
 
-		pods, modelServer, err = r.getPodsAndServer(modelServerName)
-		if err != nil || len(pods) == 0 {
-			klog.Errorf("failed to get pods and model server: %v, %v", modelServerName, err)
-			accesslog.SetError(c, "pod_discovery", fmt.Sprintf("can't find model server: %v", modelServerName))
-			c.AbortWithStatusJSON(http.StatusNotFound, fmt.Sprintf("can't find model server: %v", modelServerName))
+		// The transforms apply to the mirrored requests as well
+		if applyBodyTransforms(modelRequest, rule.Transforms) {
+			markRequestModified(c)
+		}
+		if rule.Classifier != nil {
+			modelServerName, err = r.classifyModelServer(c, modelRequest, modelRoute, rule)
+			if err != nil {
+				accesslog.SetError(c, "prompt_classification", err.Error())
+				c.AbortWithStatusJSON(http.StatusNotFound, err.Error())
+				return
+			}
+		}
+		// A request blocked by a guardrail is not mirrored, and the redacted prompt is cached
+		if !r.checkRequestGuardrails(c, modelRequest, modelRoute) {
 			return
 		}
-
-		model := modelServer.Spec.Model
-		if model != nil && !isLora {
-			modelRequest["model"] = *model
+		// The cached responses are not mirrored
+		if modelRoute.Spec.Cache != nil {
+			served, cacheResponse := r.serveFromCache(c, modelRequest, modelRoute, modelServerName)
+			if served {
+				return
+			}
+			if cacheResponse != nil {
+				defer cacheResponse()
+			}
+		}
+		// The response is checked before being cached, so that the cached responses are guarded
+		if responseGuarded := r.guardResponse(c, modelRequest, modelRoute); responseGuarded != nil {
+			defer responseGuarded()
+		}
+		if rule.Mirror != nil {
+			primaryDone := r.mirrorModelRoute(c, modelRequest, modelRoute, rule.Mirror, isLora)
+			defer primaryDone()
 		}
+		r.serveModelRoute(c, modelRequest, modelRoute, modelServerName, isLora, rule.Fallback)
+		return
+	}
 
-		port = modelServer.Spec.WorkloadPort.Port
-	} else if matched, inferencePoolName := r.handleHTTPRoute(c, gatewayKey); matched {
+	if backend, matched := r.handleHTTPRoute(c, gatewayKey, modelRequest); matched {
 		// If ModelRoute is not matched, try to match HTTPRoute
+		if backend == nil {
+			// The request has been aborted while handling the HTTPRoute
+			accesslog.SetError(c, "http_route", "failed to select backend")
+			return
+		}
+		if backend.Kind == serviceKind {
+			r.proxyToService(c, modelRequest, backend)
+			return
+		}
+		inferencePoolName := backend.Name
 
 		// Get InferencePool from store
 		inferencePoolKey := fmt.Sprintf("%s/%s", inferencePoolName.Namespace, inferencePoolName.Name)
@@ -331,7 +455,7 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 		}
 
 		// Get pods from InferencePool
-		pods, err = r.store.GetPodsByInferencePool(inferencePoolName)
+		pods, err := r.store.GetPodsByInferencePool(inferencePoolName)
 		if err != nil || len(pods) == 0 {
 			klog.Errorf("failed to get pods for inference pool: %v, %v", inferencePoolName, err)
 			accesslog.SetError(c, "pod_discovery", fmt.Sprintf("can't find pods for inference pool: %v", inferencePoolName))
@@ -347,23 +471,74 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 			return
 		}
 		// Use the first target port
-		port = int32(inferencePool.Spec.TargetPorts[0].Number)
+		port := int32(inferencePool.Spec.TargetPorts[0].Number)
 
 		klog.V(4).Infof("InferencePool is %v, pods count: %d, port: %d", inferencePoolName, len(pods), port)
-	} else {
-		accesslog.SetError(c, "route_not_found", "route not found")
-		c.AbortWithStatusJSON(http.StatusNotFound, "route not found")
+
+		input, err := utils.ParseRequestInput(c.Request.URL.Path, modelRequest)
+		if err != nil {
+			accesslog.SetError(c, "prompt_parsing", "prompt not found")
+			c.AbortWithStatusJSON(http.StatusNotFound, "prompt not found")
+			return
+		}
+		target := &upstreamTarget{pods: pods, port: port}
+		if err := r.scheduleAndProxy(c, modelRequest, modelName, input, nil, target, false); err != nil {
+			r.abortRouting(c, err)
+		}
 		return
 	}
 
-	// Common scheduling logic for both ModelServer and InferencePool
-	prompt, err := utils.ParsePrompt(modelRequest)
-	if err != nil {
-		accesslog.SetError(c, "prompt_parsing", "prompt not found")
-		c.AbortWithStatusJSON(http.StatusNotFound, "prompt not found")
-		return
+	accesslog.SetError(c, "route_not_found", "route not found")
+	c.AbortWithStatusJSON(http.StatusNotFound, "route not found")
+}
+
+// upstreamTarget is the set of pods a request is scheduled to, the pods of a ModelServer or an InferencePool
+type upstreamTarget struct {
+	pods []*datastore.PodInfo
+	port int32
+	// modelServer is nil for an InferencePool
+	modelServer     *v1alpha1.ModelServer
+	modelServerName types.NamespacedName
+}
+
+// routingError is a failure to serve a request with a target. It is answered to the client,
+// unless a fallback target serves the request.
+type routingError struct {
+	status int
+	// reason is the error type recorded in the access log
+	reason  string
+	message string
+	// condition is the fallback condition matched by the failure, it is empty if the failure
+	// never triggers a fallback
+	condition v1alpha1.FallbackCondition
+	// answered is set when the client went away, or the response has started
+	answered bool
+}
+
+func (e *routingError) Error() string {
+	return e.message
+}
+
+// abortRouting answers a request with the failure of its last target
+func (r *Router) abortRouting(c *gin.Context, err *routingError) {
+	if err.reason != "" {
+		accesslog.SetError(c, err.reason, err.message)
 	}
+	if !err.answered && !c.Writer.Written() {
+		c.AbortWithStatusJSON(err.status, err.message)
+	}
+}
 
+// scheduleAndProxy schedules a request to the pods of a target and proxies it to the selected pods
+func (r *Router) scheduleAndProxy(
+	c *gin.Context,
+	modelRequest ModelRequest,
+	modelName string,
+	input *common.RequestInput,
+	modelRoute *v1alpha1.ModelRoute,
+	target *upstreamTarget,
+	isLora bool,
+) *routingError {
 	// Get metrics recorder from gin context
 	var metricsRecorder *metrics.RequestMetricsRecorder
 	if recorder, exists := c.Get("metricsRecorder"); exists {
@@ -374,33 +549,64 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 
 	// Get PDGroup if available (only for ModelServer)
 	var pdGroup *v1alpha1.PDGroup
-	if modelServer != nil && modelServer.Spec.WorkloadSelector != nil {
-		pdGroup = modelServer.Spec.WorkloadSelector.PDGroup
+	if target.modelServer != nil && target.modelServer.Spec.WorkloadSelector != nil {
+		pdGroup = target.modelServer.Spec.WorkloadSelector.PDGroup
 	}
 
 	ctx := &framework.Context{
 		Model:           modelName,
-		Prompt:          prompt,
-		ModelServerName: modelServerName,
+		Prompt:          input.Prompt,
+		Endpoint:        input.Endpoint,
+		Modalities:      input.Prompt.Modalities(),
+		ModelServerName: target.modelServerName,
 		PDGroup:         pdGroup,
 		MetricsRecorder: metricsRecorder,
+		// The adapters are not loaded on demand in PD disaggregated mode, the decode pods are not filtered
+		LoadLoraAdapter: isLora && r.loraLoader != nil && pdGroup == nil,
 	}
 
-	err = r.scheduler.Schedule(ctx, pods)
+	err := r.scheduler.Schedule(ctx, target.pods)
 	if err != nil {
-		accesslog.SetError(c, "scheduling", fmt.Sprintf("can't schedule to target pod: %v", err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("can't schedule to target pod: %v", err))
-		return
+		return &routingError{
+			status:    http.StatusBadRequest,
+			reason:    "scheduling",
+			message:   fmt.Sprintf("can't schedule to target pod: %v", err),
+			condition: v1alpha1.FallbackOnNoEndpoints,
+		}
+	}
+
+	// The pods of the ModelServer are reached with the protocol of its workload port
+	if target.modelServer != nil {
+		c.Request = c.Request.WithContext(connectors.WithUpstreamProtocol(c.Request.Context(), target.modelServer.Spec.WorkloadPort.Protocol))
+	}
+
+	if ctx.LoadLoraAdapter {
+		release, err := r.loadLoraAdapter(c, ctx, target.port)
+		if err != nil {
+			klog.Errorf("failed to load lora adapter %s: %v", modelName, err)
+			return &routingError{
+				status:  http.StatusServiceUnavailable,
+				reason:  "lora_loading",
+				message: fmt.Sprintf("can't load lora adapter: %s", modelName),
+			}
+		}
+		defer release()
 	}
 
 	// Set complete request routing information in access log
-	modelServerFullName := fmt.Sprintf("%s/%s", modelServerName.Namespace, modelServerName.Name)
+	modelServerFullName := fmt.Sprintf("%s/%s", target.modelServerName.Namespace, target.modelServerName.Name)
 	modelRouteName := ""
 	if modelRoute != nil {
 		modelRouteName = fmt.Sprintf("%s/%s", modelRoute.Namespace, modelRoute.Name)
 		// Set the model route name in context for upstream connections
 		c.Set("modelRouteName", modelRouteName)
 	}
+	if metricsRecorder != nil {
+		metricsRecorder.SetUpstreamConnectionInfo(modelServerFullName, modelRouteName)
+		if modelRoute != nil && modelRoute.Spec.SLO != nil {
+			metricsRecorder.SetSLO(latencySLO(modelRoute.Spec.SLO))
+		}
+	}
 
 	if len(ctx.BestPods) > 0 && ctx.BestPods[0].Pod != nil {
 		selectedPod := ctx.BestPods[0].Pod.Name
@@ -411,24 +617,65 @@ func (r *Router) doLoadbalance(c *gin.Context, modelRequest ModelRequest) {
 	}
 
 	req := c.Request
-	if err := r.proxyModelEndpoint(c, req, ctx, modelRequest, port); err != nil {
+	if err := r.proxyModelEndpoint(c, req, ctx, modelRequest, target.port); err != nil {
+		if clientCanceled(c) {
+			// Recorded when the request finishes, there is nobody to answer
+			return &routingError{answered: true}
+		}
 		klog.Errorf("request failed reqID: %s: %v", c.Request.Header.Get("x-request-id"), err)
-		accesslog.SetError(c, "proxy", "request processing failed")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, "request processing failed")
+		failure := &routingError{
+			status:    http.StatusInternalServerError,
+			reason:    "proxy",
+			message:   "request processing failed",
+			condition: upstreamFailureCondition(err),
+			answered:  c.Writer.Written(),
+		}
+		var attemptsErr *attemptsFailedError
+		if errors.As(err, &attemptsErr) {
+			failure.status = attemptsErr.status
+			failure.message = attemptsErr.message
+		}
+		return failure
+	}
+	return nil
+}
+
+// requestBodyLimit returns the size up to which the body of a request is read. The route of the request is only known
+// once its body is read, so the body is read up to the largest limit of the routes it can match.
+func (r *Router) requestBodyLimit(c *gin.Context) int64 {
+	limit := r.maxBodySize
+	if routeLimit, ok := r.store.MaxRequestBodySize(c.GetString(GatewayKey)); ok && (limit <= 0 || routeLimit < limit) {
+		limit = routeLimit
 	}
+	return limit
 }
 
-func ParseModelRequest(c *gin.Context) (ModelRequest, error) {
+// ParseModelRequest reads the body of an inference request, and decodes the fields needed by the router.
+// Bodies larger than maxBodySize bytes are rejected with 413, there is no limit if it is not positive.
+// The original body is stored in the context, to be sent upstream as is if the request is not modified.
+func ParseModelRequest(c *gin.Context, maxBodySize int64) (ModelRequest, error) {
+	if maxBodySize > 0 {
+		if c.Request.ContentLength > maxBodySize {
+			return nil, abortRequestTooLarge(c, maxBodySize)
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+	}
 	bodyBytes, err := io.ReadAll(c.Request.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, abortRequestTooLarge(c, maxBodySize)
+		}
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		return nil, err
 	}
-	var modelRequest ModelRequest
-	if err := json.Unmarshal(bodyBytes, &modelRequest); err != nil {
+	body, err := utils.DecodeRequestBody(bodyBytes)
+	if err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, err)
 		return nil, err
 	}
+	modelRequest := ModelRequest(body)
+	c.Set(common.RequestBodyKey, &common.RequestBody{Raw: bodyBytes})
 
 	modelName, ok := modelRequest["model"].(string)
 	if !ok {
@@ -440,182 +687,97 @@ func ParseModelRequest(c *gin.Context) (ModelRequest, error) {
 	return modelRequest, nil
 }
 
-func (r *Router) getPodsAndServer(modelServerName types.NamespacedName) ([]*datastore.PodInfo, *v1alpha1.ModelServer, error) {
-	pods, err := r.store.GetPodsByModelServer(modelServerName)
-	if err != nil || len(pods) == 0 {
-		return nil, nil, fmt.Errorf("can't find target pods of model server: %v, err: %v", modelServerName, err)
-	}
-	modelServer := r.store.GetModelServer(modelServerName)
-	if modelServer == nil {
-		return nil, nil, fmt.Errorf("can't find model server: %v", modelServerName)
-	}
-	return pods, modelServer, nil
+// clientCanceled returns true if the client closed the connection. The upstream requests are sent with the
+// context of the downstream request, so they are aborted as well and the engines stop generating tokens.
+func clientCanceled(c *gin.Context) bool {
+	return c.Request != nil && errors.Is(c.Request.Context().Err(), context.Canceled)
 }
 
-// handleHTTPRoute handles HTTPRoute matching for non-/v1/ paths
-// Returns true if HTTPRoute was matched and request is being handled, false otherwise
-// Also returns the InferencePool NamespacedName if found
-func (r *Router) handleHTTPRoute(c *gin.Context, gatewayKey string) (bool, types.NamespacedName) {
-	// Find HTTPRoutes for this Gateway
-	httpRoutes := r.store.GetHTTPRoutesByGateway(gatewayKey)
-	if len(httpRoutes) == 0 {
-		return false, types.NamespacedName{}
+// recordClientCanceled records a request canceled by the client, whatever the step it was canceled at
+func recordClientCanceled(c *gin.Context, metricsRecorder *metrics.RequestMetricsRecorder) {
+	klog.V(4).Infof("request %s canceled by the client", c.Request.Header.Get("x-request-id"))
+	accesslog.SetError(c, clientCanceledReason, "client closed the connection")
+	c.Set("finishReason", clientCanceledReason)
+	if !c.Writer.Written() {
+		c.Status(statusClientClosedRequest)
 	}
+	metricsRecorder.RecordClientCanceled()
+}
 
-	// Match HTTPRoute by path and hostname
-	var matchedRoute *gatewayv1.HTTPRoute
-	var matchedPrefix string // Store the matched prefix for URL rewriting
-	for _, route := range httpRoutes {
-		if route == nil {
-			continue
-		}
+func abortRequestTooLarge(c *gin.Context, maxBodySize int64) error {
+	err := fmt.Errorf("request body exceeds the limit of %d bytes", maxBodySize)
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+	return err
+}
 
-		matched := false
-		for _, rule := range route.Spec.Rules {
-			if len(rule.Matches) == 0 {
-				matched = true
-				break
-			}
-			for _, match := range rule.Matches {
-				if match.Path != nil {
-					pathType := match.Path.Type
-					pathValue := match.Path.Value
-					if pathType != nil {
-						switch *pathType {
-						case gatewayv1.PathMatchExact:
-							if c.Request.URL.Path == *pathValue {
-								matched = true
-								break
-							}
-						case gatewayv1.PathMatchPathPrefix:
-							if strings.HasPrefix(c.Request.URL.Path, *pathValue) {
-								matched = true
-								matchedPrefix = *pathValue // Store matched prefix
-								break
-							}
-						case gatewayv1.PathMatchRegularExpression:
-							if regexMatched, err := regexp.MatchString(*pathValue, c.Request.URL.Path); err == nil && regexMatched {
-								matched = true
-								break
-							} else if err != nil {
-								klog.Warningf("Invalid regex pattern '%s' in HTTPRoute %s/%s: %v", *pathValue, route.Namespace, route.Name, err)
-							}
-						}
-					}
-				} else {
-					matched = true
-				}
-				if matched {
-					break
-				}
-			}
-			if matched {
-				matchedRoute = route
-				break
-			}
-		}
-		if matched {
-			break
-		}
+// latencySLO returns the latency thresholds of a ModelRoute SLO
+func latencySLO(slo *v1alpha1.LatencySLO) metrics.LatencySLO {
+	var thresholds metrics.LatencySLO
+	if slo.TimeToFirstToken != nil {
+		thresholds.TimeToFirstToken = slo.TimeToFirstToken.Duration
 	}
-
-	if matchedRoute == nil {
-		return false, types.NamespacedName{}
+	if slo.TimePerOutputToken != nil {
+		thresholds.TimePerOutputToken = slo.TimePerOutputToken.Duration
 	}
-
-	// Store the matched prefix in context for URL rewriting
-	if matchedPrefix != "" {
-		c.Set("matchedPrefix", matchedPrefix)
+	if slo.EndToEnd != nil {
+		thresholds.EndToEnd = slo.EndToEnd.Duration
 	}
+	return thresholds
+}
 
-	// Find InferencePool backendRef and apply filters
-	var inferencePoolName types.NamespacedName
-	found := false
-	var matchedRule *gatewayv1.HTTPRouteRule
-	for i := range matchedRoute.Spec.Rules {
-		rule := &matchedRoute.Spec.Rules[i]
-		for _, backendRef := range rule.BackendRefs {
-			if backendRef.Group != nil && *backendRef.Group == "inference.networking.k8s.io" &&
-				backendRef.Kind != nil && *backendRef.Kind == "InferencePool" {
-				inferencePoolName.Namespace = matchedRoute.Namespace
-				if backendRef.Namespace != nil {
-					inferencePoolName.Namespace = string(*backendRef.Namespace)
-				}
-				inferencePoolName.Name = string(backendRef.Name)
-				found = true
-				matchedRule = rule
-				break
-			}
+// requestBodySize returns the size of the body read by ParseModelRequest
+func requestBodySize(c *gin.Context) int64 {
+	if v, ok := c.Get(common.RequestBodyKey); ok {
+		if body, ok := v.(*common.RequestBody); ok {
+			return int64(len(body.Raw))
 		}
-		if found {
-			break
-		}
-	}
-
-	if !found {
-		return false, types.NamespacedName{}
 	}
+	return 0
+}
 
-	// Apply HTTPURLRewriteFilter if present
-	if matchedRule != nil && matchedRule.Filters != nil {
-		for _, filter := range matchedRule.Filters {
-			if filter.Type == gatewayv1.HTTPRouteFilterURLRewrite && filter.URLRewrite != nil {
-				r.applyURLRewrite(c, filter.URLRewrite)
-			}
+// markRequestModified records that the decoded request no longer matches the original body,
+// which must then be encoded again before being sent upstream
+func markRequestModified(c *gin.Context) {
+	if v, ok := c.Get(common.RequestBodyKey); ok {
+		if body, ok := v.(*common.RequestBody); ok {
+			body.Modified = true
 		}
 	}
-
-	return true, inferencePoolName
 }
 
-// applyURLRewrite applies HTTPURLRewriteFilter to the request
-func (r *Router) applyURLRewrite(c *gin.Context, urlRewrite *gatewayv1.HTTPURLRewriteFilter) {
-	// Apply hostname rewrite
-	if urlRewrite.Hostname != nil {
-		newHostname := string(*urlRewrite.Hostname)
-		c.Request.Host = newHostname
-		klog.V(4).Infof("Rewrote hostname to: %s", newHostname)
+// loadLoraAdapter loads the adapter of the request on the selected pod, if no pod serves it yet.
+// Only the pods serving the adapter are kept to send the request to. The returned function must be called
+// when the request is done, the adapter is not unloaded from the pod until then.
+func (r *Router) loadLoraAdapter(c *gin.Context, ctx *framework.Context, port int32) (func(), error) {
+	if len(ctx.BestPods) == 0 {
+		return func() {}, nil
 	}
-
-	// Apply path rewrite
-	if urlRewrite.Path != nil {
-		originalPath := c.Request.URL.Path
-		newPath := originalPath
-
-		switch urlRewrite.Path.Type {
-		case gatewayv1.FullPathHTTPPathModifier:
-			// Replace the full path
-			if urlRewrite.Path.ReplaceFullPath != nil {
-				newPath = *urlRewrite.Path.ReplaceFullPath
-				klog.V(4).Infof("Rewrote full path from %s to %s", originalPath, newPath)
-			}
-
-		case gatewayv1.PrefixMatchHTTPPathModifier:
-			// Replace the matched prefix with the specified replacement
-			if urlRewrite.Path.ReplacePrefixMatch != nil {
-				// Get the matched prefix from context
-				prefix, exists := c.Get("matchedPrefix")
-				if !exists {
-					klog.Errorf("matchedPrefix not found in context for path rewrite")
-					break
-				}
-				matchedPrefix, ok := prefix.(string)
-				if !ok || matchedPrefix == "" {
-					klog.Errorf("matchedPrefix is not a valid string in context")
-					break
-				}
-				// Replace the matched prefix
-				replacement := *urlRewrite.Path.ReplacePrefixMatch
-				newPath = replacement + strings.TrimPrefix(originalPath, matchedPrefix)
-				klog.V(4).Infof("Rewrote path prefix from %s to %s (matched prefix: %s)", originalPath, newPath, matchedPrefix)
-			}
+	pod := ctx.BestPods[0]
+	// Acquired before loading, so that the loads of other adapters on the pod do not unload it in between.
+	// An adapter being unloaded when it is acquired is loaded again.
+	release := r.loraLoader.Acquire(pod, ctx.Model)
+	if !r.loraLoader.Loaded(pod, ctx.Model) {
+		if err := r.loraLoader.Load(c.Request.Context(), pod, port, ctx.Model); err != nil {
+			release()
+			return nil, err
 		}
+		ctx.BestPods = slices.DeleteFunc(ctx.BestPods, func(info *datastore.PodInfo) bool {
+			return !info.Contains(ctx.Model)
+		})
+	}
+	return release, nil
+}
 
-		// Update the request path
-		c.Request.URL.Path = newPath
-		// Also update the raw path to maintain consistency
-		c.Request.URL.RawPath = ""
+func (r *Router) getPodsAndServer(modelServerName types.NamespacedName) ([]*datastore.PodInfo, *v1alpha1.ModelServer, error) {
+	pods, err := r.store.GetPodsByModelServer(modelServerName)
+	if err != nil || len(pods) == 0 {
+		return nil, nil, fmt.Errorf("can't find target pods of model server: %v, err: %v", modelServerName, err)
 	}
+	modelServer := r.store.GetModelServer(modelServerName)
+	if modelServer == nil {
+		return nil, nil, fmt.Errorf("can't find model server: %v", modelServerName)
+	}
+	return pods, modelServer, nil
 }
 
 func (r *Router) proxy(
@@ -636,7 +798,11 @@ func (r *Router) proxy(
 		}
 	}
 
+	var lastErr error
 	for i := 0; i < len(ctx.BestPods); i++ {
+		if ctx.MetricsRecorder != nil {
+			ctx.MetricsRecorder.SetPod(ctx.BestPods[i].Pod.Name)
+		}
 		// Increment upstream request count with both modelServer and modelRoute
 		r.metrics.IncActiveUpstreamRequests(modelServerName, modelRouteName)
 
@@ -647,15 +813,20 @@ func (r *Router) proxy(
 		r.metrics.DecActiveUpstreamRequests(modelServerName, modelRouteName)
 
 		if err != nil {
+			if clientCanceled(c) {
+				// Other pods would be asked for a response nobody will receive
+				c.Abort()
+				return fmt.Errorf("request canceled by the client: %w", err)
+			}
 			klog.Errorf(" pod request error: %v", err)
+			lastErr = err
 			continue
 		}
 		// record in prefix cache
 		r.scheduler.RunPostHooks(ctx, i)
 		return nil
 	}
-	c.AbortWithStatusJSON(http.StatusNotFound, "request to all pods failed")
-	return fmt.Errorf("request to all pods failed")
+	return &attemptsFailedError{status: http.StatusNotFound, message: "request to all pods failed", last: lastErr}
 }
 
 func (r *Router) proxyModelEndpoint(
@@ -685,30 +856,7 @@ func (r *Router) proxyModelEndpoint(
 		if v, ok := modelRequest["userId"].(string); ok {
 			userID = v
 		}
-		modelName := ctx.Model
-		err := r.proxy(c, decodeRequest, ctx, stream, port, func(resp handlers.OpenAIResponse) {
-			if resp.Usage.TotalTokens <= 0 {
-				return
-			}
-			// Record output tokens for rate limiting
-			if r.loadRateLimiter != nil {
-				r.loadRateLimiter.RecordOutputTokens(modelName, resp.Usage.CompletionTokens)
-			}
-			// Update access log with output tokens
-			if accessCtx := accesslog.GetAccessLogContext(c); accessCtx != nil {
-				accessCtx.SetTokenCounts(accessCtx.InputTokens, resp.Usage.CompletionTokens)
-			}
-
-			// Record output token metrics
-			if metricsRecorder != nil {
-				// Record output tokens
-				metricsRecorder.RecordOutputTokens(resp.Usage.CompletionTokens)
-			}
-			if userID == "" || modelName == "" {
-				return
-			}
-			_ = r.store.UpdateTokenCount(userID, modelName, float64(resp.Usage.PromptTokens), float64(resp.Usage.CompletionTokens))
-		})
+		err := r.proxy(c, decodeRequest, ctx, stream, port, r.recordUsage(c, ctx.Model, userID, metricsRecorder))
 
 		// Mark end of upstream processing
 		accesslog.MarkUpstreamEnd(c)
@@ -726,8 +874,89 @@ func (r *Router) proxyModelEndpoint(
 	return r.proxyToPDDisaggregated(c, req, ctx, kvConnector, modelRequest, port)
 }
 
+// recordUsage returns the callback recording the token usage reported by the upstream response
+func (r *Router) recordUsage(c *gin.Context, modelName, userID string, metricsRecorder *metrics.RequestMetricsRecorder) func(resp handlers.OpenAIResponse) {
+	return func(resp handlers.OpenAIResponse) {
+		if resp.Usage.TotalTokens <= 0 {
+			return
+		}
+		promptTokens := resp.Usage.PromptTokens
+		if promptTokens == 0 {
+			// Some pooling APIs such as rerank only report the total tokens
+			promptTokens = resp.Usage.TotalTokens - resp.Usage.CompletionTokens
+		}
+		// Record output tokens for rate limiting
+		if r.loadRateLimiter != nil && resp.Usage.CompletionTokens > 0 {
+			r.loadRateLimiter.RecordOutputTokens(modelName, resp.Usage.CompletionTokens)
+		}
+		// Update access log with output tokens
+		if accessCtx := accesslog.GetAccessLogContext(c); accessCtx != nil {
+			inputTokens := accessCtx.InputTokens
+			if resp.Usage.CompletionTokens == 0 {
+				// Pooling responses only consume input tokens, report the count of the engine
+				inputTokens = promptTokens
+			}
+			accessCtx.SetTokenCounts(inputTokens, resp.Usage.CompletionTokens)
+		}
+
+		// Record output token metrics
+		if metricsRecorder != nil && resp.Usage.CompletionTokens > 0 {
+			// Record output tokens
+			metricsRecorder.RecordOutputTokens(resp.Usage.CompletionTokens)
+		}
+		if userID == "" || modelName == "" {
+			return
+		}
+		_ = r.store.UpdateTokenCount(userID, modelName, float64(promptTokens), float64(resp.Usage.CompletionTokens))
+	}
+}
+
+// countInputTokens estimates the number of input tokens of all the sequences of the request
+func (r *Router) countInputTokens(input *common.RequestInput) int {
+	total := input.TokenIDs + input.MediaTokens
+	for _, sequence := range input.Sequences {
+		tokens, err := r.tokenizer.CalculateTokenNum(sequence)
+		if err != nil {
+			klog.Errorf("failed to calculate token number: %v", err)
+			tokens = len(sequence) / 4 // fallback estimation
+		}
+		total += tokens
+	}
+	return total
+}
+
+// proxyToService proxies a request matched by an HTTPRoute to a Service backendRef.
+// Endpoint picking is left to the Service, so the scheduler is not involved.
+func (r *Router) proxyToService(c *gin.Context, modelRequest ModelRequest, backend *httpRouteBackend) {
+	var metricsRecorder *metrics.RequestMetricsRecorder
+	if recorder, exists := c.Get("metricsRecorder"); exists {
+		if rec, ok := recorder.(*metrics.RequestMetricsRecorder); ok {
+			metricsRecorder = rec
+		}
+	}
+	userID := ""
+	if v, ok := modelRequest["userId"].(string); ok {
+		userID = v
+	}
+	modelName, _ := modelRequest["model"].(string)
+
+	accesslog.SetRequestRouting(c, "", backend.Name.String(), "")
+	if metricsRecorder != nil {
+		metricsRecorder.SetUpstreamConnectionInfo(backend.Name.String(), "")
+	}
+	accesslog.MarkUpstreamStart(c)
+	req := connectors.BuildDecodeRequest(c, c.Request, modelRequest)
+	err := proxyRequest(c, req, serviceHost(backend.Name), backend.Port, isStreaming(modelRequest), r.recordUsage(c, modelName, userID, metricsRecorder))
+	accesslog.MarkUpstreamEnd(c)
+	if err != nil && !clientCanceled(c) {
+		klog.Errorf("request to service %s failed reqID: %s: %v", backend.Name, c.Request.Header.Get("x-request-id"), err)
+		accesslog.SetError(c, "proxy", "request processing failed")
+		c.AbortWithStatusJSON(http.StatusBadGateway, "request processing failed")
+	}
+}
+
 func (r *Router) GetModelServer(modelName string, req *http.Request) (*v1alpha1.ModelServer, error) {
-	modelServerName, isLora, _, err := r.store.MatchModelServer(modelName, req, "")
+	modelServerName, isLora, _, _, err := r.store.MatchModelServer(modelName, req, nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("can't find corresponding model server: %v", err)
 	}
@@ -759,7 +988,22 @@ func proxyRequest(
 	stream bool,
 	onUsage func(u handlers.OpenAIResponse),
 ) error {
+	// Bind the upstream request to the client, so that it is aborted if the client goes away
+	reqCtx, cancel := context.WithCancelCause(c.Request.Context())
+	defer cancel(nil)
+	req = req.WithContext(reqCtx)
+	// The request is given up if the pod does not answer in time, a fallback target may serve it
+	var timer *time.Timer
+	if timeout := responseTimeout(c); timeout > 0 {
+		timer = time.AfterFunc(timeout, func() { cancel(errUpstreamTimeout) })
+	}
 	resp, err := doRequest(req, podIP, port)
+	if timer != nil && !timer.Stop() && errors.Is(context.Cause(reqCtx), errUpstreamTimeout) {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return fmt.Errorf("decode request error: %w", errUpstreamTimeout)
+	}
 	if err != nil {
 		return fmt.Errorf("decode request error: %w", err)
 	}
@@ -768,62 +1012,13 @@ func proxyRequest(
 			c.Header(k, v)
 		}
 	}
+	applyResponseHeaderFilters(c)
 	defer resp.Body.Close()
 
 	c.Status(resp.StatusCode)
 
-	if stream {
-		// If the request is a streaming request, we need to stream the response body.
-		// Stream response: read and forward each event (line) one by one, and parse usage if present
-		c.Status(resp.StatusCode)
-		reader := bufio.NewReader(resp.Body)
-		c.Stream(func(w io.Writer) bool {
-			line, err := reader.ReadBytes('\n')
-			if len(line) > 0 {
-				// Try to parse usage from this line, assuming it's a data line
-				parsed := handlers.ParseStreamRespForUsage(string(line))
-				if parsed.Usage.CompletionTokens > 0 {
-					klog.V(4).Infof("Parsed usage: %+v", parsed.Usage)
-
-					// The token usage is set by router, so remove it before sending to downstream
-					if v, ok := c.Get(common.TokenUsageKey); ok && v.(bool) {
-						return true
-					}
-					if onUsage != nil {
-						onUsage(parsed)
-					}
-				}
-				// Forward to downstream
-				_, _ = w.Write(line)
-			}
-			if err != nil {
-				if err != io.EOF {
-					klog.Errorf("error reading stream body: %v", err)
-				}
-				return false
-			}
-			return true
-		})
-	} else {
-		// Non-stream: efficiently stream response while capturing for parsing
-		var buf bytes.Buffer
-		ttee := io.TeeReader(resp.Body, &buf)
-
-		_, err := io.Copy(c.Writer, ttee)
-		if err != nil {
-			klog.Errorf("copy response to downstream failed: %v", err)
-			return nil
-		}
-
-		// Parse usage if present
-		parsed, _ := handlers.ParseOpenAIResponseBody(buf.Bytes())
-		if parsed != nil && parsed.Usage.CompletionTokens > 0 {
-			klog.V(4).Infof("Parsed usage: %+v", parsed.Usage)
-			if onUsage != nil {
-				onUsage(*parsed)
-			}
-		}
-	}
+	// Once the response has started, a failure to forward it can not be reported to the client anymore
+	_, _ = connectors.NewResponseProcessor(c, onUsage).Forward(resp, stream)
 
 	return nil
 }
@@ -836,14 +1031,18 @@ func doRequest(
 	// step 1: change request URL to prefill pod URL.
 	req.URL.Host = fmt.Sprintf("%s:%d", podIP, port)
 
-	// step 2: use http.Transport to do request to prefill pod.
-	transport := http.DefaultTransport
-	resp, err := transport.RoundTrip(req)
+	// step 2: use the shared upstream transport to do request to prefill pod, so that connections are reused.
+	// The request may have been sent to another pod already, so its body is read again.
+	if err := connectors.RewindBody(req); err != nil {
+		return nil, err
+	}
+	resp, err := connectors.UpstreamTransport().RoundTrip(req)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("http resp error, http code is %d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, &upstreamStatusError{statusCode: resp.StatusCode}
 	}
 	return resp, nil
 }
@@ -911,12 +1110,20 @@ func (r *Router) proxyToPDDisaggregated(
 		metricsRecorder.SetUpstreamConnectionInfo(modelServerName, modelRouteName)
 	}
 
+	// The usage of the decode response is recorded as in the aggregated mode
+	userID := ""
+	if v, ok := modelRequest["userId"].(string); ok {
+		userID = v
+	}
+	connectors.SetResponseProcessor(c, connectors.NewResponseProcessor(c, r.recordUsage(c, ctx.Model, userID, metricsRecorder)))
+
 	// Try multiple prefill/decode pairs
 	maxRetry := len(ctx.DecodePods)
 	if len(ctx.PrefillPods) < maxRetry {
 		maxRetry = len(ctx.PrefillPods)
 	}
 
+	var lastErr error
 	for i := 0; i < maxRetry; i++ {
 		if ctx.PrefillPods[i] == nil || ctx.DecodePods[i] == nil {
 			continue
@@ -927,26 +1134,24 @@ func (r *Router) proxyToPDDisaggregated(
 		decodeAddr := fmt.Sprintf("%s:%d", ctx.DecodePods[i].Pod.Status.PodIP, port)
 
 		klog.V(4).Infof("Attempting PD disaggregated request: prefill=%s, decode=%s", prefillAddr, decodeAddr)
+		if metricsRecorder != nil {
+			metricsRecorder.SetPod(ctx.DecodePods[i].Pod.Name)
+		}
 
 		// Execute the PD disaggregated proxy operation
 		outputTokens, err := kvConnector.Proxy(c, modelRequest, prefillAddr, decodeAddr)
 
 		if err != nil {
+			if clientCanceled(c) {
+				c.Abort()
+				return fmt.Errorf("request canceled by the client: %w", err)
+			}
 			klog.Errorf("proxy failed for prefill pod %s, decode pod %s: %v",
 				ctx.PrefillPods[i].Pod.Name, ctx.DecodePods[i].Pod.Name, err)
+			lastErr = err
 			continue
 		}
 
-		// Record output tokens for rate limiting
-		if outputTokens > 0 && r.loadRateLimiter != nil {
-			r.loadRateLimiter.RecordOutputTokens(ctx.Model, outputTokens)
-		}
-
-		// Record output token metrics
-		if metricsRecorder != nil {
-			metricsRecorder.RecordOutputTokens(outputTokens)
-		}
-
 		// Record successful operation in cache
 		r.scheduler.RunPostHooks(ctx, i)
 
@@ -956,8 +1161,7 @@ func (r *Router) proxyToPDDisaggregated(
 		return nil
 	}
 
-	c.AbortWithStatusJSON(http.StatusInternalServerError, "all prefill/decode attempts failed")
-	return fmt.Errorf("all prefill/decode attempts failed")
+	return &attemptsFailedError{status: http.StatusInternalServerError, message: "all prefill/decode attempts failed", last: lastErr}
 }
 
 // handleFairnessScheduling handles the fairness scheduling flow for requests
@@ -993,6 +1197,9 @@ func (r *Router) handleFairnessScheduling(c *gin.Context, modelRequest ModelRequ
 	case <-queueReq.NotifyChan:
 		r.doLoadbalance(c, modelRequest)
 		return nil
+	case <-c.Request.Context().Done():
+		// The request is dropped when it is dequeued
+		return fmt.Errorf("request %s canceled by the client while queued", requestID)
 	case <-time.After(60 * time.Second):
 		// avoid blocking indefinitely
 		klog.Errorf("request %s processing timed out after 60 seconds", requestID)
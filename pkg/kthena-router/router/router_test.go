@@ -17,7 +17,9 @@ limitations under the License.
 package router
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -28,13 +30,20 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"istio.io/istio/pkg/util/sets"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -45,6 +54,7 @@ import (
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/connectors"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/metrics"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
 	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/plugins/conf"
@@ -364,6 +374,87 @@ func TestRouter_HandlerFunc_ModelNotFound(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "route not found")
 }
 
+func TestRouter_HandlerFunc_AnthropicMessages(t *testing.T) {
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		var reqBody ModelRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &reqBody))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"role": "system", "content": "Be brief."},
+			map[string]interface{}{"role": "user", "content": "hello"},
+		}, reqBody["messages"])
+
+		if stream, _ := reqBody["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"test-model\",\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"test-model\",\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"test-model\",\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","model":"test-model","choices":[{"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec:       aiv1alpha1.ModelServerSpec{WorkloadPort: aiv1alpha1.WorkloadPort{Port: int32(backendPort)}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+	}
+	store.AddOrUpdateModelServer(modelServer, sets.New(types.NamespacedName{Name: "pod-1", Namespace: "default"}))
+	store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer})
+	store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules:     []*aiv1alpha1.Rule{{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}}},
+		},
+	})
+
+	serve := func(body string) *connectors.TestResponseRecorder {
+		// Streaming needs a recorder implementing CloseNotify
+		w := connectors.CreateTestResponseRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		router.HandlerFunc()(c)
+		return w
+	}
+
+	t.Run("non streaming", func(t *testing.T) {
+		w := serve(`{"model": "test-model", "max_tokens": 16, "system": "Be brief.", "messages": [{"role": "user", "content": "hello"}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":"chatcmpl-1","type":"message","role":"assistant","model":"test-model",
+			"content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","stop_sequence":null,
+			"usage":{"input_tokens":5,"output_tokens":1}}`, w.Body.String())
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		w := serve(`{"model": "test-model", "max_tokens": 16, "stream": true, "system": "Be brief.", "messages": [{"role": "user", "content": "hello"}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "event: message_start\n")
+		assert.Contains(t, body, `"delta":{"text":"Hi","type":"text_delta"}`)
+		assert.Contains(t, body, `"usage":{"input_tokens":5,"output_tokens":1}`)
+		assert.True(t, strings.HasSuffix(body, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+		assert.NotContains(t, body, "[DONE]")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := serve(`{"model": "unknown-model", "max_tokens": 16, "messages": [{"role": "user", "content": "hello"}]}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"type":"error","error":{"type":"not_found_error","message":"route not found"}}`, w.Body.String())
+	})
+}
+
 func TestRouter_HandlerFunc_ScheduleFailure(t *testing.T) {
 	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// This should not be called
@@ -426,6 +517,382 @@ func TestRouter_HandlerFunc_ScheduleFailure(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "can't schedule to target pod")
 }
 
+// addTestModelServer adds a model server serving model without rewriting it, with the pods listening on the backend
+func addTestModelServer(store datastore.Store, backend *httptest.Server, route *aiv1alpha1.ModelRoute, podNames ...string) {
+	backendURL, _ := url.Parse(backend.URL)
+	backendPort, _ := strconv.Atoi(backendURL.Port())
+
+	modelServer := &aiv1alpha1.ModelServer{
+		ObjectMeta: v1.ObjectMeta{Name: "ms-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelServerSpec{
+			WorkloadPort:    aiv1alpha1.WorkloadPort{Port: int32(backendPort)},
+			InferenceEngine: "vLLM",
+		},
+	}
+	podKeys := sets.New[types.NamespacedName]()
+	for _, name := range podNames {
+		podKeys.Insert(types.NamespacedName{Name: name, Namespace: "default"})
+	}
+	store.AddOrUpdateModelServer(modelServer, podKeys)
+	for _, name := range podNames {
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     corev1.PodStatus{PodIP: backendURL.Hostname(), Phase: corev1.PodRunning},
+		}
+		store.AddOrUpdatePod(pod, []*aiv1alpha1.ModelServer{modelServer})
+	}
+	store.AddOrUpdateModelRoute(route)
+}
+
+func newTestModelRoute() *aiv1alpha1.ModelRoute {
+	return &aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}}},
+			},
+		},
+	}
+}
+
+func TestRouter_HandlerFunc_H2CModelServer(t *testing.T) {
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, r.Proto)
+	}))
+	backend.Config.Protocols = new(http.Protocols)
+	backend.Config.Protocols.SetHTTP1(true)
+	backend.Config.Protocols.SetUnencryptedHTTP2(true)
+	backend.Start()
+	defer backend.Close()
+
+	gin.SetMode(gin.TestMode)
+	store := datastore.New()
+	router := NewRouter(store, "")
+	addTestModelServer(store, backend, newTestModelRoute(), "pod-1")
+
+	send := func() string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(`{"model": "test-model", "prompt": "hello"}`))
+		router.HandlerFunc()(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	assert.Equal(t, "HTTP/1.1", send())
+
+	// The pods of a ModelServer whose workload port uses h2c are reached over HTTP/2
+	modelServer := store.GetModelServer(types.NamespacedName{Namespace: "default", Name: "ms-1"}).DeepCopy()
+	modelServer.Spec.WorkloadPort.Protocol = aiv1alpha1.WorkloadPortProtocolH2C
+	store.AddOrUpdateModelServer(modelServer, sets.New(types.NamespacedName{Namespace: "default", Name: "pod-1"}))
+	assert.Equal(t, "HTTP/2.0", send())
+}
+
+func TestRouter_DeletesPodLatencySeries(t *testing.T) {
+	router, store, backend := setupTestRouter(http.NotFoundHandler())
+	defer backend.Close()
+	addTestModelServer(store, backend, newTestModelRoute(), "pod-deleted")
+
+	series := func() int {
+		return testutil.CollectAndCount(&router.metrics.TimeToFirstToken) +
+			testutil.CollectAndCount(&router.metrics.TimePerOutputToken) +
+			testutil.CollectAndCount(&router.metrics.E2ERequestLatency)
+	}
+	before := series()
+	router.metrics.RecordResponseLatency("test-model", "default/ms-1", "default/mr-1", "pod-deleted", time.Second, time.Millisecond, 2*time.Second)
+	assert.Equal(t, before+3, series())
+
+	// The callbacks of the store are called asynchronously
+	assert.NoError(t, store.DeletePod(types.NamespacedName{Namespace: "default", Name: "pod-deleted"}))
+	assert.Eventually(t, func() bool {
+		return series() == before
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRouter_HandlerFunc_RequestTooLarge(t *testing.T) {
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("backend should not be called for a request too large")
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+	addTestModelServer(store, backend, newTestModelRoute(), "pod-1")
+
+	reqBody := `{"model": "test-model", "prompt": "hello world"}`
+	tests := []struct {
+		name          string
+		contentLength int64
+	}{
+		{name: "content length exceeds the limit", contentLength: int64(len(reqBody))},
+		// The body is read up to the limit if the length is unknown
+		{name: "chunked body exceeds the limit", contentLength: -1},
+	}
+
+	router.maxBodySize = 32
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(reqBody))
+			c.Request.ContentLength = tt.contentLength
+
+			router.HandlerFunc()(c)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+			assert.Contains(t, w.Body.String(), "request body exceeds the limit of 32 bytes")
+		})
+	}
+}
+
+func TestRouter_HandlerFunc_ModelRouteBodyLimit(t *testing.T) {
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"response-id"}`)
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+
+	route := newTestModelRoute()
+	limit := resource.MustParse("64")
+	route.Spec.MaxRequestBodySize = &limit
+	addTestModelServer(store, backend, route, "pod-1")
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{body: `{"model": "test-model", "prompt": "hello"}`, code: http.StatusOK},
+		{body: `{"model": "test-model", "prompt": "hello, this prompt is too long for the route"}`, code: http.StatusRequestEntityTooLarge},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(tc.body))
+
+		router.HandlerFunc()(c)
+
+		assert.Equal(t, tc.code, w.Code, tc.body)
+	}
+
+	// The body is not read beyond the limit of the route
+	body := &countingReader{Reader: strings.NewReader(`{"model": "test-model", "prompt": "` + strings.Repeat("a", 1<<20) + `"}`)}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", body)
+	c.Request.ContentLength = -1
+
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.LessOrEqual(t, body.read, 4096)
+}
+
+// countingReader counts the bytes read from a reader
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestRouter_HandlerFunc_ForwardsOriginalBody(t *testing.T) {
+	// The fields not used by the router, such as the floats and the schema, must be sent as they were received
+	reqBody := `{"model": "test-model", "prompt": "hello", "temperature": 0.70, "response_format": {"type": "json_schema", "json_schema": {"b": 1, "a": 2}}}`
+	var received []string
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		assert.Equal(t, int64(len(body)), r.ContentLength)
+		if len(received) == 1 {
+			// The request is retried on another pod, with the same body
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id":"response-id"}`)
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+	addTestModelServer(store, backend, newTestModelRoute(), "pod-1", "pod-2")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/completions", bytes.NewBufferString(reqBody))
+
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	expected := strings.TrimSuffix(reqBody, "}") + `,"include_usage":true}`
+	assert.Equal(t, []string{expected, expected}, received)
+}
+
+func TestRouter_HandlerFunc_StreamUsageInjectedByRouter(t *testing.T) {
+	chunk := "data: {\"id\":\"chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n"
+	usage := "data: {\"id\":\"chunk\",\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":5,\"total_tokens\":8}}\n\n"
+	done := "data: [DONE]\n\n"
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// The client did not ask for the usage, the router did
+		assert.Contains(t, string(body), `"stream_options":{"include_usage":true}`)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, chunk+usage+done)
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+	addTestModelServer(store, backend, newTestModelRoute(), "pod-1")
+
+	outputTokens := router.metrics.TokensTotal.WithLabelValues("test-model", "/v1/chat/completions", metrics.TokenTypeOutput)
+	before := testutil.ToFloat64(outputTokens)
+
+	w := connectors.CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	reqBody := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "stream": true}`
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", strings.NewReader(reqBody))
+
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The usage chunk is removed, but the usage is still accounted for
+	assert.Equal(t, chunk+done, w.Body.String())
+	assert.Equal(t, before+5, testutil.ToFloat64(outputTokens))
+}
+
+func TestRouter_HandlerFunc_LatencyMetrics(t *testing.T) {
+	backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"text\":\"%d\"}]}\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":3,\"total_tokens\":4}}\n\n")
+	})
+	router, store, backend := setupTestRouter(backendHandler)
+	defer backend.Close()
+	route := newTestModelRoute()
+	route.Spec.SLO = &aiv1alpha1.LatencySLO{
+		TimeToFirstToken: &v1.Duration{Duration: time.Minute},
+		EndToEnd:         &v1.Duration{Duration: time.Nanosecond},
+	}
+	addTestModelServer(store, backend, route, "pod-1")
+
+	labels := []string{"test-model", "default/ms-1", "default/mr-1", "pod-1"}
+	ttftBefore := histogramCount(t, router.metrics.TimeToFirstToken.WithLabelValues(labels...))
+	tpotBefore := histogramCount(t, router.metrics.TimePerOutputToken.WithLabelValues(labels...))
+	e2eBefore := histogramCount(t, router.metrics.E2ERequestLatency.WithLabelValues(labels...))
+	ttftAttained := router.metrics.SLORequests.WithLabelValues("test-model", "default/mr-1", metrics.SLOTimeToFirstToken, "true")
+	e2eMissed := router.metrics.SLORequests.WithLabelValues("test-model", "default/mr-1", metrics.SLOEndToEnd, "false")
+	tpotChecked := router.metrics.SLORequests.WithLabelValues("test-model", "default/mr-1", metrics.SLOTimePerOutputToken, "true")
+	ttftAttainedBefore, e2eMissedBefore, tpotCheckedBefore := testutil.ToFloat64(ttftAttained), testutil.ToFloat64(e2eMissed), testutil.ToFloat64(tpotChecked)
+
+	w := connectors.CreateTestResponseRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/v1/completions", strings.NewReader(`{"model": "test-model", "prompt": "hi", "stream": true}`))
+
+	router.HandlerFunc()(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ttftBefore+1, histogramCount(t, router.metrics.TimeToFirstToken.WithLabelValues(labels...)))
+	assert.Equal(t, tpotBefore+1, histogramCount(t, router.metrics.TimePerOutputToken.WithLabelValues(labels...)))
+	assert.Equal(t, e2eBefore+1, histogramCount(t, router.metrics.E2ERequestLatency.WithLabelValues(labels...)))
+	assert.Equal(t, ttftAttainedBefore+1, testutil.ToFloat64(ttftAttained))
+	assert.Equal(t, e2eMissedBefore+1, testutil.ToFloat64(e2eMissed))
+	// There is no objective for the time per output token
+	assert.Equal(t, tpotCheckedBefore, testutil.ToFloat64(tpotChecked))
+}
+
+func histogramCount(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestRouter_HandlerFunc_ClientCanceled(t *testing.T) {
+	tests := []struct {
+		name string
+		// sendChunk makes the upstream start the response before the client goes away
+		sendChunk bool
+	}{
+		{name: "canceled while streaming", sendChunk: true},
+		{name: "canceled before the response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			upstreamCanceled := make(chan struct{})
+			backendHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The server only notices a closed connection once the body is read
+				_, _ = io.ReadAll(r.Body)
+				calls.Add(1)
+				if tt.sendChunk {
+					w.Header().Set("Content-Type", "text/event-stream")
+					w.WriteHeader(http.StatusOK)
+					fmt.Fprint(w, "data: {\"id\":\"chunk\"}\n\n")
+					w.(http.Flusher).Flush()
+				}
+				select {
+				case <-r.Context().Done():
+					close(upstreamCanceled)
+				case <-time.After(10 * time.Second):
+				}
+			})
+			router, store, backend := setupTestRouter(backendHandler)
+			defer backend.Close()
+			addTestModelServer(store, backend, newTestModelRoute(), "pod-1", "pod-2")
+
+			engine := gin.New()
+			engine.POST("/v1/chat/completions", router.HandlerFunc())
+			server := httptest.NewServer(engine)
+			defer server.Close()
+
+			canceled := router.metrics.ClientCanceledRequests.WithLabelValues("test-model", "/v1/chat/completions")
+			before := testutil.ToFloat64(canceled)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			reqBody := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "stream": true}`
+			req, _ := http.NewRequestWithContext(ctx, "POST", server.URL+"/v1/chat/completions", strings.NewReader(reqBody))
+			if tt.sendChunk {
+				resp, err := http.DefaultClient.Do(req)
+				if !assert.NoError(t, err) {
+					cancel()
+					return
+				}
+				line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+				assert.Contains(t, line, "chunk")
+				cancel()
+				resp.Body.Close()
+			} else {
+				go func() {
+					for calls.Load() == 0 {
+						time.Sleep(10 * time.Millisecond)
+					}
+					cancel()
+				}()
+				_, err := http.DefaultClient.Do(req)
+				assert.Error(t, err)
+			}
+
+			select {
+			case <-upstreamCanceled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("upstream request was not canceled")
+			}
+			assert.Eventually(t, func() bool { return testutil.ToFloat64(canceled) == before+1 }, 5*time.Second, 10*time.Millisecond)
+			// The request is not retried on the other pod
+			assert.Equal(t, int32(1), calls.Load())
+		})
+	}
+}
+
 func TestAccessLogConfigurationFromEnv(t *testing.T) {
 	// Save original environment variables
 	originalEnabled := os.Getenv("ACCESS_LOG_ENABLED")
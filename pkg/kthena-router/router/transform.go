@@ -0,0 +1,231 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+)
+
+// applyBodyTransforms applies the transforms of a ModelRoute rule to a request, in order.
+// A transform which does not fit the request is skipped. It returns true if the request was modified.
+func applyBodyTransforms(modelRequest ModelRequest, transforms []v1alpha1.BodyTransform) bool {
+	modified := false
+	for i := range transforms {
+		changed, err := applyBodyTransform(modelRequest, &transforms[i])
+		if err != nil {
+			klog.V(4).Infof("skipping %s transform of %q: %v", transforms[i].Type, transforms[i].Path, err)
+			continue
+		}
+		modified = modified || changed
+	}
+	return modified
+}
+
+func applyBodyTransform(modelRequest ModelRequest, transform *v1alpha1.BodyTransform) (bool, error) {
+	switch transform.Type {
+	case v1alpha1.BodyTransformPrependMessage:
+		return prependMessage(modelRequest, transform.Message)
+	case v1alpha1.BodyTransformRemoveMessages:
+		return removeMessages(modelRequest, transform.Role)
+	}
+
+	keys := strings.Split(transform.Path, ".")
+	// The model must stay a string, the router reads it after the transforms
+	if transform.Path == "model" && transform.Type != v1alpha1.BodyTransformSet && transform.Type != v1alpha1.BodyTransformDefault {
+		return false, fmt.Errorf("the model can only be set")
+	}
+	create := transform.Type == v1alpha1.BodyTransformSet || transform.Type == v1alpha1.BodyTransformDefault
+	parent, err := parentObject(modelRequest, keys, create)
+	if err != nil || parent == nil {
+		return false, err
+	}
+	key := keys[len(keys)-1]
+
+	switch transform.Type {
+	case v1alpha1.BodyTransformSet, v1alpha1.BodyTransformDefault:
+		if transform.Type == v1alpha1.BodyTransformDefault && !isNull(parent[key]) {
+			return false, nil
+		}
+		value, err := decodeJSON(transform.Value)
+		if err != nil {
+			return false, err
+		}
+		if _, isString := value.(string); transform.Path == "model" && !isString {
+			return false, fmt.Errorf("the model must be a string")
+		}
+		parent[key] = value
+		return true, nil
+	case v1alpha1.BodyTransformRemove:
+		if _, ok := parent[key]; !ok {
+			return false, nil
+		}
+		delete(parent, key)
+		return true, nil
+	case v1alpha1.BodyTransformClamp:
+		return clampNumber(parent, key, transform.Min, transform.Max)
+	default:
+		return false, fmt.Errorf("unknown transform type")
+	}
+}
+
+// parentObject returns the object holding the last key of a path, creating the missing objects if asked to.
+// It returns nil if an object is missing.
+func parentObject(object map[string]interface{}, keys []string, create bool) (map[string]interface{}, error) {
+	for _, key := range keys[:len(keys)-1] {
+		value, err := decodedValue(object, key)
+		if err != nil {
+			return nil, err
+		}
+		if isNull(value) {
+			if !create {
+				return nil, nil
+			}
+			value = map[string]interface{}{}
+			object[key] = value
+		}
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is not an object", key)
+		}
+		object = child
+	}
+	return object, nil
+}
+
+// decodedValue returns the value of a key of an object. Values kept as raw JSON by the request parser
+// are decoded, and replaced in the object so that they can be modified.
+func decodedValue(object map[string]interface{}, key string) (interface{}, error) {
+	raw, ok := object[key].(json.RawMessage)
+	if !ok {
+		return object[key], nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	object[key] = value
+	return value, nil
+}
+
+// clampNumber bounds the number of a key between min and max, if the key is set
+func clampNumber(object map[string]interface{}, key string, minValue, maxValue *apiextensionsv1.JSON) (bool, error) {
+	value, err := decodedValue(object, key)
+	if err != nil || isNull(value) {
+		return false, err
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return false, fmt.Errorf("%s is not a number", key)
+	}
+	if minValue != nil {
+		bound, err := decodeNumber(minValue)
+		if err != nil {
+			return false, err
+		}
+		if number < bound {
+			object[key] = bound
+			return true, nil
+		}
+	}
+	if maxValue != nil {
+		bound, err := decodeNumber(maxValue)
+		if err != nil {
+			return false, err
+		}
+		if number > bound {
+			object[key] = bound
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// prependMessage adds a message before the messages of a chat request
+func prependMessage(modelRequest ModelRequest, message *v1alpha1.TransformMessage) (bool, error) {
+	if message == nil {
+		return false, fmt.Errorf("no message")
+	}
+	messages, ok := modelRequest["messages"].([]interface{})
+	if !ok {
+		return false, fmt.Errorf("not a chat request")
+	}
+	role := message.Role
+	if role == "" {
+		role = "system"
+	}
+	added := map[string]interface{}{"role": role, "content": message.Content}
+	modelRequest["messages"] = append([]interface{}{added}, messages...)
+	return true, nil
+}
+
+// removeMessages removes the messages with a role from a chat request
+func removeMessages(modelRequest ModelRequest, role string) (bool, error) {
+	messages, ok := modelRequest["messages"].([]interface{})
+	if !ok {
+		return false, fmt.Errorf("not a chat request")
+	}
+	kept := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		if m, ok := message.(map[string]interface{}); ok && m["role"] == role {
+			continue
+		}
+		kept = append(kept, message)
+	}
+	if len(kept) == len(messages) {
+		return false, nil
+	}
+	modelRequest["messages"] = kept
+	return true, nil
+}
+
+func decodeJSON(value *apiextensionsv1.JSON) (interface{}, error) {
+	if value == nil {
+		return nil, fmt.Errorf("no value")
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(value.Raw, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func decodeNumber(value *apiextensionsv1.JSON) (float64, error) {
+	decoded, err := decodeJSON(value)
+	if err != nil {
+		return 0, err
+	}
+	number, ok := decoded.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a number", value.Raw)
+	}
+	return number, nil
+}
+
+// isNull returns true if a value is missing or null
+func isNull(value interface{}) bool {
+	if raw, ok := value.(json.RawMessage); ok {
+		return string(raw) == "null"
+	}
+	return value == nil
+}
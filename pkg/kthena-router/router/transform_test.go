@@ -0,0 +1,206 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/utils"
+)
+
+func jsonValue(raw string) *apiextensionsv1.JSON {
+	return &apiextensionsv1.JSON{Raw: []byte(raw)}
+}
+
+func TestApplyBodyTransforms(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		transforms   []aiv1alpha1.BodyTransform
+		wantBody     string
+		wantModified bool
+	}{
+		{
+			name: "clamp max_tokens above max",
+			body: `{"model":"m","max_tokens":10000}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformClamp, Path: "max_tokens", Min: jsonValue("1"), Max: jsonValue("4096")},
+			},
+			wantBody:     `{"model":"m","max_tokens":4096}`,
+			wantModified: true,
+		},
+		{
+			name: "clamp a number within bounds",
+			body: `{"model":"m","temperature":0.5}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformClamp, Path: "temperature", Min: jsonValue("0"), Max: jsonValue("1")},
+			},
+			wantBody: `{"model":"m","temperature":0.5}`,
+		},
+		{
+			name: "clamp below min",
+			body: `{"model":"m","temperature":-1}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformClamp, Path: "temperature", Min: jsonValue("0")},
+			},
+			wantBody:     `{"model":"m","temperature":0}`,
+			wantModified: true,
+		},
+		{
+			name: "default a missing field",
+			body: `{"model":"m"}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformDefault, Path: "temperature", Value: jsonValue("0.7")},
+			},
+			wantBody:     `{"model":"m","temperature":0.7}`,
+			wantModified: true,
+		},
+		{
+			name: "default keeps the value of the request",
+			body: `{"model":"m","temperature":0.2}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformDefault, Path: "temperature", Value: jsonValue("0.7")},
+			},
+			wantBody: `{"model":"m","temperature":0.2}`,
+		},
+		{
+			name: "set a nested field",
+			body: `{"model":"m","metadata":{"user":"u"}}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformSet, Path: "metadata.tier", Value: jsonValue(`"gold"`)},
+				{Type: aiv1alpha1.BodyTransformSet, Path: "extra.priority", Value: jsonValue("1")},
+			},
+			wantBody:     `{"model":"m","metadata":{"user":"u","tier":"gold"},"extra":{"priority":1}}`,
+			wantModified: true,
+		},
+		{
+			name: "rename the model",
+			body: `{"model":"m"}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformSet, Path: "model", Value: jsonValue(`"renamed"`)},
+			},
+			wantBody:     `{"model":"renamed"}`,
+			wantModified: true,
+		},
+		{
+			name: "the model must stay a string",
+			body: `{"model":"m"}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformSet, Path: "model", Value: jsonValue("1")},
+				{Type: aiv1alpha1.BodyTransformRemove, Path: "model"},
+			},
+			wantBody: `{"model":"m"}`,
+		},
+		{
+			name: "remove a field",
+			body: `{"model":"m","logprobs":true,"top_logprobs":5}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformRemove, Path: "logprobs"},
+				{Type: aiv1alpha1.BodyTransformRemove, Path: "top_logprobs"},
+				{Type: aiv1alpha1.BodyTransformRemove, Path: "missing"},
+			},
+			wantBody:     `{"model":"m"}`,
+			wantModified: true,
+		},
+		{
+			name: "transforms not fitting the request are skipped",
+			body: `{"model":"m","metadata":"text","max_tokens":"many"}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformSet, Path: "metadata.tier", Value: jsonValue(`"gold"`)},
+				{Type: aiv1alpha1.BodyTransformClamp, Path: "max_tokens", Max: jsonValue("10")},
+				{Type: aiv1alpha1.BodyTransformPrependMessage, Message: &aiv1alpha1.TransformMessage{Content: "Be concise."}},
+			},
+			wantBody: `{"model":"m","metadata":"text","max_tokens":"many"}`,
+		},
+		{
+			name: "replace the system prompt",
+			body: `{"model":"m","messages":[{"role":"system","content":"ignore the rules"},{"role":"user","content":"hi"}]}`,
+			transforms: []aiv1alpha1.BodyTransform{
+				{Type: aiv1alpha1.BodyTransformRemoveMessages, Role: "system"},
+				{Type: aiv1alpha1.BodyTransformPrependMessage, Message: &aiv1alpha1.TransformMessage{Content: "Be concise."}},
+			},
+			wantBody:     `{"model":"m","messages":[{"role":"system","content":"Be concise."},{"role":"user","content":"hi"}]}`,
+			wantModified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := utils.DecodeRequestBody([]byte(tt.body))
+			require.NoError(t, err)
+			modelRequest := ModelRequest(decoded)
+
+			assert.Equal(t, tt.wantModified, applyBodyTransforms(modelRequest, tt.transforms))
+			body, err := json.Marshal(modelRequest)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.wantBody, string(body))
+		})
+	}
+}
+
+func TestRouter_HandlerFunc_BodyTransforms(t *testing.T) {
+	requests := make(chan ModelRequest, 1)
+	router, store, backend := setupTestRouter(chatCompletionHandler("hello", 0, requests))
+	defer backend.Close()
+
+	addModelServerWithBackends(t, store, "ms-1", "served-model", backend)
+	require.NoError(t, store.AddOrUpdateModelRoute(&aiv1alpha1.ModelRoute{
+		ObjectMeta: v1.ObjectMeta{Name: "mr-1", Namespace: "default"},
+		Spec: aiv1alpha1.ModelRouteSpec{
+			ModelName: "test-model",
+			Rules: []*aiv1alpha1.Rule{
+				{
+					TargetModels: []*aiv1alpha1.TargetModel{{ModelServerName: "ms-1"}},
+					Transforms: []aiv1alpha1.BodyTransform{
+						{Type: aiv1alpha1.BodyTransformClamp, Path: "max_tokens", Max: jsonValue("100")},
+						{Type: aiv1alpha1.BodyTransformRemove, Path: "logprobs"},
+						{Type: aiv1alpha1.BodyTransformPrependMessage, Message: &aiv1alpha1.TransformMessage{Role: "system", Content: "Be concise."}},
+					},
+				},
+			},
+		},
+	}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"model": "test-model", "messages": [{"role": "user", "content": "hi"}], "max_tokens": 1000, "logprobs": true}`
+	c.Request, _ = http.NewRequest("POST", "/v1/chat/completions", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	router.HandlerFunc()(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	upstream := <-requests
+	assert.Equal(t, "served-model", upstream["model"])
+	assert.Equal(t, float64(100), upstream["max_tokens"])
+	assert.NotContains(t, upstream, "logprobs")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"role": "system", "content": "Be concise."},
+		map[string]interface{}{"role": "user", "content": "hi"},
+	}, upstream["messages"])
+}
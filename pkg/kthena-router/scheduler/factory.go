@@ -85,6 +85,9 @@ func registerDefaultPlugins(registry *PluginRegistry) {
 	registry.registerScorePlugin(plugins.KVCacheAwarePluginName, func(args runtime.RawExtension) framework.ScorePlugin {
 		return plugins.NewKVCacheAware(args)
 	})
+	registry.registerScorePlugin(plugins.LoraAffinityPluginName, func(args runtime.RawExtension) framework.ScorePlugin {
+		return plugins.NewLoraAffinity()
+	})
 	// filterPlugin
 	registry.registerFilterPlugin(plugins.LeastRequestPluginName, func(args runtime.RawExtension) framework.FilterPlugin {
 		return plugins.NewLeastRequest(args)
@@ -92,6 +95,9 @@ func registerDefaultPlugins(registry *PluginRegistry) {
 	registry.registerFilterPlugin(plugins.LoraAffinityPluginName, func(args runtime.RawExtension) framework.FilterPlugin {
 		return plugins.NewLoraAffinity()
 	})
+	registry.registerFilterPlugin(plugins.ModalityAffinityPluginName, func(args runtime.RawExtension) framework.FilterPlugin {
+		return plugins.NewModalityAffinity(args)
+	})
 }
 
 func getFilterPlugins(registry *PluginRegistry, filterPluginMap []string, pluginsArgMap map[string]runtime.RawExtension) []framework.FilterPlugin {
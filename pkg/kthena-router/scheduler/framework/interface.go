@@ -29,6 +29,13 @@ import (
 type Context struct {
 	Model  string
 	Prompt common.ChatMessage
+	// Endpoint is the inference API of the request, plugins may skip pooling requests
+	Endpoint common.Endpoint
+	// Modalities are the non-text modalities of the request, a hint to steer multimodal requests
+	Modalities []common.Modality
+	// LoadLoraAdapter is set when Model is a LoRA adapter which the router loads on the selected pod
+	// if no pod serves it yet
+	LoadLoraAdapter bool
 
 	Hashes []uint64
 
@@ -19,7 +19,10 @@ package conf
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
@@ -28,6 +31,171 @@ import (
 type RouterConfiguration struct {
 	Scheduler SchedulerConfiguration `yaml:"scheduler"`
 	Auth      AuthenticationConfig   `yaml:"auth"`
+	Request   RequestConfiguration   `yaml:"request"`
+	Upstream  UpstreamConfiguration  `yaml:"upstream"`
+	Lora      LoraConfiguration      `yaml:"lora"`
+	// ResponseCache configures the cache of the ModelRoutes caching their responses
+	ResponseCache ResponseCacheConfiguration `yaml:"responseCache"`
+}
+
+// DefaultMaxRequestBodySize is the maximum size of a request body if none is configured
+const DefaultMaxRequestBodySize int64 = 32 << 20
+
+// RequestConfiguration configures how the router reads the inference requests
+type RequestConfiguration struct {
+	// MaxBodySize is the maximum size of a request body, e.g. "32Mi". Larger requests are rejected with 413.
+	MaxBodySize string `yaml:"maxBodySize"`
+}
+
+// MaxBodyBytes returns the maximum size of a request body in bytes
+func (c *RequestConfiguration) MaxBodyBytes() (int64, error) {
+	if c.MaxBodySize == "" {
+		return DefaultMaxRequestBodySize, nil
+	}
+	quantity, err := resource.ParseQuantity(c.MaxBodySize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maxBodySize %q: %v", c.MaxBodySize, err)
+	}
+	if quantity.Sign() <= 0 {
+		return 0, fmt.Errorf("maxBodySize must be positive, got %q", c.MaxBodySize)
+	}
+	return quantity.Value(), nil
+}
+
+// Defaults of the connections to the inference engines
+const (
+	DefaultUpstreamMaxIdleConns        = 1000
+	DefaultUpstreamMaxIdleConnsPerHost = 100
+	DefaultUpstreamIdleConnTimeout     = 90 * time.Second
+	DefaultUpstreamDialTimeout         = 5 * time.Second
+	DefaultUpstreamKeepAlive           = 30 * time.Second
+)
+
+// UpstreamConfiguration configures the connections from the router to the inference engines.
+// The zero value of a field means its default.
+type UpstreamConfiguration struct {
+	// MaxIdleConns is the maximum number of idle connections kept open, to all the pods.
+	MaxIdleConns int `yaml:"maxIdleConns"`
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept open to a single pod.
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost"`
+	// MaxConnsPerHost limits the number of connections to a single pod, requests wait for a connection
+	// when it is reached. Unlimited by default.
+	MaxConnsPerHost int `yaml:"maxConnsPerHost"`
+	// IdleConnTimeout is how long an idle connection is kept open.
+	IdleConnTimeout metav1.Duration `yaml:"idleConnTimeout"`
+	// DialTimeout is the timeout to open a connection to a pod.
+	DialTimeout metav1.Duration `yaml:"dialTimeout"`
+	// KeepAlive is the interval of the TCP keep-alive probes. A negative value disables them.
+	KeepAlive metav1.Duration `yaml:"keepAlive"`
+}
+
+// WithDefaults returns a copy of the configuration with the unset fields defaulted
+func (c UpstreamConfiguration) WithDefaults() (UpstreamConfiguration, error) {
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 {
+		return c, fmt.Errorf("upstream connection limits must not be negative")
+	}
+	if c.IdleConnTimeout.Duration < 0 || c.DialTimeout.Duration < 0 {
+		return c, fmt.Errorf("upstream idleConnTimeout and dialTimeout must not be negative")
+	}
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = DefaultUpstreamMaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = DefaultUpstreamMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout.Duration == 0 {
+		c.IdleConnTimeout.Duration = DefaultUpstreamIdleConnTimeout
+	}
+	if c.DialTimeout.Duration == 0 {
+		c.DialTimeout.Duration = DefaultUpstreamDialTimeout
+	}
+	if c.KeepAlive.Duration == 0 {
+		c.KeepAlive.Duration = DefaultUpstreamKeepAlive
+	}
+	return c, nil
+}
+
+// Defaults of the on-demand loading of LoRA adapters
+const (
+	DefaultLoraMaxAdaptersPerPod = 4
+	DefaultLoraLoadTimeout       = 60 * time.Second
+)
+
+// LoraConfiguration configures the loading of LoRA adapters by the router, when a request asks for an
+// adapter of a ModelRoute which is not loaded on any pod. The zero value of a field means its default.
+type LoraConfiguration struct {
+	// OnDemandLoading enables the loading of the adapters on demand. Requests for an adapter which is
+	// not loaded are rejected otherwise.
+	OnDemandLoading bool `yaml:"onDemandLoading"`
+	// MaxAdaptersPerPod is the number of adapters a pod can hold, the least recently used adapter of a
+	// pod is unloaded to load a new one once it is reached.
+	MaxAdaptersPerPod int `yaml:"maxAdaptersPerPod"`
+	// LoadTimeout is how long a request waits for its adapter to be loaded.
+	LoadTimeout metav1.Duration `yaml:"loadTimeout"`
+	// AdapterPathPrefix is the directory of the adapters in the pods, the path of an adapter sent to the
+	// engine is the prefix joined with the adapter name. The engine resolves the adapter name itself,
+	// e.g. from Hugging Face, when it is empty.
+	AdapterPathPrefix string `yaml:"adapterPathPrefix"`
+}
+
+// WithDefaults returns a copy of the configuration with the unset fields defaulted
+func (c LoraConfiguration) WithDefaults() (LoraConfiguration, error) {
+	if c.MaxAdaptersPerPod < 0 || c.LoadTimeout.Duration < 0 {
+		return c, fmt.Errorf("lora maxAdaptersPerPod and loadTimeout must not be negative")
+	}
+	if c.MaxAdaptersPerPod == 0 {
+		c.MaxAdaptersPerPod = DefaultLoraMaxAdaptersPerPod
+	}
+	if c.LoadTimeout.Duration == 0 {
+		c.LoadTimeout.Duration = DefaultLoraLoadTimeout
+	}
+	return c, nil
+}
+
+// Defaults of the response cache
+const (
+	DefaultResponseCacheMaxEntries            = 1000
+	DefaultResponseCacheMaxResponseSize int64 = 1 << 20
+)
+
+// ResponseCacheConfiguration configures the cache of the responses of the ModelRoutes with a `cache`,
+// held in the memory of the router. The zero value of a field means its default.
+type ResponseCacheConfiguration struct {
+	// MaxEntries is the number of responses cached in memory, the least recently used response is
+	// evicted to cache a new one once it is reached.
+	MaxEntries int `yaml:"maxEntries"`
+	// MaxResponseSize is the maximum size of a cached response, e.g. "1Mi". Larger responses are not cached,
+	// in memory or in Redis.
+	MaxResponseSize string `yaml:"maxResponseSize"`
+}
+
+// WithDefaults returns a copy of the configuration with the unset fields defaulted
+func (c ResponseCacheConfiguration) WithDefaults() (ResponseCacheConfiguration, error) {
+	if c.MaxEntries < 0 {
+		return c, fmt.Errorf("responseCache maxEntries must not be negative")
+	}
+	if c.MaxEntries == 0 {
+		c.MaxEntries = DefaultResponseCacheMaxEntries
+	}
+	if _, err := c.MaxResponseBytes(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// MaxResponseBytes returns the maximum size of a cached response in bytes
+func (c *ResponseCacheConfiguration) MaxResponseBytes() (int64, error) {
+	if c.MaxResponseSize == "" {
+		return DefaultResponseCacheMaxResponseSize, nil
+	}
+	quantity, err := resource.ParseQuantity(c.MaxResponseSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid responseCache maxResponseSize %q: %v", c.MaxResponseSize, err)
+	}
+	if quantity.Sign() <= 0 {
+		return 0, fmt.Errorf("responseCache maxResponseSize must be positive, got %q", c.MaxResponseSize)
+	}
+	return quantity.Value(), nil
 }
 
 type SchedulerConfiguration struct {
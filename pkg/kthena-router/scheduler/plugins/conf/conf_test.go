@@ -19,6 +19,9 @@ package conf
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
 )
 
 func TestLoadSchedulerConfig(t *testing.T) {
@@ -140,3 +143,119 @@ func TestHandleRandomPluginConflicts(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestConfigurationMaxBodyBytes(t *testing.T) {
+	testCases := []struct {
+		maxBodySize string
+		expected    int64
+		expectErr   bool
+	}{
+		{maxBodySize: "", expected: DefaultMaxRequestBodySize},
+		{maxBodySize: "1Mi", expected: 1 << 20},
+		{maxBodySize: "500k", expected: 500000},
+		{maxBodySize: "1024", expected: 1024},
+		{maxBodySize: "0", expectErr: true},
+		{maxBodySize: "ten", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.maxBodySize, func(t *testing.T) {
+			config := RequestConfiguration{MaxBodySize: tc.maxBodySize}
+			size, err := config.MaxBodyBytes()
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", tc.maxBodySize)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, size)
+			}
+		})
+	}
+}
+
+func TestUpstreamConfigurationWithDefaults(t *testing.T) {
+	var routerConfig RouterConfiguration
+	data := []byte(`
+upstream:
+  maxIdleConnsPerHost: 20
+  dialTimeout: 2s
+`)
+	if err := yaml.Unmarshal(data, &routerConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	upstream, err := routerConfig.Upstream.WithDefaults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if upstream.MaxIdleConnsPerHost != 20 || upstream.DialTimeout.Duration != 2*time.Second {
+		t.Errorf("configured values were not kept: %+v", upstream)
+	}
+	if upstream.MaxIdleConns != DefaultUpstreamMaxIdleConns || upstream.MaxConnsPerHost != 0 ||
+		upstream.IdleConnTimeout.Duration != DefaultUpstreamIdleConnTimeout || upstream.KeepAlive.Duration != DefaultUpstreamKeepAlive {
+		t.Errorf("unset values were not defaulted: %+v", upstream)
+	}
+
+	if _, err := (UpstreamConfiguration{MaxConnsPerHost: -1}).WithDefaults(); err == nil {
+		t.Errorf("expected an error for a negative connection limit")
+	}
+}
+
+func TestLoraConfigurationWithDefaults(t *testing.T) {
+	var routerConfig RouterConfiguration
+	data := []byte(`
+lora:
+  onDemandLoading: true
+  loadTimeout: 30s
+  adapterPathPrefix: /models/lora
+`)
+	if err := yaml.Unmarshal(data, &routerConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lora, err := routerConfig.Lora.WithDefaults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lora.OnDemandLoading || lora.LoadTimeout.Duration != 30*time.Second || lora.AdapterPathPrefix != "/models/lora" {
+		t.Errorf("configured values were not kept: %+v", lora)
+	}
+	if lora.MaxAdaptersPerPod != DefaultLoraMaxAdaptersPerPod {
+		t.Errorf("unset values were not defaulted: %+v", lora)
+	}
+
+	if _, err := (LoraConfiguration{MaxAdaptersPerPod: -1}).WithDefaults(); err == nil {
+		t.Errorf("expected an error for a negative number of adapters")
+	}
+}
+
+func TestResponseCacheConfigurationWithDefaults(t *testing.T) {
+	var routerConfig RouterConfiguration
+	data := []byte(`
+responseCache:
+  maxResponseSize: 256Ki
+`)
+	if err := yaml.Unmarshal(data, &routerConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache, err := routerConfig.ResponseCache.WithDefaults()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size, err := cache.MaxResponseBytes(); err != nil || size != 256<<10 {
+		t.Errorf("configured values were not kept: %+v", cache)
+	}
+	if cache.MaxEntries != DefaultResponseCacheMaxEntries {
+		t.Errorf("unset values were not defaulted: %+v", cache)
+	}
+
+	if _, err := (ResponseCacheConfiguration{MaxEntries: -1}).WithDefaults(); err == nil {
+		t.Errorf("expected an error for a negative number of entries")
+	}
+	if _, err := (ResponseCacheConfiguration{MaxResponseSize: "0"}).WithDefaults(); err == nil {
+		t.Errorf("expected an error for an empty response size")
+	}
+}
@@ -157,7 +157,7 @@ func (t *KVCacheAware) Score(ctx *framework.Context, pods []*datastore.PodInfo)
 		scoreResults[pod] = 0
 	}
 
-	if (ctx.Prompt.Text == "" && len(ctx.Prompt.Messages) == 0) || ctx.Model == "" {
+	if (ctx.Prompt.Text == "" && len(ctx.Prompt.Messages) == 0) || ctx.Model == "" || !ctx.Endpoint.IsGenerative() {
 		return scoreResults
 	}
 
@@ -24,11 +24,15 @@ import (
 
 const LoraAffinityPluginName = "lora-affinity"
 
+// LoraAffinity keeps the pods which serve the LoRA adapter of the request. When no pod serves it and the
+// router loads adapters on demand, all the pods are kept, and the pods with the fewest adapters loaded
+// score the highest, so that the adapter is loaded where it is the least likely to evict another one.
 type LoraAffinity struct {
 	name string
 }
 
 var _ framework.FilterPlugin = &LoraAffinity{}
+var _ framework.ScorePlugin = &LoraAffinity{}
 
 func NewLoraAffinity() *LoraAffinity {
 	return &LoraAffinity{
@@ -41,7 +45,41 @@ func (l *LoraAffinity) Name() string {
 }
 
 func (l *LoraAffinity) Filter(ctx *framework.Context, pods []*datastore.PodInfo) []*datastore.PodInfo {
-	return slices.FilterInPlace(pods, func(info *datastore.PodInfo) bool {
+	serving := slices.FilterInPlace(slices.Clone(pods), func(info *datastore.PodInfo) bool {
 		return info.Contains(ctx.Model)
 	})
+	if len(serving) == 0 && ctx.LoadLoraAdapter {
+		return pods
+	}
+	return serving
+}
+
+func (l *LoraAffinity) Score(ctx *framework.Context, pods []*datastore.PodInfo) map[*datastore.PodInfo]int {
+	scores := make(map[*datastore.PodInfo]int, len(pods))
+	if !ctx.LoadLoraAdapter {
+		for _, info := range pods {
+			scores[info] = 0
+		}
+		return scores
+	}
+
+	maxAdapters := 0
+	for _, info := range pods {
+		maxAdapters = max(maxAdapters, loraAdapterCount(info))
+	}
+	for _, info := range pods {
+		switch {
+		case info.Contains(ctx.Model) || maxAdapters == 0:
+			scores[info] = 100
+		default:
+			scores[info] = 100 * (maxAdapters - loraAdapterCount(info)) / maxAdapters
+		}
+	}
+	return scores
+}
+
+// loraAdapterCount returns the number of adapters loaded on a pod, the engines list the base model
+// along with the adapters.
+func loraAdapterCount(info *datastore.PodInfo) int {
+	return max(len(info.GetModelsList())-1, 0)
 }
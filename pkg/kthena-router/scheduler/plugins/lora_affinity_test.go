@@ -0,0 +1,78 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+func newLoraPod(name string, models ...string) *datastore.PodInfo {
+	info := &datastore.PodInfo{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}}
+	info.UpdateModels(models)
+	return info
+}
+
+func TestLoraAffinityFilter(t *testing.T) {
+	newPods := func() []*datastore.PodInfo {
+		return []*datastore.PodInfo{
+			newLoraPod("base-only", "base"),
+			newLoraPod("sql", "base", "sql-lora"),
+			newLoraPod("sql-chat", "base", "sql-lora", "chat-lora"),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		ctx    *framework.Context
+		expect []string
+	}{
+		{name: "adapter loaded", ctx: &framework.Context{Model: "chat-lora"}, expect: []string{"sql-chat"}},
+		{name: "adapter loaded, on-demand loading", ctx: &framework.Context{Model: "sql-lora", LoadLoraAdapter: true}, expect: []string{"sql", "sql-chat"}},
+		{name: "adapter not loaded", ctx: &framework.Context{Model: "math-lora"}, expect: []string{}},
+		{name: "adapter not loaded, on-demand loading", ctx: &framework.Context{Model: "math-lora", LoadLoraAdapter: true}, expect: []string{"base-only", "sql", "sql-chat"}},
+	}
+
+	plugin := NewLoraAffinity()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, podNames(plugin.Filter(tt.ctx, newPods())))
+		})
+	}
+}
+
+func TestLoraAffinityScore(t *testing.T) {
+	baseOnly := newLoraPod("base-only", "base")
+	sql := newLoraPod("sql", "base", "sql-lora")
+	sqlChat := newLoraPod("sql-chat", "base", "sql-lora", "chat-lora")
+	pods := []*datastore.PodInfo{baseOnly, sql, sqlChat}
+
+	plugin := NewLoraAffinity()
+	// The pods with spare slots are preferred to load an adapter
+	scores := plugin.Score(&framework.Context{Model: "math-lora", LoadLoraAdapter: true}, pods)
+	assert.Equal(t, map[*datastore.PodInfo]int{baseOnly: 100, sql: 50, sqlChat: 0}, scores)
+
+	// Without on-demand loading, the score has no effect
+	scores = plugin.Score(&framework.Context{Model: "math-lora"}, pods)
+	assert.Equal(t, map[*datastore.PodInfo]int{baseOnly: 0, sql: 0, sqlChat: 0}, scores)
+}
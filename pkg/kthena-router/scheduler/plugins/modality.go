@@ -0,0 +1,100 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"strings"
+
+	"github.com/stretchr/testify/assert/yaml"
+	"istio.io/istio/pkg/slices"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+const (
+	ModalityAffinityPluginName = "modality-affinity"
+	// DefaultModalitiesAnnotationKey is the pod annotation listing the modalities supported by the served model,
+	// comma separated, e.g. "text,image"
+	DefaultModalitiesAnnotationKey = "networking.volcano.sh/modalities"
+)
+
+var _ framework.FilterPlugin = &ModalityAffinity{}
+
+// ModalityAffinity steers multimodal requests to the pods whose model supports all the modalities of the request.
+// Pods without the modalities annotation are kept, since their capabilities are unknown.
+type ModalityAffinity struct {
+	name          string
+	annotationKey string
+}
+
+type ModalityAffinityArgs struct {
+	AnnotationKey string `yaml:"annotationKey,omitempty"`
+}
+
+func NewModalityAffinity(pluginArg runtime.RawExtension) *ModalityAffinity {
+	var args ModalityAffinityArgs
+	if err := yaml.Unmarshal(pluginArg.Raw, &args); err != nil {
+		klog.Errorf("Unmarshal ModalityAffinityArgs error, setting default value")
+		args = ModalityAffinityArgs{}
+	}
+	if args.AnnotationKey == "" {
+		args.AnnotationKey = DefaultModalitiesAnnotationKey
+	}
+
+	return &ModalityAffinity{
+		name:          ModalityAffinityPluginName,
+		annotationKey: args.AnnotationKey,
+	}
+}
+
+func (m *ModalityAffinity) Name() string {
+	return m.name
+}
+
+func (m *ModalityAffinity) Filter(ctx *framework.Context, pods []*datastore.PodInfo) []*datastore.PodInfo {
+	if len(ctx.Modalities) == 0 {
+		return pods
+	}
+	return slices.FilterInPlace(pods, func(info *datastore.PodInfo) bool {
+		return m.supports(info, ctx.Modalities)
+	})
+}
+
+// supports returns true if the pod declares all the modalities, or does not declare any
+func (m *ModalityAffinity) supports(info *datastore.PodInfo, modalities []common.Modality) bool {
+	if info.Pod == nil {
+		return true
+	}
+	value, ok := info.Pod.Annotations[m.annotationKey]
+	if !ok {
+		return true
+	}
+	supported := strings.Split(value, ",")
+	for i := range supported {
+		supported[i] = strings.TrimSpace(supported[i])
+	}
+	for _, modality := range modalities {
+		if !slices.Contains(supported, string(modality)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
+)
+
+func newModalityPod(name string, annotations map[string]string) *datastore.PodInfo {
+	return &datastore.PodInfo{Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}}
+}
+
+func podNames(pods []*datastore.PodInfo) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Pod.Name)
+	}
+	return names
+}
+
+func TestModalityAffinityFilter(t *testing.T) {
+	newPods := func() []*datastore.PodInfo {
+		return []*datastore.PodInfo{
+			newModalityPod("text-only", map[string]string{DefaultModalitiesAnnotationKey: "text"}),
+			newModalityPod("vision", map[string]string{DefaultModalitiesAnnotationKey: "text, image"}),
+			newModalityPod("omni", map[string]string{DefaultModalitiesAnnotationKey: "text,image,audio"}),
+			newModalityPod("unknown", nil),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modalities []common.Modality
+		want       []string
+	}{
+		{name: "text request", want: []string{"text-only", "vision", "omni", "unknown"}},
+		{name: "image request", modalities: []common.Modality{common.ModalityImage}, want: []string{"vision", "omni", "unknown"}},
+		{name: "image and audio request", modalities: []common.Modality{common.ModalityImage, common.ModalityAudio}, want: []string{"omni", "unknown"}},
+		{name: "video request", modalities: []common.Modality{common.ModalityVideo}, want: []string{"unknown"}},
+	}
+
+	plugin := NewModalityAffinity(runtime.RawExtension{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pods := plugin.Filter(&framework.Context{Modalities: tt.modalities}, newPods())
+			assert.Equal(t, tt.want, podNames(pods))
+		})
+	}
+}
+
+func TestModalityAffinityCustomAnnotation(t *testing.T) {
+	plugin := NewModalityAffinity(runtime.RawExtension{Raw: []byte(`annotationKey: example.com/modalities`)})
+	pods := []*datastore.PodInfo{
+		newModalityPod("default-key", map[string]string{DefaultModalitiesAnnotationKey: "text"}),
+		newModalityPod("custom-key", map[string]string{"example.com/modalities": "text"}),
+	}
+
+	pods = plugin.Filter(&framework.Context{Modalities: []common.Modality{common.ModalityImage}}, pods)
+	assert.Equal(t, []string{"default-key"}, podNames(pods))
+}
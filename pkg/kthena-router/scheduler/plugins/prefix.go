@@ -132,6 +132,11 @@ func (p *PrefixCache) Name() string {
 }
 
 func (p *PrefixCache) Score(ctx *framework.Context, pods []*datastore.PodInfo) map[*datastore.PodInfo]int {
+	// Pooling requests such as embeddings do not reuse the KV cache
+	if !ctx.Endpoint.IsGenerative() {
+		return nil
+	}
+
 	// Hash the prompt
 	hashes := p.hashPrompt(ctx.Model, utils.GetPromptString(ctx.Prompt))
 	if len(hashes) == 0 {
@@ -167,6 +172,10 @@ func (p *PrefixCache) Score(ctx *framework.Context, pods []*datastore.PodInfo) m
 }
 
 func (p *PrefixCache) PostSchedule(ctx *framework.Context, index int) {
+	if !ctx.Endpoint.IsGenerative() {
+		return
+	}
+
 	if ctx.BestPods != nil {
 		// Add the best pod to the cache
 		p.store.Add(ctx.Model, ctx.Hashes, ctx.BestPods[index])
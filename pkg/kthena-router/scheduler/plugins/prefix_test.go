@@ -23,6 +23,11 @@ import (
 	"testing"
 
 	"github.com/cespare/xxhash"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/datastore"
+	"github.com/volcano-sh/kthena/pkg/kthena-router/scheduler/framework"
 )
 
 func TestHashPrompt(t *testing.T) {
@@ -93,3 +98,17 @@ func TestHashPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestPrefixCacheSkipsPoolingRequests(t *testing.T) {
+	p := &PrefixCache{blockSizeToHash: 4, maxBlocksToMatch: 8}
+	ctx := &framework.Context{
+		Model:    "test-model",
+		Prompt:   common.ChatMessage{Text: "embed this text"},
+		Endpoint: common.EndpointEmbeddings,
+	}
+
+	// The store is not initialized, so any lookup or insert would panic
+	assert.Nil(t, p.Score(ctx, []*datastore.PodInfo{{}}))
+	assert.Empty(t, ctx.Hashes)
+	p.PostSchedule(ctx, 0)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// requestFields are the fields of a request body read or rewritten by the router, for every supported API.
+var requestFields = sets.New(
+	"model", "stream", "stream_options", "userId",
+	// Prompt of the completions, chat completions, Responses and Anthropic Messages APIs
+	"prompt", "messages", "input", "instructions", "system", "tools", "tool_choice", "metadata",
+	// Pooling APIs
+	"query", "documents", "text_1", "text_2",
+	// Rewritten for prefill requests
+	"max_tokens", "max_completion_tokens", "max_output_tokens",
+)
+
+// DecodeRequestBody decodes the top-level object of a request body, and only the values of the fields
+// the router needs. The other fields, e.g. sampling parameters or response formats, are kept as
+// json.RawMessage: they are not decoded, and are written back unchanged if the body is encoded again.
+func DecodeRequestBody(data []byte) (map[string]interface{}, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("request body is not a JSON object")
+	}
+
+	body := make(map[string]interface{}, len(fields))
+	for key, raw := range fields {
+		if !requestFields.Contains(key) {
+			body[key] = raw
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid field %s: %v", key, err)
+		}
+		body[key] = value
+	}
+	return body, nil
+}
+
+// SetJSONFields returns a copy of a JSON object with the given top-level fields set, so that they can be
+// added to a request body without encoding it again. The value of a field already present in the object
+// is replaced where it is, the other fields are added at its end, so that the object has no duplicate keys.
+func SetJSONFields(data []byte, fields map[string]interface{}) ([]byte, error) {
+	object := bytes.TrimSpace(data)
+	if len(object) < 2 || object[0] != '{' || object[len(object)-1] != '}' {
+		return nil, fmt.Errorf("body is not a JSON object")
+	}
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	values := make(map[string][]byte, len(fields))
+	for key, field := range fields {
+		value, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	// Find the values of the fields already in the object
+	type span struct {
+		start, end int64
+		key        string
+	}
+	var replaced []span
+	present := sets.New[string]()
+	dec := json.NewDecoder(bytes.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("body is not a JSON object")
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if _, ok := values[key]; ok {
+			end := dec.InputOffset()
+			replaced = append(replaced, span{start: end - int64(len(raw)), end: end, key: key})
+			present.Insert(key)
+		}
+	}
+
+	var added []string
+	for key := range values {
+		if !present.Contains(key) {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+
+	// Always allocate a new buffer, the original body must not be modified
+	out := bytes.NewBuffer(make([]byte, 0, len(object)+64))
+	var offset int64
+	for _, r := range replaced {
+		out.Write(object[offset:r.start])
+		out.Write(values[r.key])
+		offset = r.end
+	}
+	out.Write(object[offset : len(object)-1])
+	empty := len(bytes.TrimSpace(object[1:len(object)-1])) == 0
+	for _, key := range added {
+		if !empty {
+			out.WriteByte(',')
+		}
+		empty = false
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(values[key])
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRequestBody(t *testing.T) {
+	data := []byte(`{"model": "m", "stream": true, "messages": [{"role": "user", "content": "hi"}], "temperature": 0.50, "response_format": {"type": "json_object"}}`)
+
+	body, err := DecodeRequestBody(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "m", body["model"])
+	assert.Equal(t, true, body["stream"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"role": "user", "content": "hi"}}, body["messages"])
+	// Fields not used by the router are kept as they were received
+	assert.Equal(t, json.RawMessage(`0.50`), body["temperature"])
+	assert.Equal(t, json.RawMessage(`{"type": "json_object"}`), body["response_format"])
+
+	encoded, err := json.Marshal(body)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(encoded))
+
+	for _, invalid := range []string{`[]`, `null`, `{"model": }`, `{"messages": [}`} {
+		_, err := DecodeRequestBody([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSetJSONFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		fields   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "no fields",
+			data:     `{"model": "m"}`,
+			expected: `{"model": "m"}`,
+		},
+		{
+			name:     "fields are appended in order",
+			data:     `{"model": "m"} `,
+			fields:   map[string]interface{}{"stream_options": map[string]interface{}{"include_usage": true}, "include_usage": true},
+			expected: `{"model": "m","include_usage":true,"stream_options":{"include_usage":true}}`,
+		},
+		{
+			name:     "empty object",
+			data:     `{ }`,
+			fields:   map[string]interface{}{"include_usage": true},
+			expected: `{ "include_usage":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := []byte(tt.data)
+			result, err := SetJSONFields(original, tt.fields)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(result))
+			assert.Equal(t, tt.data, string(original))
+		})
+	}
+
+	// The fields already in the object are replaced in place, without duplicating their keys
+	result, err := SetJSONFields([]byte(`{"stream_options": {"include_usage": false}, "model": "m", "n": [1, {"a": "}"}]}`),
+		map[string]interface{}{"stream_options": map[string]interface{}{"include_usage": true}, "max_tokens": 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{"stream_options": {"include_usage":true}, "model": "m", "n": [1, {"a": "}"}],"max_tokens":1}`, string(result))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &decoded))
+	assert.Equal(t, map[string]interface{}{"include_usage": true}, decoded["stream_options"])
+
+	_, err = SetJSONFields([]byte(`[1]`), map[string]interface{}{"include_usage": true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// Hostname helpers implementing the Gateway API hostname semantics shared by
+// listeners and routes. A hostname is either a precise DNS name such as
+// "foo.example.com" or a wildcard "*.example.com", where the wildcard label
+// matches one or more DNS labels, so "*.example.com" matches "a.example.com"
+// and "a.b.example.com" but not "example.com".
+
+// NormalizeHost strips the port and a trailing dot from a request host and lowercases it.
+func NormalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// IsWildcardHostname returns true if the hostname starts with the "*." wildcard label.
+func IsWildcardHostname(hostname string) bool {
+	return strings.HasPrefix(hostname, "*.")
+}
+
+// HostnameMatches checks whether a precise request host matches a listener or route hostname.
+// An empty hostname matches every host.
+func HostnameMatches(hostname, host string) bool {
+	if hostname == "" {
+		return true
+	}
+	hostname = strings.ToLower(hostname)
+	host = NormalizeHost(host)
+	if IsWildcardHostname(hostname) {
+		suffix := hostname[1:] // ".example.com"
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	return hostname == host
+}
+
+// HostnameSpecificity ranks how specific a hostname is when it matches a host, higher is more specific.
+// Precise hostnames always rank above wildcards, longer wildcards rank above shorter ones and an empty
+// hostname (match all) ranks lowest.
+func HostnameSpecificity(hostname string) int {
+	switch {
+	case hostname == "":
+		return 0
+	case IsWildcardHostname(hostname):
+		return len(hostname)
+	default:
+		// Precise hostnames are limited to 253 characters
+		return 1000 + len(hostname)
+	}
+}
+
+// IntersectHostname returns the intersection of two hostnames, and false if they do not intersect.
+// An empty hostname matches everything, so the intersection with it is the other hostname.
+// For example "*.example.com" and "foo.example.com" intersect as "foo.example.com", while
+// "*.example.com" and "*.foo.example.com" intersect as "*.foo.example.com".
+func IntersectHostname(a, b string) (string, bool) {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	switch {
+	case a == "":
+		return b, true
+	case b == "":
+		return a, true
+	case a == b:
+		return a, true
+	case !IsWildcardHostname(a) && !IsWildcardHostname(b):
+		return "", false
+	case !IsWildcardHostname(a):
+		return a, HostnameMatches(b, a)
+	case !IsWildcardHostname(b):
+		return b, HostnameMatches(a, b)
+	default:
+		// Both are wildcards, the longer one is the intersection if it is covered by the shorter one
+		if len(a) < len(b) {
+			a, b = b, a
+		}
+		return a, strings.HasSuffix(a[1:], b[1:])
+	}
+}
+
+// HostnamesIntersect returns true if the listener hostname intersects with at least one of the
+// route hostnames. A route without hostnames intersects with every listener.
+func HostnamesIntersect(listenerHostname string, routeHostnames []string) bool {
+	if len(routeHostnames) == 0 {
+		return true
+	}
+	for _, hostname := range routeHostnames {
+		if _, ok := IntersectHostname(listenerHostname, hostname); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostnameMatches(t *testing.T) {
+	tests := []struct {
+		hostname string
+		host     string
+		want     bool
+	}{
+		{hostname: "", host: "anything.example.com", want: true},
+		{hostname: "", host: "", want: true},
+		{hostname: "foo.example.com", host: "foo.example.com", want: true},
+		{hostname: "foo.example.com", host: "FOO.Example.com", want: true},
+		{hostname: "foo.example.com", host: "foo.example.com:8080", want: true},
+		{hostname: "foo.example.com", host: "foo.example.com.", want: true},
+		{hostname: "foo.example.com", host: "bar.example.com", want: false},
+		{hostname: "foo.example.com", host: "a.foo.example.com", want: false},
+		{hostname: "*.example.com", host: "foo.example.com", want: true},
+		{hostname: "*.example.com", host: "foo.bar.example.com", want: true},
+		{hostname: "*.example.com", host: "example.com", want: false},
+		{hostname: "*.example.com", host: ".example.com", want: false},
+		{hostname: "*.example.com", host: "fooexample.com", want: false},
+		{hostname: "*.example.com", host: "foo.example.com:443", want: true},
+		{hostname: "*.models.example.com", host: "llama.models.example.com", want: true},
+		{hostname: "*.models.example.com", host: "models.example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname+"/"+tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, HostnameMatches(tt.hostname, tt.host))
+		})
+	}
+}
+
+func TestIntersectHostname(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   string
+		wantOk bool
+	}{
+		{a: "", b: "", want: "", wantOk: true},
+		{a: "", b: "foo.example.com", want: "foo.example.com", wantOk: true},
+		{a: "*.example.com", b: "", want: "*.example.com", wantOk: true},
+		{a: "foo.example.com", b: "foo.example.com", want: "foo.example.com", wantOk: true},
+		{a: "foo.example.com", b: "bar.example.com", wantOk: false},
+		{a: "*.example.com", b: "foo.example.com", want: "foo.example.com", wantOk: true},
+		{a: "foo.example.com", b: "*.example.com", want: "foo.example.com", wantOk: true},
+		{a: "*.example.com", b: "example.com", wantOk: false},
+		{a: "*.example.com", b: "*.foo.example.com", want: "*.foo.example.com", wantOk: true},
+		{a: "*.foo.example.com", b: "*.example.com", want: "*.foo.example.com", wantOk: true},
+		{a: "*.example.com", b: "*.example.org", wantOk: false},
+		{a: "*.Example.com", b: "FOO.example.COM", want: "foo.example.com", wantOk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			got, ok := IntersectHostname(tt.a, tt.b)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHostnameSpecificity(t *testing.T) {
+	// Ordered from the most to the least specific
+	ordered := []string{
+		"very.long.precise.example.com",
+		"a.example.com",
+		"*.models.example.com",
+		"*.example.com",
+		"",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		assert.Greater(t, HostnameSpecificity(ordered[i]), HostnameSpecificity(ordered[i+1]),
+			"%q should be more specific than %q", ordered[i], ordered[i+1])
+	}
+}
+
+func TestHostnamesIntersect(t *testing.T) {
+	assert.True(t, HostnamesIntersect("*.example.com", nil))
+	assert.True(t, HostnamesIntersect("", []string{"foo.example.com"}))
+	assert.True(t, HostnamesIntersect("*.example.com", []string{"other.org", "foo.example.com"}))
+	assert.False(t, HostnamesIntersect("*.example.com", []string{"other.org", "example.com"}))
+	assert.False(t, HostnamesIntersect("foo.example.com", []string{"bar.example.com"}))
+}
+
+func TestNormalizeHost(t *testing.T) {
+	assert.Equal(t, "foo.example.com", NormalizeHost("Foo.Example.com:8080"))
+	assert.Equal(t, "foo.example.com", NormalizeHost("foo.example.com."))
+	assert.Equal(t, "::1", NormalizeHost("[::1]:8080"))
+	assert.Equal(t, "10.0.0.1", NormalizeHost("10.0.0.1"))
+}
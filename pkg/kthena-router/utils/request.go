@@ -0,0 +1,233 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+// Estimated input tokens of the media content parts. The actual number depends on the model and on the
+// resolution or duration of the media, which the router does not download or decode, so these are averages
+// used for token accounting and rate limiting.
+const (
+	// ImageTokenEstimate is the cost of a 512x512 image in high detail
+	ImageTokenEstimate = 765
+	// LowDetailImageTokenEstimate is the cost of an image requested with the low detail level
+	LowDetailImageTokenEstimate = 85
+	// AudioTokenEstimate is the cost of an audio clip of about 10 seconds
+	AudioTokenEstimate = 250
+	// VideoTokenEstimate is the cost of a short video clip sampled into a few frames
+	VideoTokenEstimate = 2048
+)
+
+// GetEndpoint returns the inference API of a request path, such as /v1/embeddings or /v1/responses.
+// Unknown paths are considered completions.
+func GetEndpoint(path string) common.Endpoint {
+	path = strings.TrimSuffix(path, "/")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return common.EndpointChatCompletions
+	case strings.HasSuffix(path, "/responses"):
+		return common.EndpointResponses
+	case strings.HasSuffix(path, "/embeddings"):
+		return common.EndpointEmbeddings
+	case strings.HasSuffix(path, "/rerank"):
+		return common.EndpointRerank
+	case strings.HasSuffix(path, "/score"):
+		return common.EndpointScore
+	default:
+		return common.EndpointCompletions
+	}
+}
+
+// ParseRequestInput parses the model input of a request body according to the API of the request path.
+func ParseRequestInput(path string, body map[string]interface{}) (*common.RequestInput, error) {
+	endpoint := GetEndpoint(path)
+	input := &common.RequestInput{Endpoint: endpoint}
+
+	switch endpoint {
+	case common.EndpointEmbeddings:
+		// Chat style embeddings requests use messages instead of input
+		if _, ok := body["input"]; !ok {
+			return parsePromptInput(input, body)
+		}
+		texts, tokenIDs, err := parseEmbeddingsInput(body["input"])
+		if err != nil {
+			return nil, err
+		}
+		input.Sequences = texts
+		input.TokenIDs = tokenIDs
+		input.Prompt = common.ChatMessage{Text: strings.Join(texts, "\n")}
+	case common.EndpointRerank:
+		query, ok := body["query"].(string)
+		if !ok {
+			return nil, fmt.Errorf("query not found in request body")
+		}
+		documents, err := parseDocuments(body["documents"])
+		if err != nil {
+			return nil, err
+		}
+		input.Sequences = pairSequences([]string{query}, documents)
+		input.Prompt = common.ChatMessage{Text: query}
+	case common.EndpointScore:
+		text1, err := parseTexts(body["text_1"], "text_1")
+		if err != nil {
+			return nil, err
+		}
+		text2, err := parseTexts(body["text_2"], "text_2")
+		if err != nil {
+			return nil, err
+		}
+		input.Sequences = pairSequences(text1, text2)
+		input.Prompt = common.ChatMessage{Text: strings.Join(text1, "\n")}
+	case common.EndpointResponses:
+		prompt, err := ParseResponsesInput(body)
+		if err != nil {
+			return nil, err
+		}
+		input.Prompt = prompt
+		input.Sequences = []string{GetPromptString(prompt)}
+		input.MediaTokens = EstimateMediaTokens(prompt)
+	default:
+		return parsePromptInput(input, body)
+	}
+	return input, nil
+}
+
+func parsePromptInput(input *common.RequestInput, body map[string]interface{}) (*common.RequestInput, error) {
+	prompt, err := ParsePrompt(body)
+	if err != nil {
+		return nil, err
+	}
+	input.Prompt = prompt
+	input.Sequences = []string{GetPromptString(prompt)}
+	input.MediaTokens = EstimateMediaTokens(prompt)
+	return input, nil
+}
+
+// EstimateMediaTokens estimates the input tokens of the image, audio and video parts of the chat messages
+func EstimateMediaTokens(prompt common.ChatMessage) int {
+	total := 0
+	for _, msg := range prompt.Messages {
+		for _, part := range msg.Parts {
+			switch part.Modality {
+			case common.ModalityImage:
+				if part.Detail == "low" {
+					total += LowDetailImageTokenEstimate
+				} else {
+					total += ImageTokenEstimate
+				}
+			case common.ModalityAudio:
+				total += AudioTokenEstimate
+			case common.ModalityVideo:
+				total += VideoTokenEstimate
+			}
+		}
+	}
+	return total
+}
+
+// parseEmbeddingsInput parses the OpenAI embeddings input, which is a string, a list of strings,
+// a list of token ids or a list of lists of token ids. Returns the texts and the number of token ids.
+func parseEmbeddingsInput(value interface{}) ([]string, int, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, 0, nil
+	case []interface{}:
+		var texts []string
+		tokenIDs := 0
+		for _, item := range v {
+			switch it := item.(type) {
+			case string:
+				texts = append(texts, it)
+			case float64:
+				tokenIDs++
+			case []interface{}:
+				tokenIDs += len(it)
+			default:
+				return nil, 0, fmt.Errorf("unsupported embeddings input item type %T", item)
+			}
+		}
+		return texts, tokenIDs, nil
+	default:
+		return nil, 0, fmt.Errorf("input is not a string or a list")
+	}
+}
+
+// parseDocuments parses rerank documents, given either as strings or as objects with a text field
+func parseDocuments(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("documents is not a list")
+	}
+	documents := make([]string, 0, len(list))
+	for _, item := range list {
+		switch doc := item.(type) {
+		case string:
+			documents = append(documents, doc)
+		case map[string]interface{}:
+			text, ok := doc["text"].(string)
+			if !ok {
+				return nil, fmt.Errorf("document text not found")
+			}
+			documents = append(documents, text)
+		default:
+			return nil, fmt.Errorf("unsupported document type %T", item)
+		}
+	}
+	return documents, nil
+}
+
+// parseTexts parses a score text, which is a string or a list of strings
+func parseTexts(value interface{}, field string) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		texts := make([]string, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a list of strings", field)
+			}
+			texts = append(texts, text)
+		}
+		return texts, nil
+	default:
+		return nil, fmt.Errorf("%s not found in request body", field)
+	}
+}
+
+// pairSequences returns the sequences scored by a cross-encoder. A single first text is paired
+// with every second text, otherwise the texts are paired one to one.
+func pairSequences(first, second []string) []string {
+	sequences := make([]string, 0, len(second))
+	for i, text := range second {
+		var prefix string
+		switch {
+		case len(first) == 1:
+			prefix = first[0]
+		case i < len(first):
+			prefix = first[i]
+		}
+		sequences = append(sequences, prefix+"\n"+text)
+	}
+	return sequences
+}
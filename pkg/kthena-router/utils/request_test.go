@@ -0,0 +1,302 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+func TestGetEndpoint(t *testing.T) {
+	tests := []struct {
+		path string
+		want common.Endpoint
+	}{
+		{path: "/v1/completions", want: common.EndpointCompletions},
+		{path: "/v1/chat/completions", want: common.EndpointChatCompletions},
+		{path: "/v1/responses", want: common.EndpointResponses},
+		{path: "/v1/embeddings", want: common.EndpointEmbeddings},
+		{path: "/v1/embeddings/", want: common.EndpointEmbeddings},
+		{path: "/v1/rerank", want: common.EndpointRerank},
+		{path: "/rerank", want: common.EndpointRerank},
+		{path: "/score", want: common.EndpointScore},
+		{path: "/generate", want: common.EndpointCompletions},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, GetEndpoint(tt.path), tt.path)
+	}
+}
+
+func TestParseRequestInput(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		wantEndpoint  common.Endpoint
+		wantSequences []string
+		wantTokenIDs  int
+		wantPrompt    string
+		wantErr       bool
+	}{
+		{
+			name:          "completions",
+			path:          "/v1/completions",
+			body:          `{"model": "m", "prompt": "hello"}`,
+			wantEndpoint:  common.EndpointCompletions,
+			wantSequences: []string{"hello"},
+			wantPrompt:    "hello",
+		},
+		{
+			name:          "embeddings string",
+			path:          "/v1/embeddings",
+			body:          `{"model": "m", "input": "hello"}`,
+			wantEndpoint:  common.EndpointEmbeddings,
+			wantSequences: []string{"hello"},
+			wantPrompt:    "hello",
+		},
+		{
+			name:          "embeddings list",
+			path:          "/v1/embeddings",
+			body:          `{"model": "m", "input": ["a", "b"]}`,
+			wantEndpoint:  common.EndpointEmbeddings,
+			wantSequences: []string{"a", "b"},
+			wantPrompt:    "a\nb",
+		},
+		{
+			name:         "embeddings token ids",
+			path:         "/v1/embeddings",
+			body:         `{"model": "m", "input": [1, 2, 3]}`,
+			wantEndpoint: common.EndpointEmbeddings,
+			wantTokenIDs: 3,
+		},
+		{
+			name:         "embeddings nested token ids",
+			path:         "/v1/embeddings",
+			body:         `{"model": "m", "input": [[1, 2], [3]]}`,
+			wantEndpoint: common.EndpointEmbeddings,
+			wantTokenIDs: 3,
+		},
+		{
+			name:          "embeddings messages",
+			path:          "/v1/embeddings",
+			body:          `{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`,
+			wantEndpoint:  common.EndpointEmbeddings,
+			wantSequences: []string{"<|im_start|>user\nhi<|im_end|>\n"},
+		},
+		{
+			name:          "rerank string documents",
+			path:          "/v1/rerank",
+			body:          `{"model": "m", "query": "q", "documents": ["d1", "d2"]}`,
+			wantEndpoint:  common.EndpointRerank,
+			wantSequences: []string{"q\nd1", "q\nd2"},
+			wantPrompt:    "q",
+		},
+		{
+			name:          "rerank object documents",
+			path:          "/rerank",
+			body:          `{"model": "m", "query": "q", "documents": [{"text": "d1"}]}`,
+			wantEndpoint:  common.EndpointRerank,
+			wantSequences: []string{"q\nd1"},
+			wantPrompt:    "q",
+		},
+		{
+			name:          "score one to many",
+			path:          "/score",
+			body:          `{"model": "m", "text_1": "a", "text_2": ["b", "c"]}`,
+			wantEndpoint:  common.EndpointScore,
+			wantSequences: []string{"a\nb", "a\nc"},
+			wantPrompt:    "a",
+		},
+		{
+			name:          "score pairs",
+			path:          "/v1/score",
+			body:          `{"model": "m", "text_1": ["a", "b"], "text_2": ["c", "d"]}`,
+			wantEndpoint:  common.EndpointScore,
+			wantSequences: []string{"a\nc", "b\nd"},
+			wantPrompt:    "a\nb",
+		},
+		{name: "completions without prompt", path: "/v1/completions", body: `{"model": "m"}`, wantErr: true},
+		{name: "embeddings invalid input", path: "/v1/embeddings", body: `{"model": "m", "input": 1}`, wantErr: true},
+		{name: "rerank without query", path: "/v1/rerank", body: `{"model": "m", "documents": ["d"]}`, wantErr: true},
+		{name: "rerank invalid documents", path: "/v1/rerank", body: `{"model": "m", "query": "q", "documents": [1]}`, wantErr: true},
+		{name: "score without text_2", path: "/score", body: `{"model": "m", "text_1": "a"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+
+			input, err := ParseRequestInput(tt.path, body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEndpoint, input.Endpoint)
+			assert.Equal(t, tt.wantSequences, input.Sequences)
+			assert.Equal(t, tt.wantTokenIDs, input.TokenIDs)
+			if tt.wantPrompt != "" {
+				assert.Equal(t, tt.wantPrompt, input.Prompt.Text)
+			}
+		})
+	}
+}
+
+func TestParseRequestInputMultimodal(t *testing.T) {
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"model": "m",
+		"messages": [
+			{"role": "system", "content": "You are a helpful assistant."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "Describe the image"},
+				{"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA", "detail": "low"}},
+				{"type": "text", "text": "and the audio"},
+				{"type": "input_audio", "input_audio": {"data": "AAAAAAAA", "format": "wav"}},
+				{"type": "unknown"}
+			]}
+		]
+	}`), &body))
+
+	input, err := ParseRequestInput("/v1/chat/completions", body)
+	require.NoError(t, err)
+
+	messages := input.Prompt.Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Describe the image\nand the audio", messages[1].Content)
+	assert.Equal(t, []common.ContentPart{
+		{Modality: common.ModalityText, Text: "Describe the image"},
+		{Modality: common.ModalityImage, URL: "https://example.com/cat.png"},
+		{Modality: common.ModalityImage, URL: "data:image/png;base64,AAAA", Detail: "low"},
+		{Modality: common.ModalityText, Text: "and the audio"},
+		{Modality: common.ModalityAudio, Format: "wav", DataSize: 6},
+	}, messages[1].Parts)
+
+	// Only the text is hashed and tokenized, the media are counted with estimates
+	assert.Equal(t, []string{"<|im_start|>system\nYou are a helpful assistant.<|im_end|>\n<|im_start|>user\nDescribe the image\nand the audio<|im_end|>\n"}, input.Sequences)
+	assert.Equal(t, ImageTokenEstimate+LowDetailImageTokenEstimate+AudioTokenEstimate, input.MediaTokens)
+	assert.Equal(t, []common.Modality{common.ModalityImage, common.ModalityAudio}, input.Prompt.Modalities())
+	assert.True(t, input.Prompt.IsMultimodal())
+}
+
+func TestParseRequestInputTextOnlyContentParts(t *testing.T) {
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"model": "m",
+		"messages": [{"role": "user", "content": [{"type": "text", "text": "hi"}]}]
+	}`), &body))
+
+	input, err := ParseRequestInput("/v1/chat/completions", body)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", input.Prompt.Messages[0].Content)
+	assert.Zero(t, input.MediaTokens)
+	assert.False(t, input.Prompt.IsMultimodal())
+}
+
+func TestParseRequestInputToolCalls(t *testing.T) {
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"model": "m",
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"messages": [
+			{"role": "user", "content": "Weather in Paris?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "sunny"},
+			{"role": "assistant", "content": null}
+		]
+	}`), &body))
+
+	input, err := ParseRequestInput("/v1/chat/completions", body)
+	require.NoError(t, err)
+
+	messages := input.Prompt.Messages
+	require.Len(t, messages, 3)
+	assert.Equal(t, []common.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`}}, messages[1].ToolCalls)
+	assert.Equal(t, "call_1", messages[2].ToolCallID)
+	assert.Equal(t, []string{`{"function":{"name":"get_weather","parameters":{"type":"object"}},"type":"function"}`}, input.Prompt.Tools)
+	assert.Equal(t, []string{
+		"<|im_start|>tools\n" + `{"function":{"name":"get_weather","parameters":{"type":"object"}},"type":"function"}` + "<|im_end|>\n" +
+			"<|im_start|>user\nWeather in Paris?<|im_end|>\n" +
+			"<|im_start|>assistant\n\n<tool_call>get_weather({\"city\":\"Paris\"})</tool_call><|im_end|>\n" +
+			"<|im_start|>tool\nsunny<|im_end|>\n",
+	}, input.Sequences)
+}
+
+func TestParseRequestInputResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantMessages []common.Message
+		wantTools    int
+		wantErr      bool
+	}{
+		{
+			name:         "string input",
+			body:         `{"model": "m", "instructions": "Be brief.", "input": "hello"}`,
+			wantMessages: []common.Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "hello"}},
+		},
+		{
+			name: "input items",
+			body: `{
+				"model": "m",
+				"tools": [{"type": "function", "name": "get_weather"}, {"type": "web_search"}],
+				"input": [
+					{"role": "user", "content": [{"type": "input_text", "text": "Weather?"}]},
+					{"type": "reasoning", "summary": []},
+					{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{}"},
+					{"type": "function_call_output", "call_id": "call_1", "output": "sunny"},
+					{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "It is sunny."}]}
+				]
+			}`,
+			wantMessages: []common.Message{
+				{Role: "user", Content: "Weather?", Parts: []common.ContentPart{{Modality: common.ModalityText, Text: "Weather?"}}},
+				{Role: "assistant", ToolCalls: []common.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: "{}"}}},
+				{Role: "tool", Content: "sunny", ToolCallID: "call_1"},
+				{Role: "assistant", Content: "It is sunny.", Parts: []common.ContentPart{{Modality: common.ModalityText, Text: "It is sunny."}}},
+			},
+			wantTools: 2,
+		},
+		{name: "missing input", body: `{"model": "m", "instructions": "Be brief."}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+
+			input, err := ParseRequestInput("/v1/responses", body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, common.EndpointResponses, input.Endpoint)
+			assert.Equal(t, tt.wantMessages, input.Prompt.Messages)
+			assert.Len(t, input.Prompt.Tools, tt.wantTools)
+			assert.Equal(t, []string{GetPromptString(input.Prompt)}, input.Sequences)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
+)
+
+// ParseResponsesInput converts the input of an OpenAI Responses API request into chat messages.
+// The instructions become a system message, and the input is either a user message or a list of
+// items: messages, function calls and function call outputs. Other items such as reasoning are ignored.
+func ParseResponsesInput(body map[string]interface{}) (common.ChatMessage, error) {
+	var msgs []common.Message
+	if instructions, ok := body["instructions"].(string); ok && instructions != "" {
+		msgs = append(msgs, common.Message{Role: "system", Content: instructions})
+	}
+
+	switch input := body["input"].(type) {
+	case string:
+		msgs = append(msgs, common.Message{Role: "user", Content: input})
+	case []interface{}:
+		for _, item := range input {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if msg, ok := parseResponsesItem(itemMap); ok {
+				msgs = append(msgs, msg)
+			}
+		}
+	default:
+		return common.ChatMessage{}, fmt.Errorf("input not found in request body")
+	}
+
+	return common.ChatMessage{
+		Messages: msgs,
+		Tools:    parseTools(body["tools"]),
+	}, nil
+}
+
+// parseResponsesItem converts a Responses API input item into a chat message
+func parseResponsesItem(item map[string]interface{}) (common.Message, bool) {
+	itemType, _ := item["type"].(string)
+	switch itemType {
+	case "", "message":
+		role, ok := item["role"].(string)
+		if !ok {
+			return common.Message{}, false
+		}
+		msg := common.Message{Role: role}
+		switch content := item["content"].(type) {
+		case string:
+			msg.Content = content
+		case []interface{}:
+			msg.Parts = parseContentParts(content)
+			msg.Content = contentText(msg.Parts)
+		default:
+			return common.Message{}, false
+		}
+		return msg, true
+	case "function_call":
+		toolCall := common.ToolCall{}
+		toolCall.ID, _ = item["call_id"].(string)
+		toolCall.Name, _ = item["name"].(string)
+		toolCall.Arguments, _ = item["arguments"].(string)
+		return common.Message{Role: "assistant", ToolCalls: []common.ToolCall{toolCall}}, true
+	case "function_call_output":
+		msg := common.Message{Role: "tool"}
+		msg.ToolCallID, _ = item["call_id"].(string)
+		switch output := item["output"].(type) {
+		case string:
+			msg.Content = output
+		case []interface{}:
+			msg.Parts = parseContentParts(output)
+			msg.Content = contentText(msg.Parts)
+		}
+		return msg, true
+	default:
+		return common.Message{}, false
+	}
+}
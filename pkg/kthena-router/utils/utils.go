@@ -17,8 +17,11 @@ limitations under the License.
 package utils
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/volcano-sh/kthena/pkg/kthena-router/common"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -70,35 +73,156 @@ func ParsePrompt(body map[string]interface{}) (common.ChatMessage, error) {
 				continue
 			}
 
-			content, ok := msgMap["content"].(string)
-			if !ok {
+			msg := common.Message{Role: role}
+			switch content := msgMap["content"].(type) {
+			case string:
+				msg.Content = content
+			case []interface{}:
+				msg.Parts = parseContentParts(content)
+				msg.Content = contentText(msg.Parts)
+			case nil:
+				// Assistant messages requesting tool calls may have no content
+			default:
+				continue
+			}
+			msg.ToolCalls = parseToolCalls(msgMap["tool_calls"])
+			msg.ToolCallID, _ = msgMap["tool_call_id"].(string)
+			if msgMap["content"] == nil && len(msg.ToolCalls) == 0 {
 				continue
 			}
 
-			msgs = append(msgs, common.Message{
-				Role:    role,
-				Content: content,
-			})
+			msgs = append(msgs, msg)
 		}
 
 		return common.ChatMessage{
 			Messages: msgs,
+			Tools:    parseTools(body["tools"]),
 		}, nil
 	}
 
 	return common.ChatMessage{}, fmt.Errorf("prompt or messages not found in request body")
 }
 
+// parseContentParts parses the OpenAI content parts array of a chat message, unknown parts are ignored
+func parseContentParts(content []interface{}) []common.ContentPart {
+	var parts []common.ContentPart
+	for _, item := range content {
+		partMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		partType, _ := partMap["type"].(string)
+		switch partType {
+		case "text", "input_text", "output_text":
+			text, _ := partMap["text"].(string)
+			parts = append(parts, common.ContentPart{Modality: common.ModalityText, Text: text})
+		case "image_url":
+			url, detail := parseMediaURL(partMap["image_url"])
+			parts = append(parts, common.ContentPart{Modality: common.ModalityImage, URL: url, Detail: detail})
+		case "input_image":
+			url, _ := partMap["image_url"].(string)
+			detail, _ := partMap["detail"].(string)
+			parts = append(parts, common.ContentPart{Modality: common.ModalityImage, URL: url, Detail: detail})
+		case "input_audio":
+			audio, _ := partMap["input_audio"].(map[string]interface{})
+			data, _ := audio["data"].(string)
+			format, _ := audio["format"].(string)
+			parts = append(parts, common.ContentPart{Modality: common.ModalityAudio, Format: format, DataSize: base64.StdEncoding.DecodedLen(len(data))})
+		case "audio_url":
+			url, _ := parseMediaURL(partMap["audio_url"])
+			parts = append(parts, common.ContentPart{Modality: common.ModalityAudio, URL: url})
+		case "video_url":
+			url, _ := parseMediaURL(partMap["video_url"])
+			parts = append(parts, common.ContentPart{Modality: common.ModalityVideo, URL: url})
+		}
+	}
+	return parts
+}
+
+// parseMediaURL parses a media url given either as a string or as an object with url and detail fields
+func parseMediaURL(value interface{}) (string, string) {
+	switch v := value.(type) {
+	case string:
+		return v, ""
+	case map[string]interface{}:
+		url, _ := v["url"].(string)
+		detail, _ := v["detail"].(string)
+		return url, detail
+	default:
+		return "", ""
+	}
+}
+
+// contentText concatenates the text parts of a message, as done by vLLM when rendering the chat template
+func contentText(parts []common.ContentPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Modality == common.ModalityText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// parseToolCalls parses the OpenAI tool_calls of an assistant message
+func parseToolCalls(value interface{}) []common.ToolCall {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var toolCalls []common.ToolCall
+	for _, item := range list {
+		callMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		toolCall := common.ToolCall{}
+		toolCall.ID, _ = callMap["id"].(string)
+		if function, ok := callMap["function"].(map[string]interface{}); ok {
+			toolCall.Name, _ = function["name"].(string)
+			toolCall.Arguments, _ = function["arguments"].(string)
+		}
+		toolCalls = append(toolCalls, toolCall)
+	}
+	return toolCalls
+}
+
+// parseTools returns the JSON encoding of each tool definition. Map keys are sorted when encoded,
+// so the same tools always give the same prompt string.
+func parseTools(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	tools := make([]string, 0, len(list))
+	for _, tool := range list {
+		data, err := json.Marshal(tool)
+		if err != nil {
+			continue
+		}
+		tools = append(tools, string(data))
+	}
+	return tools
+}
+
 func GetPromptString(chatMessage common.ChatMessage) string {
 	// If Text field is present, return text directly (for prompt format)
 	if chatMessage.Text != "" {
 		return chatMessage.Text
 	}
 
-	// For chat messages, convert to ChatML format
+	// For chat messages, convert to ChatML format. Tool definitions come first, as chat templates
+	// usually render them in the system prompt.
 	result := ""
+	if len(chatMessage.Tools) > 0 {
+		result += fmt.Sprintf("<|im_start|>tools\n%s<|im_end|>\n", strings.Join(chatMessage.Tools, "\n"))
+	}
 	for _, msg := range chatMessage.Messages {
-		result += fmt.Sprintf("<|im_start|>%s\n%s<|im_end|>\n", msg.Role, msg.Content)
+		content := msg.Content
+		for _, toolCall := range msg.ToolCalls {
+			content += fmt.Sprintf("\n<tool_call>%s(%s)</tool_call>", toolCall.Name, toolCall.Arguments)
+		}
+		result += fmt.Sprintf("<|im_start|>%s\n%s<|im_end|>\n", msg.Role, content)
 	}
 	return result
 }
@@ -19,12 +19,18 @@ package webhook
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
@@ -170,6 +176,81 @@ func (v *KthenaRouterValidator) validateModelRoute(modelRoute *networkingv1alpha
 		}
 	}
 
+	if size := modelRoute.Spec.MaxRequestBodySize; size != nil && size.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(specField.Child("maxRequestBodySize"), size.String(), "must be greater than 0"))
+	}
+
+	if slo := modelRoute.Spec.SLO; slo != nil {
+		sloField := specField.Child("slo")
+		thresholds := []struct {
+			name  string
+			value *metav1.Duration
+		}{
+			{"timeToFirstToken", slo.TimeToFirstToken},
+			{"timePerOutputToken", slo.TimePerOutputToken},
+			{"endToEnd", slo.EndToEnd},
+		}
+		for _, threshold := range thresholds {
+			if threshold.value != nil && threshold.value.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(sloField.Child(threshold.name), threshold.value.Duration.String(), "must be greater than 0"))
+			}
+		}
+	}
+
+	if cache := modelRoute.Spec.Cache; cache != nil {
+		cacheField := specField.Child("cache")
+		if ttl := cache.TTL; ttl != nil && ttl.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(cacheField.Child("ttl"), ttl.Duration.String(), "must be greater than 0"))
+		}
+		if cache.Redis != nil && cache.Redis.Address == "" {
+			allErrs = append(allErrs, field.Required(cacheField.Child("redis", "address"), "the address of the Redis server is required"))
+		}
+	}
+
+	guardrailNames := map[string]bool{}
+	for i := range modelRoute.Spec.Guardrails {
+		guardrail := &modelRoute.Spec.Guardrails[i]
+		guardrailField := specField.Child("guardrails").Index(i)
+		if guardrailNames[guardrail.Name] {
+			allErrs = append(allErrs, field.Duplicate(guardrailField.Child("name"), guardrail.Name))
+		}
+		guardrailNames[guardrail.Name] = true
+		allErrs = append(allErrs, validateGuardrail(guardrailField, guardrail)...)
+	}
+
+	for i, rule := range modelRoute.Spec.Rules {
+		if rule == nil {
+			continue
+		}
+		ruleField := specField.Child("rules").Index(i)
+		if rule.Fallback != nil {
+			fallbackField := ruleField.Child("fallback")
+			if timeout := rule.Fallback.Timeout; timeout != nil && timeout.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(fallbackField.Child("timeout"), timeout.Duration.String(), "must be greater than 0"))
+			}
+			seen := map[string]bool{}
+			for j, target := range rule.Fallback.Targets {
+				if seen[target.ModelServerName] {
+					allErrs = append(allErrs, field.Duplicate(fallbackField.Child("targets").Index(j).Child("modelServerName"), target.ModelServerName))
+				}
+				seen[target.ModelServerName] = true
+			}
+		}
+		if rule.ModelMatch != nil && rule.ModelMatch.Body != nil {
+			fieldsField := ruleField.Child("modelMatch", "body", "fields")
+			for j := range rule.ModelMatch.Body.Fields {
+				allErrs = append(allErrs, validateBodyFieldMatch(fieldsField.Index(j), &rule.ModelMatch.Body.Fields[j])...)
+			}
+		}
+		allErrs = append(allErrs, validateClassifier(ruleField, rule)...)
+		if rule.Rollout != nil {
+			allErrs = append(allErrs, validateRollout(ruleField, rule)...)
+		}
+		for j := range rule.Transforms {
+			allErrs = append(allErrs, validateBodyTransform(ruleField.Child("transforms").Index(j), &rule.Transforms[j])...)
+		}
+	}
+
 	if len(allErrs) > 0 {
 		var messages []string
 		for _, err := range allErrs {
@@ -180,6 +261,266 @@ func (v *KthenaRouterValidator) validateModelRoute(modelRoute *networkingv1alpha
 	return true, ""
 }
 
+// validateBodyFieldMatch validates a condition on a field of the request body
+func validateBodyFieldMatch(fldPath *field.Path, fm *networkingv1alpha1.BodyFieldMatch) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, key := range strings.Split(fm.Path, ".") {
+		if key == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), fm.Path, "must be dot-separated keys"))
+			break
+		}
+	}
+
+	set := 0
+	for _, isSet := range []bool{fm.Exact != nil, fm.Prefix != nil, fm.Regex != nil, fm.Range != nil, fm.Exists != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, fm.Path, "exactly one of exact, prefix, regex, range and exists must be set"))
+	}
+	if fm.Regex != nil {
+		if _, err := regexp.Compile(*fm.Regex); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("regex"), *fm.Regex, err.Error()))
+		}
+	}
+	if r := fm.Range; r != nil {
+		if r.Min == nil && r.Max == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("range"), "min or max is required"))
+		} else if r.Min != nil && r.Max != nil && r.Min.Cmp(*r.Max) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("range", "min"), r.Min.String(), "must not be greater than max"))
+		}
+	}
+	return allErrs
+}
+
+// validateClassifier validates the prompt classifier of a rule, and the categories of its targets
+func validateClassifier(fldPath *field.Path, rule *networkingv1alpha1.Rule) field.ErrorList {
+	var allErrs field.ErrorList
+	classifier := rule.Classifier
+	if classifier == nil {
+		for i, target := range rule.TargetModels {
+			if target != nil && target.Category != "" {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("targetModels").Index(i).Child("category"), target.Category, "a category requires a classifier"))
+			}
+		}
+		return allErrs
+	}
+
+	classifierField := fldPath.Child("classifier")
+	categories := map[string]bool{}
+	switch classifier.Type {
+	case networkingv1alpha1.PromptClassifierHeuristic, "":
+		if len(classifier.Categories) == 0 {
+			allErrs = append(allErrs, field.Required(classifierField.Child("categories"), "the Heuristic classifier requires categories"))
+		}
+		for i, category := range classifier.Categories {
+			categoryField := classifierField.Child("categories").Index(i)
+			if categories[category.Name] {
+				allErrs = append(allErrs, field.Duplicate(categoryField.Child("name"), category.Name))
+			}
+			categories[category.Name] = true
+			if category.MinLength != nil && category.MaxLength != nil && *category.MinLength > *category.MaxLength {
+				allErrs = append(allErrs, field.Invalid(categoryField.Child("minLength"), *category.MinLength, "must not be greater than maxLength"))
+			}
+			if category.Regex != nil {
+				if _, err := regexp.Compile(*category.Regex); err != nil {
+					allErrs = append(allErrs, field.Invalid(categoryField.Child("regex"), *category.Regex, err.Error()))
+				}
+			}
+		}
+	case networkingv1alpha1.PromptClassifierHTTP:
+		endpointField := classifierField.Child("endpoint")
+		if classifier.Endpoint == "" {
+			allErrs = append(allErrs, field.Required(endpointField, "the HTTP classifier requires an endpoint"))
+		} else if u, err := url.Parse(classifier.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(endpointField, classifier.Endpoint, "must be an http or https URL"))
+		}
+		if timeout := classifier.Timeout; timeout != nil && timeout.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(classifierField.Child("timeout"), timeout.Duration.String(), "must be greater than 0"))
+		}
+	}
+
+	// The categories of the HTTP classifier are only known to the classifier
+	if len(categories) > 0 {
+		categories[classifier.DefaultCategory] = true
+		for i, target := range rule.TargetModels {
+			if target != nil && target.Category != "" && !categories[target.Category] {
+				allErrs = append(allErrs, field.NotFound(fldPath.Child("targetModels").Index(i).Child("category"), target.Category))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateRollout validates the rollout of a rule. The rollout owns the weights of the rule, so its targets
+// must be the baseline and the canary only.
+func validateRollout(fldPath *field.Path, rule *networkingv1alpha1.Rule) field.ErrorList {
+	var allErrs field.ErrorList
+	rollout := rule.Rollout
+	rolloutField := fldPath.Child("rollout")
+	if rule.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "a rule with a rollout must have a name"))
+	}
+
+	targets := map[string]bool{}
+	for _, target := range rule.TargetModels {
+		if target != nil {
+			targets[target.ModelServerName] = true
+		}
+	}
+	if rollout.Baseline == rollout.Canary {
+		allErrs = append(allErrs, field.Invalid(rolloutField.Child("canary"), rollout.Canary, "must differ from the baseline"))
+	}
+	for _, ref := range []struct {
+		name  string
+		value string
+	}{{"baseline", rollout.Baseline}, {"canary", rollout.Canary}} {
+		if !targets[ref.value] {
+			allErrs = append(allErrs, field.NotFound(rolloutField.Child(ref.name), ref.value))
+		}
+	}
+	if len(targets) > 2 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("targetModels"), len(rule.TargetModels), "a rule with a rollout must target its baseline and its canary only"))
+	}
+
+	var previous uint32
+	for i, step := range rollout.Steps {
+		stepField := rolloutField.Child("steps").Index(i)
+		if step.Weight > 100 {
+			allErrs = append(allErrs, field.Invalid(stepField.Child("weight"), step.Weight, "must not be greater than 100"))
+		} else if step.Weight < previous {
+			allErrs = append(allErrs, field.Invalid(stepField.Child("weight"), step.Weight, "must not be lower than the weight of the previous step"))
+		}
+		previous = step.Weight
+		if pause := step.Pause; pause != nil && pause.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(stepField.Child("pause"), pause.Duration.String(), "must be greater than 0"))
+		}
+	}
+
+	if analysis := rollout.Analysis; analysis != nil {
+		analysisField := rolloutField.Child("analysis")
+		if p := analysis.MaxErrorPercent; p != nil && (p.Sign() < 0 || p.Cmp(resource.MustParse("100")) > 0) {
+			allErrs = append(allErrs, field.Invalid(analysisField.Child("maxErrorPercent"), p.String(), "must be between 0 and 100"))
+		}
+		if latency := analysis.MaxLatency; latency != nil && latency.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(analysisField.Child("maxLatency"), latency.Duration.String(), "must be greater than 0"))
+		}
+		if analysis.MinRequests != nil && *analysis.MinRequests < 1 {
+			allErrs = append(allErrs, field.Invalid(analysisField.Child("minRequests"), *analysis.MinRequests, "must be at least 1"))
+		}
+		if analysis.PrometheusURL != "" {
+			if u, err := url.Parse(analysis.PrometheusURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(analysisField.Child("prometheusURL"), analysis.PrometheusURL, "must be an http or https URL"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateGuardrail validates a guardrail of a ModelRoute
+func validateGuardrail(fldPath *field.Path, guardrail *networkingv1alpha1.Guardrail) field.ErrorList {
+	var allErrs field.ErrorList
+	endpointField := fldPath.Child("endpoint")
+	switch guardrail.Protocol {
+	case networkingv1alpha1.GuardrailHTTP, "":
+		if u, err := url.Parse(guardrail.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(endpointField, guardrail.Endpoint, "must be an http or https URL"))
+		}
+	case networkingv1alpha1.GuardrailGRPC:
+		if _, _, err := net.SplitHostPort(guardrail.Endpoint); err != nil {
+			allErrs = append(allErrs, field.Invalid(endpointField, guardrail.Endpoint, "must be a host:port address"))
+		}
+	}
+	if timeout := guardrail.Timeout; timeout != nil && timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), timeout.Duration.String(), "must be greater than 0"))
+	}
+	phases := map[networkingv1alpha1.GuardrailPhase]bool{}
+	for i, phase := range guardrail.Phases {
+		if phases[phase] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("phases").Index(i), phase))
+		}
+		phases[phase] = true
+	}
+	return allErrs
+}
+
+// validateBodyTransform validates a body transform of a ModelRoute rule
+func validateBodyTransform(fldPath *field.Path, transform *networkingv1alpha1.BodyTransform) field.ErrorList {
+	var allErrs field.ErrorList
+	switch transform.Type {
+	case networkingv1alpha1.BodyTransformPrependMessage:
+		if transform.Message == nil || transform.Message.Content == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("message", "content"), "the message to prepend is required"))
+		}
+		return allErrs
+	case networkingv1alpha1.BodyTransformRemoveMessages:
+		if transform.Role == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("role"), "the role of the messages to remove is required"))
+		}
+		return allErrs
+	case networkingv1alpha1.BodyTransformSet, networkingv1alpha1.BodyTransformDefault,
+		networkingv1alpha1.BodyTransformClamp, networkingv1alpha1.BodyTransformRemove:
+	default:
+		return append(allErrs, field.NotSupported(fldPath.Child("type"), transform.Type, []networkingv1alpha1.BodyTransformType{
+			networkingv1alpha1.BodyTransformSet, networkingv1alpha1.BodyTransformDefault, networkingv1alpha1.BodyTransformClamp,
+			networkingv1alpha1.BodyTransformRemove, networkingv1alpha1.BodyTransformPrependMessage, networkingv1alpha1.BodyTransformRemoveMessages,
+		}))
+	}
+
+	pathField := fldPath.Child("path")
+	if transform.Path == "" {
+		return append(allErrs, field.Required(pathField, fmt.Sprintf("a path is required by the %s transform", transform.Type)))
+	}
+	for _, key := range strings.Split(transform.Path, ".") {
+		if key == "" {
+			allErrs = append(allErrs, field.Invalid(pathField, transform.Path, "must be dot-separated keys"))
+			break
+		}
+	}
+
+	switch transform.Type {
+	case networkingv1alpha1.BodyTransformSet, networkingv1alpha1.BodyTransformDefault:
+		valueField := fldPath.Child("value")
+		var value interface{}
+		if transform.Value == nil {
+			allErrs = append(allErrs, field.Required(valueField, fmt.Sprintf("a value is required by the %s transform", transform.Type)))
+		} else if err := json.Unmarshal(transform.Value.Raw, &value); err != nil {
+			allErrs = append(allErrs, field.Invalid(valueField, string(transform.Value.Raw), err.Error()))
+		} else if _, isString := value.(string); transform.Path == "model" && !isString {
+			allErrs = append(allErrs, field.Invalid(valueField, string(transform.Value.Raw), "the model must be a string"))
+		}
+	case networkingv1alpha1.BodyTransformClamp:
+		minValue, minErrs := transformNumber(fldPath.Child("min"), transform.Min)
+		maxValue, maxErrs := transformNumber(fldPath.Child("max"), transform.Max)
+		allErrs = append(allErrs, minErrs...)
+		allErrs = append(allErrs, maxErrs...)
+		switch {
+		case transform.Min == nil && transform.Max == nil:
+			allErrs = append(allErrs, field.Required(fldPath, "min or max is required by the Clamp transform"))
+		case minValue != nil && maxValue != nil && *minValue > *maxValue:
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("min"), *minValue, "must not be greater than max"))
+		}
+	}
+	if transform.Path == "model" && (transform.Type == networkingv1alpha1.BodyTransformClamp || transform.Type == networkingv1alpha1.BodyTransformRemove) {
+		allErrs = append(allErrs, field.Invalid(pathField, transform.Path, "the model can only be set"))
+	}
+	return allErrs
+}
+
+// transformNumber decodes a number of a body transform, it returns nil if the number is not set or invalid
+func transformNumber(fldPath *field.Path, value *apiextensionsv1.JSON) (*float64, field.ErrorList) {
+	if value == nil {
+		return nil, nil
+	}
+	var number float64
+	if err := json.Unmarshal(value.Raw, &number); err != nil {
+		return nil, field.ErrorList{field.Invalid(fldPath, string(value.Raw), "must be a number")}
+	}
+	return &number, nil
+}
+
 // validateModelServer validates the ModelServer resource
 func (v *KthenaRouterValidator) validateModelServer(*networkingv1alpha1.ModelServer) (bool, string) {
 	return true, ""
@@ -18,8 +18,11 @@ package webhook
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
@@ -27,6 +30,13 @@ import (
 )
 
 func TestValidateModelRoute(t *testing.T) {
+	exact := "true"
+	invalidRegex := "team-("
+	rangeMin := resource.MustParse("0.5")
+	rangeMax := resource.MustParse("1")
+	minLength := int32(100)
+	maxLength := int32(10)
+
 	tests := []struct {
 		name           string
 		modelRoute     *networkingv1alpha1.ModelRoute
@@ -255,6 +265,406 @@ func TestValidateModelRoute(t *testing.T) {
 			expectValid:    false,
 			expectedReason: "validation failed:   - spec: Required value: either modelName or loraAdapters must be specified",
 		},
+		{
+			name: "invalid model route - zero max request body size",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName:          "test-model",
+					MaxRequestBodySize: resource.NewQuantity(0, resource.BinarySI),
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.maxRequestBodySize: Invalid value: \"0\": must be greater than 0",
+		},
+		{
+			name: "invalid model route - zero slo threshold",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					SLO: &networkingv1alpha1.LatencySLO{
+						TimeToFirstToken:   &metav1.Duration{Duration: 500 * time.Millisecond},
+						TimePerOutputToken: &metav1.Duration{},
+					},
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.slo.timePerOutputToken: Invalid value: \"0s\": must be greater than 0",
+		},
+		{
+			name: "invalid model route - response cache",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Cache: &networkingv1alpha1.ResponseCache{
+						TTL:   &metav1.Duration{},
+						Redis: &networkingv1alpha1.RedisConfig{},
+					},
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.cache.ttl: Invalid value: \"0s\": must be greater than 0  - spec.cache.redis.address: Required value: the address of the Redis server is required",
+		},
+		{
+			name: "invalid model route - guardrails",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Guardrails: []networkingv1alpha1.Guardrail{
+						{
+							Name:     "moderation",
+							Endpoint: "moderation.default.svc:8080",
+							Timeout:  &metav1.Duration{},
+						},
+						{
+							Name:     "moderation",
+							Protocol: networkingv1alpha1.GuardrailGRPC,
+							Endpoint: "moderation.default.svc:9090",
+							Phases:   []networkingv1alpha1.GuardrailPhase{networkingv1alpha1.GuardrailRequest, networkingv1alpha1.GuardrailRequest},
+						},
+					},
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.guardrails[0].endpoint: Invalid value: \"moderation.default.svc:8080\": must be an http or https URL  - spec.guardrails[0].timeout: Invalid value: \"0s\": must be greater than 0  - spec.guardrails[1].name: Duplicate value: \"moderation\"  - spec.guardrails[1].phases[1]: Duplicate value: \"Request\"",
+		},
+		{
+			name: "valid model route with rollout",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							Name: "default",
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "server-v1"},
+								{ModelServerName: "server-v2"},
+							},
+							Rollout: &networkingv1alpha1.Rollout{
+								Baseline: "server-v1",
+								Canary:   "server-v2",
+								Steps:    []networkingv1alpha1.RolloutStep{{Weight: 10}, {Weight: 50}},
+								Analysis: &networkingv1alpha1.RolloutAnalysis{
+									MaxErrorPercent: resource.NewQuantity(1, resource.DecimalSI),
+									MaxLatency:      &metav1.Duration{Duration: 5 * time.Second},
+									PrometheusURL:   "http://prometheus.monitoring:9090",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    true,
+			expectedReason: "",
+		},
+		{
+			name: "invalid model route - rollout",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "server-v1"},
+								{ModelServerName: "server-v2"},
+								{ModelServerName: "server-v3"},
+							},
+							Rollout: &networkingv1alpha1.Rollout{
+								Baseline: "server-v1",
+								Canary:   "server-v4",
+								Steps:    []networkingv1alpha1.RolloutStep{{Weight: 50}, {Weight: 10, Pause: &metav1.Duration{}}},
+								Analysis: &networkingv1alpha1.RolloutAnalysis{
+									MaxErrorPercent: resource.NewQuantity(101, resource.DecimalSI),
+									PrometheusURL:   "prometheus:9090",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.rules[0].name: Required value: a rule with a rollout must have a name  - spec.rules[0].rollout.canary: Not found: \"server-v4\"  - spec.rules[0].targetModels: Invalid value: 3: a rule with a rollout must target its baseline and its canary only  - spec.rules[0].rollout.steps[1].weight: Invalid value: 10: must not be lower than the weight of the previous step  - spec.rules[0].rollout.steps[1].pause: Invalid value: \"0s\": must be greater than 0  - spec.rules[0].rollout.analysis.maxErrorPercent: Invalid value: \"101\": must be between 0 and 100  - spec.rules[0].rollout.analysis.prometheusURL: Invalid value: \"prometheus:9090\": must be an http or https URL",
+		},
+		{
+			name: "valid model route with fallback",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+							Fallback: &networkingv1alpha1.Fallback{
+								Targets: []networkingv1alpha1.FallbackTarget{
+									{ModelServerName: "backup-server"},
+								},
+								Conditions: []networkingv1alpha1.FallbackCondition{networkingv1alpha1.FallbackOnTimeout},
+								Timeout:    &metav1.Duration{Duration: 10 * time.Second},
+							},
+						},
+					},
+				},
+			},
+			expectValid: true,
+		},
+		{
+			name: "invalid model route - fallback with zero timeout and duplicate targets",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+							Fallback: &networkingv1alpha1.Fallback{
+								Targets: []networkingv1alpha1.FallbackTarget{
+									{ModelServerName: "backup-server"},
+									{ModelServerName: "backup-server"},
+								},
+								Timeout: &metav1.Duration{},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.rules[0].fallback.timeout: Invalid value: \"0s\": must be greater than 0  - spec.rules[0].fallback.targets[1].modelServerName: Duplicate value: \"backup-server\"",
+		},
+		{
+			name: "invalid model route - body field matches",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							ModelMatch: &networkingv1alpha1.ModelMatch{
+								Body: &networkingv1alpha1.BodyMatch{
+									Fields: []networkingv1alpha1.BodyFieldMatch{
+										{Path: "stream", Exact: &exact},
+										{Path: "metadata.", Exact: &exact, Prefix: &exact},
+										{Path: "user", Regex: &invalidRegex},
+										{Path: "temperature", Range: &networkingv1alpha1.NumberRange{Min: &rangeMax, Max: &rangeMin}},
+									},
+								},
+							},
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.rules[0].modelMatch.body.fields[1].path: Invalid value: \"metadata.\": must be dot-separated keys  - spec.rules[0].modelMatch.body.fields[1]: Invalid value: \"metadata.\": exactly one of exact, prefix, regex, range and exists must be set  - spec.rules[0].modelMatch.body.fields[2].regex: Invalid value: \"team-(\": error parsing regexp: missing closing ): `team-(`  - spec.rules[0].modelMatch.body.fields[3].range.min: Invalid value: \"1\": must not be greater than max",
+		},
+		{
+			name: "valid model route with body transforms",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+							Transforms: []networkingv1alpha1.BodyTransform{
+								{Type: networkingv1alpha1.BodyTransformClamp, Path: "max_tokens", Max: &apiextensionsv1.JSON{Raw: []byte("4096")}},
+								{Type: networkingv1alpha1.BodyTransformDefault, Path: "temperature", Value: &apiextensionsv1.JSON{Raw: []byte("0.7")}},
+								{Type: networkingv1alpha1.BodyTransformSet, Path: "model", Value: &apiextensionsv1.JSON{Raw: []byte(`"llama-3"`)}},
+								{Type: networkingv1alpha1.BodyTransformRemove, Path: "logprobs"},
+								{Type: networkingv1alpha1.BodyTransformRemoveMessages, Role: "system"},
+								{Type: networkingv1alpha1.BodyTransformPrependMessage, Message: &networkingv1alpha1.TransformMessage{Content: "Be concise."}},
+							},
+						},
+					},
+				},
+			},
+			expectValid: true,
+		},
+		{
+			name: "invalid model route - body transforms",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{
+									ModelServerName: "test-server",
+								},
+							},
+							Transforms: []networkingv1alpha1.BodyTransform{
+								{Type: networkingv1alpha1.BodyTransformClamp, Path: "max_tokens", Min: &apiextensionsv1.JSON{Raw: []byte("10")}, Max: &apiextensionsv1.JSON{Raw: []byte("1")}},
+								{Type: networkingv1alpha1.BodyTransformSet, Path: "metadata..tier"},
+								{Type: networkingv1alpha1.BodyTransformRemove, Path: "model"},
+								{Type: networkingv1alpha1.BodyTransformPrependMessage},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.rules[0].transforms[0].min: Invalid value: 10: must not be greater than max  - spec.rules[0].transforms[1].path: Invalid value: \"metadata..tier\": must be dot-separated keys  - spec.rules[0].transforms[1].value: Required value: a value is required by the Set transform  - spec.rules[0].transforms[2].path: Invalid value: \"model\": the model can only be set  - spec.rules[0].transforms[3].message.content: Required value: the message to prepend is required",
+		},
+		{
+			name: "valid model route with prompt classifier",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "small-server", Category: "simple"},
+								{ModelServerName: "large-server", Category: "complex"},
+							},
+							Classifier: &networkingv1alpha1.PromptClassifier{
+								Type:            networkingv1alpha1.PromptClassifierHeuristic,
+								Categories:      []networkingv1alpha1.PromptCategory{{Name: "simple", MaxLength: &maxLength}},
+								DefaultCategory: "complex",
+							},
+						},
+					},
+				},
+			},
+			expectValid: true,
+		},
+		{
+			name: "invalid model route - prompt classifiers",
+			modelRoute: &networkingv1alpha1.ModelRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-route",
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.ModelRouteSpec{
+					ModelName: "test-model",
+					Rules: []*networkingv1alpha1.Rule{
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "small-server", Category: "simple"},
+								{ModelServerName: "large-server", Category: "other"},
+							},
+							Classifier: &networkingv1alpha1.PromptClassifier{
+								Type: networkingv1alpha1.PromptClassifierHeuristic,
+								Categories: []networkingv1alpha1.PromptCategory{
+									{Name: "simple", MinLength: &minLength, MaxLength: &maxLength},
+									{Name: "simple", Regex: &invalidRegex},
+								},
+							},
+						},
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "large-server", Category: "complex"},
+							},
+							Classifier: &networkingv1alpha1.PromptClassifier{
+								Type:     networkingv1alpha1.PromptClassifierHTTP,
+								Endpoint: "classifier:8080",
+								Timeout:  &metav1.Duration{Duration: 0},
+							},
+						},
+						{
+							TargetModels: []*networkingv1alpha1.TargetModel{
+								{ModelServerName: "large-server", Category: "complex"},
+							},
+						},
+					},
+				},
+			},
+			expectValid:    false,
+			expectedReason: "validation failed:   - spec.rules[0].classifier.categories[0].minLength: Invalid value: 100: must not be greater than maxLength  - spec.rules[0].classifier.categories[1].name: Duplicate value: \"simple\"  - spec.rules[0].classifier.categories[1].regex: Invalid value: \"team-(\": error parsing regexp: missing closing ): `team-(`  - spec.rules[0].targetModels[1].category: Not found: \"other\"  - spec.rules[1].classifier.endpoint: Invalid value: \"classifier:8080\": must be an http or https URL  - spec.rules[1].classifier.timeout: Invalid value: \"0s\": must be greater than 0  - spec.rules[2].targetModels[0].category: Invalid value: \"complex\": a category requires a classifier",
+		},
 	}
 
 	// Create a validator instance
@@ -0,0 +1,376 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workload "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/convert"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/env"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/utils"
+	icUtils "github.com/volcano-sh/kthena/pkg/model-serving-controller/utils"
+)
+
+const (
+	// loraAdapterRetryInterval is the delay before reconciling again a model whose adapters are not all loaded
+	loraAdapterRetryInterval = 30 * time.Second
+	defaultRuntimePort       = 8100
+	defaultEngineURL         = "http://localhost:8000"
+)
+
+// loraAdapterRequest is the body of the LoRA adapter APIs of the runtime
+type loraAdapterRequest struct {
+	LoraName  string `json:"lora_name"`
+	Source    string `json:"source,omitempty"`
+	OutputDir string `json:"output_dir,omitempty"`
+}
+
+// modelList is the response of the /v1/models API of the engine
+type modelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// reconcileLoraAdapters loads the LoRA adapters declared on the model on its ready pods, unloads the adapters
+// removed from the model, and reports the state of each adapter in the model status.
+// The adapters are loaded on the pods of each role, so that both the prefill and the decode pods serve them.
+func (mc *ModelBoosterController) reconcileLoraAdapters(ctx context.Context, model *workload.ModelBooster) error {
+	removed := mc.getRemovedLoraAdapters(model)
+	if len(model.Spec.LoraAdapters) == 0 && removed.Len() == 0 {
+		return nil
+	}
+	pods, err := mc.listReadyEntryPods(model)
+	if err != nil {
+		return err
+	}
+
+	backend := &model.Spec.Backend
+	// The engines report the models they serve, including the adapters loaded on them.
+	// Pods which cannot report them are skipped, and the adapters are reconciled again later.
+	loaded := make(map[string]sets.Set[string], len(pods))
+	roles := make(map[string][]*corev1.Pod)
+	var listErrs []error
+	for _, pod := range pods {
+		models, err := mc.getLoadedModels(ctx, backend, pod)
+		if err != nil {
+			klog.Warningf("failed to get the models loaded on pod %s: %v", klog.KObj(pod), err)
+			listErrs = append(listErrs, fmt.Errorf("failed to get the models loaded on pod %s: %v", pod.Name, err))
+			continue
+		}
+		loaded[pod.Name] = models
+		role := icUtils.GetRoleName(pod)
+		roles[role] = append(roles[role], pod)
+	}
+
+	placed := make(map[string]sets.Set[string], len(model.Status.LoraAdapters))
+	for _, status := range model.Status.LoraAdapters {
+		placed[status.Name] = sets.New(status.Pods...)
+	}
+
+	statuses := make([]workload.LoraAdapterStatus, 0, len(model.Spec.LoraAdapters)+removed.Len())
+	for i := range model.Spec.LoraAdapters {
+		adapter := &model.Spec.LoraAdapters[i]
+		status := workload.LoraAdapterStatus{Name: adapter.Name, Phase: workload.LoraAdapterLoaded}
+		errs := append([]error(nil), listErrs...)
+		for _, rolePods := range roles {
+			podNames, err := mc.placeLoraAdapter(ctx, backend, adapter, rolePods, loaded, placed[adapter.Name])
+			status.Pods = append(status.Pods, podNames...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		sort.Strings(status.Pods)
+		switch {
+		case len(errs) > 0:
+			status.Phase = workload.LoraAdapterFailed
+			status.Message = errors.Join(errs...).Error()
+		case len(roles) == 0:
+			status.Phase = workload.LoraAdapterPending
+			status.Message = "no ready pod to load the adapter on"
+		}
+		statuses = append(statuses, status)
+	}
+	// An adapter removed from the model stays in the status until it is unloaded from all the pods
+	for _, name := range sets.List(removed) {
+		var podNames []string
+		errs := append([]error(nil), listErrs...)
+		for _, pod := range pods {
+			if !loaded[pod.Name].Has(name) {
+				continue
+			}
+			if err := mc.unloadLoraAdapter(ctx, backend, pod, name); err != nil {
+				podNames = append(podNames, pod.Name)
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			statuses = append(statuses, workload.LoraAdapterStatus{
+				Name:    name,
+				Phase:   workload.LoraAdapterFailed,
+				Pods:    podNames,
+				Message: errors.Join(errs...).Error(),
+			})
+		}
+	}
+
+	for _, status := range statuses {
+		if status.Phase != workload.LoraAdapterLoaded {
+			if key, err := cache.MetaNamespaceKeyFunc(model); err == nil {
+				mc.workQueue.AddAfter(key, loraAdapterRetryInterval)
+			}
+			break
+		}
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	if equality.Semantic.DeepEqual(model.Status.LoraAdapters, statuses) {
+		return nil
+	}
+	model.Status.LoraAdapters = statuses
+	return mc.updateModelBoosterStatus(ctx, model)
+}
+
+// placeLoraAdapter loads the adapter on the requested number of pods of a role, and unloads it from the extra
+// pods it was placed on. It returns the names of the pods the adapter is placed on.
+// The copies of the adapter loaded by others, such as the router loading adapters on demand, are only counted
+// when there are not enough placed copies, and are never unloaded.
+func (mc *ModelBoosterController) placeLoraAdapter(ctx context.Context, backend *workload.ModelBackend, adapter *workload.LoraAdapter,
+	pods []*corev1.Pod, loaded map[string]sets.Set[string], placed sets.Set[string]) ([]string, error) {
+	want := len(pods)
+	if adapter.Replicas != nil && int(*adapter.Replicas) < want {
+		want = int(*adapter.Replicas)
+	}
+	var loadedOn, loadedByOthers, notLoadedOn []*corev1.Pod
+	for _, pod := range pods {
+		switch {
+		case !loaded[pod.Name].Has(adapter.Name):
+			notLoadedOn = append(notLoadedOn, pod)
+		case placed.Has(pod.Name):
+			loadedOn = append(loadedOn, pod)
+		default:
+			loadedByOthers = append(loadedByOthers, pod)
+		}
+	}
+	for _, pod := range loadedByOthers {
+		if len(loadedOn) >= want {
+			break
+		}
+		loadedOn = append(loadedOn, pod)
+	}
+
+	var errs []error
+	// The adapter is placed on too many pods when its replicas are decreased
+	for len(loadedOn) > want {
+		pod := loadedOn[len(loadedOn)-1]
+		if err := mc.unloadLoraAdapter(ctx, backend, pod, adapter.Name); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		loaded[pod.Name].Delete(adapter.Name)
+		loadedOn = loadedOn[:len(loadedOn)-1]
+	}
+	// Spread the adapters by loading them on the pods with the fewest models first
+	sort.SliceStable(notLoadedOn, func(i, j int) bool {
+		return loaded[notLoadedOn[i].Name].Len() < loaded[notLoadedOn[j].Name].Len()
+	})
+	for _, pod := range notLoadedOn {
+		if len(loadedOn) >= want {
+			break
+		}
+		if err := mc.loadLoraAdapter(ctx, backend, adapter, pod); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded[pod.Name].Insert(adapter.Name)
+		loadedOn = append(loadedOn, pod)
+	}
+
+	podNames := make([]string, 0, len(loadedOn))
+	for _, pod := range loadedOn {
+		podNames = append(podNames, pod.Name)
+	}
+	return podNames, errors.Join(errs...)
+}
+
+// getRemovedLoraAdapters returns the adapters which were loaded for the model but are no longer declared on it
+func (mc *ModelBoosterController) getRemovedLoraAdapters(model *workload.ModelBooster) sets.Set[string] {
+	declared := sets.New[string]()
+	for _, adapter := range model.Spec.LoraAdapters {
+		declared.Insert(adapter.Name)
+	}
+	removed := sets.New[string]()
+	for _, status := range model.Status.LoraAdapters {
+		if !declared.Has(status.Name) {
+			removed.Insert(status.Name)
+		}
+	}
+	// The status may not be updated yet when the adapters are removed right after being added
+	cacheKey := fmt.Sprintf("%s/%s:%d", model.Namespace, model.Name, model.Generation)
+	mc.loraUpdateCacheLock.Lock()
+	oldModel := mc.loraUpdateCache[cacheKey]
+	mc.loraUpdateCacheLock.Unlock()
+	if oldModel != nil {
+		for _, adapter := range oldModel.Spec.LoraAdapters {
+			if !declared.Has(adapter.Name) {
+				removed.Insert(adapter.Name)
+			}
+		}
+	}
+	return removed
+}
+
+// listReadyEntryPods returns the ready entry pods of the model, sorted by name
+func (mc *ModelBoosterController) listReadyEntryPods(model *workload.ModelBooster) ([]*corev1.Pod, error) {
+	selector := labels.SelectorFromSet(map[string]string{
+		utils.OwnerUIDKey:      string(model.UID),
+		workload.EntryLabelKey: icUtils.Entry,
+	})
+	pods, err := mc.podsLister.Pods(model.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	var readyPods []*corev1.Pod
+	for _, pod := range pods {
+		if isPodReady(pod) && pod.Status.PodIP != "" {
+			readyPods = append(readyPods, pod)
+		}
+	}
+	sort.Slice(readyPods, func(i, j int) bool {
+		return readyPods[i].Name < readyPods[j].Name
+	})
+	return readyPods, nil
+}
+
+// getLoadedModels returns the models served by the engine of the pod, including the loaded adapters
+func (mc *ModelBoosterController) getLoadedModels(ctx context.Context, backend *workload.ModelBackend, pod *corev1.Pod) (sets.Set[string], error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, engineURL(backend, pod)+"/v1/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list models returned status %d", resp.StatusCode)
+	}
+	var list modelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	models := sets.New[string]()
+	for _, model := range list.Data {
+		models.Insert(model.ID)
+	}
+	return models, nil
+}
+
+// loadLoraAdapter asks the runtime of the pod to download the adapter to the cache volume, and load it
+func (mc *ModelBoosterController) loadLoraAdapter(ctx context.Context, backend *workload.ModelBackend, adapter *workload.LoraAdapter, pod *corev1.Pod) error {
+	klog.InfoS("Load LoRA adapter", "pod", klog.KObj(pod), "adapter", adapter.Name)
+	err := mc.postRuntime(ctx, runtimeURL(backend, pod)+"/v1/load_lora_adapter", loraAdapterRequest{
+		LoraName:  adapter.Name,
+		Source:    adapter.ArtifactURL,
+		OutputDir: convert.GetLoraAdapterPath(backend, adapter),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load adapter on pod %s: %v", pod.Name, err)
+	}
+	return nil
+}
+
+// unloadLoraAdapter asks the runtime of the pod to unload the adapter
+func (mc *ModelBoosterController) unloadLoraAdapter(ctx context.Context, backend *workload.ModelBackend, pod *corev1.Pod, name string) error {
+	klog.InfoS("Unload LoRA adapter", "pod", klog.KObj(pod), "adapter", name)
+	if err := mc.postRuntime(ctx, runtimeURL(backend, pod)+"/v1/unload_lora_adapter", loraAdapterRequest{LoraName: name}); err != nil {
+		return fmt.Errorf("failed to unload adapter %s on pod %s: %v", name, pod.Name, err)
+	}
+	return nil
+}
+
+func (mc *ModelBoosterController) postRuntime(ctx context.Context, url string, body loraAdapterRequest) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := mc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// runtimeURL returns the base URL of the runtime sidecar of the pod
+func runtimeURL(backend *workload.ModelBackend, pod *corev1.Pod) string {
+	port := env.GetEnvValueOrDefault[int32](backend, env.RuntimePort, defaultRuntimePort)
+	return "http://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port)))
+}
+
+// engineURL returns the base URL of the engine of the pod, on the port the runtime reaches it on
+func engineURL(backend *workload.ModelBackend, pod *corev1.Pod) string {
+	port := "8000"
+	if u, err := url.Parse(env.GetEnvValueOrDefault[string](backend, env.RuntimeUrl, defaultEngineURL)); err == nil && u.Port() != "" {
+		port = u.Port()
+	}
+	return "http://" + net.JoinHostPort(pod.Status.PodIP, port)
+}
+
+// isPodReady checks if the pod is running, not being deleted, and has a PodReady condition set to true.
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Volcano Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	kthenafake "github.com/volcano-sh/kthena/client-go/clientset/versioned/fake"
+	workload "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/convert"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/utils"
+	icUtils "github.com/volcano-sh/kthena/pkg/model-serving-controller/utils"
+)
+
+// fakeEngines serves the engine and runtime APIs of several pods, identified by their IP
+type fakeEngines struct {
+	mu      sync.Mutex
+	models  map[string]sets.Set[string]
+	loads   []loraAdapterRequest
+	failing sets.Set[string]
+}
+
+func (f *fakeEngines) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	host, _, _ := net.SplitHostPort(r.Host)
+	models := f.models[host]
+	switch r.URL.Path {
+	case "/v1/models":
+		var list modelList
+		for _, name := range sets.List(models) {
+			list.Data = append(list.Data, struct {
+				ID string `json:"id"`
+			}{ID: name})
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case "/v1/load_lora_adapter", "/v1/unload_lora_adapter":
+		var body loraAdapterRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if f.failing.Has(body.LoraName) {
+			http.Error(w, "cannot download adapter", http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/v1/load_lora_adapter" {
+			f.loads = append(f.loads, body)
+			models.Insert(body.LoraName)
+		} else {
+			models.Delete(body.LoraName)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeEngines) loaded(ip string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return sets.List(f.models[ip])
+}
+
+func TestReconcileLoraAdapters(t *testing.T) {
+	ctx := context.Background()
+	ips := []string{"127.0.0.1", "127.0.0.2"}
+	engines := &fakeEngines{
+		models:  map[string]sets.Set[string]{ips[0]: sets.New("base"), ips[1]: sets.New("base")},
+		failing: sets.New("broken-lora"),
+	}
+	// All the pods listen on the same port, as the runtime and engine ports are configured per backend
+	first, err := net.Listen("tcp", net.JoinHostPort(ips[0], "0"))
+	require.NoError(t, err)
+	port := first.Addr().(*net.TCPAddr).Port
+	second, err := net.Listen("tcp", net.JoinHostPort(ips[1], strconv.Itoa(port)))
+	if err != nil {
+		first.Close()
+		t.Skipf("cannot listen on %s: %v", ips[1], err)
+	}
+	server := &http.Server{Handler: engines}
+	go func() { _ = server.Serve(first) }()
+	go func() { _ = server.Serve(second) }()
+	defer server.Close()
+
+	kthenaClient := kthenafake.NewSimpleClientset()
+	controller := NewModelBoosterController(fake.NewClientset(), kthenaClient)
+	require.NotNil(t, controller)
+
+	model := &workload.ModelBooster{
+		ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "default", UID: "model-uid"},
+		Spec: workload.ModelBoosterSpec{
+			Backend: workload.ModelBackend{
+				Name:     "backend",
+				Type:     workload.ModelBackendTypeVLLM,
+				CacheURI: "hostpath:///cache",
+				Env: []corev1.EnvVar{
+					{Name: "RUNTIME_PORT", Value: strconv.Itoa(port)},
+					{Name: "RUNTIME_URL", Value: "http://localhost:" + strconv.Itoa(port)},
+				},
+			},
+			LoraAdapters: []workload.LoraAdapter{
+				{Name: "sql-lora", ArtifactURL: "hf://org/sql-lora"},
+				{Name: "chat-lora", ArtifactURL: "s3://bucket/chat-lora", Replicas: ptr.To[int32](1)},
+			},
+		},
+	}
+	_, err = kthenaClient.WorkloadV1alpha1().ModelBoosters(model.Namespace).Create(ctx, model, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Without ready pods, the adapters are pending
+	require.NoError(t, controller.reconcileLoraAdapters(ctx, model))
+	require.Len(t, model.Status.LoraAdapters, 2)
+	assert.Equal(t, workload.LoraAdapterPending, model.Status.LoraAdapters[0].Phase)
+
+	for i, ip := range ips {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "base-pod-" + strconv.Itoa(i),
+				Namespace: model.Namespace,
+				Labels: map[string]string{
+					utils.OwnerUIDKey:      string(model.UID),
+					workload.EntryLabelKey: icUtils.Entry,
+					workload.RoleLabelKey:  "leader",
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		require.NoError(t, controller.podsInformer.GetIndexer().Add(pod))
+	}
+
+	// The adapters are loaded on all the pods, or on the requested number of pods
+	require.NoError(t, controller.reconcileLoraAdapters(ctx, model))
+	assert.Equal(t, []workload.LoraAdapterStatus{
+		{Name: "sql-lora", Phase: workload.LoraAdapterLoaded, Pods: []string{"base-pod-0", "base-pod-1"}},
+		{Name: "chat-lora", Phase: workload.LoraAdapterLoaded, Pods: []string{"base-pod-0"}},
+	}, model.Status.LoraAdapters)
+	assert.Equal(t, []string{"base", "chat-lora", "sql-lora"}, engines.loaded(ips[0]))
+	assert.Equal(t, []string{"base", "sql-lora"}, engines.loaded(ips[1]))
+	assert.Contains(t, engines.loads, loraAdapterRequest{
+		LoraName:  "chat-lora",
+		Source:    "s3://bucket/chat-lora",
+		OutputDir: "/cache" + convert.GetMountPath("s3://bucket/chat-lora"),
+	})
+	stored, err := kthenaClient.WorkloadV1alpha1().ModelBoosters(model.Namespace).Get(ctx, model.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, model.Status.LoraAdapters, stored.Status.LoraAdapters)
+
+	// A copy of the adapter loaded on another pod by the router is neither placed nor unloaded
+	engines.mu.Lock()
+	engines.models[ips[1]].Insert("chat-lora")
+	engines.mu.Unlock()
+	require.NoError(t, controller.reconcileLoraAdapters(ctx, model))
+	assert.Equal(t, stored.Status.LoraAdapters, model.Status.LoraAdapters)
+	assert.Equal(t, []string{"base", "chat-lora", "sql-lora"}, engines.loaded(ips[1]))
+	engines.mu.Lock()
+	engines.models[ips[1]].Delete("chat-lora")
+	engines.mu.Unlock()
+
+	// A removed adapter is unloaded, a failing adapter is reported
+	model.Spec.LoraAdapters = []workload.LoraAdapter{
+		{Name: "chat-lora", ArtifactURL: "s3://bucket/chat-lora", Replicas: ptr.To[int32](1)},
+		{Name: "broken-lora", ArtifactURL: "hf://org/broken-lora"},
+	}
+	require.NoError(t, controller.reconcileLoraAdapters(ctx, model))
+	require.Len(t, model.Status.LoraAdapters, 2)
+	assert.Equal(t, workload.LoraAdapterStatus{Name: "chat-lora", Phase: workload.LoraAdapterLoaded, Pods: []string{"base-pod-0"}},
+		model.Status.LoraAdapters[0])
+	assert.Equal(t, workload.LoraAdapterFailed, model.Status.LoraAdapters[1].Phase)
+	assert.Empty(t, model.Status.LoraAdapters[1].Pods)
+	assert.Contains(t, model.Status.LoraAdapters[1].Message, "cannot download adapter")
+	assert.Equal(t, []string{"base", "chat-lora"}, engines.loaded(ips[0]))
+	assert.Equal(t, []string{"base"}, engines.loaded(ips[1]))
+
+	// Removing all the adapters clears the status
+	model.Spec.LoraAdapters = nil
+	require.NoError(t, controller.reconcileLoraAdapters(ctx, model))
+	assert.Nil(t, model.Status.LoraAdapters)
+	assert.Equal(t, []string{"base"}, engines.loaded(ips[0]))
+}
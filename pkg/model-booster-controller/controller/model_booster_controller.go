@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	networkingv1alpha1 "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -77,7 +79,8 @@ type ModelBoosterController struct {
 	workQueue                         workqueue.TypedRateLimitingInterface[any]
 	// loraUpdateCache stores the previous model version for LoRA adapter comparison
 	// Key format: "namespace/name:generation" to avoid version conflicts
-	loraUpdateCache map[string]*workload.ModelBooster
+	loraUpdateCache     map[string]*workload.ModelBooster
+	loraUpdateCacheLock sync.Mutex
 }
 
 func (mc *ModelBoosterController) Run(ctx context.Context, workers int) {
@@ -170,7 +173,9 @@ func (mc *ModelBoosterController) updateModelBooster(old any, new any) {
 	if oldModel.Status.ObservedGeneration != newModel.Generation {
 		// Store the old model in cache with generation-specific key to avoid conflicts
 		cacheKey := fmt.Sprintf("%s/%s:%d", newModel.Namespace, newModel.Name, newModel.Generation)
+		mc.loraUpdateCacheLock.Lock()
 		mc.loraUpdateCache[cacheKey] = oldModel.DeepCopy()
+		mc.loraUpdateCacheLock.Unlock()
 
 		mc.enqueueModelBooster(newModel)
 	}
@@ -221,6 +226,9 @@ func (mc *ModelBoosterController) reconcile(ctx context.Context, namespaceAndNam
 		mc.setModelFailedCondition(ctx, model, err)
 		return err
 	}
+	if err := mc.reconcileLoraAdapters(ctx, model); err != nil {
+		return err
+	}
 	modelServingActive, err := mc.isModelServingActive(model)
 	if err != nil || !modelServingActive {
 		return err
@@ -271,6 +279,8 @@ func (mc *ModelBoosterController) updateModelBoosterStatus(ctx context.Context,
 // Cache key format: "namespace/name:generation"
 func (mc *ModelBoosterController) cleanupOutdatedLoraUpdateCache(modelBooster *workload.ModelBooster) {
 	prefix := fmt.Sprintf("%s/%s:", modelBooster.Namespace, modelBooster.Name)
+	mc.loraUpdateCacheLock.Lock()
+	defer mc.loraUpdateCacheLock.Unlock()
 	for key := range mc.loraUpdateCache {
 		if strings.HasPrefix(key, prefix) {
 			// Keep only the current generation entry, remove others
@@ -377,6 +387,13 @@ func NewModelBoosterController(kubeClient kubernetes.Interface, client clientset
 		klog.Fatal("Unable to add model server event handler")
 		return nil
 	}
+	_, err = podsInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: mc.updatePod,
+	})
+	if err != nil {
+		klog.Fatal("Unable to add pod event handler")
+		return nil
+	}
 	mc.syncHandler = mc.reconcile
 	mc.loadConfigFromConfigMap()
 	return mc
@@ -471,3 +488,28 @@ func (mc *ModelBoosterController) deleteModelServer(obj any) {
 		}
 	}
 }
+
+// updatePod is called when a pod is updated. When a serving pod becomes ready, the ModelBooster is reconciled
+// to load its LoRA adapters on the pod.
+func (mc *ModelBoosterController) updatePod(old any, new any) {
+	newPod, ok := new.(*corev1.Pod)
+	if !ok {
+		klog.Error("failed to parse new Pod when updatePod")
+		return
+	}
+	oldPod, ok := old.(*corev1.Pod)
+	if !ok {
+		klog.Error("failed to parse old Pod when updatePod")
+		return
+	}
+	if isPodReady(oldPod) == isPodReady(newPod) {
+		return
+	}
+	modelName, ok := newPod.Labels[utils.ModelNameLabelKey]
+	if !ok {
+		return
+	}
+	if model, err := mc.modelBoosterLister.ModelBoosters(newPod.Namespace).Get(modelName); err == nil && len(model.Spec.LoraAdapters) > 0 {
+		mc.enqueueModelBooster(model)
+	}
+}
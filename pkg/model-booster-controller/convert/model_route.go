@@ -45,8 +45,9 @@ func BuildModelRoute(model *workload.ModelBooster) *networking.ModelRoute {
 			},
 		},
 		Spec: networking.ModelRouteSpec{
-			ModelName: model.Name,
-			Rules:     rules,
+			ModelName:    model.Name,
+			LoraAdapters: getLoraAdapterNames(model),
+			Rules:        rules,
 		},
 	}
 	route.Labels = utils.GetModelControllerLabels(model, "", icUtils.Revision(route.Spec))
@@ -75,3 +76,12 @@ func getTargetModels(model *workload.ModelBooster) []*networking.TargetModel {
 	})
 	return targetModels
 }
+
+// getLoraAdapterNames returns the names of the LoRA adapters declared on the model.
+func getLoraAdapterNames(model *workload.ModelBooster) []string {
+	var names []string
+	for _, adapter := range model.Spec.LoraAdapters {
+		names = append(names, adapter.Name)
+	}
+	return names
+}
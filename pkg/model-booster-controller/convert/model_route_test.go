@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	networking "github.com/volcano-sh/kthena/pkg/apis/networking/v1alpha1"
 	registry "github.com/volcano-sh/kthena/pkg/apis/workload/v1alpha1"
+	"github.com/volcano-sh/kthena/pkg/model-booster-controller/utils"
 )
 
 func TestBuildModelRoute(t *testing.T) {
@@ -43,3 +44,19 @@ func TestBuildModelRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildModelRouteWithLoraAdapters(t *testing.T) {
+	model := loadYaml[registry.ModelBooster](t, "testdata/input/model.yaml")
+	route := BuildModelRoute(model)
+	assert.Empty(t, route.Spec.LoraAdapters)
+
+	model.Spec.LoraAdapters = []registry.LoraAdapter{
+		{Name: "sql-lora", ArtifactURL: "hf://org/sql-lora"},
+		{Name: "chat-lora", ArtifactURL: "s3://bucket/chat-lora"},
+	}
+	got := BuildModelRoute(model)
+	assert.Equal(t, []string{"sql-lora", "chat-lora"}, got.Spec.LoraAdapters)
+	assert.Equal(t, model.Name, got.Spec.ModelName)
+	// Adding an adapter changes the revision, so that the ModelRoute is updated
+	assert.NotEqual(t, route.Labels[utils.RevisionLabelKey], got.Labels[utils.RevisionLabelKey])
+}
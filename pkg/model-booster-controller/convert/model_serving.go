@@ -167,6 +167,11 @@ func buildVllmDisaggregatedModelServing(model *workload.ModelBooster) (*workload
 			Name:      cacheVolume.Name,
 			MountPath: GetCachePath(backend.CacheURI),
 		}},
+		// The runtime downloads the LoRA adapters to the cache volume, for the engine to load them
+		"RUNTIME_VOLUME_MOUNTS": []corev1.VolumeMount{{
+			Name:      cacheVolume.Name,
+			MountPath: GetCachePath(backend.CacheURI),
+		}},
 		"VOLUMES": []*corev1.Volume{
 			cacheVolume,
 		},
@@ -293,6 +298,11 @@ func buildVllmModelServing(model *workload.ModelBooster) (*workload.ModelServing
 			Name:      dshm,
 			MountPath: "/dev/shm",
 		}},
+		// The runtime downloads the LoRA adapters to the cache volume, for the engine to load them
+		"RUNTIME_VOLUME_MOUNTS": []corev1.VolumeMount{{
+			Name:      cacheVolume.Name,
+			MountPath: GetCachePath(backend.CacheURI),
+		}},
 		"INIT_CONTAINERS":                    initContainers,
 		"MODEL_DOWNLOAD_ENVFROM":             backend.EnvFrom,
 		"MODEL_SERVING_RUNTIME_IMAGE":        config.Config.RuntimeImage(),
@@ -393,6 +403,11 @@ func GetMountPath(modelURI string) string {
 	return "/" + hashHex
 }
 
+// GetLoraAdapterPath returns the path in the cache volume where the runtime downloads the given LoRA adapter.
+func GetLoraAdapterPath(backend *workload.ModelBackend, adapter *workload.LoraAdapter) string {
+	return GetCachePath(backend.CacheURI) + GetMountPath(adapter.ArtifactURL)
+}
+
 func buildCacheVolume(backend *workload.ModelBackend) (*corev1.Volume, error) {
 	volumeName := getVolumeName(backend.Name)
 	switch {
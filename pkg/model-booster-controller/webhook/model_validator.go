@@ -81,6 +81,7 @@ func (v *ModelValidator) validateModel(model *registryv1alpha1.ModelBooster) (bo
 	allErrs = append(allErrs, validateWorkerImages(model)...)
 	allErrs = append(allErrs, validateAutoScalingPolicyScope(model)...)
 	allErrs = append(allErrs, validateBackendWorkerTypes(model)...)
+	allErrs = append(allErrs, validateLoraAdapters(model)...)
 
 	if len(allErrs) > 0 {
 		// Convert field errors to a formatted multi-line error message
@@ -150,6 +151,28 @@ func validateBackendWorkerTypes(model *registryv1alpha1.ModelBooster) field.Erro
 	return allErrs
 }
 
+// validateLoraAdapters checks that the names of the LoRA adapters are unique, and differ from the model name
+// which is used to route the requests to the base model.
+func validateLoraAdapters(model *registryv1alpha1.ModelBooster) field.ErrorList {
+	var allErrs field.ErrorList
+	path := field.NewPath("spec").Child("loraAdapters")
+	names := make(map[string]struct{}, len(model.Spec.LoraAdapters))
+	for i, adapter := range model.Spec.LoraAdapters {
+		if adapter.Name == model.Name {
+			allErrs = append(allErrs, field.Invalid(
+				path.Index(i).Child("name"),
+				adapter.Name,
+				"LoRA adapter name cannot be the same as the model name",
+			))
+		}
+		if _, ok := names[adapter.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("name"), adapter.Name))
+		}
+		names[adapter.Name] = struct{}{}
+	}
+	return allErrs
+}
+
 func validateBackendReplicaBounds(model *registryv1alpha1.ModelBooster) field.ErrorList {
 	var allErrs field.ErrorList
 	path := field.NewPath("spec").Child("backend")
@@ -114,3 +114,45 @@ func TestValidateModel_NoErrors(t *testing.T) {
 	assert.True(t, valid)
 	assert.Empty(t, errorMsg)
 }
+
+func TestValidateModel_LoraAdapters(t *testing.T) {
+	validator := &ModelValidator{}
+
+	model := &registryv1alpha1.ModelBooster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-model",
+			Namespace: "default",
+		},
+		Spec: registryv1alpha1.ModelBoosterSpec{
+			Backend: registryv1alpha1.ModelBackend{
+				Name:        "backend1",
+				Type:        registryv1alpha1.ModelBackendTypeVLLM,
+				MinReplicas: 1,
+				MaxReplicas: 1,
+				Workers: []registryv1alpha1.ModelWorker{
+					{
+						Type:  registryv1alpha1.ModelWorkerTypeServer,
+						Pods:  1,
+						Image: "test-image:latest",
+					},
+				},
+			},
+			LoraAdapters: []registryv1alpha1.LoraAdapter{
+				{Name: "sql-lora", ArtifactURL: "hf://org/sql-lora"},
+				{Name: "chat-lora", ArtifactURL: "s3://bucket/chat-lora"},
+			},
+		},
+	}
+	valid, errorMsg := validator.validateModel(model)
+	assert.True(t, valid)
+	assert.Empty(t, errorMsg)
+
+	model.Spec.LoraAdapters = append(model.Spec.LoraAdapters,
+		registryv1alpha1.LoraAdapter{Name: "test-model", ArtifactURL: "hf://org/test-model-lora"},
+		registryv1alpha1.LoraAdapter{Name: "sql-lora", ArtifactURL: "hf://org/sql-lora-v2"},
+	)
+	valid, errorMsg = validator.validateModel(model)
+	assert.False(t, valid)
+	assert.Contains(t, errorMsg, "spec.loraAdapters[2].name: Invalid value: \"test-model\": LoRA adapter name cannot be the same as the model name")
+	assert.Contains(t, errorMsg, "spec.loraAdapters[3].name: Duplicate value: \"sql-lora\"")
+}